@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"desktop/internal/hub"
 	"desktop/internal/hubclient"
@@ -45,6 +46,14 @@ func runHub() {
 	logger := log.New(logFile, "[HUB] ", log.LstdFlags|log.Lshortfile)
 
 	h := hub.New(dataDir, defaultRoom, logger)
+	// Rooms idle this long are archived; "0" turns archival off.
+	if v := os.Getenv("AGENT_CHAT_ROOM_TTL"); v != "" {
+		if ttl, err := time.ParseDuration(v); err != nil || ttl < 0 {
+			logger.Printf("Ignoring invalid AGENT_CHAT_ROOM_TTL %q", v)
+		} else {
+			h.SetRoomTTL(ttl)
+		}
+	}
 
 	// Handle graceful shutdown
 	sigCh := make(chan os.Signal, 1)
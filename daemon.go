@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"desktop/internal/daemon"
+	"desktop/internal/engine"
+)
+
+// teamList collects repeated --team flags.
+type teamList []string
+
+func (l *teamList) String() string     { return strings.Join(*l, ",") }
+func (l *teamList) Set(v string) error { *l = append(*l, v); return nil }
+
+// runDaemon runs "agent-chat daemon": the hub, the agent terminals and the
+// orchestrator without the desktop window, controlled over a local API.
+func runDaemon(args []string) int {
+	flags := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	dir := flags.String("data-dir", dataDir(), "data directory")
+	addr := flags.String("addr", "127.0.0.1:0", "control API address (loopback only)")
+	var teams teamList
+	flags.Var(&teams, "team", "team ID or name whose agents to launch (repeatable)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: agent-chat daemon [--team NAME]... [--data-dir DIR] [--addr HOST:PORT]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	events := daemon.NewBroadcaster()
+	eng := engine.New(engine.Config{
+		DataDir:      *dir,
+		MCPServerBin: mcpServerBin,
+		Prompts:      promptsFS,
+		Sink:         events,
+	})
+	if err := daemon.Run(ctx, eng, events, daemon.Options{Addr: *addr, Teams: teams}); err != nil {
+		log.Printf("[DAEMON] %v", err)
+		return 1
+	}
+	return 0
+}
@@ -2,9 +2,9 @@ import { useEffect, useState, useRef, Component, ReactNode } from "react";
 import { Panel, Group as PanelGroup, Separator as PanelResizeHandle, type PanelImperativeHandle } from "react-resizable-panels";
 import { useTeams } from "./store/useTeams";
 import { useMessages } from "./store/useMessages";
-import { MessagesNewEvent, AgentsUpdatedEvent } from "./lib/types";
-// useTerminals imported by PromptLibrary for target picker
-import { SendPromptToAgent } from "../wailsjs/go/main/App";
+import { MessagesNewEvent, MessagesUpdatedEvent, AgentsUpdatedEvent, LoopDetectedEvent, DeliveryFailedEvent, RestoredSession } from "./lib/types";
+import { useTerminals } from "./store/useTerminals";
+import { SendPromptToAgent, GetRestoredSessions } from "../wailsjs/go/main/App";
 import TabBar from "./components/TabBar";
 import TerminalGrid from "./components/TerminalGrid";
 import Sidebar from "./components/Sidebar";
@@ -38,7 +38,8 @@ function AppContent() {
   const activeTeamID = useTeams((s) => s.activeTeamID);
   const loadTeams = useTeams((s) => s.loadTeams);
   const createTeam = useTeams((s) => s.createTeam);
-  const { addMessages, setAgents, loadMessages, loadAgents } = useMessages();
+  const { addMessages, updateMessages, setAgents, loadMessages, loadAgents, addLoop, loadLoops, addFailure } = useMessages();
+  const addRestored = useTerminals((s) => s.addRestored);
   const [ready, setReady] = useState(false);
   const [sidebarCollapsed, setSidebarCollapsed] = useState(false);
   const sidebarRef = useRef<PanelImperativeHandle>(null);
@@ -84,15 +85,45 @@ function AppContent() {
           addMessages(data.chatDir, data.messages);
         }
       });
+      EventsOn("messages:updated", (data: MessagesUpdatedEvent) => {
+        if (data?.chatDir && data?.messages) {
+          updateMessages(data.chatDir, data.messages);
+        }
+      });
       EventsOn("agents:updated", (data: AgentsUpdatedEvent) => {
         if (data?.chatDir && data?.agents) {
           setAgents(data.chatDir, data.agents);
         }
       });
+      EventsOn("loop:detected", (data: LoopDetectedEvent) => {
+        if (data?.chatDir && data?.alert) {
+          addLoop(data.chatDir, data.alert);
+        }
+      });
+      EventsOn("delivery:failed", (data: DeliveryFailedEvent) => {
+        if (data?.chatDir && data?.failure) {
+          addFailure(data.chatDir, data.failure);
+        }
+      });
+      EventsOn("session:restored", (data: RestoredSession) => {
+        if (data?.sessionID) {
+          addRestored(data);
+        }
+      });
+      // Terminals restored before the listener was registered
+      GetRestoredSessions().then((restored) => {
+        (restored ?? []).forEach((r) => addRestored(r as unknown as RestoredSession));
+      }).catch((e) => {
+        if (import.meta.env.DEV) console.warn("GetRestoredSessions failed:", e);
+      });
       cleanupFn = () => {
         try {
           EventsOff("messages:new");
+          EventsOff("messages:updated");
           EventsOff("agents:updated");
+          EventsOff("loop:detected");
+          EventsOff("delivery:failed");
+          EventsOff("session:restored");
         } catch (e) {
           if (import.meta.env.DEV) console.warn("EventsOff cleanup failed:", e);
         }
@@ -115,6 +146,7 @@ function AppContent() {
     loadAgents(roomName).catch((e) => {
       if (import.meta.env.DEV) console.warn("Failed to load agents:", e);
     });
+    loadLoops(roomName);
   }, [activeTeamID]);
 
   const activeTeam = teams.find((t) => t.id === activeTeamID);
@@ -1,5 +1,5 @@
 import { useAgentsFor } from "../store/useMessages";
-import { Agent } from "../lib/types";
+import { Agent, formatAgentStatus } from "../lib/types";
 
 interface Props {
   chatDir: string;
@@ -20,13 +20,25 @@ export default function AgentStatus({ chatDir }: Props) {
   }
 
   const isActive = (agent: Agent) => {
+    if (agent.human) return true;
     return Date.now() / 1000 - agent.last_seen < 300;
   };
 
+  const indicatorClass = (agent: Agent) => {
+    if (!isActive(agent)) return "agent-offline";
+    return agent.activity === "busy" ? "agent-active agent-busy" : "agent-active";
+  };
+
   const isManager = (agent: Agent) => {
     return agent.role?.toLowerCase() === "manager";
   };
 
+  const capabilityList = (agent: Agent) => {
+    const caps = agent.capabilities;
+    if (!caps) return [];
+    return [...(caps.areas ?? []), ...(caps.languages ?? []), ...(caps.tools ?? [])];
+  };
+
   return (
     <div className="agent-status">
       <h3 className="sidebar-section-title">
@@ -36,16 +48,28 @@ export default function AgentStatus({ chatDir }: Props) {
         {entries.map(([name, agent]) => (
           <div key={name} className="agent-card">
             <span
-              className={`agent-indicator ${isActive(agent) ? "agent-active" : "agent-offline"}`}
+              className={`agent-indicator ${indicatorClass(agent)}`}
+              title={agent.activity?.replace("_", " ")}
             />
             <div className="agent-info">
               <span className="agent-name">
                 {name}
                 {isManager(agent) ? " (manager)" : ""}
+                {agent.human ? " (you)" : ""}
               </span>
               {agent.role && (
                 <span className="agent-role">{agent.role}</span>
               )}
+              {agent.status && (
+                <span className={`agent-presence agent-status-${agent.status}`}>
+                  {formatAgentStatus(agent)}
+                </span>
+              )}
+              {capabilityList(agent).length > 0 && (
+                <span className="agent-capabilities">
+                  {capabilityList(agent).join(" · ")}
+                </span>
+              )}
             </div>
           </div>
         ))}
@@ -0,0 +1,81 @@
+import { useState } from "react";
+import { useAgentsFor } from "../store/useMessages";
+import { SendHumanMessage } from "../../wailsjs/go/main/App";
+
+interface Props {
+  chatDir: string;
+}
+
+// Lets the desktop user post into the room as a human participant.
+export default function MessageComposer({ chatDir }: Props) {
+  const agents = useAgentsFor(chatDir);
+  const [content, setContent] = useState("");
+  const [to, setTo] = useState("all");
+  const [urgent, setUrgent] = useState(false);
+  const [bypassManager, setBypassManager] = useState(false);
+  const [sending, setSending] = useState(false);
+  const [error, setError] = useState("");
+
+  const recipients = Object.entries(agents)
+    .filter(([, agent]) => !agent.human)
+    .map(([name]) => name)
+    .sort();
+  const hasManager = Object.values(agents).some((a) => a.role?.toLowerCase() === "manager");
+
+  const send = async () => {
+    const text = content.trim();
+    if (!text || sending) return;
+    setSending(true);
+    setError("");
+    try {
+      await SendHumanMessage(chatDir, to, text, urgent ? "urgent" : "normal", bypassManager);
+      setContent("");
+    } catch (e) {
+      setError(String(e));
+    } finally {
+      setSending(false);
+    }
+  };
+
+  return (
+    <div className="msg-composer">
+      <textarea
+        className="msg-composer-input"
+        rows={2}
+        placeholder="Message the room..."
+        value={content}
+        onChange={(e) => setContent(e.target.value)}
+        onKeyDown={(e) => {
+          if (e.key === "Enter" && !e.shiftKey) {
+            e.preventDefault();
+            send();
+          }
+        }}
+      />
+      <div className="msg-composer-options">
+        <select value={to} onChange={(e) => setTo(e.target.value)}>
+          <option value="all">all</option>
+          {recipients.map((name) => (
+            <option key={name} value={name}>
+              {name}
+            </option>
+          ))}
+        </select>
+        <label title="Notify immediately, skipping batching">
+          <input type="checkbox" checked={urgent} onChange={(e) => setUrgent(e.target.checked)} />
+          urgent
+        </label>
+        {hasManager && (
+          <label title="Deliver directly instead of through the manager">
+            <input type="checkbox" checked={bypassManager} onChange={(e) => setBypassManager(e.target.checked)} />
+            bypass manager
+          </label>
+        )}
+        <button className="loop-resume" onClick={send} disabled={sending || !content.trim()}>
+          Send
+        </button>
+      </div>
+      {error && <div className="msg-meta">{error}</div>}
+    </div>
+  );
+}
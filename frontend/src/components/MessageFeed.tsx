@@ -1,5 +1,7 @@
 import { useEffect, useRef } from "react";
-import { useMessagesFor } from "../store/useMessages";
+import { useMessages, useMessagesFor, useLoopsFor, useFailuresFor } from "../store/useMessages";
+import { DeliveryFailure, LoopAlert } from "../lib/types";
+import MessageComposer from "./MessageComposer";
 
 interface Props {
   chatDir: string;
@@ -7,17 +9,48 @@ interface Props {
 
 export default function MessageFeed({ chatDir }: Props) {
   const messages = useMessagesFor(chatDir);
+  const loops = useLoopsFor(chatDir);
+  const resumeLoop = useMessages((s) => s.resumeLoop);
+  const failures = useFailuresFor(chatDir);
+  const dismissFailure = useMessages((s) => s.dismissFailure);
   const bottomRef = useRef<HTMLDivElement>(null);
 
   useEffect(() => {
     bottomRef.current?.scrollIntoView({ behavior: "smooth" });
   }, [messages.length]);
 
+  const loopBanner = (loops.length > 0 || failures.length > 0) && (
+    <div className="loop-alerts">
+      {failures.map((f: DeliveryFailure, i) => (
+        <div key={`fail-${f.agent}-${i}`} className="loop-alert">
+          <span>
+            {f.agent} has not read message{f.message_ids.length > 1 ? "s" : ""} {f.message_ids.map((id) => `#${id}`).join(", ")} after {f.attempts} notifications
+          </span>
+          <button className="loop-resume" onClick={() => dismissFailure(chatDir, f)}>
+            Dismiss
+          </button>
+        </div>
+      ))}
+      {loops.map((l: LoopAlert) => (
+        <div key={`${l.agent_a}-${l.agent_b}`} className="loop-alert">
+          <span>
+            Loop paused: {l.agent_a} ↔ {l.agent_b} ({l.reason === "repeated" ? "repeated content" : "message rate"})
+          </span>
+          <button className="loop-resume" onClick={() => resumeLoop(chatDir, l)}>
+            Resume
+          </button>
+        </div>
+      ))}
+    </div>
+  );
+
   if (messages.length === 0) {
     return (
       <div className="message-feed">
         <h3 className="sidebar-section-title">Messages</h3>
+        {loopBanner}
         <p className="sidebar-empty">No messages yet</p>
+        <MessageComposer chatDir={chatDir} />
       </div>
     );
   }
@@ -27,6 +60,7 @@ export default function MessageFeed({ chatDir }: Props) {
       <h3 className="sidebar-section-title">
         Messages ({messages.length})
       </h3>
+      {loopBanner}
       <div className="message-list">
         {messages.slice(-50).map((msg) => {
           const time = msg.timestamp
@@ -46,25 +80,41 @@ export default function MessageFeed({ chatDir }: Props) {
             );
           }
 
+          const expired = !!msg.expires_at && new Date(msg.expires_at) <= new Date();
+          const classes = ["msg"];
+          if (msg.priority === "urgent") classes.push("msg-urgent");
+          if (msg.from_human) classes.push("msg-human");
+          if (msg.retracted) classes.push("msg-retracted");
+          else if (expired) classes.push("msg-expired");
+
           return (
-            <div
-              key={msg.id}
-              className={`msg ${msg.priority === "urgent" ? "msg-urgent" : ""}`}
-            >
+            <div key={msg.id} className={classes.join(" ")}>
               <div className="msg-header">
-                <span className="msg-from">{msg.from}</span>
-                <span className="msg-arrow">
+                <span className="msg-from" title={msg.from_human ? "human" : undefined}>
+                  {msg.from_human ? `👤 ${msg.from}` : msg.from}
+                </span>
+                <span className="msg-arrow" title={msg.recipients?.join(", ")}>
                   {msg.to === "all" ? "=> ALL" : `=> ${msg.to}`}
                   {msg.original_to && msg.original_to !== msg.to ? ` (intended: ${msg.original_to})` : null}
                 </span>
                 <span className="msg-time">{time}</span>
               </div>
               <div className="msg-content">{msg.content}</div>
+              {msg.retracted ? (
+                <div className="msg-meta">retracted{msg.retracted_by ? ` by ${msg.retracted_by}` : ""}</div>
+              ) : msg.edited_at ? (
+                <div className="msg-meta" title={msg.edits?.map((e) => e.content).join("\n---\n")}>
+                  edited{msg.edits && msg.edits.length > 1 ? ` (${msg.edits.length}x)` : ""}
+                </div>
+              ) : expired ? (
+                <div className="msg-meta">expired</div>
+              ) : null}
             </div>
           );
         })}
         <div ref={bottomRef} />
       </div>
+      <MessageComposer chatDir={chatDir} />
     </div>
   );
 }
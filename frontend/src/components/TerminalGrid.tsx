@@ -124,6 +124,7 @@ export default function TerminalGrid() {
   const capacity = gridCapacity(currentGridLayout);
   const isCustomMode = isCustomLayout(currentGridLayout);
   const customLayout = team ? (customLayouts[team.id] ?? []) : [];
+  const chatDir = team?.name || "default";
 
   // Load available CLIs on mount
   useEffect(() => {
@@ -233,6 +234,7 @@ export default function TerminalGrid() {
             <TerminalPane
               sessionID={s.sessionID}
               agentName={s.agentName}
+              chatDir={chatDir}
               cliType={s.cliType}
               isFocused={s.sessionID === focusedSessionID}
               onToggleFocus={() => toggleFocusSession(s.sessionID)}
@@ -250,6 +252,7 @@ export default function TerminalGrid() {
         <TerminalPane
           sessionID={slot.session.sessionID}
           agentName={slot.session.agentName}
+          chatDir={chatDir}
           cliType={slot.session.cliType}
           isFocused={false}
           onToggleFocus={() => toggleFocusSession(slot.session.sessionID)}
@@ -307,6 +310,7 @@ export default function TerminalGrid() {
                 <TerminalPane
                   sessionID={s.sessionID}
                   agentName={s.agentName}
+                  chatDir={chatDir}
                   cliType={s.cliType}
                   isFocused={false}
                   onToggleFocus={() => toggleFocusSession(s.sessionID)}
@@ -3,12 +3,14 @@ import { Terminal } from "@xterm/xterm";
 import { FitAddon } from "@xterm/addon-fit";
 import { WebLinksAddon } from "@xterm/addon-web-links";
 import "@xterm/xterm/css/xterm.css";
-import { WriteToTerminal, ResizeTerminal } from "../../wailsjs/go/main/App";
-import { CLIType } from "../lib/types";
+import { WriteToTerminal, ResizeTerminal, GetScrollback } from "../../wailsjs/go/main/App";
+import { CLIType, formatAgentStatus } from "../lib/types";
+import { useAgentsFor } from "../store/useMessages";
 
 interface Props {
   sessionID: string;
   agentName: string;
+  chatDir?: string;
   cliType?: CLIType;
   isFocused?: boolean;
   onToggleFocus?: () => void;
@@ -16,7 +18,9 @@ interface Props {
   onRestart?: () => void;
 }
 
-export default function TerminalPane({ sessionID, agentName, cliType, isFocused, onToggleFocus, onRemove, onRestart }: Props) {
+export default function TerminalPane({ sessionID, agentName, chatDir, cliType, isFocused, onToggleFocus, onRemove, onRestart }: Props) {
+  const agent = useAgentsFor(chatDir ?? "")[agentName];
+  const statusLabel = agent ? formatAgentStatus(agent) : "";
   const containerRef = useRef<HTMLDivElement>(null);
   const termRef = useRef<Terminal | null>(null);
   const fitRef = useRef<FitAddon | null>(null);
@@ -87,8 +91,16 @@ export default function TerminalPane({ sessionID, agentName, cliType, isFocused,
     let cancelled = false;
     let eventCleanup = () => {};
 
-    import("../../wailsjs/runtime/runtime").then(({ EventsOn, EventsOff }) => {
+    // Replay buffered output first so a re-mounted pane keeps its screen.
+    Promise.all([
+      import("../../wailsjs/runtime/runtime"),
+      GetScrollback(sessionID).catch((e) => {
+        if (import.meta.env.DEV) console.warn("GetScrollback failed:", e);
+        return "";
+      }),
+    ]).then(([{ EventsOn, EventsOff }, scrollback]) => {
       if (cancelled) return;
+      if (scrollback) term.write(scrollback);
       EventsOn(eventName, (data: string) => {
         term.write(data);
       });
@@ -142,6 +154,14 @@ export default function TerminalPane({ sessionID, agentName, cliType, isFocused,
         {cliType && cliType !== "shell" && (
           <span className={`cli-badge cli-badge-${cliType}`}>{cliType}</span>
         )}
+        {statusLabel && (
+          <span
+            className={`terminal-agent-status agent-status-${agent?.status}`}
+            title={statusLabel}
+          >
+            {statusLabel}
+          </span>
+        )}
         <div
           className="terminal-header-actions"
           onMouseDown={(e) => e.stopPropagation()}
@@ -16,6 +16,24 @@ export interface AgentConfig {
   prompt_id: string;
   work_dir: string;
   cli_type: string;
+  delivery?: DeliverySettings;
+}
+
+export interface DeliverySettings {
+  do_not_disturb?: boolean;
+  quiet_hours?: string; // "22:00-08:00"
+  max_per_window?: number;
+  window_minutes?: number;
+  digest_minutes?: number;
+  muted_senders?: string[];
+}
+
+export interface AnalysisRule {
+  name?: string;
+  patterns?: string[];
+  sender?: string;
+  priority?: "urgent" | "normal" | "low";
+  action: "skip" | "notify";
 }
 
 export interface Team {
@@ -26,6 +44,7 @@ export interface Team {
   chat_dir: string;
   manager_agent: string;
   custom_prompt: string;
+  analysis_rules?: AnalysisRule[];
   created_at: string;
 }
 
@@ -49,14 +68,63 @@ export interface Message {
   timestamp: string;
   type: string;
   routed_by_manager?: boolean;
+  from_human?: boolean;
   expects_reply: boolean;
   priority: string;
+  recipients?: string[];
+  expires_at?: string;
+  edited_at?: string;
+  edits?: MessageEdit[];
+  retracted?: boolean;
+  retracted_at?: string;
+  retracted_by?: string;
+}
+
+export interface MessageEdit {
+  content: string;
+  edited_at: string;
+  edited_by: string;
+}
+
+export interface Capabilities {
+  languages?: string[];
+  areas?: string[];
+  tools?: string[];
 }
 
+export type AgentStatusValue =
+  | "available"
+  | "thinking"
+  | "working"
+  | "testing"
+  | "waiting_review"
+  | "blocked"
+  | "away";
+
 export interface Agent {
   role: string;
   joined_at: string;
   last_seen: number;
+  capabilities?: Capabilities;
+  status?: AgentStatusValue;
+  status_text?: string;
+  status_eta?: string;
+  status_updated_at?: string;
+  activity?: "busy" | "idle" | "prompt_waiting";
+  activity_at?: string;
+  human?: boolean;
+}
+
+// Renders an agent's self-reported status as "working · running tests · ETA 14:30".
+export function formatAgentStatus(agent: Agent): string {
+  if (!agent.status) return "";
+  const parts: string[] = [agent.status.replace("_", " ")];
+  if (agent.status_text) parts.push(agent.status_text);
+  if (agent.status_eta) {
+    const time = agent.status_eta.split("T")[1]?.slice(0, 5);
+    if (time) parts.push(`ETA ${time}`);
+  }
+  return parts.join(" \u00B7 ");
 }
 
 export interface TerminalSession {
@@ -68,17 +136,58 @@ export interface TerminalSession {
   slotIndex: number;
 }
 
+// A terminal recreated from the previous run ("session:restored" event)
+export interface RestoredSession {
+  sessionID: string;
+  teamID: string;
+  agentName: string;
+  cliType: CLIType;
+  slotIndex: number;
+  resumed: boolean;
+}
+
 // Wails event payload types
 export interface MessagesNewEvent {
   chatDir: string;
   messages: Message[];
 }
 
+export interface MessagesUpdatedEvent {
+  chatDir: string;
+  messages: Message[];
+}
+
 export interface AgentsUpdatedEvent {
   chatDir: string;
   agents: Record<string, Agent>;
 }
 
+export interface LoopAlert {
+  chat_dir: string;
+  agent_a: string;
+  agent_b: string;
+  reason: "rate" | "repeated";
+  messages: number;
+  paused_until: string;
+}
+
+export interface LoopDetectedEvent {
+  chatDir: string;
+  alert: LoopAlert;
+}
+
+export interface DeliveryFailure {
+  chat_dir: string;
+  agent: string;
+  message_ids: number[];
+  attempts: number;
+}
+
+export interface DeliveryFailedEvent {
+  chatDir: string;
+  failure: DeliveryFailure;
+}
+
 // Grid layout type: "1x1" | "1x2" | "2x1" | "2x2" | "2x3" | "3x2" | "3x3" | "3x4" | "4x3" | "custom"
 export type GridLayout = string;
 
@@ -1,24 +1,36 @@
 import { create } from "zustand";
-import { Message, Agent } from "../lib/types";
-import { GetMessages, GetAgents } from "../../wailsjs/go/main/App";
+import { Message, Agent, LoopAlert, DeliveryFailure } from "../lib/types";
+import { GetMessages, GetAgents, GetPausedPairs, ResumeAgentPair } from "../../wailsjs/go/main/App";
 
 // Stable empty references to avoid infinite re-render loops
 const EMPTY_MESSAGES: Message[] = [];
 const EMPTY_AGENTS: Record<string, Agent> = {};
+const EMPTY_LOOPS: LoopAlert[] = [];
+const EMPTY_FAILURES: DeliveryFailure[] = [];
 
 interface MessagesState {
   messages: Record<string, Message[]>;
   agents: Record<string, Record<string, Agent>>;
+  loops: Record<string, LoopAlert[]>;
+  failures: Record<string, DeliveryFailure[]>;
 
   addMessages: (chatDir: string, newMessages: Message[]) => void;
+  updateMessages: (chatDir: string, updated: Message[]) => void;
   setAgents: (chatDir: string, agents: Record<string, Agent>) => void;
   loadMessages: (chatDir: string) => Promise<void>;
   loadAgents: (chatDir: string) => Promise<void>;
+  addLoop: (chatDir: string, alert: LoopAlert) => void;
+  loadLoops: (chatDir: string) => Promise<void>;
+  resumeLoop: (chatDir: string, alert: LoopAlert) => Promise<void>;
+  addFailure: (chatDir: string, failure: DeliveryFailure) => void;
+  dismissFailure: (chatDir: string, failure: DeliveryFailure) => void;
 }
 
 export const useMessages = create<MessagesState>((set) => ({
   messages: {},
   agents: {},
+  loops: {},
+  failures: {},
 
   addMessages: (chatDir, newMessages) => {
     set((s) => {
@@ -35,6 +47,25 @@ export const useMessages = create<MessagesState>((set) => ({
     });
   },
 
+  updateMessages: (chatDir, updated) => {
+    set((s) => {
+      const existing = s.messages[chatDir];
+      if (!existing) return s;
+      const byID = new Map(updated.map((m) => [m.id, m]));
+      let changed = false;
+      const next = existing.map((m) => {
+        const u = byID.get(m.id);
+        if (!u) return m;
+        changed = true;
+        return u;
+      });
+      if (!changed) return s;
+      return {
+        messages: { ...s.messages, [chatDir]: next },
+      };
+    });
+  },
+
   setAgents: (chatDir, agents) => {
     set((s) => ({
       agents: { ...s.agents, [chatDir]: agents },
@@ -62,6 +93,58 @@ export const useMessages = create<MessagesState>((set) => ({
       if (import.meta.env.DEV) console.warn("Failed to load agents:", e);
     }
   },
+
+  addLoop: (chatDir, alert) => {
+    set((s) => {
+      const existing = (s.loops[chatDir] ?? EMPTY_LOOPS).filter(
+        (l) => !(l.agent_a === alert.agent_a && l.agent_b === alert.agent_b)
+      );
+      return { loops: { ...s.loops, [chatDir]: [...existing, alert] } };
+    });
+  },
+
+  loadLoops: async (chatDir) => {
+    try {
+      const loops = await GetPausedPairs(chatDir);
+      set((s) => ({
+        loops: { ...s.loops, [chatDir]: (loops as unknown as LoopAlert[]) || EMPTY_LOOPS },
+      }));
+    } catch (e) {
+      if (import.meta.env.DEV) console.warn("Failed to load paused pairs:", e);
+    }
+  },
+
+  resumeLoop: async (chatDir, alert) => {
+    try {
+      await ResumeAgentPair(chatDir, alert.agent_a, alert.agent_b);
+    } catch (e) {
+      if (import.meta.env.DEV) console.warn("Failed to resume pair:", e);
+    }
+    set((s) => ({
+      loops: {
+        ...s.loops,
+        [chatDir]: (s.loops[chatDir] ?? EMPTY_LOOPS).filter((l) => l !== alert),
+      },
+    }));
+  },
+
+  addFailure: (chatDir, failure) => {
+    set((s) => ({
+      failures: {
+        ...s.failures,
+        [chatDir]: [...(s.failures[chatDir] ?? EMPTY_FAILURES), failure],
+      },
+    }));
+  },
+
+  dismissFailure: (chatDir, failure) => {
+    set((s) => ({
+      failures: {
+        ...s.failures,
+        [chatDir]: (s.failures[chatDir] ?? EMPTY_FAILURES).filter((f) => f !== failure),
+      },
+    }));
+  },
 }));
 
 // Selector hooks with stable references
@@ -72,3 +155,11 @@ export function useMessagesFor(chatDir: string): Message[] {
 export function useAgentsFor(chatDir: string): Record<string, Agent> {
   return useMessages((s) => s.agents[chatDir] ?? EMPTY_AGENTS);
 }
+
+export function useLoopsFor(chatDir: string): LoopAlert[] {
+  return useMessages((s) => s.loops[chatDir] ?? EMPTY_LOOPS);
+}
+
+export function useFailuresFor(chatDir: string): DeliveryFailure[] {
+  return useMessages((s) => s.failures[chatDir] ?? EMPTY_FAILURES);
+}
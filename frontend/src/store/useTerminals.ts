@@ -1,5 +1,5 @@
 import { create } from "zustand";
-import { CLIInfo, CLIType, TerminalSession } from "../lib/types";
+import { CLIInfo, CLIType, RestoredSession, TerminalSession } from "../lib/types";
 import {
   CreateTerminal,
   CloseTerminal,
@@ -24,6 +24,7 @@ interface TerminalsState {
     promptId?: string,
     slotIndex?: number
   ) => Promise<string>;
+  addRestored: (restored: RestoredSession) => void;
   removeTerminal: (teamID: string, sessionID: string) => Promise<void>;
   removeAllForTeam: (teamID: string) => Promise<void>;
   writeToTerminal: (sessionID: string, data: string) => Promise<void>;
@@ -80,6 +81,24 @@ export const useTerminals = create<TerminalsState>((set, get) => ({
     return sessionID;
   },
 
+  addRestored: (restored) => {
+    set((s) => {
+      const current = s.sessions[restored.teamID] ?? [];
+      if (current.some((t) => t.sessionID === restored.sessionID)) return s;
+      // Keep the slot from the previous run unless the user filled it already.
+      const taken = current.some((t) => t.slotIndex === restored.slotIndex);
+      const session: TerminalSession = {
+        sessionID: restored.sessionID,
+        teamID: restored.teamID,
+        agentName: restored.agentName,
+        cliType: restored.cliType,
+        index: current.length,
+        slotIndex: taken ? Math.max(...current.map((t) => t.slotIndex)) + 1 : restored.slotIndex,
+      };
+      return { sessions: { ...s.sessions, [restored.teamID]: [...current, session] } };
+    });
+  },
+
   removeTerminal: async (teamID, sessionID) => {
     try {
       await CloseTerminal(sessionID);
@@ -3,7 +3,12 @@
 import {prompt} from '../models';
 import {team} from '../models';
 import {cli} from '../models';
+import {orchestrator} from '../models';
 import {types} from '../models';
+import {pty} from '../models';
+import {engine} from '../models';
+
+export function ArchiveRoom(arg1:string):Promise<void>;
 
 export function CloseTerminal(arg1:string):Promise<void>;
 
@@ -15,38 +20,76 @@ export function CreateTerminal(arg1:string,arg2:string,arg3:string,arg4:string,a
 
 export function DeletePrompt(arg1:string):Promise<void>;
 
+export function DeleteRoom(arg1:string):Promise<void>;
+
 export function DeleteTeam(arg1:string):Promise<void>;
 
 export function DetectCLIs():Promise<Array<cli.CLIInfo>>;
 
+export function ExportRoom(arg1:string,arg2:string):Promise<string>;
+
 export function GetAgents(arg1:string):Promise<Record<string, types.Agent>>;
 
+export function GetDeliveryMetrics():Promise<orchestrator.DeliveryMetrics>;
+
+export function GetDeliveryStates(arg1:string):Promise<Array<orchestrator.MessageDelivery>>;
+
 export function GetGlobalPrompt():Promise<string>;
 
+export function GetHumanName():Promise<string>;
+
 export function GetMessages(arg1:string):Promise<Array<types.Message>>;
 
+export function GetPausedPairs(arg1:string):Promise<Array<orchestrator.LoopAlert>>;
+
 export function GetPrompt(arg1:string):Promise<prompt.Prompt>;
 
+export function GetRateStats(arg1:string):Promise<types.RateStats>;
+
+export function GetRestoredSessions():Promise<Array<engine.RestoredSession>>;
+
+export function GetScrollback(arg1:string):Promise<string>;
+
 export function GetTeam(arg1:string):Promise<team.Team>;
 
 export function GetTerminalSessions(arg1:string):Promise<Array<Record<string, string>>>;
 
+export function GetTranscriptSettings():Promise<pty.TranscriptSettings>;
+
+export function ImportRoom(arg1:string,arg2:string):Promise<void>;
+
 export function ListPrompts():Promise<Array<prompt.Prompt>>;
 
 export function ListTeams():Promise<Array<team.Team>>;
 
 export function OpenDirectoryDialog():Promise<string>;
 
+export function RenameRoom(arg1:string,arg2:string):Promise<void>;
+
 export function ResizeTerminal(arg1:string,arg2:number,arg3:number):Promise<void>;
 
 export function RestartTerminal(arg1:string):Promise<string>;
 
+export function ResumeAgentPair(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function SearchTranscripts(arg1:string,arg2:string):Promise<Array<pty.TranscriptMatch>>;
+
+export function SendHumanMessage(arg1:string,arg2:string,arg3:string,arg4:string,arg5:boolean):Promise<void>;
+
 export function SendPromptToAgent(arg1:string,arg2:string,arg3:Record<string, string>):Promise<void>;
 
+export function SetAgentDeliverySettings(arg1:string,arg2:string,arg3:team.DeliverySettings):Promise<team.Team>;
+
 export function SetGlobalPrompt(arg1:string):Promise<void>;
 
+export function SetHumanName(arg1:string):Promise<void>;
+
+export function SetTeamAnalysisRules(arg1:string,arg2:Array<team.AnalysisRule>):Promise<team.Team>;
+
 export function SetTeamManager(arg1:string,arg2:string):Promise<team.Team>;
 
+export function SetTranscriptSettings(arg1:pty.TranscriptSettings):Promise<void>;
+
 export function UpdatePrompt(arg1:string,arg2:string,arg3:string,arg4:string,arg5:Array<string>):Promise<prompt.Prompt>;
 
 export function UpdateTeam(arg1:string,arg2:string,arg3:string,arg4:Array<team.AgentConfig>):Promise<team.Team>;
@@ -2,6 +2,10 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function ArchiveRoom(arg1) {
+  return window['go']['main']['App']['ArchiveRoom'](arg1);
+}
+
 export function CloseTerminal(arg1) {
   return window['go']['main']['App']['CloseTerminal'](arg1);
 }
@@ -22,6 +26,10 @@ export function DeletePrompt(arg1) {
   return window['go']['main']['App']['DeletePrompt'](arg1);
 }
 
+export function DeleteRoom(arg1) {
+  return window['go']['main']['App']['DeleteRoom'](arg1);
+}
+
 export function DeleteTeam(arg1) {
   return window['go']['main']['App']['DeleteTeam'](arg1);
 }
@@ -30,22 +38,54 @@ export function DetectCLIs() {
   return window['go']['main']['App']['DetectCLIs']();
 }
 
+export function ExportRoom(arg1, arg2) {
+  return window['go']['main']['App']['ExportRoom'](arg1, arg2);
+}
+
 export function GetAgents(arg1) {
   return window['go']['main']['App']['GetAgents'](arg1);
 }
 
+export function GetDeliveryMetrics() {
+  return window['go']['main']['App']['GetDeliveryMetrics']();
+}
+
+export function GetDeliveryStates(arg1) {
+  return window['go']['main']['App']['GetDeliveryStates'](arg1);
+}
+
 export function GetGlobalPrompt() {
   return window['go']['main']['App']['GetGlobalPrompt']();
 }
 
+export function GetHumanName() {
+  return window['go']['main']['App']['GetHumanName']();
+}
+
 export function GetMessages(arg1) {
   return window['go']['main']['App']['GetMessages'](arg1);
 }
 
+export function GetPausedPairs(arg1) {
+  return window['go']['main']['App']['GetPausedPairs'](arg1);
+}
+
 export function GetPrompt(arg1) {
   return window['go']['main']['App']['GetPrompt'](arg1);
 }
 
+export function GetRateStats(arg1) {
+  return window['go']['main']['App']['GetRateStats'](arg1);
+}
+
+export function GetRestoredSessions() {
+  return window['go']['main']['App']['GetRestoredSessions']();
+}
+
+export function GetScrollback(arg1) {
+  return window['go']['main']['App']['GetScrollback'](arg1);
+}
+
 export function GetTeam(arg1) {
   return window['go']['main']['App']['GetTeam'](arg1);
 }
@@ -54,6 +94,14 @@ export function GetTerminalSessions(arg1) {
   return window['go']['main']['App']['GetTerminalSessions'](arg1);
 }
 
+export function GetTranscriptSettings() {
+  return window['go']['main']['App']['GetTranscriptSettings']();
+}
+
+export function ImportRoom(arg1, arg2) {
+  return window['go']['main']['App']['ImportRoom'](arg1, arg2);
+}
+
 export function ListPrompts() {
   return window['go']['main']['App']['ListPrompts']();
 }
@@ -66,6 +114,10 @@ export function OpenDirectoryDialog() {
   return window['go']['main']['App']['OpenDirectoryDialog']();
 }
 
+export function RenameRoom(arg1, arg2) {
+  return window['go']['main']['App']['RenameRoom'](arg1, arg2);
+}
+
 export function ResizeTerminal(arg1, arg2, arg3) {
   return window['go']['main']['App']['ResizeTerminal'](arg1, arg2, arg3);
 }
@@ -74,18 +126,46 @@ export function RestartTerminal(arg1) {
   return window['go']['main']['App']['RestartTerminal'](arg1);
 }
 
+export function ResumeAgentPair(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ResumeAgentPair'](arg1, arg2, arg3);
+}
+
+export function SearchTranscripts(arg1, arg2) {
+  return window['go']['main']['App']['SearchTranscripts'](arg1, arg2);
+}
+
+export function SendHumanMessage(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['SendHumanMessage'](arg1, arg2, arg3, arg4, arg5);
+}
+
 export function SendPromptToAgent(arg1, arg2, arg3) {
   return window['go']['main']['App']['SendPromptToAgent'](arg1, arg2, arg3);
 }
 
+export function SetAgentDeliverySettings(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetAgentDeliverySettings'](arg1, arg2, arg3);
+}
+
 export function SetGlobalPrompt(arg1) {
   return window['go']['main']['App']['SetGlobalPrompt'](arg1);
 }
 
+export function SetHumanName(arg1) {
+  return window['go']['main']['App']['SetHumanName'](arg1);
+}
+
+export function SetTeamAnalysisRules(arg1, arg2) {
+  return window['go']['main']['App']['SetTeamAnalysisRules'](arg1, arg2);
+}
+
 export function SetTeamManager(arg1, arg2) {
   return window['go']['main']['App']['SetTeamManager'](arg1, arg2);
 }
 
+export function SetTranscriptSettings(arg1) {
+  return window['go']['main']['App']['SetTranscriptSettings'](arg1);
+}
+
 export function UpdatePrompt(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['main']['App']['UpdatePrompt'](arg1, arg2, arg3, arg4, arg5);
 }
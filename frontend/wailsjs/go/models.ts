@@ -23,6 +23,127 @@ export namespace cli {
 
 }
 
+export namespace engine {
+	
+	export class RestoredSession {
+	    sessionID: string;
+	    teamID: string;
+	    agentName: string;
+	    cliType: string;
+	    slotIndex: number;
+	    resumed: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new RestoredSession(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sessionID = source["sessionID"];
+	        this.teamID = source["teamID"];
+	        this.agentName = source["agentName"];
+	        this.cliType = source["cliType"];
+	        this.slotIndex = source["slotIndex"];
+	        this.resumed = source["resumed"];
+	    }
+	}
+
+}
+
+export namespace orchestrator {
+	
+	export class DeliveryMetrics {
+	    delivered: number;
+	    forced: number;
+	    dropped: number;
+	    pending: number;
+	    avg_latency_ms: number;
+	    max_latency_ms: number;
+	    last_latency_ms: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeliveryMetrics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.delivered = source["delivered"];
+	        this.forced = source["forced"];
+	        this.dropped = source["dropped"];
+	        this.pending = source["pending"];
+	        this.avg_latency_ms = source["avg_latency_ms"];
+	        this.max_latency_ms = source["max_latency_ms"];
+	        this.last_latency_ms = source["last_latency_ms"];
+	    }
+	}
+	export class MessageDelivery {
+	    message_id: number;
+	    agent: string;
+	    from: string;
+	    state: string;
+	    attempts: number;
+	    notified_at: string;
+	    read_at?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MessageDelivery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.message_id = source["message_id"];
+	        this.agent = source["agent"];
+	        this.from = source["from"];
+	        this.state = source["state"];
+	        this.attempts = source["attempts"];
+	        this.notified_at = source["notified_at"];
+	        this.read_at = source["read_at"];
+	    }
+	}
+	export class LoopAlert {
+	    chat_dir: string;
+	    agent_a: string;
+	    agent_b: string;
+	    reason: string;
+	    messages: number;
+	    // Go type: time
+	    paused_until: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new LoopAlert(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.chat_dir = source["chat_dir"];
+	        this.agent_a = source["agent_a"];
+	        this.agent_b = source["agent_b"];
+	        this.reason = source["reason"];
+	        this.messages = source["messages"];
+	        this.paused_until = this.convertValues(source["paused_until"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace prompt {
 	
 	export class Prompt {
@@ -54,14 +175,98 @@ export namespace prompt {
 
 }
 
+export namespace pty {
+	
+	export class TranscriptMatch {
+	    file: string;
+	    team_id: string;
+	    agent: string;
+	    started: string;
+	    line: number;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TranscriptMatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file = source["file"];
+	        this.team_id = source["team_id"];
+	        this.agent = source["agent"];
+	        this.started = source["started"];
+	        this.line = source["line"];
+	        this.text = source["text"];
+	    }
+	}
+	export class TranscriptSettings {
+	    enabled: boolean;
+	    raw: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TranscriptSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.raw = source["raw"];
+	    }
+	}
+
+}
+
 export namespace team {
 	
+	export class AnalysisRule {
+	    name?: string;
+	    patterns?: string[];
+	    sender?: string;
+	    priority?: string;
+	    action: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AnalysisRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.patterns = source["patterns"];
+	        this.sender = source["sender"];
+	        this.priority = source["priority"];
+	        this.action = source["action"];
+	    }
+	}
+	export class DeliverySettings {
+	    do_not_disturb?: boolean;
+	    quiet_hours?: string;
+	    max_per_window?: number;
+	    window_minutes?: number;
+	    digest_minutes?: number;
+	    muted_senders?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DeliverySettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.do_not_disturb = source["do_not_disturb"];
+	        this.quiet_hours = source["quiet_hours"];
+	        this.max_per_window = source["max_per_window"];
+	        this.window_minutes = source["window_minutes"];
+	        this.digest_minutes = source["digest_minutes"];
+	        this.muted_senders = source["muted_senders"];
+	    }
+	}
 	export class AgentConfig {
 	    name: string;
 	    role: string;
 	    prompt_id: string;
 	    work_dir: string;
 	    cli_type: string;
+	    delivery?: DeliverySettings;
 	
 	    static createFrom(source: any = {}) {
 	        return new AgentConfig(source);
@@ -74,7 +279,26 @@ export namespace team {
 	        this.prompt_id = source["prompt_id"];
 	        this.work_dir = source["work_dir"];
 	        this.cli_type = source["cli_type"];
+	        this.delivery = this.convertValues(source["delivery"], DeliverySettings);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class Team {
 	    id: string;
@@ -84,6 +308,7 @@ export namespace team {
 	    chat_dir: string;
 	    manager_agent: string;
 	    custom_prompt: string;
+	    analysis_rules?: AnalysisRule[];
 	    created_at: string;
 	
 	    static createFrom(source: any = {}) {
@@ -99,6 +324,7 @@ export namespace team {
 	        this.chat_dir = source["chat_dir"];
 	        this.manager_agent = source["manager_agent"];
 	        this.custom_prompt = source["custom_prompt"];
+	        this.analysis_rules = this.convertValues(source["analysis_rules"], AnalysisRule);
 	        this.created_at = source["created_at"];
 	    }
 	
@@ -125,6 +351,22 @@ export namespace team {
 
 export namespace types {
 	
+	export class MessageEdit {
+	    content: string;
+	    edited_at: string;
+	    edited_by: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MessageEdit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.content = source["content"];
+	        this.edited_at = source["edited_at"];
+	        this.edited_by = source["edited_by"];
+	    }
+	}
 	export class Message {
 	    id: number;
 	    from: string;
@@ -134,8 +376,16 @@ export namespace types {
 	    timestamp: string;
 	    type: string;
 	    routed_by_manager?: boolean;
+	    from_human?: boolean;
 	    expects_reply: boolean;
 	    priority: string;
+	    recipients?: string[];
+	    expires_at?: string;
+	    edited_at?: string;
+	    edits?: MessageEdit[];
+	    retracted?: boolean;
+	    retracted_at?: string;
+	    retracted_by?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new Message(source);
@@ -151,9 +401,87 @@ export namespace types {
 	        this.timestamp = source["timestamp"];
 	        this.type = source["type"];
 	        this.routed_by_manager = source["routed_by_manager"];
+	        this.from_human = source["from_human"];
 	        this.expects_reply = source["expects_reply"];
 	        this.priority = source["priority"];
+	        this.recipients = source["recipients"];
+	        this.expires_at = source["expires_at"];
+	        this.edited_at = source["edited_at"];
+	        this.edits = this.convertValues(source["edits"], MessageEdit);
+	        this.retracted = source["retracted"];
+	        this.retracted_at = source["retracted_at"];
+	        this.retracted_by = source["retracted_by"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RateCounter {
+	    allowed: number;
+	    limited: number;
+	    bytes: number;
+	    last_limited?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RateCounter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.allowed = source["allowed"];
+	        this.limited = source["limited"];
+	        this.bytes = source["bytes"];
+	        this.last_limited = source["last_limited"];
+	    }
+	}
+	export class RateStats {
+	    room: string;
+	    total: RateCounter;
+	    agents: Record<string, RateCounter>;
+	
+	    static createFrom(source: any = {}) {
+	        return new RateStats(source);
 	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.room = source["room"];
+	        this.total = this.convertValues(source["total"], RateCounter);
+	        this.agents = this.convertValues(source["agents"], RateCounter, true);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 
 }
@@ -0,0 +1,54 @@
+package cli
+
+import "regexp"
+
+// conversationPatterns find the conversation ID a CLI prints for resuming its
+// session (usually in the hint shown when it exits).
+var conversationPatterns = map[CLIType]*regexp.Regexp{
+	CLIClaude:  regexp.MustCompile(`claude (?:--resume|-r) ([0-9a-fA-F-]{36})`),
+	CLICodex:   regexp.MustCompile(`codex resume ([0-9a-fA-F-]{36})|(?i)session id:\s*([0-9a-f-]{36})`),
+	CLIGemini:  regexp.MustCompile(`gemini (?:--resume|-r) ([\w-]+)`),
+	CLICopilot: regexp.MustCompile(`copilot --resume[= ]([0-9a-fA-F-]{36})`),
+}
+
+// SupportsResume reports whether a CLI can resume a previous conversation.
+func SupportsResume(cliType CLIType) bool {
+	_, ok := conversationPatterns[cliType]
+	return ok
+}
+
+// ScrapeConversationID returns the last conversation ID found in a CLI's
+// ANSI-stripped output, or "" if there is none.
+func ScrapeConversationID(cliType CLIType, output string) string {
+	re, ok := conversationPatterns[cliType]
+	if !ok {
+		return ""
+	}
+	matches := re.FindAllStringSubmatch(output, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		for _, group := range matches[i][1:] {
+			if group != "" {
+				return group
+			}
+		}
+	}
+	return ""
+}
+
+// ResumeArgs returns the arguments that start a CLI on a previous
+// conversation, given its normal arguments from GetCommand.
+func ResumeArgs(cliType CLIType, args []string, conversationID string) []string {
+	out := make([]string, 0, len(args)+2)
+	switch cliType {
+	case CLICodex:
+		// Codex resumes through a subcommand that takes the usual flags.
+		out = append(out, "resume", conversationID)
+		out = append(out, args...)
+	case CLIClaude, CLIGemini, CLICopilot:
+		out = append(out, args...)
+		out = append(out, "--resume", conversationID)
+	default:
+		out = append(out, args...)
+	}
+	return out
+}
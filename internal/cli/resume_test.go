@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScrapeConversationID_LastHintWins(t *testing.T) {
+	out := "Resume this session with:\nclaude --resume 11111111-2222-3333-4444-555555555555\n" +
+		"...\nclaude --resume aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee\n"
+	if got := ScrapeConversationID(CLIClaude, out); got != "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee" {
+		t.Fatalf("claude conversation = %q", got)
+	}
+	if got := ScrapeConversationID(CLICodex, "session id: 0199a1b2-c3d4-7e5f-8a9b-0c1d2e3f4a5b\n"); got != "0199a1b2-c3d4-7e5f-8a9b-0c1d2e3f4a5b" {
+		t.Fatalf("codex conversation = %q", got)
+	}
+	if got := ScrapeConversationID(CLIShell, out); got != "" {
+		t.Fatalf("shell has no conversations, got %q", got)
+	}
+}
+
+func TestResumeArgs(t *testing.T) {
+	base := []string{"--dangerously-skip-permissions"}
+	if got := ResumeArgs(CLIClaude, base, "abc"); !reflect.DeepEqual(got, []string{"--dangerously-skip-permissions", "--resume", "abc"}) {
+		t.Fatalf("claude resume args = %q", got)
+	}
+	if got := ResumeArgs(CLICodex, []string{"--x"}, "abc"); !reflect.DeepEqual(got, []string{"resume", "abc", "--x"}) {
+		t.Fatalf("codex resume args = %q", got)
+	}
+	if got := ResumeArgs(CLIShell, []string{"-l"}, "abc"); !reflect.DeepEqual(got, []string{"-l"}) {
+		t.Fatalf("shell resume args = %q", got)
+	}
+	if len(base) != 1 {
+		t.Fatalf("ResumeArgs must not modify its input")
+	}
+}
@@ -43,6 +43,7 @@ func ComposeStartupPrompt(basePrompt, globalPrompt, teamPrompt, selectedPrompt,
 	joinInstruction := fmt.Sprintf(
 		"Sen '%s' agent'ısın. '%s' takımındasın.\n"+
 			"Hemen join_room(\"%s\", \"%s\") çağır ve odaya katıl.\n"+
+			"Uzmanlıklarını join_room'un languages/areas/tools parametreleriyle belirt (ör. areas=\"db,backend\").\n"+
 			"%s\n"+
 			"Tüm tool çağrılarında agent_name olarak her zaman \"%s\" kullan.",
 		agentName, teamName,
@@ -0,0 +1,102 @@
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// settingList collects repeated --set key=value flags.
+type settingList map[string]string
+
+func (s settingList) String() string { return fmt.Sprint(map[string]string(s)) }
+
+func (s settingList) Set(v string) error {
+	key, value, ok := strings.Cut(v, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", v)
+	}
+	s[key] = value
+	return nil
+}
+
+func runConnectors(e *env, args []string) error {
+	fs := e.flags("connectors")
+	kind := fs.String("kind", "webhook", "add: connector kind")
+	target := fs.String("url", "", "add: external URL room messages are posted to (webhook)")
+	format := fs.String("format", "", "add: outbound body, json or text (webhook)")
+	settings := settingList{}
+	fs.Var(settings, "set", "add: connector setting key=value (repeatable)")
+	pos, err := parse(fs, args, 1, 3)
+	if err != nil {
+		return err
+	}
+
+	action, rest := "list", pos
+	switch pos[0] {
+	case "list", "add", "rm":
+		action, rest = pos[0], pos[1:]
+	}
+	want := map[string]int{"list": 1, "add": 1, "rm": 2}[action]
+	if len(rest) != want {
+		fs.Usage()
+		return fmt.Errorf("usage error: connectors %s", strings.Join(pos, " "))
+	}
+	room := rest[0]
+
+	client, err := e.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	switch action {
+	case "add":
+		if *target != "" {
+			settings["url"] = *target
+		}
+		if *format != "" {
+			settings["format"] = *format
+		}
+		resp, err := client.AddConnector(room, *kind, settings)
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return fmt.Errorf("%s", resp.Error)
+		}
+		var data struct {
+			Text      string `json:"text"`
+			Connector struct {
+				ID       int               `json:"id"`
+				Settings map[string]string `json:"settings"`
+			} `json:"connector"`
+		}
+		if err := json.Unmarshal(resp.Data, &data); err != nil {
+			return err
+		}
+		fmt.Fprintln(e.stdout, data.Text)
+		fmt.Fprintf(e.stdout, "inbound: POST /api/connectors/%d/inbound\n", data.Connector.ID)
+		keys := make([]string, 0, len(data.Connector.Settings))
+		for k := range data.Connector.Settings {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(e.stdout, "%s: %s\n", k, data.Connector.Settings[k])
+		}
+		return nil
+	case "rm":
+		id, err := strconv.Atoi(rest[1])
+		if err != nil {
+			return fmt.Errorf("invalid connector ID %q", rest[1])
+		}
+		resp, err := client.RemoveConnector(room, id)
+		return printText(e.stdout, resp, err)
+	default:
+		resp, err := client.ListConnectors(room)
+		return printText(e.stdout, resp, err)
+	}
+}
@@ -0,0 +1,163 @@
+// Package ctl implements the agent-chat command-line client: scripting and
+// inspecting rooms from a shell without writing a WebSocket client. Hub
+// commands connect as the desktop client using the token the running app or
+// daemon writes to hub.token; team commands go through the daemon API.
+package ctl
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"desktop/internal/hubclient"
+)
+
+// command is one agent-chat subcommand.
+type command struct {
+	usage string
+	help  string
+	run   func(env *env, args []string) error
+}
+
+// commands is filled in init: the subcommands refer back to it for usage.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"rooms":      {"rooms [--archived]", "list rooms, or archived rooms", runRooms},
+		"agents":     {"agents [--json] ROOM", "list a room's agents", runAgents},
+		"tail":       {"tail [-n N] [-f=false] [--json] ROOM", "print recent messages and follow new ones", runTail},
+		"send":       {"send [--as NAME] [--to AGENTS] [--priority P] [--no-reply] [--bypass-manager] ROOM [--] TEXT|-", "send a message to a room", runSend},
+		"clear":      {"clear ROOM", "clear a room's messages and agents", runClear},
+		"rename":     {"rename ROOM NEW_NAME", "rename a room; agents keep reaching it by the old name", runRename},
+		"archive":    {"archive ROOM", "archive an idle room; joining it restores it", runArchive},
+		"delete":     {"delete ROOM", "delete a live or archived room for good", runDelete},
+		"export":     {"export [-o FILE] [--format jsonl|markdown] ROOM", "write a room as JSON Lines or a Markdown transcript", runExport},
+		"import":     {"import [-i FILE] ROOM", "seed an empty room from a JSON Lines export", runImport},
+		"replay":     {"replay [--speed N] [--until ID] [--json] [-o FILE] EXPORT|-", "replay a room export through a sandbox hub and orchestrator", runReplay},
+		"teams":      {"teams [list | start TEAM]", "list teams or launch a team's agents (daemon)", runTeams},
+		"hooks":      {"hooks [list|add|rm|log] [--url U|--command C] ROOM [ID]", "manage a room's event hooks", runHooks},
+		"connectors": {"connectors [list|add|rm] [--url U] [--set K=V]... ROOM [ID]", "bridge a room to an external chat", runConnectors},
+	}
+}
+
+// IsCommand reports whether name is an agent-chat client subcommand.
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// env is what a subcommand runs with.
+type env struct {
+	dataDir string
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+	// dial connects to the hub as the desktop client.
+	dial func() (*hubclient.HubClient, error)
+}
+
+// Run runs the subcommand args[0] with the rest of args and returns the
+// process exit code.
+func Run(args []string, dataDir string) int {
+	e := &env{dataDir: dataDir, stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	e.dial = e.dialHub
+	return e.run(args)
+}
+
+func (e *env) run(args []string) int {
+	if len(args) == 0 || !IsCommand(args[0]) {
+		e.usage()
+		return 2
+	}
+	if err := commands[args[0]].run(e, args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return 2
+		}
+		fmt.Fprintf(e.stderr, "agent-chat %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func (e *env) usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(e.stderr, "usage: agent-chat COMMAND [ARGS]")
+	fmt.Fprintln(e.stderr, "\ncommands:")
+	for _, name := range names {
+		c := commands[name]
+		fmt.Fprintf(e.stderr, "  %-58s %s\n", c.usage, c.help)
+	}
+	fmt.Fprintln(e.stderr, "  daemon [--team TEAM]...                                    run without the desktop window")
+	fmt.Fprintln(e.stderr, "\nAGENT_CHAT_DATA_DIR overrides the data directory (~/.agent-chat).")
+}
+
+// flags creates the flag set of a subcommand.
+func (e *env) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: agent-chat %s\n", commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses a subcommand's flags and checks its positional argument count.
+func parse(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	// Allow flags after the positional arguments too ("tail ROOM -n 5"). A
+	// "--" ends flag parsing, so text starting with "-" can still be sent.
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		args = rest
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) < min || (max >= 0 && len(positional) > max) {
+		fs.Usage()
+		return nil, flag.ErrHelp
+	}
+	return positional, nil
+}
+
+// dialHub connects to the hub of the app or daemon running on the data
+// directory and identifies as the desktop client.
+func (e *env) dialHub() (*hubclient.HubClient, error) {
+	addr, err := hubclient.DiscoverHubAddr(e.dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("hub not running (start the app or agent-chat daemon): %w", err)
+	}
+	token, err := os.ReadFile(filepath.Join(e.dataDir, "hub.token"))
+	if err != nil {
+		return nil, fmt.Errorf("hub token: %w", err)
+	}
+	client := hubclient.New(addr, log.New(io.Discard, "", 0))
+	if err := client.Connect(); err != nil {
+		return nil, err
+	}
+	if err := client.Identify("desktop", "", "", strings.TrimSpace(string(token))); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
@@ -0,0 +1,228 @@
+package ctl
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"desktop/internal/hub"
+	"desktop/internal/types"
+)
+
+// startHub runs a real hub on a temporary data directory, the way the app
+// does, and returns a client environment pointed at it.
+func startHub(t *testing.T) (*env, *bytes.Buffer) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("AGENT_CHAT_HUB_TOKEN", "test-token")
+	h := hub.New(dir, "default", log.New(io.Discard, "", 0))
+	go h.Run(0)
+	t.Cleanup(h.Shutdown)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !fileExists(filepath.Join(dir, "hub.port")) {
+		if time.Now().After(deadline) {
+			t.Fatal("hub did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "hub.token"), []byte("test-token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	e := &env{dataDir: dir, stdin: strings.NewReader(""), stdout: &out, stderr: io.Discard}
+	e.dial = e.dialHub
+	return e, &out
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestSendThenTail(t *testing.T) {
+	e, out := startHub(t)
+
+	if code := e.run([]string{"send", "--as", "ops", "--priority", "urgent", "lobby", "deploy is done"}); code != 0 {
+		t.Fatalf("send exited %d", code)
+	}
+	out.Reset()
+	if code := e.run([]string{"tail", "lobby", "-f=false"}); code != 0 {
+		t.Fatalf("tail exited %d", code)
+	}
+	got := out.String()
+	if !strings.Contains(got, "ops -> all !urgent: deploy is done") {
+		t.Errorf("tail output = %q", got)
+	}
+}
+
+func TestSendTextAfterTerminator(t *testing.T) {
+	e, out := startHub(t)
+
+	if code := e.run([]string{"send", "--as", "ops", "lobby", "--", "-1 on that"}); code != 0 {
+		t.Fatalf("send exited %d", code)
+	}
+	out.Reset()
+	e.run([]string{"tail", "-f=false", "lobby"})
+	if !strings.Contains(out.String(), "ops -> all: -1 on that") {
+		t.Errorf("tail output = %q", out.String())
+	}
+}
+
+func TestSendReadsStdin(t *testing.T) {
+	e, out := startHub(t)
+	e.stdin = strings.NewReader("from a pipe\n")
+
+	if code := e.run([]string{"send", "--as", "ci", "lobby", "-"}); code != 0 {
+		t.Fatalf("send exited %d", code)
+	}
+	out.Reset()
+	e.run([]string{"tail", "-f=false", "lobby"})
+	if !strings.Contains(out.String(), "ci -> all: from a pipe") {
+		t.Errorf("tail output = %q", out.String())
+	}
+}
+
+func TestDialRejectsWrongToken(t *testing.T) {
+	e, _ := startHub(t)
+	os.WriteFile(filepath.Join(e.dataDir, "hub.token"), []byte("wrong"), 0600)
+	if code := e.run([]string{"rooms"}); code != 1 {
+		t.Errorf("rooms with a wrong token exited %d, want 1", code)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	e := &env{stderr: io.Discard, stdout: io.Discard}
+	for _, args := range [][]string{nil, {"nope"}, {"tail"}, {"send", "room"}} {
+		if code := e.run(args); code != 2 {
+			t.Errorf("%v exited %d, want 2", args, code)
+		}
+	}
+}
+
+func TestFormatMessage(t *testing.T) {
+	m := types.Message{ID: 7, From: "dev", To: "qa", Content: "ready", Timestamp: "not a time", EditedAt: "x"}
+	if got, want := formatMessage(m), "#7 not a time dev -> qa (edited): ready"; got != want {
+		t.Errorf("formatMessage = %q, want %q", got, want)
+	}
+}
+
+func TestHooksAddListRemove(t *testing.T) {
+	e, out := startHub(t)
+
+	if code := e.run([]string{"hooks", "add", "--event", "room_cleared", "--command", "true", "lobby"}); code != 0 {
+		t.Fatalf("hooks add exited %d", code)
+	}
+	out.Reset()
+	if code := e.run([]string{"hooks", "lobby"}); code != 0 {
+		t.Fatalf("hooks list exited %d", code)
+	}
+	if !strings.Contains(out.String(), "[1] room_cleared") {
+		t.Errorf("hooks output = %q", out.String())
+	}
+	if code := e.run([]string{"hooks", "add", "--url", "http://example.com/x", "lobby"}); code != 1 {
+		t.Errorf("non-local hook URL exited %d, want 1", code)
+	}
+	if code := e.run([]string{"hooks", "rm", "lobby", "1"}); code != 0 {
+		t.Errorf("hooks rm exited %d", code)
+	}
+}
+
+func TestConnectorsAddPrintsSecret(t *testing.T) {
+	e, out := startHub(t)
+
+	if code := e.run([]string{"connectors", "add", "--url", "http://127.0.0.1:1/in", "--format", "text", "lobby"}); code != 0 {
+		t.Fatalf("connectors add exited %d", code)
+	}
+	got := out.String()
+	if !strings.Contains(got, "inbound: POST /api/connectors/1/inbound") || !strings.Contains(got, "secret: ") {
+		t.Errorf("connectors add output = %q", got)
+	}
+	if code := e.run([]string{"connectors", "add", "--kind", "nope", "lobby"}); code != 1 {
+		t.Errorf("unknown kind exited %d, want 1", code)
+	}
+	if code := e.run([]string{"connectors", "rm", "lobby", "1"}); code != 0 {
+		t.Errorf("connectors rm exited %d", code)
+	}
+}
+
+func TestExportThenImport(t *testing.T) {
+	e, out := startHub(t)
+
+	e.run([]string{"send", "--as", "ops", "lobby", "release notes are up"})
+	out.Reset()
+	if code := e.run([]string{"export", "lobby"}); code != 0 {
+		t.Fatalf("export exited %d", code)
+	}
+	exported := out.String()
+	if !strings.HasPrefix(exported, `{"type":"room"`) {
+		t.Fatalf("export output = %q", exported)
+	}
+
+	e.stdin = strings.NewReader(exported)
+	out.Reset()
+	if code := e.run([]string{"import", "replay"}); code != 0 {
+		t.Fatalf("import exited %d", code)
+	}
+	out.Reset()
+	if code := e.run([]string{"export", "--format", "markdown", "replay"}); code != 0 {
+		t.Fatalf("markdown export exited %d", code)
+	}
+	if got := out.String(); !strings.Contains(got, "# replay") || !strings.Contains(got, "> release notes are up") {
+		t.Errorf("markdown export = %q", got)
+	}
+}
+
+func TestRenameArchiveDelete(t *testing.T) {
+	e, out := startHub(t)
+
+	e.run([]string{"send", "--as", "ops", "lobby", "hello"})
+	if code := e.run([]string{"rename", "lobby", "hall"}); code != 0 {
+		t.Fatalf("rename exited %d", code)
+	}
+	if code := e.run([]string{"archive", "hall"}); code != 0 {
+		t.Fatalf("archive exited %d", code)
+	}
+	out.Reset()
+	e.run([]string{"rooms", "--archived"})
+	if got := out.String(); !strings.Contains(got, "hall - ") {
+		t.Errorf("archived rooms = %q", got)
+	}
+	if code := e.run([]string{"delete", "hall"}); code != 0 {
+		t.Fatalf("delete exited %d", code)
+	}
+	out.Reset()
+	e.run([]string{"rooms", "--archived"})
+	if got := out.String(); strings.Contains(got, "hall") {
+		t.Errorf("deleted room still archived: %q", got)
+	}
+	if code := e.run([]string{"delete", "hall"}); code != 1 {
+		t.Errorf("deleting a missing room exited %d, want 1", code)
+	}
+}
+
+func TestReplayPrintsTraceAndWritesSandbox(t *testing.T) {
+	var out bytes.Buffer
+	e := &env{dataDir: t.TempDir(), stdin: strings.NewReader(""), stdout: &out, stderr: io.Discard}
+	sandbox := filepath.Join(t.TempDir(), "sandbox.jsonl")
+
+	if code := e.run([]string{"replay", "-o", sandbox, "../replay/testdata/schema-incident.jsonl"}); code != 0 {
+		t.Fatalf("replay exited %d", code)
+	}
+	if got := out.String(); !strings.Contains(got, "1m34s notify          lead         [agent-chat] 1 new messages from backend") {
+		t.Errorf("replay output = %q", got)
+	}
+	data, err := os.ReadFile(sandbox)
+	if err != nil || !strings.HasPrefix(string(data), `{"type":"room"`) {
+		t.Errorf("sandbox export = %q, %v", data, err)
+	}
+	if code := e.run([]string{"replay", "--until", "99", "../replay/testdata/schema-incident.jsonl"}); code != 1 {
+		t.Errorf("unknown --until exited %d, want 1", code)
+	}
+}
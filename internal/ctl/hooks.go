@@ -0,0 +1,94 @@
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func runHooks(e *env, args []string) error {
+	fs := e.flags("hooks")
+	event := fs.String("event", "message_new", "add: event that triggers the hook, or * for every event")
+	from := fs.String("from", "", "add: only messages from this sender")
+	to := fs.String("to", "", "add: only messages addressed to this agent")
+	contains := fs.String("contains", "", "add: only messages containing this text (case-insensitive)")
+	priority := fs.String("priority", "", "add: only messages with this priority")
+	hookURL := fs.String("url", "", "add: local URL to POST the event JSON to")
+	command := fs.String("command", "", "add: shell command to run with the event JSON on stdin")
+	timeout := fs.String("timeout", "", "add: per-attempt timeout (default 10s)")
+	retries := fs.Int("retries", 0, "add: retries after a failed attempt")
+	n := fs.Int("n", 20, "log: number of deliveries to print")
+	pos, err := parse(fs, args, 1, 3)
+	if err != nil {
+		return err
+	}
+
+	action, rest := "list", pos
+	switch pos[0] {
+	case "list", "add", "rm", "log":
+		action, rest = pos[0], pos[1:]
+	}
+	want := map[string]int{"list": 1, "add": 1, "rm": 2, "log": 1}[action]
+	if len(rest) != want {
+		fs.Usage()
+		return fmt.Errorf("usage error: hooks %s", strings.Join(pos, " "))
+	}
+	room := rest[0]
+
+	client, err := e.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	switch action {
+	case "add":
+		hook := map[string]any{
+			"event": *event, "from": *from, "to": *to, "contains": *contains, "priority": *priority,
+			"url": *hookURL, "command": *command, "timeout": *timeout, "retries": *retries,
+		}
+		resp, err := client.AddHook(room, hook)
+		return printText(e.stdout, resp, err)
+	case "rm":
+		id, err := strconv.Atoi(rest[1])
+		if err != nil {
+			return fmt.Errorf("invalid hook ID %q", rest[1])
+		}
+		resp, err := client.RemoveHook(room, id)
+		return printText(e.stdout, resp, err)
+	case "log":
+		resp, err := client.HookLog(room, *n)
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return fmt.Errorf("%s", resp.Error)
+		}
+		var data struct {
+			Deliveries []struct {
+				HookID     int    `json:"hook_id"`
+				Event      string `json:"event"`
+				Attempts   int    `json:"attempts"`
+				Success    bool   `json:"success"`
+				Error      string `json:"error"`
+				DurationMS int64  `json:"duration_ms"`
+				At         string `json:"at"`
+			} `json:"deliveries"`
+		}
+		if err := json.Unmarshal(resp.Data, &data); err != nil {
+			return err
+		}
+		for _, d := range data.Deliveries {
+			status := "ok"
+			if !d.Success {
+				status = "failed: " + d.Error
+			}
+			fmt.Fprintf(e.stdout, "%s hook=%d %s attempts=%d %dms %s\n", d.At, d.HookID, d.Event, d.Attempts, d.DurationMS, status)
+		}
+		return nil
+	default:
+		resp, err := client.ListHooks(room)
+		return printText(e.stdout, resp, err)
+	}
+}
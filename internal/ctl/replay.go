@@ -0,0 +1,89 @@
+package ctl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"desktop/internal/hub"
+	"desktop/internal/replay"
+)
+
+// runReplay replays a room export in-process; it needs no running hub.
+func runReplay(e *env, args []string) error {
+	fs := e.flags("replay")
+	speed := fs.Float64("speed", 0, "playback speed: 1 recorded pace, 10 ten times faster, 0 as fast as possible")
+	until := fs.Int("until", 0, "stop after the recorded message with this ID")
+	tail := fs.Duration("tail", replay.DefaultTail, "keep the clock running this long after the last message")
+	asJSON := fs.Bool("json", false, "print the trace and the final sandbox state as JSON")
+	out := fs.String("o", "", "write the sandbox room as JSON Lines (for agent-chat import)")
+	verbose := fs.Bool("v", false, "print the hub and orchestrator logs to stderr")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	var data io.Reader = e.stdin
+	if pos[0] != "-" {
+		f, err := os.Open(pos[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		data = f
+	}
+	rec, err := hub.ReadExport(data)
+	if err != nil {
+		return err
+	}
+
+	opts := replay.Options{Speed: *speed, Until: *until, Tail: *tail}
+	// The orchestrator logs through the standard logger.
+	logOut := log.Writer()
+	defer log.SetOutput(logOut)
+	if *verbose {
+		log.SetOutput(e.stderr)
+		opts.Logger = log.New(e.stderr, "[HUB] ", log.LstdFlags)
+	} else {
+		log.SetOutput(io.Discard)
+	}
+	// A timed replay is watched as it runs; otherwise print the final trace,
+	// whose order does not depend on the run.
+	live := *speed > 0 && !*asJSON
+	if live {
+		opts.OnEvent = func(ev replay.Event) { fmt.Fprintln(e.stdout, formatReplayEvent(ev)) }
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	res, err := replay.Run(ctx, rec, opts)
+	if res != nil && *out != "" {
+		if werr := os.WriteFile(*out, []byte(res.Export), 0o644); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(e.stdout, res)
+	}
+	if !live {
+		for _, ev := range res.Trace {
+			fmt.Fprintln(e.stdout, formatReplayEvent(ev))
+		}
+	}
+	return nil
+}
+
+func formatReplayEvent(ev replay.Event) string {
+	var ids string
+	if ev.MessageID > 0 {
+		ids = fmt.Sprintf("#%d ", ev.MessageID)
+	}
+	return strings.TrimRight(fmt.Sprintf("%8s %-15s %-12s %s%s", ev.At, ev.Kind, ev.Agent, ids, ev.Text), " ")
+}
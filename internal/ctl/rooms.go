@@ -0,0 +1,390 @@
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"desktop/internal/hubclient"
+	"desktop/internal/types"
+)
+
+// printText prints the text of a hub response ({"text": ...}) or its error.
+func printText(w io.Writer, resp *types.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("%s", resp.Error)
+	}
+	var data struct {
+		Text string `json:"text"`
+	}
+	json.Unmarshal(resp.Data, &data)
+	if data.Text != "" {
+		fmt.Fprintln(w, strings.TrimRight(data.Text, "\n"))
+	}
+	return nil
+}
+
+func runRooms(e *env, args []string) error {
+	fs := e.flags("rooms")
+	archived := fs.Bool("archived", false, "list archived rooms instead")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	client, err := e.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if *archived {
+		resp, err := client.ListArchivedRooms()
+		return printText(e.stdout, resp, err)
+	}
+	resp, err := client.ListRooms()
+	return printText(e.stdout, resp, err)
+}
+
+func runAgents(e *env, args []string) error {
+	fs := e.flags("agents")
+	asJSON := fs.Bool("json", false, "print the raw agent records")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	client, err := e.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	agents, err := client.GetAgentsRaw(pos[0])
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(e.stdout, agents)
+	}
+	names := make([]string, 0, len(agents))
+	for name := range agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(e.stdout, formatAgent(name, agents[name]))
+	}
+	return nil
+}
+
+// formatAgent renders an agent as one line: name, role, status, activity.
+func formatAgent(name string, a types.Agent) string {
+	parts := []string{name}
+	if a.Role != "" {
+		parts = append(parts, "("+a.Role+")")
+	}
+	if a.Status != "" {
+		status := a.Status
+		if a.StatusText != "" {
+			status += ": " + a.StatusText
+		}
+		parts = append(parts, "["+status+"]")
+	}
+	if a.Activity != "" {
+		parts = append(parts, a.Activity)
+	}
+	return strings.Join(parts, " ")
+}
+
+func runTail(e *env, args []string) error {
+	fs := e.flags("tail")
+	n := fs.Int("n", 20, "number of recent messages to print")
+	follow := fs.Bool("f", true, "keep printing new messages until interrupted")
+	asJSON := fs.Bool("json", false, "print one JSON message per line")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	room := pos[0]
+	client, err := e.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	emit := func(m types.Message) {
+		if *asJSON {
+			data, _ := json.Marshal(m)
+			fmt.Fprintln(e.stdout, string(data))
+			return
+		}
+		fmt.Fprintln(e.stdout, formatMessage(m))
+	}
+
+	// Subscribe first so nothing sent between the history read and the
+	// subscription is lost; lastID drops what both of them return. The
+	// handler runs on the client's read loop and must never block it: when
+	// the buffer is full it flags overflow and the history is re-read.
+	events := make(chan types.Message, 64)
+	overflow := make(chan struct{}, 1)
+	if *follow {
+		client.SetEventHandler(func(ev types.Event) {
+			if ev.Event != "message_new" || ev.Room != room {
+				return
+			}
+			var data struct {
+				Message types.Message `json:"message"`
+			}
+			if json.Unmarshal(ev.Data, &data) != nil {
+				return
+			}
+			select {
+			case events <- data.Message:
+			default:
+				select {
+				case overflow <- struct{}{}:
+				default:
+				}
+			}
+		})
+		if err := client.Subscribe([]string{room}); err != nil {
+			return err
+		}
+	}
+
+	msgs, err := client.GetMessagesRaw(room)
+	if err != nil {
+		return err
+	}
+	lastID := 0
+	if len(msgs) > 0 {
+		lastID = msgs[len(msgs)-1].ID
+	}
+	if *n >= 0 && len(msgs) > *n {
+		msgs = msgs[len(msgs)-*n:]
+	}
+	for _, m := range msgs {
+		if !m.Retracted {
+			emit(m)
+		}
+	}
+	if !*follow {
+		return nil
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	alive := time.NewTicker(time.Second)
+	defer alive.Stop()
+	for {
+		select {
+		case m := <-events:
+			if m.ID > lastID {
+				lastID = m.ID
+				emit(m)
+			}
+		case <-overflow:
+			msgs, err := client.GetMessagesRaw(room)
+			if err != nil {
+				return err
+			}
+			for _, m := range msgs {
+				if m.ID > lastID {
+					lastID = m.ID
+					if !m.Retracted {
+						emit(m)
+					}
+				}
+			}
+		case <-alive.C:
+			if !client.Connected() {
+				return fmt.Errorf("hub connection lost")
+			}
+		case <-interrupt:
+			return nil
+		}
+	}
+}
+
+// formatMessage renders a message as one line for tail.
+func formatMessage(m types.Message) string {
+	at := m.Timestamp
+	if t, err := time.Parse(time.RFC3339Nano, m.Timestamp); err == nil {
+		at = t.Local().Format("15:04:05")
+	}
+	var flags string
+	if m.Priority != "" && m.Priority != "normal" {
+		flags += " !" + m.Priority
+	}
+	if m.EditedAt != "" {
+		flags += " (edited)"
+	}
+	return fmt.Sprintf("#%d %s %s -> %s%s: %s", m.ID, at, m.From, m.To, flags, m.Content)
+}
+
+func runSend(e *env, args []string) error {
+	fs := e.flags("send")
+	as := fs.String("as", defaultSender(), "name to send as (joins the room as a human under this name)")
+	to := fs.String("to", "all", "recipients: agent, comma-separated agents, @group or all")
+	priority := fs.String("priority", "normal", "urgent, normal or low")
+	noReply := fs.Bool("no-reply", false, "mark the message as not expecting a reply")
+	bypass := fs.Bool("bypass-manager", false, "deliver directly even when the room has a manager")
+	pos, err := parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	room, content := pos[0], pos[1]
+	if content == "-" {
+		data, err := io.ReadAll(e.stdin)
+		if err != nil {
+			return err
+		}
+		content = strings.TrimRight(string(data), "\n")
+	}
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("empty message")
+	}
+
+	client, err := e.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.JoinHuman(room, *as); err != nil {
+		return err
+	}
+	resp, err := client.SendMessage(room, *as, *to, content, !*noReply, *priority, hubclient.SendOptions{
+		Human:         true,
+		BypassManager: *bypass,
+	})
+	return printText(e.stdout, resp, err)
+}
+
+// defaultSender is the name send joins rooms under when --as is not given.
+func defaultSender() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return "human"
+}
+
+func runClear(e *env, args []string) error {
+	fs := e.flags("clear")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	client, err := e.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	resp, err := client.ClearRoom(pos[0])
+	return printText(e.stdout, resp, err)
+}
+
+func runRename(e *env, args []string) error {
+	fs := e.flags("rename")
+	pos, err := parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	client, err := e.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	resp, err := client.RenameRoom(pos[0], pos[1])
+	return printText(e.stdout, resp, err)
+}
+
+func runArchive(e *env, args []string) error {
+	fs := e.flags("archive")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	client, err := e.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	resp, err := client.ArchiveRoom(pos[0])
+	return printText(e.stdout, resp, err)
+}
+
+func runDelete(e *env, args []string) error {
+	fs := e.flags("delete")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	client, err := e.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	resp, err := client.DeleteRoom(pos[0])
+	return printText(e.stdout, resp, err)
+}
+
+func runExport(e *env, args []string) error {
+	fs := e.flags("export")
+	out := fs.String("o", "", "output file (default stdout)")
+	format := fs.String("format", "jsonl", "jsonl (full metadata, importable) or markdown (transcript)")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	client, err := e.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	data, err := client.ExportRoom(pos[0], *format)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err := io.WriteString(e.stdout, data)
+		return err
+	}
+	return os.WriteFile(*out, []byte(data), 0o644)
+}
+
+func runImport(e *env, args []string) error {
+	fs := e.flags("import")
+	in := fs.String("i", "-", "JSONL export to read, - for stdin")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if *in == "-" {
+		data, err = io.ReadAll(e.stdin)
+	} else {
+		data, err = os.ReadFile(*in)
+	}
+	if err != nil {
+		return err
+	}
+	client, err := e.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	resp, err := client.ImportRoom(pos[0], string(data))
+	return printText(e.stdout, resp, err)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
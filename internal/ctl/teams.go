@@ -0,0 +1,97 @@
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"desktop/internal/daemon"
+	"desktop/internal/team"
+)
+
+func runTeams(e *env, args []string) error {
+	fs := e.flags("teams")
+	pos, err := parse(fs, args, 0, 2)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(pos) == 0 || (len(pos) == 1 && pos[0] == "list"):
+		return listTeams(e)
+	case len(pos) == 2 && pos[0] == "start":
+		return startTeam(e, pos[1])
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown teams command: %s", strings.Join(pos, " "))
+	}
+}
+
+// listTeams prints the configured teams. It reads teams.json directly, so
+// it works whether or not the app is running.
+func listTeams(e *env) error {
+	store, err := team.NewStore(e.dataDir)
+	if err != nil {
+		return err
+	}
+	for _, t := range store.List() {
+		names := make([]string, 0, len(t.Agents))
+		for _, ag := range t.Agents {
+			names = append(names, ag.Name)
+		}
+		fmt.Fprintf(e.stdout, "%s  %s  room=%s  agents=%s\n", t.ID, t.Name, t.ChatDir, strings.Join(names, ","))
+	}
+	return nil
+}
+
+// startTeam asks the running daemon to launch a team's agents.
+func startTeam(e *env, name string) error {
+	var out struct {
+		TeamID  string   `json:"team_id"`
+		Started []string `json:"started"`
+	}
+	if err := daemonRequest(e.dataDir, "POST", "/v1/teams/"+url.PathEscape(name)+"/start", &out); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "started %d agent(s)\n", len(out.Started))
+	return nil
+}
+
+// daemonClient bounds daemon API calls; starting a team waits for the
+// previous run's sessions to be restored first.
+var daemonClient = &http.Client{Timeout: time.Minute}
+
+// daemonRequest calls the control API of the daemon running on dataDir and
+// decodes its JSON answer into out.
+func daemonRequest(dataDir, method, path string, out interface{}) error {
+	info, err := daemon.ReadInfo(dataDir)
+	if err != nil {
+		return fmt.Errorf("no running daemon (start it with agent-chat daemon): %w", err)
+	}
+	req, err := http.NewRequest(method, "http://"+info.Addr+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+info.Token)
+	resp, err := daemonClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Error == "" {
+			apiErr.Error = resp.Status
+		}
+		return fmt.Errorf("%s", apiErr.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,60 @@
+package daemon
+
+import (
+	"strings"
+	"sync"
+)
+
+// Event is one engine event as streamed by GET /v1/events.
+type Event struct {
+	Name string      `json:"name"`
+	Data interface{} `json:"data"`
+}
+
+// subscriberBuffer bounds how far a slow event stream may fall behind before
+// events are dropped for it.
+const subscriberBuffer = 256
+
+// Broadcaster is the engine's EventSink in daemon mode. It fans events out to
+// the connected event streams; nothing is kept for clients that connect later.
+type Broadcaster struct {
+	mu   sync.Mutex
+	subs map[chan Event]bool // value: wants terminal output
+}
+
+// NewBroadcaster creates an empty broadcaster.
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{subs: make(map[chan Event]bool)}
+}
+
+// Emit implements engine.EventSink.
+func (b *Broadcaster) Emit(name string, data interface{}) {
+	isOutput := strings.HasPrefix(name, "pty:output:")
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch, wantsOutput := range b.subs {
+		if isOutput && !wantsOutput {
+			continue
+		}
+		select {
+		case ch <- Event{Name: name, Data: data}:
+		default: // slow reader: drop rather than stall the engine
+		}
+	}
+}
+
+// subscribe registers an event stream. Terminal output is only included
+// when withOutput is set, as it dwarfs every other event.
+func (b *Broadcaster) subscribe(withOutput bool) chan Event {
+	ch := make(chan Event, subscriberBuffer)
+	b.mu.Lock()
+	b.subs[ch] = withOutput
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *Broadcaster) unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	delete(b.subs, ch)
+	b.mu.Unlock()
+}
@@ -0,0 +1,148 @@
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"desktop/internal/engine"
+)
+
+// InfoFile is where a running daemon publishes its address and token,
+// relative to the data directory.
+const InfoFile = "daemon.json"
+
+// Info tells local clients how to reach a running daemon.
+type Info struct {
+	Addr  string `json:"addr"`
+	Token string `json:"token"`
+	PID   int    `json:"pid"`
+}
+
+// ReadInfo reads the info file of the daemon running on dataDir.
+func ReadInfo(dataDir string) (Info, error) {
+	var info Info
+	data, err := os.ReadFile(filepath.Join(dataDir, InfoFile))
+	if err != nil {
+		return info, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, fmt.Errorf("invalid %s: %w", InfoFile, err)
+	}
+	return info, nil
+}
+
+func writeInfo(dataDir string, info Info) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dataDir, InfoFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Options configures Run.
+type Options struct {
+	// Addr is the control API address; it must be a loopback address.
+	// Empty means a random port on 127.0.0.1.
+	Addr string
+	// Teams are started (by ID or name) once the engine is up.
+	Teams []string
+}
+
+// Run starts the engine, launches the requested teams and serves the control
+// API until ctx ends or a client asks for shutdown. The engine is stopped
+// before Run returns, which records its terminals for the next start.
+func Run(ctx context.Context, eng *engine.Engine, events *Broadcaster, opts Options) error {
+	addr := opts.Addr
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("control API must listen on loopback, not %q", host)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := eng.Start(ctx); err != nil {
+		eng.Stop()
+		return err
+	}
+	defer eng.Stop()
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("control API: %w", err)
+	}
+	info := Info{Addr: listener.Addr().String(), Token: token, PID: os.Getpid()}
+	if err := writeInfo(eng.DataDir(), info); err != nil {
+		listener.Close()
+		return fmt.Errorf("write %s: %w", InfoFile, err)
+	}
+	defer os.Remove(filepath.Join(eng.DataDir(), InfoFile))
+
+	srv := &http.Server{Handler: NewServer(eng, events, token, cancel).Handler()}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(listener) }()
+	log.Printf("[DAEMON] Control API on http://%s", info.Addr)
+
+	for _, name := range opts.Teams {
+		go startTeam(eng, name)
+	}
+
+	select {
+	case <-ctx.Done():
+	case err = <-serveErr:
+	}
+	log.Printf("[DAEMON] Shutting down")
+	shutdownCtx, stop := context.WithTimeout(context.Background(), 3*time.Second)
+	defer stop()
+	srv.Shutdown(shutdownCtx)
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	return err
+}
+
+func startTeam(eng *engine.Engine, name string) {
+	t, err := eng.FindTeam(name)
+	if err != nil {
+		log.Printf("[DAEMON] %v", err)
+		return
+	}
+	started, err := eng.StartTeam(t.ID)
+	if err != nil {
+		log.Printf("[DAEMON] Team %s: %v", t.Name, err)
+	}
+	log.Printf("[DAEMON] Team %s: started %d agent(s)", t.Name, len(started))
+}
+
+func newToken() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,245 @@
+// Package daemon serves the local control API of "agent-chat daemon", which
+// runs the engine without the desktop window. The API listens on loopback
+// only and every request must carry the bearer token from daemon.json.
+package daemon
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"desktop/internal/engine"
+	"desktop/internal/team"
+)
+
+// Controller is the part of the engine the control API drives.
+type Controller interface {
+	ListTeams() []team.Team
+	FindTeam(idOrName string) (team.Team, error)
+	StartTeam(teamID string) ([]string, error)
+	Sessions() []engine.SessionInfo
+	CreateTerminal(teamID, agentName, workDir, cliType, promptID string) (string, error)
+	CloseTerminal(sessionID string) error
+	WriteToTerminal(sessionID, data string) error
+	InjectInput(sessionID, text string) error
+	GetScrollback(sessionID string) (string, error)
+}
+
+// Server handles the control API.
+type Server struct {
+	ctl      Controller
+	events   *Broadcaster
+	token    string
+	shutdown func()
+	started  time.Time
+}
+
+// NewServer creates a control API server. shutdown is called when a client
+// asks the daemon to stop.
+func NewServer(ctl Controller, events *Broadcaster, token string, shutdown func()) *Server {
+	return &Server{ctl: ctl, events: events, token: token, shutdown: shutdown, started: time.Now()}
+}
+
+// Handler returns the API routes behind token authentication.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", s.handleStatus)
+	mux.HandleFunc("GET /v1/teams", s.handleTeams)
+	mux.HandleFunc("POST /v1/teams/{team}/start", s.handleStartTeam)
+	mux.HandleFunc("GET /v1/sessions", s.handleSessions)
+	mux.HandleFunc("POST /v1/sessions", s.handleCreateSession)
+	mux.HandleFunc("DELETE /v1/sessions/{id}", s.handleCloseSession)
+	mux.HandleFunc("POST /v1/sessions/{id}/input", s.handleInput)
+	mux.HandleFunc("GET /v1/sessions/{id}/scrollback", s.handleScrollback)
+	mux.HandleFunc("GET /v1/events", s.handleEvents)
+	mux.HandleFunc("POST /v1/shutdown", s.handleShutdown)
+	return s.authorize(mux)
+}
+
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if s.token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid or missing token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Status is the response of GET /v1/status.
+type Status struct {
+	PID      int    `json:"pid"`
+	Started  string `json:"started"`
+	Sessions int    `json:"sessions"`
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Status{
+		PID:      os.Getpid(),
+		Started:  s.started.Format(time.RFC3339),
+		Sessions: len(s.ctl.Sessions()),
+	})
+}
+
+func (s *Server) handleTeams(w http.ResponseWriter, r *http.Request) {
+	teams := s.ctl.ListTeams()
+	if teams == nil {
+		teams = []team.Team{}
+	}
+	writeJSON(w, http.StatusOK, teams)
+}
+
+func (s *Server) handleStartTeam(w http.ResponseWriter, r *http.Request) {
+	t, err := s.ctl.FindTeam(r.PathValue("team"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	started, err := s.ctl.StartTeam(t.ID)
+	if started == nil {
+		started = []string{}
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": err.Error(), "started": started})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"team_id": t.ID, "started": started})
+}
+
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	sessions := s.ctl.Sessions()
+	if sessions == nil {
+		sessions = []engine.SessionInfo{}
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+// CreateSessionRequest is the body of POST /v1/sessions.
+type CreateSessionRequest struct {
+	TeamID    string `json:"team_id"`
+	AgentName string `json:"agent_name"`
+	WorkDir   string `json:"work_dir"`
+	CLIType   string `json:"cli_type"`
+	PromptID  string `json:"prompt_id"`
+}
+
+func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	var req CreateSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	if req.TeamID != "" {
+		t, err := s.ctl.FindTeam(req.TeamID)
+		if err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		req.TeamID = t.ID
+	}
+	sessionID, err := s.ctl.CreateTerminal(req.TeamID, req.AgentName, req.WorkDir, req.CLIType, req.PromptID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"session_id": sessionID})
+}
+
+func (s *Server) handleCloseSession(w http.ResponseWriter, r *http.Request) {
+	if err := s.ctl.CloseTerminal(r.PathValue("id")); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// InputRequest is the body of POST /v1/sessions/{id}/input. With Submit the
+// text is typed the way the session's CLI expects and submitted; otherwise
+// it is written to the terminal as is.
+type InputRequest struct {
+	Text   string `json:"text"`
+	Submit bool   `json:"submit"`
+}
+
+func (s *Server) handleInput(w http.ResponseWriter, r *http.Request) {
+	var req InputRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	id := r.PathValue("id")
+	var err error
+	if req.Submit {
+		err = s.ctl.InjectInput(id, req.Text)
+	} else {
+		err = s.ctl.WriteToTerminal(id, req.Text)
+	}
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleScrollback(w http.ResponseWriter, r *http.Request) {
+	text, err := s.ctl.GetScrollback(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(text))
+}
+
+// handleEvents streams engine events as server-sent events until the client
+// disconnects. Terminal output is included only with ?pty=1.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming unsupported"))
+		return
+	}
+	ch := s.events.subscribe(r.URL.Query().Get("pty") == "1")
+	defer s.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev := <-ch:
+			data, err := json.Marshal(ev.Data)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Name, data)
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) handleShutdown(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusAccepted)
+	if s.shutdown != nil {
+		go s.shutdown()
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,185 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"desktop/internal/engine"
+	"desktop/internal/team"
+)
+
+type fakeController struct {
+	teams    []team.Team
+	sessions []engine.SessionInfo
+	written  []string
+	injected []string
+	started  []string
+}
+
+func (f *fakeController) ListTeams() []team.Team { return f.teams }
+
+func (f *fakeController) FindTeam(idOrName string) (team.Team, error) {
+	for _, t := range f.teams {
+		if t.ID == idOrName || t.Name == idOrName {
+			return t, nil
+		}
+	}
+	return team.Team{}, fmt.Errorf("team not found: %s", idOrName)
+}
+
+func (f *fakeController) StartTeam(teamID string) ([]string, error) {
+	f.started = append(f.started, teamID)
+	return []string{"s-" + teamID}, nil
+}
+
+func (f *fakeController) Sessions() []engine.SessionInfo { return f.sessions }
+
+func (f *fakeController) CreateTerminal(teamID, agentName, workDir, cliType, promptID string) (string, error) {
+	return teamID + "/" + agentName, nil
+}
+
+func (f *fakeController) CloseTerminal(sessionID string) error {
+	return f.check(sessionID)
+}
+
+func (f *fakeController) WriteToTerminal(sessionID, data string) error {
+	f.written = append(f.written, data)
+	return f.check(sessionID)
+}
+
+func (f *fakeController) InjectInput(sessionID, text string) error {
+	f.injected = append(f.injected, text)
+	return f.check(sessionID)
+}
+
+func (f *fakeController) GetScrollback(sessionID string) (string, error) {
+	return "scrollback of " + sessionID, f.check(sessionID)
+}
+
+func (f *fakeController) check(sessionID string) error {
+	for _, s := range f.sessions {
+		if s.SessionID == sessionID {
+			return nil
+		}
+	}
+	return fmt.Errorf("session not found: %s", sessionID)
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *fakeController, *Broadcaster) {
+	t.Helper()
+	ctl := &fakeController{
+		teams:    []team.Team{{ID: "t1", Name: "backend"}},
+		sessions: []engine.SessionInfo{{SessionID: "abc", TeamID: "t1", AgentName: "dev"}},
+	}
+	events := NewBroadcaster()
+	srv := httptest.NewServer(NewServer(ctl, events, "secret", nil).Handler())
+	t.Cleanup(srv.Close)
+	return srv, ctl, events
+}
+
+func do(t *testing.T, srv *httptest.Server, method, path, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+	for _, header := range []string{"", "Bearer wrong"} {
+		req, _ := http.NewRequest("GET", srv.URL+"/v1/status", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status %d, want 401", header, resp.StatusCode)
+		}
+	}
+}
+
+func TestServerStartTeamByName(t *testing.T) {
+	srv, ctl, _ := newTestServer(t)
+	resp := do(t, srv, "POST", "/v1/teams/backend/start", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	if len(ctl.started) != 1 || ctl.started[0] != "t1" {
+		t.Errorf("started = %v, want [t1]", ctl.started)
+	}
+	if resp := do(t, srv, "POST", "/v1/teams/nope/start", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown team: status %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestServerInput(t *testing.T) {
+	srv, ctl, _ := newTestServer(t)
+	do(t, srv, "POST", "/v1/sessions/abc/input", `{"text":"ls\r"}`)
+	do(t, srv, "POST", "/v1/sessions/abc/input", `{"text":"hello","submit":true}`)
+	if len(ctl.written) != 1 || ctl.written[0] != "ls\r" {
+		t.Errorf("written = %q", ctl.written)
+	}
+	if len(ctl.injected) != 1 || ctl.injected[0] != "hello" {
+		t.Errorf("injected = %q", ctl.injected)
+	}
+	if resp := do(t, srv, "POST", "/v1/sessions/zzz/input", `{"text":"x"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown session: status %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestServerCreateSessionResolvesTeam(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+	resp := do(t, srv, "POST", "/v1/sessions", `{"team_id":"backend","agent_name":"qa","cli_type":"claude"}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	var out map[string]string
+	json.NewDecoder(resp.Body).Decode(&out)
+	if out["session_id"] != "t1/qa" {
+		t.Errorf("session_id = %q, want t1/qa", out["session_id"])
+	}
+}
+
+func TestServerEventsSkipOutputByDefault(t *testing.T) {
+	srv, _, events := newTestServer(t)
+	resp := do(t, srv, "GET", "/v1/events", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+
+	// The handler subscribes before answering, so these are not missed.
+	events.Emit("pty:output:abc", "noise")
+	events.Emit("messages:new", map[string]string{"from": "dev"})
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	select {
+	case line := <-lines:
+		if line != "event: messages:new" {
+			t.Errorf("first line = %q, want the messages:new event", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+	}
+}
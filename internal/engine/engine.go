@@ -0,0 +1,200 @@
+// Package engine runs agent-chat without any UI: the hub process, the agent
+// terminals, startup prompts, session restore and the orchestrator that
+// nudges agents about new messages. The Wails desktop app and the headless
+// daemon are thin front ends over an Engine.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"desktop/internal/cli"
+	"desktop/internal/hubclient"
+	"desktop/internal/orchestrator"
+	"desktop/internal/prompt"
+	ptymgr "desktop/internal/pty"
+	"desktop/internal/session"
+	"desktop/internal/team"
+)
+
+// EventSink receives what the engine reports to its front end: hub activity
+// ("messages:new", "agents:updated", ...), terminal output
+// ("pty:output:<sessionID>"), restored sessions and orchestrator alerts.
+type EventSink interface {
+	Emit(name string, data interface{})
+}
+
+// Config configures an Engine.
+type Config struct {
+	// DataDir holds teams, prompts, rooms and the hub files.
+	// Empty means DefaultDataDir().
+	DataDir string
+	// MCPServerBin is the MCP server binary, which also runs the hub.
+	MCPServerBin []byte
+	// Prompts holds prompts/base_prompt.md and prompts/manager_prompt.md.
+	Prompts fs.FS
+	// Sink receives engine events; nil discards them.
+	Sink EventSink
+}
+
+// Engine owns the hub process, the PTY sessions and the orchestrator.
+type Engine struct {
+	cfg          Config
+	ctx          context.Context
+	ptyManager   *ptymgr.Manager
+	hubClient    *hubclient.HubClient
+	hubProcess   *os.Process
+	hubAuthToken string
+	orchestrator *orchestrator.Orchestrator
+	promptStore  *prompt.Store
+	teamStore    *team.Store
+	sessionStore *session.Store
+	dataDir      string
+
+	restoring   atomic.Bool   // sessions.json is saved once restoring ends
+	restoreDone chan struct{} // closed when the previous run's terminals are back
+	restoredMu  sync.Mutex
+	restored    []RestoredSession
+}
+
+// DefaultDataDir returns ~/.agent-chat.
+func DefaultDataDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".agent-chat")
+}
+
+// New creates an engine. Nothing runs until Start.
+func New(cfg Config) *Engine {
+	if cfg.DataDir == "" {
+		cfg.DataDir = DefaultDataDir()
+	}
+	return &Engine{cfg: cfg, dataDir: cfg.DataDir}
+}
+
+// DataDir returns the engine's data directory.
+func (e *Engine) DataDir() string {
+	return e.dataDir
+}
+
+// HubAuthToken returns the token that identifies desktop-level clients to
+// the hub (empty until Start).
+func (e *Engine) HubAuthToken() string {
+	return e.hubAuthToken
+}
+
+func (e *Engine) emit(name string, data interface{}) {
+	if e.cfg.Sink != nil {
+		e.cfg.Sink.Emit(name, data)
+	}
+}
+
+// Start initializes the stores, starts and connects to the hub, restores the
+// previous run's terminals and starts background loops that end with ctx.
+func (e *Engine) Start(ctx context.Context) error {
+	e.ctx = ctx
+	e.restoreDone = make(chan struct{})
+	os.MkdirAll(e.dataDir, 0700)
+
+	// Initialize PTY manager
+	e.ptyManager = ptymgr.NewManager(func(sessionID string, data []byte) {
+		e.emit("pty:output:"+sessionID, string(data))
+	})
+
+	e.ptyManager.SetTranscripts(e.transcriptsDir(), e.GetTranscriptSettings())
+
+	// Initialize orchestrator
+	e.orchestrator = orchestrator.New(e.ptyManager)
+	e.orchestrator.SetLoopHandler(e.handleLoopDetected)
+	e.orchestrator.SetDeliveryFailureHandler(e.handleDeliveryFailed)
+
+	// Initialize stores
+	e.promptStore, _ = prompt.NewStore(e.dataDir)
+	e.teamStore, _ = team.NewStore(e.dataDir)
+	e.sessionStore = session.NewStore(e.dataDir)
+
+	// Seed prompts from existing files
+	e.seedPrompts()
+
+	// Load per-team message analysis rules and agent delivery settings
+	for _, t := range e.teamStore.List() {
+		e.applyAnalysisRules(t)
+		e.applyDeliverySettings(t)
+	}
+
+	// Setup MCP server binary synchronously
+	if err := cli.EnsureMCPServerBinary(e.cfg.MCPServerBin, e.dataDir); err != nil {
+		log.Printf("MCP server setup error: %v", err)
+	} else {
+		for _, ct := range []cli.CLIType{cli.CLIClaude, cli.CLIGemini, cli.CLICopilot, cli.CLICodex} {
+			cli.ResetMCPConfig(ct, e.dataDir)
+		}
+	}
+
+	// Start hub process
+	if err := e.startHub(); err != nil {
+		close(e.restoreDone)
+		return fmt.Errorf("hub start: %w", err)
+	}
+
+	// Connect to hub
+	if err := e.connectToHub(); err != nil {
+		close(e.restoreDone)
+		return fmt.Errorf("hub connect: %w", err)
+	}
+
+	// Subscribe to existing teams
+	e.subscribeExistingTeams()
+
+	// Bring back the terminals that were running when the app last quit
+	go e.restoreSessions()
+
+	// Monitor hub process
+	e.monitorHub()
+
+	// Report PTY activity of agent terminals to the hub
+	go e.reportActivityLoop()
+	return nil
+}
+
+// Stop stops the hub, closes every terminal and records them for the next
+// start.
+func (e *Engine) Stop() {
+	// Close hub client
+	if e.hubClient != nil {
+		e.hubClient.Close()
+	}
+
+	// Stop hub process gracefully
+	if e.hubProcess != nil {
+		e.hubProcess.Signal(syscall.SIGTERM)
+		// Wait up to 3s for hub to persist and shut down
+		done := make(chan struct{})
+		go func() {
+			e.hubProcess.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			log.Printf("[SHUTDOWN] Hub process exited gracefully")
+		case <-time.After(3 * time.Second):
+			log.Printf("[SHUTDOWN] Hub process did not exit in 3s, killing")
+			e.hubProcess.Kill()
+		}
+	}
+
+	// Close PTY sessions, then record them for the next start. Closing asks
+	// each CLI to exit, which is when most print their resume hint.
+	if e.ptyManager != nil {
+		sessions := e.ptyManager.ListSessions()
+		e.ptyManager.CloseAll()
+		e.saveSessionRecords(sessions)
+	}
+}
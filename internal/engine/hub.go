@@ -0,0 +1,519 @@
+package engine
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"desktop/internal/cli"
+	"desktop/internal/hubclient"
+	"desktop/internal/orchestrator"
+	"desktop/internal/types"
+)
+
+func newHubAuthToken() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// startHub spawns the hub process.
+func (e *Engine) startHub() error {
+	binPath := cli.GetMCPBinaryPath(e.dataDir)
+	if strings.TrimSpace(e.hubAuthToken) == "" {
+		token, err := newHubAuthToken()
+		if err != nil {
+			return fmt.Errorf("hub auth token üretilemedi: %w", err)
+		}
+		e.hubAuthToken = token
+	}
+	// Let local tools (agent-chat rooms, tail, send...) identify as desktop.
+	if err := os.WriteFile(filepath.Join(e.dataDir, "hub.token"), []byte(e.hubAuthToken), 0600); err != nil {
+		log.Printf("[STARTUP] Failed to write hub.token: %v", err)
+	}
+
+	// Remove stale port file to prevent connecting to old hub
+	os.Remove(filepath.Join(e.dataDir, "hub.port"))
+
+	cmd := exec.Command(binPath, "--hub")
+	cmd.Env = append(os.Environ(),
+		"AGENT_CHAT_DATA_DIR="+e.dataDir,
+		"AGENT_CHAT_HUB_TOKEN="+e.hubAuthToken,
+	)
+	cmd.Stdout = nil
+	cmd.Stderr = nil
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("hub start: %w", err)
+	}
+
+	e.hubProcess = cmd.Process
+	log.Printf("[STARTUP] Hub process started: pid=%d", cmd.Process.Pid)
+
+	// Wait for hub.port file (max 5s)
+	portPath := filepath.Join(e.dataDir, "hub.port")
+	for i := 0; i < 50; i++ {
+		if _, err := os.Stat(portPath); err == nil {
+			data, _ := os.ReadFile(portPath)
+			log.Printf("[STARTUP] Hub ready on port %s", strings.TrimSpace(string(data)))
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return fmt.Errorf("hub.port not created within 5s")
+}
+
+// connectToHub creates a hub client and connects.
+func (e *Engine) connectToHub() error {
+	hubAddr, err := hubclient.DiscoverHubAddr(e.dataDir)
+	if err != nil {
+		return err
+	}
+
+	client := hubclient.New(hubAddr, log.New(os.Stderr, "[HUB-CLIENT] ", log.LstdFlags))
+	if err := client.ConnectWithRetry(5); err != nil {
+		return err
+	}
+
+	// Set event handler
+	client.SetEventHandler(func(event types.Event) {
+		e.handleHubEvent(event)
+	})
+
+	// Identify as desktop client
+	if err := client.Identify("desktop", "", "", e.hubAuthToken); err != nil {
+		client.Close()
+		return err
+	}
+
+	e.hubClient = client
+	log.Printf("[STARTUP] Connected to hub")
+	return nil
+}
+
+// handleHubEvent processes events from the hub.
+func (e *Engine) handleHubEvent(event types.Event) {
+	switch event.Event {
+	case "message_new":
+		// Parse message from event data
+		var data struct {
+			Message types.Message `json:"message"`
+		}
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.Printf("[HUB-EVENT] Failed to parse message_new: %v", err)
+			return
+		}
+
+		// Emit to frontend
+		e.emit("messages:new", map[string]interface{}{
+			"chatDir":  event.Room,
+			"messages": []types.Message{data.Message},
+		})
+
+		// Process through orchestrator
+		e.orchestrator.ProcessMessage(event.Room, data.Message)
+
+	case "message_updated", "message_retracted":
+		var data struct {
+			Message types.Message `json:"message"`
+		}
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.Printf("[HUB-EVENT] Failed to parse %s: %v", event.Event, err)
+			return
+		}
+
+		e.emit("messages:updated", map[string]interface{}{
+			"chatDir":  event.Room,
+			"messages": []types.Message{data.Message},
+		})
+
+		if event.Event == "message_retracted" {
+			e.orchestrator.ProcessRetractedMessage(event.Room, data.Message)
+		} else {
+			e.orchestrator.ProcessUpdatedMessage(event.Room, data.Message)
+		}
+
+	case "agent_joined", "agent_left", "agent_status_changed":
+		var data struct {
+			AgentName string                 `json:"agent_name"`
+			Agents    map[string]types.Agent `json:"agents"`
+		}
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.Printf("[HUB-EVENT] Failed to parse %s: %v", event.Event, err)
+			return
+		}
+
+		e.emit("agents:updated", map[string]interface{}{
+			"chatDir": event.Room,
+			"agents":  data.Agents,
+		})
+
+	case "messages_read":
+		var data struct {
+			AgentName  string `json:"agent_name"`
+			MessageIDs []int  `json:"message_ids"`
+			UpToID     int    `json:"up_to_id"`
+		}
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.Printf("[HUB-EVENT] Failed to parse messages_read: %v", err)
+			return
+		}
+		e.orchestrator.AckRead(event.Room, data.AgentName, data.MessageIDs, data.UpToID)
+
+	case "room_renamed":
+		var data struct {
+			OldName string `json:"old_name"`
+			NewName string `json:"new_name"`
+		}
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.Printf("[HUB-EVENT] Failed to parse room_renamed: %v", err)
+			return
+		}
+		e.orchestrator.RenameChatDir(data.OldName, data.NewName)
+
+	case "room_cleared":
+		e.emit("agents:updated", map[string]interface{}{
+			"chatDir": event.Room,
+			"agents":  map[string]types.Agent{},
+		})
+	}
+}
+
+const (
+	// activityPollInterval is how often agent terminals' PTY activity is sampled.
+	activityPollInterval = 2 * time.Second
+	// activityHeartbeatInterval re-reports an unchanged activity so the hub
+	// keeps counting the agent as alive during long tool runs.
+	activityHeartbeatInterval = 30 * time.Second
+)
+
+// reportActivityLoop reports each agent terminal's PTY activity (busy, idle,
+// prompt_waiting) to the hub: immediately on change, otherwise as a heartbeat.
+func (e *Engine) reportActivityLoop() {
+	type reported struct {
+		activity string
+		at       time.Time
+	}
+	last := make(map[string]reported)
+
+	ticker := time.NewTicker(activityPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		client := e.hubClient
+		if client == nil {
+			continue
+		}
+		alive := make(map[string]bool)
+		for _, s := range e.ptyManager.ListSessions() {
+			if s.AgentName == "" || s.CLIType == "" || s.CLIType == string(cli.CLIShell) {
+				continue
+			}
+			alive[s.ID] = true
+			activity := e.ptyManager.Activity(s.ID)
+			prev := last[s.ID]
+			if activity == prev.activity && time.Since(prev.at) < activityHeartbeatInterval {
+				continue
+			}
+			// Fails until the agent has joined its room; retried on the next tick.
+			if err := client.ReportActivity(e.roomForTeam(s.TeamID), s.AgentName, activity); err != nil {
+				continue
+			}
+			last[s.ID] = reported{activity: activity, at: time.Now()}
+		}
+		for id := range last {
+			if !alive[id] {
+				delete(last, id)
+			}
+		}
+	}
+}
+
+// roomForTeam returns the hub room name of a team ("default" when unknown).
+func (e *Engine) roomForTeam(teamID string) string {
+	if teamID != "" {
+		if t, err := e.teamStore.Get(teamID); err == nil && t.Name != "" {
+			return t.Name
+		}
+	}
+	return "default"
+}
+
+// subscribeExistingTeams subscribes to hub events for all saved teams.
+func (e *Engine) subscribeExistingTeams() {
+	if e.hubClient == nil {
+		return
+	}
+	teams := e.teamStore.List()
+	var rooms []string
+	for _, t := range teams {
+		teamName := t.Name
+		if teamName == "" {
+			teamName = "default"
+		}
+		rooms = append(rooms, teamName)
+		e.syncHubManager(teamName, strings.TrimSpace(t.ManagerAgent))
+	}
+	if len(rooms) > 0 {
+		if err := e.hubClient.Subscribe(rooms); err != nil {
+			log.Printf("[HUB] Subscribe failed: %v", err)
+		}
+	}
+	for _, room := range rooms {
+		e.joinHuman(room)
+	}
+}
+
+func (e *Engine) syncHubManager(room, managerAgent string) {
+	if e.hubClient == nil || strings.TrimSpace(room) == "" {
+		return
+	}
+	if err := e.hubClient.SetManager(room, strings.TrimSpace(managerAgent)); err != nil {
+		log.Printf("[HUB] set_manager failed for room=%s manager=%s: %v", room, managerAgent, err)
+	}
+}
+
+// monitorHub watches the hub process and restarts if it crashes.
+func (e *Engine) monitorHub() {
+	if e.hubProcess == nil {
+		return
+	}
+	go func() {
+		state, err := e.hubProcess.Wait()
+		if err != nil {
+			log.Printf("[HUB-MONITOR] Hub process wait error: %v", err)
+		}
+		if state != nil && !state.Success() {
+			log.Printf("[HUB-MONITOR] Hub crashed (exit=%d), restarting...", state.ExitCode())
+			// Clean up old client
+			if e.hubClient != nil {
+				e.hubClient.Close()
+			}
+			// Restart
+			time.Sleep(500 * time.Millisecond)
+			if err := e.startHub(); err != nil {
+				log.Printf("[HUB-MONITOR] Hub restart failed: %v", err)
+				return
+			}
+			if err := e.connectToHub(); err != nil {
+				log.Printf("[HUB-MONITOR] Hub reconnect failed: %v", err)
+				return
+			}
+			e.subscribeExistingTeams()
+		}
+	}()
+}
+
+// ===================== Hub Bindings =====================
+
+// GetMessages returns all messages from a room
+func (e *Engine) GetMessages(room string) []types.Message {
+	if e.hubClient == nil {
+		return nil
+	}
+	msgs, err := e.hubClient.GetMessagesRaw(room)
+	if err != nil {
+		log.Printf("[HUB] GetMessages error for room %s: %v", room, err)
+		return nil
+	}
+	return msgs
+}
+
+// GetAgents returns all agents from a room
+func (e *Engine) GetAgents(room string) map[string]types.Agent {
+	if e.hubClient == nil {
+		return nil
+	}
+	agents, err := e.hubClient.GetAgentsRaw(room)
+	if err != nil {
+		log.Printf("[HUB] GetAgents error for room %s: %v", room, err)
+		return nil
+	}
+	return agents
+}
+
+// handleLoopDetected explains a tripped loop breaker in the room, alerts the
+// team manager's terminal and tells the frontend.
+func (e *Engine) handleLoopDetected(alert orchestrator.LoopAlert) {
+	reason := "çok sık mesajlaşma"
+	if alert.Reason == orchestrator.LoopReasonRepeated {
+		reason = "aynı içerik tekrar ediyor"
+	}
+	content := fmt.Sprintf("\u26d4 Döngü algılandı: %s \u2194 %s (%s, %d mesaj). Bu ikili arasındaki bildirimler %s saatine kadar durduruldu.",
+		alert.AgentA, alert.AgentB, reason, alert.Messages, alert.PausedUntil.Format("15:04"))
+	if client := e.hubClient; client != nil {
+		if err := client.PostSystem(alert.ChatDir, content); err != nil {
+			log.Printf("[ORCH] Loop system message failed room=%s: %v", alert.ChatDir, err)
+		}
+	}
+
+	for _, t := range e.teamStore.List() {
+		name := t.Name
+		if name == "" {
+			name = "default"
+		}
+		manager := strings.TrimSpace(t.ManagerAgent)
+		if name != alert.ChatDir || manager == "" || manager == alert.AgentA || manager == alert.AgentB {
+			continue
+		}
+		e.orchestrator.Alert(alert.ChatDir, manager, fmt.Sprintf(
+			"[agent-chat] Loop detected between %s and %s; their notifications are paused. read_all_messages() and step in if needed.",
+			alert.AgentA, alert.AgentB))
+	}
+
+	e.emit("loop:detected", map[string]interface{}{
+		"chatDir": alert.ChatDir,
+		"alert":   alert,
+	})
+}
+
+// handleDeliveryFailed tells the frontend that an agent left messages unread
+// after every reminder.
+func (e *Engine) handleDeliveryFailed(failure orchestrator.DeliveryFailure) {
+	e.emit("delivery:failed", map[string]interface{}{
+		"chatDir": failure.ChatDir,
+		"failure": failure,
+	})
+}
+
+// GetDeliveryStates returns recent per-message notification delivery states
+// (notified, retrying, read, failed) for a room.
+func (e *Engine) GetDeliveryStates(room string) []orchestrator.MessageDelivery {
+	if e.orchestrator == nil {
+		return nil
+	}
+	return e.orchestrator.DeliveryStates(room)
+}
+
+// GetPausedPairs returns the agent pairs whose notifications are paused by
+// the loop breaker in a room.
+func (e *Engine) GetPausedPairs(room string) []orchestrator.LoopAlert {
+	if e.orchestrator == nil {
+		return nil
+	}
+	return e.orchestrator.PausedPairs(room)
+}
+
+// ResumeAgentPair lifts a loop pause between two agents.
+func (e *Engine) ResumeAgentPair(room, agentA, agentB string) error {
+	if !e.orchestrator.ResumePair(room, agentA, agentB) {
+		return fmt.Errorf("no paused loop between %s and %s", agentA, agentB)
+	}
+	if client := e.hubClient; client != nil {
+		content := fmt.Sprintf("\u25b6\ufe0f %s \u2194 %s arasındaki bildirimler yeniden açıldı.", agentA, agentB)
+		if err := client.PostSystem(room, content); err != nil {
+			log.Printf("[ORCH] Resume system message failed room=%s: %v", room, err)
+		}
+	}
+	return nil
+}
+
+// GetRateStats returns the hub's send_message rate limiting counters for a room.
+func (e *Engine) GetRateStats(room string) (types.RateStats, error) {
+	if e.hubClient == nil {
+		return types.RateStats{}, fmt.Errorf("hub not connected")
+	}
+	return e.hubClient.GetRateStats(room)
+}
+
+// ExportRoom returns a room as JSON Lines ("jsonl") or a Markdown transcript ("markdown").
+func (e *Engine) ExportRoom(room, format string) (string, error) {
+	if e.hubClient == nil {
+		return "", fmt.Errorf("hub not connected")
+	}
+	return e.hubClient.ExportRoom(room, format)
+}
+
+// ImportRoom seeds an empty room from a JSON Lines export.
+func (e *Engine) ImportRoom(room, data string) error {
+	if e.hubClient == nil {
+		return fmt.Errorf("hub not connected")
+	}
+	resp, err := e.hubClient.ImportRoom(room, data)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("%s", resp.Error)
+	}
+	return nil
+}
+
+// DeleteRoom deletes a live or archived room with its persisted state.
+func (e *Engine) DeleteRoom(room string) error {
+	if e.hubClient == nil {
+		return fmt.Errorf("hub not connected")
+	}
+	return responseError(e.hubClient.DeleteRoom(room))
+}
+
+// ArchiveRoom archives an idle room; it is restored when joined again.
+func (e *Engine) ArchiveRoom(room string) error {
+	if e.hubClient == nil {
+		return fmt.Errorf("hub not connected")
+	}
+	return responseError(e.hubClient.ArchiveRoom(room))
+}
+
+// RenameRoom renames a room. A team named after the room is renamed with it,
+// so the team keeps its messages, manager and agents' room.
+func (e *Engine) RenameRoom(room, newName string) error {
+	if e.hubClient == nil {
+		return fmt.Errorf("hub not connected")
+	}
+	if err := responseError(e.hubClient.RenameRoom(room, newName)); err != nil {
+		return err
+	}
+	// Agent sessions, analyzers and delivery settings move with the room.
+	e.orchestrator.RenameChatDir(room, newName)
+	for _, t := range e.teamStore.List() {
+		if t.Name != room {
+			continue
+		}
+		if _, err := e.teamStore.Update(t.ID, newName, t.GridLayout, t.Agents); err != nil {
+			return fmt.Errorf("room renamed but team %s was not: %w", t.ID, err)
+		}
+	}
+	return nil
+}
+
+func responseError(resp *types.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("%s", resp.Error)
+	}
+	return nil
+}
+
+// GetDeliveryMetrics returns notification delivery counters and latency.
+func (e *Engine) GetDeliveryMetrics() orchestrator.DeliveryMetrics {
+	if e.orchestrator == nil {
+		return orchestrator.DeliveryMetrics{}
+	}
+	return e.orchestrator.DeliveryMetrics()
+}
+
+// WatchChatDir subscribes to a room (backward-compatible binding name).
+func (e *Engine) WatchChatDir(room string) error {
+	if e.hubClient == nil {
+		return fmt.Errorf("hub not connected")
+	}
+	return e.hubClient.Subscribe([]string{room})
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"desktop/internal/hubclient"
+	"desktop/internal/validation"
+)
+
+// defaultHumanName is the name the desktop user takes part in rooms under.
+const defaultHumanName = "user"
+
+// humanSettings is the on-disk form of human.json.
+type humanSettings struct {
+	Name string `json:"name"`
+}
+
+// GetHumanName returns the name the desktop user posts to rooms under.
+func (e *Engine) GetHumanName() string {
+	var settings humanSettings
+	data, err := os.ReadFile(filepath.Join(e.dataDir, "human.json"))
+	if err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			log.Printf("[HUMAN] invalid human.json: %v", err)
+		}
+	}
+	if strings.TrimSpace(settings.Name) == "" {
+		return defaultHumanName
+	}
+	return settings.Name
+}
+
+// SetHumanName renames the desktop user's participant in every team room.
+func (e *Engine) SetHumanName(name string) error {
+	name = strings.TrimSpace(name)
+	if err := validation.ValidateName(name); err != nil {
+		return err
+	}
+	old := e.GetHumanName()
+	data, err := json.MarshalIndent(humanSettings{Name: name}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(e.dataDir, "human.json"), data, 0644); err != nil {
+		return err
+	}
+	if old == name || e.hubClient == nil {
+		return nil
+	}
+	for _, room := range e.teamRooms() {
+		if _, err := e.hubClient.LeaveRoom(room, old); err != nil {
+			log.Printf("[HUMAN] leave failed for room=%s: %v", room, err)
+		}
+		e.joinHuman(room)
+	}
+	return nil
+}
+
+// SendHumanMessage posts a message from the desktop user to a room. With
+// bypassManager the message goes straight to its recipients even when the
+// room has an active manager.
+func (e *Engine) SendHumanMessage(room, to, content, priority string, bypassManager bool) error {
+	if e.hubClient == nil {
+		return fmt.Errorf("hub bağlantısı yok")
+	}
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("mesaj boş olamaz")
+	}
+	if strings.TrimSpace(to) == "" {
+		to = "all"
+	}
+	name := e.GetHumanName()
+	// Joining again is a no-op; it brings the participant back after the
+	// room was cleared or the team renamed.
+	if err := e.hubClient.JoinHuman(room, name); err != nil {
+		return err
+	}
+	resp, err := e.hubClient.SendMessage(room, name, to, content, true, priority, hubclient.SendOptions{
+		Human:         true,
+		BypassManager: bypassManager,
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("%s", resp.Error)
+	}
+	return nil
+}
+
+// joinHuman adds the desktop user to a room so agents can see and address them.
+func (e *Engine) joinHuman(room string) {
+	if e.hubClient == nil {
+		return
+	}
+	if err := e.hubClient.JoinHuman(room, e.GetHumanName()); err != nil {
+		log.Printf("[HUMAN] join failed for room=%s: %v", room, err)
+	}
+}
+
+// teamRooms returns the hub rooms of all saved teams.
+func (e *Engine) teamRooms() []string {
+	var rooms []string
+	for _, t := range e.teamStore.List() {
+		name := t.Name
+		if name == "" {
+			name = "default"
+		}
+		rooms = append(rooms, name)
+	}
+	return rooms
+}
@@ -0,0 +1,324 @@
+package engine
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"desktop/internal/cli"
+	"desktop/internal/orchestrator"
+	"desktop/internal/prompt"
+	"desktop/internal/team"
+	"desktop/internal/validation"
+)
+
+// ListTeams returns all teams
+func (e *Engine) ListTeams() []team.Team {
+	return e.teamStore.List()
+}
+
+// GetTeam returns a team by ID
+func (e *Engine) GetTeam(id string) (team.Team, error) {
+	return e.teamStore.Get(id)
+}
+
+// CreateTeam creates a new team
+func (e *Engine) CreateTeam(name, gridLayout string, agents []team.AgentConfig) (team.Team, error) {
+	t, err := e.teamStore.Create(name, gridLayout, agents)
+	if err != nil {
+		return team.Team{}, err
+	}
+
+	// Subscribe to hub events for this team
+	if e.hubClient != nil {
+		if err := e.hubClient.Subscribe([]string{name}); err != nil {
+			log.Printf("[HUB] Subscribe failed for room=%s: %v", name, err)
+		}
+	}
+	e.syncHubManager(t.Name, strings.TrimSpace(t.ManagerAgent))
+	e.joinHuman(t.Name)
+
+	return t, nil
+}
+
+// UpdateTeam updates a team
+func (e *Engine) UpdateTeam(id, name, gridLayout string, agents []team.AgentConfig) (team.Team, error) {
+	prev, err := e.teamStore.Get(id)
+	if err != nil {
+		return team.Team{}, err
+	}
+
+	updated, err := e.teamStore.Update(id, name, gridLayout, agents)
+	if err != nil {
+		return team.Team{}, err
+	}
+
+	if prev.Name != "" && prev.Name != updated.Name {
+		e.renameTeamRoom(prev.Name, updated.Name)
+		e.syncHubManager(prev.Name, "")
+		e.orchestrator.SetAnalyzer(prev.Name, nil)
+	}
+	e.clearDeliverySettings(prev)
+	e.syncHubManager(updated.Name, strings.TrimSpace(updated.ManagerAgent))
+	e.applyAnalysisRules(updated)
+	e.applyDeliverySettings(updated)
+
+	return updated, nil
+}
+
+// renameTeamRoom carries a renamed team's room, with its messages, over to
+// the new name. Without an existing room the new one is set up like a new
+// team's.
+func (e *Engine) renameTeamRoom(from, to string) {
+	if e.hubClient == nil {
+		return
+	}
+	err := responseError(e.hubClient.RenameRoom(from, to))
+	if err == nil {
+		e.orchestrator.RenameChatDir(from, to)
+		return
+	}
+	log.Printf("[HUB] rename_room %s -> %s failed: %v", from, to, err)
+	if err := e.hubClient.Subscribe([]string{to}); err != nil {
+		log.Printf("[HUB] Subscribe failed for room=%s: %v", to, err)
+	}
+	e.joinHuman(to)
+}
+
+// SetTeamManager sets or clears the manager agent for a team.
+func (e *Engine) SetTeamManager(id, managerAgent string) (team.Team, error) {
+	managerAgent = strings.TrimSpace(managerAgent)
+	if managerAgent != "" {
+		if err := validation.ValidateName(managerAgent); err != nil {
+			return team.Team{}, fmt.Errorf("invalid manager agent: %w", err)
+		}
+	}
+
+	t, err := e.teamStore.Get(id)
+	if err != nil {
+		return team.Team{}, err
+	}
+	if t.ManagerAgent != "" && managerAgent != "" && t.ManagerAgent != managerAgent {
+		return team.Team{}, fmt.Errorf("team already has manager '%s'; clear first before assigning '%s'", t.ManagerAgent, managerAgent)
+	}
+
+	updated, err := e.teamStore.SetManager(id, managerAgent)
+	if err != nil {
+		return team.Team{}, err
+	}
+	e.syncHubManager(updated.Name, strings.TrimSpace(updated.ManagerAgent))
+	return updated, nil
+}
+
+// DeleteTeam deletes a team
+func (e *Engine) DeleteTeam(id string) error {
+	t, getErr := e.teamStore.Get(id)
+	sessions := e.ptyManager.GetSessionsByTeam(id)
+	for _, s := range sessions {
+		e.ptyManager.Close(s.ID)
+	}
+
+	if err := e.teamStore.Delete(id); err != nil {
+		return err
+	}
+	if getErr == nil && t.Name != "" {
+		e.syncHubManager(t.Name, "")
+		e.orchestrator.SetAnalyzer(t.Name, nil)
+	}
+	if getErr == nil {
+		e.clearDeliverySettings(t)
+	}
+	return nil
+}
+
+// SetTeamAnalysisRules replaces the rules deciding which messages nudge the
+// team's agent terminals. An empty list restores the default heuristic.
+func (e *Engine) SetTeamAnalysisRules(id string, rules []team.AnalysisRule) (team.Team, error) {
+	if _, err := orchestrator.NewRuleAnalyzer(rules, nil); err != nil {
+		return team.Team{}, fmt.Errorf("invalid analysis rules: %w", err)
+	}
+	updated, err := e.teamStore.SetAnalysisRules(id, rules)
+	if err != nil {
+		return team.Team{}, err
+	}
+	e.applyAnalysisRules(updated)
+	return updated, nil
+}
+
+// SetAgentDeliverySettings replaces one agent's notification delivery
+// settings (do-not-disturb, quiet hours, budget, digest, muted senders).
+// Nil clears them.
+func (e *Engine) SetAgentDeliverySettings(id, agentName string, settings *team.DeliverySettings) (team.Team, error) {
+	if settings != nil {
+		if err := orchestrator.ValidateDeliverySettings(*settings); err != nil {
+			return team.Team{}, fmt.Errorf("invalid delivery settings: %w", err)
+		}
+	}
+	updated, err := e.teamStore.SetAgentDelivery(id, agentName, settings)
+	if err != nil {
+		return team.Team{}, err
+	}
+	e.applyDeliverySettings(updated)
+	return updated, nil
+}
+
+// applyDeliverySettings installs a team's per-agent delivery settings in the
+// orchestrator.
+func (e *Engine) applyDeliverySettings(t team.Team) {
+	room := t.Name
+	if room == "" {
+		room = "default"
+	}
+	for _, ag := range t.Agents {
+		if err := e.orchestrator.SetDeliverySettings(room, ag.Name, ag.Delivery); err != nil {
+			log.Printf("[ORCH] Ignoring invalid delivery settings for agent=%s team=%s: %v", ag.Name, t.Name, err)
+			e.orchestrator.SetDeliverySettings(room, ag.Name, nil)
+		}
+	}
+}
+
+// clearDeliverySettings removes a team's delivery settings from the
+// orchestrator (team renamed, agents removed or team deleted).
+func (e *Engine) clearDeliverySettings(t team.Team) {
+	room := t.Name
+	if room == "" {
+		room = "default"
+	}
+	for _, ag := range t.Agents {
+		e.orchestrator.SetDeliverySettings(room, ag.Name, nil)
+	}
+}
+
+// applyAnalysisRules installs a team's analysis rules in the orchestrator.
+func (e *Engine) applyAnalysisRules(t team.Team) {
+	room := t.Name
+	if room == "" {
+		room = "default"
+	}
+	if len(t.AnalysisRules) == 0 {
+		e.orchestrator.SetAnalyzer(room, nil)
+		return
+	}
+	analyzer, err := orchestrator.NewRuleAnalyzer(t.AnalysisRules, nil)
+	if err != nil {
+		log.Printf("[ORCH] Ignoring invalid analysis rules for team=%s: %v", t.Name, err)
+		e.orchestrator.SetAnalyzer(room, nil)
+		return
+	}
+	e.orchestrator.SetAnalyzer(room, analyzer)
+}
+
+// FindTeam returns the team with the given ID or name.
+func (e *Engine) FindTeam(idOrName string) (team.Team, error) {
+	for _, t := range e.teamStore.List() {
+		if t.ID == idOrName || t.Name == idOrName {
+			return t, nil
+		}
+	}
+	return team.Team{}, fmt.Errorf("team not found: %s", idOrName)
+}
+
+// StartTeam launches a terminal for every configured agent of a team that
+// is not running yet and returns the new session IDs. It waits for the
+// previous run's terminals to be restored first so none is started twice.
+func (e *Engine) StartTeam(teamID string) ([]string, error) {
+	t, err := e.teamStore.Get(teamID)
+	if err != nil {
+		return nil, err
+	}
+	if e.restoreDone != nil {
+		<-e.restoreDone
+	}
+
+	running := make(map[string]bool)
+	for _, s := range e.ptyManager.GetSessionsByTeam(teamID) {
+		running[s.AgentName] = true
+	}
+	var started []string
+	for _, ag := range t.Agents {
+		if running[ag.Name] {
+			continue
+		}
+		sessionID, err := e.CreateTerminal(t.ID, ag.Name, ag.WorkDir, ag.CLIType, ag.PromptID)
+		if err != nil {
+			return started, fmt.Errorf("agent %s: %w", ag.Name, err)
+		}
+		started = append(started, sessionID)
+	}
+	return started, nil
+}
+
+// ===================== Prompts =====================
+
+func (e *Engine) seedPrompts() {
+	basePrompt := e.readEmbeddedPrompt("prompts/base_prompt.md")
+	managerPrompt := e.readEmbeddedPrompt("prompts/manager_prompt.md")
+
+	e.promptStore.Seed(string(basePrompt), string(managerPrompt))
+}
+
+func (e *Engine) readEmbeddedPrompt(path string) []byte {
+	if e.cfg.Prompts == nil {
+		return nil
+	}
+	data, err := fs.ReadFile(e.cfg.Prompts, path)
+	if err != nil {
+		log.Printf("[PROMPT] %s okunamadı: %v", path, err)
+	}
+	return data
+}
+
+// ListPrompts returns all prompts
+func (e *Engine) ListPrompts() []prompt.Prompt {
+	return e.promptStore.List()
+}
+
+// GetPrompt returns a prompt by ID
+func (e *Engine) GetPrompt(id string) (prompt.Prompt, error) {
+	return e.promptStore.Get(id)
+}
+
+// CreatePrompt creates a new prompt
+func (e *Engine) CreatePrompt(name, content, category string, tags []string) (prompt.Prompt, error) {
+	return e.promptStore.Create(name, content, category, tags)
+}
+
+// UpdatePrompt updates a prompt
+func (e *Engine) UpdatePrompt(id, name, content, category string, tags []string) (prompt.Prompt, error) {
+	return e.promptStore.Update(id, name, content, category, tags)
+}
+
+// DeletePrompt deletes a prompt
+func (e *Engine) DeletePrompt(id string) error {
+	return e.promptStore.Delete(id)
+}
+
+// SendPromptToAgent renders a prompt and sends it to an agent's terminal
+func (e *Engine) SendPromptToAgent(sessionID, promptContent string, vars map[string]string) error {
+	rendered := prompt.RenderPrompt(promptContent, vars)
+	return e.ptyManager.Write(sessionID, []byte(rendered+"\n"))
+}
+
+// ===================== CLIs =====================
+
+// DetectCLIs returns all detected AI CLIs on the system
+func (e *Engine) DetectCLIs() []cli.CLIInfo {
+	return cli.DetectAll()
+}
+
+// GetGlobalPrompt returns the global custom prompt content
+func (e *Engine) GetGlobalPrompt() string {
+	data, err := os.ReadFile(filepath.Join(e.dataDir, "global_prompt.md"))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// SetGlobalPrompt saves the global custom prompt
+func (e *Engine) SetGlobalPrompt(content string) error {
+	return os.WriteFile(filepath.Join(e.dataDir, "global_prompt.md"), []byte(content), 0644)
+}
@@ -0,0 +1,602 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"desktop/internal/cli"
+	ptymgr "desktop/internal/pty"
+	"desktop/internal/session"
+	"desktop/internal/validation"
+)
+
+func hasPromptTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(strings.TrimSpace(t), tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Engine) isManagerPrompt(promptID string) bool {
+	if promptID == "" {
+		return false
+	}
+	p, err := e.promptStore.Get(promptID)
+	if err != nil {
+		return false
+	}
+	return hasPromptTag(p.Tags, "manager")
+}
+
+// resolveManagerIntent determines whether this terminal should start as manager.
+// If persist=true and manager is inferred from prompt tag, team manager is auto-set.
+func (e *Engine) resolveManagerIntent(teamID, agentName, promptID string, persist bool) (bool, error) {
+	if agentName == "" {
+		return false, nil
+	}
+
+	managerFromPrompt := e.isManagerPrompt(promptID)
+	if teamID == "" {
+		return managerFromPrompt, nil
+	}
+
+	t, err := e.teamStore.Get(teamID)
+	if err != nil {
+		return false, fmt.Errorf("takım bilgisi alınamadı %s: %w", teamID, err)
+	}
+
+	managerFromTeam := strings.TrimSpace(t.ManagerAgent)
+	if managerFromTeam != "" {
+		if managerFromPrompt && managerFromTeam != agentName {
+			return false, fmt.Errorf("team manager already set to '%s'; '%s' cannot use manager prompt", managerFromTeam, agentName)
+		}
+		return managerFromTeam == agentName, nil
+	}
+
+	if managerFromPrompt {
+		if persist {
+			if _, err := e.teamStore.SetManager(teamID, agentName); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// CreateTerminal creates a new terminal and returns its session ID
+func (e *Engine) CreateTerminal(teamID, agentName, workDir, cliType, promptID string) (string, error) {
+	return e.createTerminal(teamID, agentName, workDir, cliType, promptID, terminalOptions{})
+}
+
+// terminalOptions are the extras of a terminal restored after a restart.
+type terminalOptions struct {
+	resumeID string // CLI conversation to resume instead of starting fresh
+	recap    string // appended to the startup prompt of a fresh start
+}
+
+func (e *Engine) createTerminal(teamID, agentName, workDir, cliType, promptID string, opts terminalOptions) (string, error) {
+	if err := validation.ValidateName(agentName); err != nil {
+		return "", fmt.Errorf("invalid agent name: %w", err)
+	}
+
+	// Get team info for room name
+	var teamName string
+	if teamID != "" {
+		t, err := e.teamStore.Get(teamID)
+		if err == nil {
+			teamName = t.Name
+		}
+	}
+	if teamName == "" {
+		teamName = "default"
+	}
+
+	isManager, err := e.resolveManagerIntent(teamID, agentName, promptID, true)
+	if err != nil {
+		return "", err
+	}
+
+	managerAgent := ""
+	if teamID != "" {
+		if t, err := e.teamStore.Get(teamID); err == nil {
+			managerAgent = strings.TrimSpace(t.ManagerAgent)
+		}
+	}
+	if managerAgent == "" && isManager {
+		managerAgent = agentName
+	}
+	e.syncHubManager(teamName, managerAgent)
+
+	// Subscribe to room events
+	if e.hubClient != nil {
+		if err := e.hubClient.Subscribe([]string{teamName}); err != nil {
+			log.Printf("[HUB] Subscribe failed for room=%s: %v", teamName, err)
+		}
+	}
+
+	// Ensure MCP server binary is ready and configured for the selected CLI
+	ct := cli.CLIType(cliType)
+	if ct != cli.CLIShell && cliType != "" {
+		if err := cli.EnsureMCPServerBinary(e.cfg.MCPServerBin, e.dataDir); err != nil {
+			log.Printf("MCP server setup failed: %v", err)
+		}
+		if err := cli.EnsureMCPConfig(ct, e.dataDir, teamName); err != nil {
+			log.Printf("MCP config setup failed for %s: %v", cliType, err)
+		}
+	}
+
+	// Get command for CLI type
+	cmdName, cmdArgs := cli.GetCommand(ct)
+	if opts.resumeID != "" {
+		cmdArgs = cli.ResumeArgs(ct, cmdArgs, opts.resumeID)
+	}
+
+	// For Copilot, use -i flag to pass startup prompt directly as argument
+	if ct == cli.CLICopilot && agentName != "" && opts.resumeID == "" {
+		composed := e.composeAgentPrompt(teamID, agentName, promptID, isManager)
+		if composed != "" {
+			if opts.recap != "" {
+				composed += "\n\n" + opts.recap
+			}
+			cmdArgs = append(cmdArgs, "-i", composed)
+			log.Printf("[STARTUP] Copilot: using -i flag, promptLen=%d", len(composed))
+		}
+	}
+
+	env := []string{
+		"AGENT_CHAT_DATA_DIR=" + e.dataDir,
+		"AGENT_CHAT_ROOM=" + teamName,
+		"TERM=xterm-256color",
+	}
+
+	sessionID, err := e.ptyManager.Create(teamID, agentName, workDir, env, cmdName, cmdArgs, cliType)
+	if err != nil {
+		return "", err
+	}
+
+	// Store promptID for restart
+	if s := e.ptyManager.GetSession(sessionID); s != nil {
+		s.PromptID = promptID
+		s.ConversationID = opts.resumeID
+	}
+
+	// Register agent session for orchestrator (using room name)
+	if agentName != "" {
+		e.orchestrator.RegisterAgent(teamName, agentName, sessionID)
+	}
+
+	// Send startup prompt in background. A resumed conversation already has
+	// it and only hears about the restart.
+	if opts.resumeID != "" {
+		go e.sendInitialInput(sessionID, agentName, cliType, func() string {
+			return fmt.Sprintf("[agent-chat] The app restarted and your previous session was resumed. read_messages(\"%s\") to catch up on the room.", agentName)
+		})
+	} else {
+		go e.sendStartupPrompt(sessionID, teamID, agentName, cliType, promptID, isManager, opts.recap)
+	}
+
+	e.saveSessions()
+	return sessionID, nil
+}
+
+// RestartTerminal closes a terminal and creates a new one with the same parameters.
+func (e *Engine) RestartTerminal(sessionID string) (string, error) {
+	session := e.ptyManager.GetSession(sessionID)
+	if session == nil {
+		return "", fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	// Capture restart params before closing
+	teamID := session.TeamID
+	agentName := session.AgentName
+	workDir := session.WorkDir
+	cliType := session.CLIType
+	promptID := session.PromptID
+
+	// Close old terminal (unregisters from orchestrator)
+	if err := e.CloseTerminal(sessionID); err != nil {
+		log.Printf("[RESTART] Failed to close old session %s: %v", ptymgr.ShortID(sessionID), err)
+	}
+
+	log.Printf("[RESTART] Restarting terminal: agent=%s cli=%s team=%s", agentName, cliType, teamID)
+
+	return e.CreateTerminal(teamID, agentName, workDir, cliType, promptID)
+}
+
+// composeAgentPrompt builds the startup prompt for an agent without sending it
+func (e *Engine) composeAgentPrompt(teamID, agentName, promptID string, isManager bool) string {
+	if agentName == "" {
+		return ""
+	}
+
+	basePrompt := e.readEmbeddedPrompt("prompts/base_prompt.md")
+	globalPromptPath := filepath.Join(e.dataDir, "global_prompt.md")
+	globalPrompt, err := os.ReadFile(globalPromptPath)
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("[PROMPT] global_prompt.md okunamadı: %v", err)
+	}
+
+	var teamPrompt string
+	var teamName string
+	var agentRole string
+	if t, err := e.teamStore.Get(teamID); err == nil {
+		teamName = t.Name
+		teamPrompt = t.CustomPrompt
+		normalizedAgent := strings.TrimSpace(agentName)
+		for _, cfg := range t.Agents {
+			if strings.EqualFold(strings.TrimSpace(cfg.Name), normalizedAgent) {
+				agentRole = strings.TrimSpace(cfg.Role)
+				break
+			}
+		}
+	}
+
+	var selectedPrompt string
+	if promptID != "" {
+		if p, err := e.promptStore.Get(promptID); err == nil {
+			selectedPrompt = p.Content
+		}
+	}
+
+	if isManager {
+		managerPrompt := e.readEmbeddedPrompt("prompts/manager_prompt.md")
+		managerText := strings.TrimSpace(string(managerPrompt))
+		if managerText != "" {
+			if strings.TrimSpace(selectedPrompt) == "" {
+				selectedPrompt = managerText
+			} else if !strings.Contains(selectedPrompt, managerText) {
+				selectedPrompt = strings.TrimSpace(selectedPrompt) + "\n\n" + managerText
+			}
+		}
+	}
+
+	return cli.ComposeStartupPrompt(string(basePrompt), string(globalPrompt), teamPrompt, selectedPrompt, agentName, agentRole, teamName, isManager)
+}
+
+// sendStartupPrompt sends the initial prompt to a CLI agent, followed by recap
+// when a restored agent starts over.
+func (e *Engine) sendStartupPrompt(sessionID, teamID, agentName, cliType, promptID string, isManager bool, recap string) {
+	e.sendInitialInput(sessionID, agentName, cliType, func() string {
+		composed := e.composeAgentPrompt(teamID, agentName, promptID, isManager)
+		if composed != "" && recap != "" {
+			composed += "\n\n" + recap
+		}
+		return composed
+	})
+}
+
+// sendInitialInput waits for a freshly started CLI to settle, then types the
+// text returned by compose into it.
+func (e *Engine) sendInitialInput(sessionID, agentName, cliType string, compose func() string) {
+	if cliType == "" || agentName == "" {
+		return
+	}
+	driver := ptymgr.InputDriverFor(cliType)
+	if driver.StartupDelay() <= 0 {
+		return
+	}
+
+	// Wait for CLI to become idle
+	time.Sleep(driver.StartupDelay())
+	idle := e.ptyManager.WaitForIdle(sessionID, 2*time.Second, 25*time.Second)
+	log.Printf("[STARTUP] WaitForIdle: cli=%s agent=%s idle=%v", cliType, agentName, idle)
+
+	composed := compose()
+	if composed == "" {
+		return
+	}
+
+	log.Printf("[STARTUP] Sending prompt to cli=%s agent=%s session=%s promptLen=%d",
+		cliType, agentName, ptymgr.ShortID(sessionID), len(composed))
+	if err := driver.Send(e.ptyManager, sessionID, composed); err != nil {
+		log.Printf("[STARTUP] Prompt write failed agent=%s: %v", agentName, err)
+	}
+}
+
+// WriteToTerminal writes data to a terminal
+func (e *Engine) WriteToTerminal(sessionID, data string) error {
+	session := e.ptyManager.GetSession(sessionID)
+	if session != nil && session.CLIType == "copilot" {
+		// Filter Focus Out events
+		if data == "\x1b[O" {
+			return nil
+		}
+		raw := []byte(data)
+		log.Printf("[USER-INPUT] copilot agent=%s len=%d hex=%x ascii=%q",
+			session.AgentName, len(raw), raw, data)
+	}
+	e.ptyManager.MarkUserInput(sessionID, []byte(data))
+	return e.ptyManager.Write(sessionID, []byte(data))
+}
+
+// ResizeTerminal resizes a terminal
+func (e *Engine) ResizeTerminal(sessionID string, cols, rows int) error {
+	return e.ptyManager.Resize(sessionID, uint16(cols), uint16(rows))
+}
+
+// CloseTerminal closes a terminal
+func (e *Engine) CloseTerminal(sessionID string) error {
+	session := e.ptyManager.GetSession(sessionID)
+	if session != nil {
+		if session.TeamID != "" && session.AgentName != "" {
+			t, err := e.teamStore.Get(session.TeamID)
+			if err == nil {
+				teamName := t.Name
+				if teamName == "" {
+					teamName = "default"
+				}
+				e.orchestrator.UnregisterAgent(teamName, session.AgentName)
+			}
+		}
+	}
+	err := e.ptyManager.Close(sessionID)
+	e.saveSessions()
+	return err
+}
+
+// GetTerminalSessions returns all active terminal sessions for a team
+func (e *Engine) GetTerminalSessions(teamID string) []map[string]string {
+	sessions := e.ptyManager.GetSessionsByTeam(teamID)
+	var result []map[string]string
+	for _, s := range sessions {
+		result = append(result, map[string]string{
+			"sessionID": s.ID,
+			"agentName": s.AgentName,
+			"teamID":    s.TeamID,
+		})
+	}
+	return result
+}
+
+// SessionInfo describes a live terminal.
+type SessionInfo struct {
+	SessionID string `json:"session_id"`
+	TeamID    string `json:"team_id"`
+	AgentName string `json:"agent_name"`
+	CLIType   string `json:"cli_type"`
+	WorkDir   string `json:"work_dir"`
+	Activity  string `json:"activity"`
+	StartedAt string `json:"started_at"`
+}
+
+// Sessions returns every live terminal, oldest first.
+func (e *Engine) Sessions() []SessionInfo {
+	sessions := e.ptyManager.ListSessions()
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt.Before(sessions[j].StartedAt)
+	})
+	result := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, SessionInfo{
+			SessionID: s.ID,
+			TeamID:    s.TeamID,
+			AgentName: s.AgentName,
+			CLIType:   s.CLIType,
+			WorkDir:   s.WorkDir,
+			Activity:  e.ptyManager.Activity(s.ID),
+			StartedAt: s.StartedAt.Format(time.RFC3339),
+		})
+	}
+	return result
+}
+
+// InjectInput types text into a terminal's CLI and submits it, the way
+// notifications are delivered.
+func (e *Engine) InjectInput(sessionID, text string) error {
+	return e.ptyManager.Inject(sessionID, text)
+}
+
+// ===================== Session Restore =====================
+
+// resumeGrace is how long a resumed CLI must keep running for the resume to
+// count as successful.
+const resumeGrace = 8 * time.Second
+
+// recapMessages is how many room messages the recap of a fresh start quotes.
+const recapMessages = 10
+
+// RestoredSession is a terminal brought back after an app restart.
+type RestoredSession struct {
+	SessionID string `json:"sessionID"`
+	TeamID    string `json:"teamID"`
+	AgentName string `json:"agentName"`
+	CLIType   string `json:"cliType"`
+	SlotIndex int    `json:"slotIndex"`
+	Resumed   bool   `json:"resumed"`
+}
+
+// saveSessions records the live terminals in sessions.json.
+func (e *Engine) saveSessions() {
+	if e.sessionStore == nil || e.restoring.Load() {
+		return
+	}
+	e.saveSessionRecords(e.ptyManager.ListSessions())
+}
+
+func (e *Engine) saveSessionRecords(sessions []*ptymgr.PTYSession) {
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt.Before(sessions[j].StartedAt)
+	})
+	records := make([]session.Record, 0, len(sessions))
+	for _, s := range sessions {
+		// Prefer what the CLI printed last; fall back to the conversation the
+		// session resumed.
+		convID := cli.ScrapeConversationID(cli.CLIType(s.CLIType), ptymgr.StripANSI(s.Scrollback()))
+		if convID == "" {
+			convID = s.ConversationID
+		}
+		records = append(records, session.Record{
+			TeamID:         s.TeamID,
+			AgentName:      s.AgentName,
+			CLIType:        s.CLIType,
+			WorkDir:        s.WorkDir,
+			PromptID:       s.PromptID,
+			ConversationID: convID,
+		})
+	}
+	if err := e.sessionStore.Save(records); err != nil {
+		log.Printf("[SESSION] save failed: %v", err)
+	}
+}
+
+// restoreSessions recreates the terminals of the previous run, resuming each
+// CLI's conversation where possible.
+func (e *Engine) restoreSessions() {
+	defer close(e.restoreDone)
+	records, err := e.sessionStore.Load()
+	if err != nil {
+		log.Printf("[SESSION] load failed: %v", err)
+		return
+	}
+	if len(records) == 0 {
+		return
+	}
+
+	e.restoring.Store(true)
+	defer func() {
+		e.restoring.Store(false)
+		e.saveSessions()
+	}()
+
+	var wg sync.WaitGroup
+	slots := make(map[string]int)
+	for _, rec := range records {
+		if rec.TeamID != "" {
+			if _, err := e.teamStore.Get(rec.TeamID); err != nil {
+				log.Printf("[SESSION] skipping agent=%s: team %s no longer exists", rec.AgentName, rec.TeamID)
+				continue
+			}
+		}
+		slot := slots[rec.TeamID]
+		slots[rec.TeamID]++
+		wg.Add(1)
+		go func(rec session.Record, slot int) {
+			defer wg.Done()
+			e.restoreSession(rec, slot)
+		}(rec, slot)
+	}
+	wg.Wait()
+}
+
+// restoreSession resumes one terminal's conversation, or starts it fresh with
+// a recap of the room when it cannot be resumed.
+func (e *Engine) restoreSession(rec session.Record, slot int) {
+	restored := RestoredSession{TeamID: rec.TeamID, AgentName: rec.AgentName, CLIType: rec.CLIType, SlotIndex: slot}
+
+	if rec.ConversationID != "" && cli.SupportsResume(cli.CLIType(rec.CLIType)) {
+		sessionID, err := e.createTerminal(rec.TeamID, rec.AgentName, rec.WorkDir, rec.CLIType, rec.PromptID,
+			terminalOptions{resumeID: rec.ConversationID})
+		if err == nil && !e.ptyManager.WaitForExit(sessionID, resumeGrace) {
+			log.Printf("[SESSION] resumed agent=%s cli=%s conversation=%s", rec.AgentName, rec.CLIType, rec.ConversationID)
+			restored.SessionID, restored.Resumed = sessionID, true
+			e.announceRestored(restored)
+			return
+		}
+		if err == nil {
+			e.CloseTerminal(sessionID)
+		}
+		log.Printf("[SESSION] resume failed agent=%s cli=%s conversation=%s, starting fresh", rec.AgentName, rec.CLIType, rec.ConversationID)
+	}
+
+	opts := terminalOptions{}
+	if rec.CLIType != "" && rec.CLIType != string(cli.CLIShell) {
+		opts.recap = e.recapFor(rec)
+	}
+	sessionID, err := e.createTerminal(rec.TeamID, rec.AgentName, rec.WorkDir, rec.CLIType, rec.PromptID, opts)
+	if err != nil {
+		log.Printf("[SESSION] restore failed agent=%s: %v", rec.AgentName, err)
+		return
+	}
+	restored.SessionID = sessionID
+	e.announceRestored(restored)
+}
+
+// recapFor summarizes the room for an agent that starts over.
+func (e *Engine) recapFor(rec session.Record) string {
+	if e.hubClient == nil {
+		return ""
+	}
+	msgs, err := e.hubClient.GetMessagesRaw(e.roomForTeam(rec.TeamID))
+	if err != nil {
+		log.Printf("[SESSION] recap messages failed agent=%s: %v", rec.AgentName, err)
+		return ""
+	}
+	return session.RecapPrompt(rec.AgentName, msgs, recapMessages)
+}
+
+func (e *Engine) announceRestored(restored RestoredSession) {
+	e.restoredMu.Lock()
+	e.restored = append(e.restored, restored)
+	e.restoredMu.Unlock()
+	e.emit("session:restored", restored)
+}
+
+// GetRestoredSessions returns the terminals restored at startup that are
+// still running, for a frontend that loads after they were announced.
+func (e *Engine) GetRestoredSessions() []RestoredSession {
+	e.restoredMu.Lock()
+	defer e.restoredMu.Unlock()
+	result := make([]RestoredSession, 0, len(e.restored))
+	for _, r := range e.restored {
+		if e.ptyManager.GetSession(r.SessionID) != nil {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// ===================== Transcripts =====================
+
+func (e *Engine) transcriptsDir() string {
+	return filepath.Join(e.dataDir, "transcripts")
+}
+
+// GetScrollback returns a terminal's recent output so a re-mounted pane can
+// restore its screen.
+func (e *Engine) GetScrollback(sessionID string) (string, error) {
+	return e.ptyManager.Scrollback(sessionID)
+}
+
+// GetTranscriptSettings returns the on-disk transcript settings (off by default).
+func (e *Engine) GetTranscriptSettings() ptymgr.TranscriptSettings {
+	var settings ptymgr.TranscriptSettings
+	data, err := os.ReadFile(filepath.Join(e.dataDir, "transcripts.json"))
+	if err != nil {
+		return settings
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		log.Printf("[TRANSCRIPT] invalid transcripts.json: %v", err)
+	}
+	return settings
+}
+
+// SetTranscriptSettings saves the transcript settings. They apply to
+// terminals opened afterwards.
+func (e *Engine) SetTranscriptSettings(settings ptymgr.TranscriptSettings) error {
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(e.dataDir, "transcripts.json"), data, 0644); err != nil {
+		return err
+	}
+	e.ptyManager.SetTranscripts(e.transcriptsDir(), settings)
+	return nil
+}
+
+// SearchTranscripts searches the plain transcripts of a team (all teams if
+// teamID is empty) for query.
+func (e *Engine) SearchTranscripts(teamID, query string) ([]ptymgr.TranscriptMatch, error) {
+	return ptymgr.SearchTranscripts(e.transcriptsDir(), teamID, query, 0)
+}
@@ -2,7 +2,9 @@ package hub
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"math"
 	"time"
 
 	"desktop/internal/types"
@@ -28,6 +30,9 @@ type Client struct {
 	desktopAuthed bool
 	agentName     string
 	joinedRoom    string
+	// roomEpoch is the hub room epoch rooms and joinedRoom were last synced
+	// to; only the client's own request goroutine updates them.
+	roomEpoch int
 }
 
 func newClient(hub *Hub, conn *websocket.Conn) *Client {
@@ -135,3 +140,20 @@ func (c *Client) sendError(id, reqType, errMsg string) {
 		Error:       errMsg,
 	})
 }
+
+// sendRateLimited rejects a throttled request with ErrCodeRateLimited.
+func (c *Client) sendRateLimited(id, reqType, scope string, retryAfter time.Duration) {
+	secs := int(math.Ceil(retryAfter.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	data, _ := json.Marshal(map[string]any{"retry_after": secs, "scope": scope})
+	c.sendJSON(types.Response{
+		ID:          id,
+		RequestType: reqType,
+		Success:     false,
+		Error:       fmt.Sprintf("hız sınırı aşıldı (%s): %d saniye sonra tekrar deneyin", scope, secs),
+		Code:        types.ErrCodeRateLimited,
+		Data:        data,
+	})
+}
@@ -0,0 +1,360 @@
+package hub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"desktop/internal/types"
+	"desktop/internal/validation"
+)
+
+const (
+	maxConnectorsPerRoom = 10
+	connectorQueueSize   = 128
+	connectorPostTimeout = 10 * time.Second
+)
+
+// Connector bridges a room to an external chat system. The hub calls Post
+// for every message posted in the room, except system messages and messages
+// the connector itself bridged in. Inbound messages are handed to the
+// deliver function given to Start; they enter the room as human participants.
+//
+// A connector that receives inbound messages over HTTP (webhooks) also
+// implements http.Handler; the hub mounts it at
+// POST /api/connectors/{id}/inbound, and the connector authenticates the
+// request itself.
+type Connector interface {
+	// Start runs the connector until ctx is cancelled.
+	Start(ctx context.Context, deliver DeliverFunc) error
+	// Post sends a room message to the external system.
+	Post(ctx context.Context, msg types.Message) error
+}
+
+// InboundMessage is a message arriving from an external chat system.
+type InboundMessage struct {
+	From     string `json:"from"`
+	To       string `json:"to,omitempty"` // default "all"
+	Content  string `json:"content"`
+	Priority string `json:"priority,omitempty"`
+}
+
+// DeliverFunc posts an inbound message into the connector's room.
+type DeliverFunc func(InboundMessage) error
+
+// ConnectorConfig is the stored configuration of one room connector.
+type ConnectorConfig struct {
+	ID       int               `json:"id"`
+	Room     string            `json:"room"`
+	Kind     string            `json:"kind"`
+	Settings map[string]string `json:"settings,omitempty"`
+	// CreatedAt is set by the hub.
+	CreatedAt string `json:"created_at"`
+}
+
+// ConnectorFactory builds a connector from its configuration. It may fill
+// in generated settings (such as secrets) on cfg before they are saved.
+type ConnectorFactory func(cfg *ConnectorConfig) (Connector, error)
+
+var (
+	connectorKindsMu sync.RWMutex
+	connectorKinds   = map[string]ConnectorFactory{}
+)
+
+// RegisterConnector makes a connector kind available to add_connector.
+// Bridges call it from an init function.
+func RegisterConnector(kind string, factory ConnectorFactory) {
+	connectorKindsMu.Lock()
+	defer connectorKindsMu.Unlock()
+	connectorKinds[kind] = factory
+}
+
+func connectorFactory(kind string) (ConnectorFactory, bool) {
+	connectorKindsMu.RLock()
+	defer connectorKindsMu.RUnlock()
+	f, ok := connectorKinds[kind]
+	return f, ok
+}
+
+// runningConnector is a started connector and its outbound queue.
+type runningConnector struct {
+	cfg      ConnectorConfig
+	conn     Connector
+	outbound chan types.Message
+	cancel   context.CancelFunc
+}
+
+// via is the tag put on messages this connector bridges in.
+func (rc *runningConnector) via() string {
+	return fmt.Sprintf("%s:%d", rc.cfg.Kind, rc.cfg.ID)
+}
+
+func (cfg *ConnectorConfig) record() (*int, *string) { return &cfg.ID, &cfg.Room }
+
+// connectorManager owns the configured connectors of all rooms and runs them
+// while the hub is up.
+type connectorManager struct {
+	roomStore[ConnectorConfig, *ConnectorConfig]
+	running map[int]*runningConnector
+	ctx     context.Context
+	deliver func(cfg ConnectorConfig, via string, in InboundMessage) error
+	logger  *log.Logger
+}
+
+func newConnectorManager(path string, logger *log.Logger) *connectorManager {
+	return &connectorManager{
+		// The file is private: settings hold secrets.
+		roomStore: roomStore[ConnectorConfig, *ConnectorConfig]{items: make(map[int]*ConnectorConfig), nextID: 1, path: path, key: "connectors", perm: 0600},
+		running:   make(map[int]*runningConnector),
+		logger:    logger,
+	}
+}
+
+// start launches every configured connector; connectors added later start
+// immediately. deliver posts inbound messages into the hub.
+func (m *connectorManager) start(ctx context.Context, deliver func(cfg ConnectorConfig, via string, in InboundMessage) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ctx = ctx
+	m.deliver = deliver
+	for _, cfg := range m.items {
+		if err := m.launchLocked(*cfg); err != nil {
+			m.logger.Printf("[CONNECTOR] %s:%d room=%s failed to start: %v", cfg.Kind, cfg.ID, cfg.Room, err)
+		}
+	}
+}
+
+// launchLocked builds and starts a connector. Must be called with mu held
+// after start.
+func (m *connectorManager) launchLocked(cfg ConnectorConfig) error {
+	factory, ok := connectorFactory(cfg.Kind)
+	if !ok {
+		return fmt.Errorf("bilinmeyen connector türü: %s", cfg.Kind)
+	}
+	conn, err := factory(&cfg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	rc := &runningConnector{cfg: cfg, conn: conn, outbound: make(chan types.Message, connectorQueueSize), cancel: cancel}
+	m.running[cfg.ID] = rc
+
+	deliver := m.deliver
+	go func() {
+		err := conn.Start(ctx, func(in InboundMessage) error { return deliver(rc.cfg, rc.via(), in) })
+		if err != nil && ctx.Err() == nil {
+			m.logger.Printf("[CONNECTOR] %s room=%s stopped: %v", rc.via(), cfg.Room, err)
+		}
+	}()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-rc.outbound:
+				postCtx, cancelPost := context.WithTimeout(ctx, connectorPostTimeout)
+				if err := conn.Post(postCtx, msg); err != nil && ctx.Err() == nil {
+					m.logger.Printf("[CONNECTOR] %s room=%s post of message %d failed: %v", rc.via(), cfg.Room, msg.ID, err)
+				}
+				cancelPost()
+			}
+		}
+	}()
+	return nil
+}
+
+// add validates and stores a connector configuration and starts it when the
+// hub is running.
+func (m *connectorManager) add(cfg ConnectorConfig) (ConnectorConfig, error) {
+	cfg.Kind = strings.TrimSpace(cfg.Kind)
+	factory, ok := connectorFactory(cfg.Kind)
+	if !ok {
+		return ConnectorConfig{}, fmt.Errorf("bilinmeyen connector türü: %q", cfg.Kind)
+	}
+	if cfg.Settings == nil {
+		cfg.Settings = map[string]string{}
+	}
+	// Build once up front so configuration errors reach the caller and
+	// generated settings are saved.
+	if _, err := factory(&cfg); err != nil {
+		return ConnectorConfig{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.countLocked(cfg.Room) >= maxConnectorsPerRoom {
+		return ConnectorConfig{}, fmt.Errorf("bu odada en fazla %d connector olabilir", maxConnectorsPerRoom)
+	}
+
+	cfg.CreatedAt = types.Timestamp()
+	m.insertLocked(&cfg)
+	if m.ctx != nil {
+		if err := m.launchLocked(cfg); err != nil {
+			m.logger.Printf("[CONNECTOR] %s:%d room=%s failed to start: %v", cfg.Kind, cfg.ID, cfg.Room, err)
+		}
+	}
+	return cfg, nil
+}
+
+// remove stops and deletes a connector of a room.
+func (m *connectorManager) remove(room string, id int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cfg, ok := m.items[id]
+	if !ok || cfg.Room != room {
+		return false
+	}
+	if rc, ok := m.running[id]; ok {
+		rc.cancel()
+		delete(m.running, id)
+	}
+	delete(m.items, id)
+	m.saveLocked()
+	return true
+}
+
+// renameRoom moves the connectors of a renamed room and restarts the
+// running ones so they bridge into the new name.
+func (m *connectorManager) renameRoom(from, to string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, cfg := range m.renameRoomLocked(from, to) {
+		if rc, ok := m.running[cfg.ID]; ok {
+			rc.cancel()
+			delete(m.running, cfg.ID)
+			if err := m.launchLocked(*cfg); err != nil {
+				m.logger.Printf("[CONNECTOR] %s:%d room=%s failed to restart: %v", cfg.Kind, cfg.ID, cfg.Room, err)
+			}
+		}
+	}
+}
+
+// deleteRoom stops and drops the connectors of a deleted room.
+func (m *connectorManager) deleteRoom(room string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, cfg := range m.deleteRoomLocked(room) {
+		if rc, ok := m.running[cfg.ID]; ok {
+			rc.cancel()
+			delete(m.running, cfg.ID)
+		}
+	}
+}
+
+// list returns the connectors of a room ordered by ID.
+func (m *connectorManager) list(room string) []ConnectorConfig {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []ConnectorConfig
+	for _, it := range m.items {
+		if it.Room == room {
+			out = append(out, *it)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// publish queues a new room message for every running connector of the room.
+func (m *connectorManager) publish(room string, msg types.Message) {
+	if msg.Type == "system" {
+		return
+	}
+	m.mu.Lock()
+	var targets []*runningConnector
+	for _, rc := range m.running {
+		if rc.cfg.Room == room && msg.Via != rc.via() {
+			targets = append(targets, rc)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, rc := range targets {
+		select {
+		case rc.outbound <- msg:
+		default:
+			m.logger.Printf("[CONNECTOR] %s room=%s queue full, dropping message %d", rc.via(), room, msg.ID)
+		}
+	}
+}
+
+// handler returns the HTTP handler of a running connector, if it has one.
+func (m *connectorManager) handler(id int) (http.Handler, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rc, ok := m.running[id]
+	if !ok {
+		return nil, false
+	}
+	h, ok := rc.conn.(http.Handler)
+	return h, ok
+}
+
+// deliverInbound posts a bridged message into a room as a human participant,
+// through the same send_message path as the desktop app.
+func (h *Hub) deliverInbound(cfg ConnectorConfig, via string, in InboundMessage) error {
+	in.From = strings.TrimSpace(in.From)
+	if in.From == "" {
+		return fmt.Errorf("from alanı gerekli")
+	}
+	if err := validation.ValidateName(in.From); err != nil {
+		return err
+	}
+
+	roomState := h.getOrCreateRoom(cfg.Room)
+	sysMsg, agents, joined, err := roomState.JoinHuman(in.From)
+	if err != nil {
+		return err
+	}
+	if joined {
+		h.logger.Printf("connector %s: human=%q joined room=%q", via, in.From, cfg.Room)
+		h.broadcastEvent(cfg.Room, "message_new", map[string]any{"message": sysMsg})
+		h.broadcastEvent(cfg.Room, "agent_joined", map[string]any{"agent_name": in.From, "agents": agents})
+	}
+
+	data, _ := json.Marshal(map[string]any{
+		"from":     in.From,
+		"to":       in.To,
+		"content":  in.Content,
+		"priority": in.Priority,
+		"human":    true,
+		"via":      via,
+	})
+	resp := h.handleLocal(types.Request{Type: "send_message", Room: cfg.Room, Data: data})
+	if !resp.Success {
+		return fmt.Errorf("%s", resp.Error)
+	}
+	return nil
+}
+
+// handleConnectorInbound passes an inbound webhook to its connector.
+func (h *Hub) handleConnectorInbound(w http.ResponseWriter, r *http.Request) {
+	var id int
+	if _, err := fmt.Sscanf(r.PathValue("id"), "%d", &id); err != nil {
+		writeHTTPError(w, http.StatusNotFound, "connector bulunamadı")
+		return
+	}
+	handler, ok := h.connectors.handler(id)
+	if !ok {
+		writeHTTPError(w, http.StatusNotFound, "connector bulunamadı")
+		return
+	}
+	handler.ServeHTTP(w, r)
+}
+
+// newSecret returns a random hex token for connector authentication.
+func newSecret() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
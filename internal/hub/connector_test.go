@@ -0,0 +1,203 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"desktop/internal/types"
+)
+
+// fakeChat stands in for an external chat system: it records what a
+// connector posts to its incoming webhook.
+type fakeChat struct {
+	*httptest.Server
+	mu    sync.Mutex
+	posts []map[string]any
+	sigs  []string
+}
+
+func newFakeChat(t *testing.T) *fakeChat {
+	f := &fakeChat{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		f.mu.Lock()
+		f.posts = append(f.posts, body)
+		f.sigs = append(f.sigs, r.Header.Get("X-Agent-Chat-Signature"))
+		f.mu.Unlock()
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeChat) received() []map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]any(nil), f.posts...)
+}
+
+func (f *fakeChat) waitPosts(t *testing.T, n int) []map[string]any {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if got := f.received(); len(got) >= n {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("fake chat got %d posts, want %d", len(f.received()), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// startConnectorHub runs the hub's connectors and HTTP API without a listener.
+func startConnectorHub(t *testing.T) (*Hub, *Client, *httptest.Server) {
+	t.Helper()
+	h, c := newTestHubClient()
+	c.clientType = "desktop"
+	c.desktopAuthed = true
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	h.connectors.start(ctx, h.deliverInbound)
+
+	mux := http.NewServeMux()
+	h.registerHTTP(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return h, c, srv
+}
+
+func addConnector(t *testing.T, h *Hub, c *Client, room string, cfg map[string]any) ConnectorConfig {
+	t.Helper()
+	h.handleRequest(c, types.Request{ID: "add", Type: "add_connector", Room: room, Data: mustRawJSON(t, cfg)})
+	resp := readResponse(t, c, "add_connector")
+	if !resp.Success {
+		t.Fatalf("add_connector failed: %s", resp.Error)
+	}
+	var data struct {
+		Connector ConnectorConfig `json:"connector"`
+	}
+	json.Unmarshal(resp.Data, &data)
+	return data.Connector
+}
+
+func TestWebhookConnector_BridgesBothWays(t *testing.T) {
+	h, desktop, srv := startConnectorHub(t)
+	chat := newFakeChat(t)
+	cfg := addConnector(t, h, desktop, "r1", map[string]any{
+		"kind":     "webhook",
+		"settings": map[string]string{"url": chat.URL},
+	})
+	secret := cfg.Settings["secret"]
+	if secret == "" {
+		t.Fatal("webhook connector should generate a secret")
+	}
+
+	// Outbound: an agent's message reaches the external chat, signed.
+	agent := &Client{hub: h, send: make(chan []byte, 64), rooms: make(map[string]bool)}
+	h.handleRequest(agent, types.Request{ID: "1", Type: "join_room", Room: "r1", Data: mustRawJSON(t, map[string]any{"agent_name": "dev"})})
+	readResponse(t, agent, "join_room")
+	h.handleRequest(agent, types.Request{ID: "2", Type: "send_message", Room: "r1", Data: mustRawJSON(t, map[string]any{
+		"from": "dev", "to": "all", "content": "build is green",
+	})})
+	if resp := readResponse(t, agent, "send_message"); !resp.Success {
+		t.Fatalf("send failed: %s", resp.Error)
+	}
+	posts := chat.waitPosts(t, 1)
+	msg, _ := posts[0]["message"].(map[string]any)
+	if posts[0]["room"] != "r1" || msg["content"] != "build is green" {
+		t.Fatalf("outbound post = %v", posts[0])
+	}
+	if sig := chat.sigs[0]; !strings.HasPrefix(sig, "sha256=") {
+		t.Errorf("signature header = %q", sig)
+	}
+
+	// Inbound: the external side posts as a human; the message is not echoed back.
+	inbound := srv.URL + "/api/connectors/" + jsonNumber(cfg.ID) + "/inbound"
+	post := func(token, body string) int {
+		req, _ := http.NewRequest("POST", inbound, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := post("wrong", `{"from":"alice","content":"hi"}`); code != http.StatusUnauthorized {
+		t.Errorf("wrong secret: status = %d, want 401", code)
+	}
+	if code := post(secret, `{"from":"alice","to":"dev","content":"please rerun"}`); code != http.StatusAccepted {
+		t.Fatalf("inbound status = %d, want 202", code)
+	}
+	room := h.getOrCreateRoom("r1")
+	if !room.IsHuman("alice") {
+		t.Error("inbound sender should join as a human")
+	}
+	msgs := room.GetMessages()
+	last := msgs[len(msgs)-1]
+	if last.From != "alice" || !last.FromHuman || last.Via != "webhook:"+jsonNumber(cfg.ID) {
+		t.Errorf("inbound message = %+v", last)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := chat.received(); len(got) != 1 {
+		t.Errorf("bridged message echoed back: %v", got)
+	}
+}
+
+// stubConnector is a custom connector kind registered from a test, the way
+// a chat bridge would register itself.
+type stubConnector struct {
+	deliver chan DeliverFunc
+	posted  chan types.Message
+}
+
+func (s *stubConnector) Start(ctx context.Context, deliver DeliverFunc) error {
+	s.deliver <- deliver
+	<-ctx.Done()
+	return nil
+}
+
+func (s *stubConnector) Post(ctx context.Context, msg types.Message) error {
+	s.posted <- msg
+	return nil
+}
+
+func TestRegisterConnector_CustomKind(t *testing.T) {
+	stub := &stubConnector{deliver: make(chan DeliverFunc, 1), posted: make(chan types.Message, 4)}
+	RegisterConnector("stub-test", func(cfg *ConnectorConfig) (Connector, error) { return stub, nil })
+
+	h, desktop, _ := startConnectorHub(t)
+	addConnector(t, h, desktop, "r1", map[string]any{"kind": "stub-test"})
+
+	var deliver DeliverFunc
+	select {
+	case deliver = <-stub.deliver:
+	case <-time.After(2 * time.Second):
+		t.Fatal("connector was not started")
+	}
+	if err := deliver(InboundMessage{From: "irc-bob", Content: "hello from irc"}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if msgs := h.getOrCreateRoom("r1").GetMessages(); msgs[len(msgs)-1].Content != "hello from irc" {
+		t.Errorf("room messages = %+v", msgs)
+	}
+	select {
+	case msg := <-stub.posted:
+		t.Errorf("connector received its own message back: %+v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	h.handleRequest(desktop, types.Request{ID: "x", Type: "add_connector", Room: "r1", Data: mustRawJSON(t, map[string]any{"kind": "nope"})})
+	if resp := readResponse(t, desktop, "add_connector"); resp.Success {
+		t.Error("unknown connector kind should be rejected")
+	}
+}
@@ -0,0 +1,157 @@
+package hub
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"desktop/internal/types"
+)
+
+func init() {
+	RegisterConnector("webhook", newWebhookConnector)
+}
+
+// webhookConnector is the generic HTTP bridge. Outbound, it POSTs each room
+// message to settings["url"], signed with HMAC-SHA256 of the body in
+// X-Agent-Chat-Signature. settings["format"] is "json" ({room, message}) or
+// "text" ({"text": ...}, the shape Slack/Mattermost incoming webhooks take).
+// Inbound, the external side POSTs an InboundMessage to
+// /api/connectors/{id}/inbound with "Authorization: Bearer <secret>".
+type webhookConnector struct {
+	room   string
+	url    string
+	format string
+	secret string
+	client *http.Client
+
+	mu      sync.Mutex
+	deliver DeliverFunc
+}
+
+func newWebhookConnector(cfg *ConnectorConfig) (Connector, error) {
+	s := cfg.Settings
+	if s == nil {
+		s = map[string]string{}
+		cfg.Settings = s
+	}
+	if target := strings.TrimSpace(s["url"]); target != "" {
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("geçersiz url %q: http(s) adresi bekleniyor", target)
+		}
+	}
+	switch s["format"] {
+	case "":
+		s["format"] = "json"
+	case "json", "text":
+	default:
+		return nil, fmt.Errorf("geçersiz format %q: json veya text bekleniyor", s["format"])
+	}
+	if s["secret"] == "" {
+		s["secret"] = newSecret()
+	}
+	return &webhookConnector{
+		room:   cfg.Room,
+		url:    strings.TrimSpace(s["url"]),
+		format: s["format"],
+		secret: s["secret"],
+		client: &http.Client{},
+	}, nil
+}
+
+func (c *webhookConnector) Start(ctx context.Context, deliver DeliverFunc) error {
+	c.mu.Lock()
+	c.deliver = deliver
+	c.mu.Unlock()
+	<-ctx.Done()
+	c.mu.Lock()
+	c.deliver = nil
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *webhookConnector) Post(ctx context.Context, msg types.Message) error {
+	if c.url == "" {
+		return nil
+	}
+	var payload any = map[string]any{"room": c.room, "message": msg}
+	if c.format == "text" {
+		payload = map[string]string{"text": formatBridgeText(msg)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Agent-Chat-Signature", "sha256="+signBody(c.secret, body))
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ServeHTTP accepts an inbound message from the external system.
+func (c *webhookConnector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(c.secret)) != 1 {
+		writeHTTPError(w, http.StatusUnauthorized, "geçersiz veya eksik token")
+		return
+	}
+	var in InboundMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMsgSize)).Decode(&in); err != nil {
+		writeHTTPError(w, http.StatusBadRequest, "geçersiz JSON gövdesi")
+		return
+	}
+	c.mu.Lock()
+	deliver := c.deliver
+	c.mu.Unlock()
+	if deliver == nil {
+		writeHTTPError(w, http.StatusServiceUnavailable, "connector çalışmıyor")
+		return
+	}
+	if err := deliver(in); err != nil {
+		writeHTTPError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// formatBridgeText renders a room message as one chat line.
+func formatBridgeText(msg types.Message) string {
+	from := msg.From
+	if msg.FromHuman {
+		from += " (human)"
+	}
+	var flag string
+	if msg.Priority == "urgent" {
+		flag = " [urgent]"
+	}
+	return fmt.Sprintf("%s → %s%s: %s", from, msg.To, flag, msg.Content)
+}
+
+func signBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,41 @@
+package hub
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule_Next(t *testing.T) {
+	base := time.Date(2026, 3, 10, 14, 7, 30, 0, time.UTC) // Tuesday
+
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 3, 10, 14, 15, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)},
+		{"30 9-17 * * 1-5", time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)},
+		{"0 10 * * 6,7", time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", base.Add(90 * time.Minute)},
+	}
+
+	for _, tc := range cases {
+		s, err := parseSchedule(tc.spec)
+		if err != nil {
+			t.Fatalf("parseSchedule(%q) failed: %v", tc.spec, err)
+		}
+		if got := s.Next(base); !got.Equal(tc.want) {
+			t.Fatalf("parseSchedule(%q).Next = %v, want %v", tc.spec, got, tc.want)
+		}
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "61 * * * *", "*/0 * * * *", "5-1 * * * *", "@every 10s", "@every soon"} {
+		if _, err := parseSchedule(spec); err == nil {
+			t.Fatalf("expected parseSchedule(%q) to fail", spec)
+		}
+	}
+}
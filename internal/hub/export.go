@@ -0,0 +1,236 @@
+package hub
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"desktop/internal/types"
+)
+
+// Export formats accepted by export_room. Only JSONL can be imported back:
+// the Markdown transcript is for people.
+const (
+	ExportJSONL    = "jsonl"
+	ExportMarkdown = "markdown"
+
+	exportVersion = 1
+)
+
+// exportRecord is one line of a JSONL export. The first line is the room
+// header (type "room"), every following line a message (type "message").
+type exportRecord struct {
+	Type       string                 `json:"type"`
+	Version    int                    `json:"version,omitempty"`
+	Room       string                 `json:"room,omitempty"`
+	ExportedAt string                 `json:"exported_at,omitempty"`
+	Agents     map[string]types.Agent `json:"agents,omitempty"`
+	Groups     map[string][]string    `json:"groups,omitempty"`
+	Message    *types.Message         `json:"message,omitempty"`
+}
+
+// writeJSONL writes a room with full message metadata, one JSON record per line.
+func writeJSONL(w io.Writer, room string, snap PersistedRoom) error {
+	enc := json.NewEncoder(w)
+	header := exportRecord{
+		Type:       "room",
+		Version:    exportVersion,
+		Room:       room,
+		ExportedAt: types.Timestamp(),
+		Agents:     snap.Agents,
+		Groups:     snap.Groups,
+	}
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	for i := range snap.Messages {
+		if err := enc.Encode(exportRecord{Type: "message", Message: &snap.Messages[i]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadExport parses a JSONL export, for import_room and replays. Agents in
+// the header are returned for reference; importing does not bring them back
+// as live participants.
+func ReadExport(r io.Reader) (PersistedRoom, error) {
+	var pr PersistedRoom
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*maxFieldLength)
+	sawHeader := false
+	lastID := 0
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var rec exportRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return PersistedRoom{}, fmt.Errorf("satır %d: geçersiz JSON: %v", line, err)
+		}
+		switch rec.Type {
+		case "room":
+			if sawHeader {
+				return PersistedRoom{}, fmt.Errorf("satır %d: birden fazla oda başlığı", line)
+			}
+			if rec.Version > exportVersion {
+				return PersistedRoom{}, fmt.Errorf("desteklenmeyen dışa aktarma sürümü: %d", rec.Version)
+			}
+			sawHeader = true
+			pr.Agents = rec.Agents
+			pr.Groups = rec.Groups
+		case "message":
+			if !sawHeader {
+				return PersistedRoom{}, fmt.Errorf("satır %d: oda başlığı bekleniyor", line)
+			}
+			m := rec.Message
+			if m == nil || m.ID <= lastID {
+				return PersistedRoom{}, fmt.Errorf("satır %d: mesaj ID'leri artan sırada olmalı", line)
+			}
+			if len(m.Content) > maxFieldLength {
+				return PersistedRoom{}, fmt.Errorf("satır %d: mesaj çok uzun (%d karakter)", line, len(m.Content))
+			}
+			lastID = m.ID
+			pr.Messages = append(pr.Messages, *m)
+		default:
+			return PersistedRoom{}, fmt.Errorf("satır %d: bilinmeyen kayıt türü %q", line, rec.Type)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return PersistedRoom{}, err
+	}
+	if !sawHeader {
+		return PersistedRoom{}, fmt.Errorf("oda başlığı bulunamadı")
+	}
+	return pr, nil
+}
+
+// writeMarkdown writes a readable transcript: participants, the direct
+// conversations (threads) between pairs of participants, and the messages
+// in order with manager routing, edits and system events marked.
+func writeMarkdown(w io.Writer, room string, snap PersistedRoom) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", room)
+	fmt.Fprintf(&sb, "_Dışa aktarıldı: %s · %d mesaj_\n\n", formatExportTime(types.Timestamp()), len(snap.Messages))
+
+	if len(snap.Agents) > 0 {
+		sb.WriteString("## Katılımcılar\n\n")
+		names := make([]string, 0, len(snap.Agents))
+		for name := range snap.Agents {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			a := snap.Agents[name]
+			role := a.Role
+			if a.Human {
+				role = "insan"
+			}
+			if role != "" {
+				fmt.Fprintf(&sb, "- **%s** (%s)\n", name, role)
+			} else {
+				fmt.Fprintf(&sb, "- **%s**\n", name)
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	if threads := transcriptThreads(snap.Messages); len(threads) > 0 {
+		sb.WriteString("## Konuşmalar\n\n")
+		for _, t := range threads {
+			refs := make([]string, len(t.ids))
+			for i, id := range t.ids {
+				refs[i] = fmt.Sprintf("[#%d](#m%d)", id, id)
+			}
+			fmt.Fprintf(&sb, "- %s ↔ %s: %d mesaj — %s\n", t.a, t.b, len(t.ids), strings.Join(refs, ", "))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Mesajlar\n\n")
+	for _, m := range snap.Messages {
+		if m.Type == "system" {
+			fmt.Fprintf(&sb, "<a id=\"m%d\"></a>_%s · %s_\n\n", m.ID, formatExportTime(m.Timestamp), sanitize(m.Content))
+			continue
+		}
+		from := m.From
+		if m.FromHuman {
+			from = "👤 " + from
+		}
+		to := m.To
+		if to == "all" {
+			to = "herkes"
+		}
+		fmt.Fprintf(&sb, "<a id=\"m%d\"></a>**#%d %s → %s** · %s", m.ID, m.ID, from, to, formatExportTime(m.Timestamp))
+		var notes []string
+		if m.Priority == "urgent" {
+			notes = append(notes, "acil")
+		}
+		if m.RoutedByManager {
+			notes = append(notes, fmt.Sprintf("manager üzerinden, asıl alıcı: %s", m.OriginalTo))
+		}
+		if m.Via != "" {
+			notes = append(notes, "köprü: "+m.Via)
+		}
+		if m.EditedAt != "" {
+			notes = append(notes, fmt.Sprintf("%d kez düzenlendi", len(m.Edits)))
+		}
+		if len(notes) > 0 {
+			fmt.Fprintf(&sb, " · _%s_", strings.Join(notes, ", "))
+		}
+		sb.WriteString("\n\n")
+		if m.Retracted {
+			fmt.Fprintf(&sb, "> _geri çekildi (%s)_\n\n", m.RetractedBy)
+			continue
+		}
+		for _, line := range strings.Split(sanitize(m.Content), "\n") {
+			sb.WriteString("> " + line + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+type transcriptThread struct {
+	a, b string
+	ids  []int
+}
+
+// transcriptThreads groups direct messages by the pair of participants
+// exchanging them, in order of each conversation's first message.
+func transcriptThreads(msgs []types.Message) []transcriptThread {
+	index := map[[2]string]int{}
+	var threads []transcriptThread
+	for _, m := range msgs {
+		if m.Type == "system" || m.To == "all" || len(m.Recipients) > 0 || m.From == m.To {
+			continue
+		}
+		a, b := m.From, m.To
+		if b < a {
+			a, b = b, a
+		}
+		key := [2]string{a, b}
+		i, ok := index[key]
+		if !ok {
+			i = len(threads)
+			index[key] = i
+			threads = append(threads, transcriptThread{a: a, b: b})
+		}
+		threads[i].ids = append(threads[i].ids, m.ID)
+	}
+	return threads
+}
+
+func formatExportTime(ts string) string {
+	t, err := types.ParseTime(ts)
+	if err != nil {
+		return ts
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,149 @@
+package hub
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"desktop/internal/types"
+)
+
+func seededRoom(t *testing.T) PersistedRoom {
+	t.Helper()
+	r := NewRoomState()
+	if _, _, err := r.Join("dev", "developer"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := r.JoinHuman("user"); err != nil {
+		t.Fatal(err)
+	}
+	r.SendMessage("dev", "qa", "tests are green", true, "normal", SendOptions{})
+	r.SendMessage("qa", "dev", "thanks", false, "urgent", SendOptions{})
+	r.SendMessage("dev", "lead", "ship it?", true, "normal", SendOptions{OriginalTo: "all", RoutedByManager: true})
+	r.SendMessage("user", "all", "line one\nline two", true, "normal", SendOptions{FromHuman: true})
+	r.SetGroup("reviewers", []string{"qa", "lead"})
+	return r.Snapshot()
+}
+
+func TestExportJSONLRoundTrip(t *testing.T) {
+	snap := seededRoom(t)
+	var out strings.Builder
+	if err := writeJSONL(&out, "backend", snap); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(snap.Messages)+1 || !strings.Contains(lines[0], `"type":"room"`) {
+		t.Fatalf("export = %s", out.String())
+	}
+
+	pr, err := ReadExport(strings.NewReader(out.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	room := NewRoomState()
+	if err := room.Seed(pr); err != nil {
+		t.Fatal(err)
+	}
+	got := room.GetMessages()
+	if len(got) != len(snap.Messages) {
+		t.Fatalf("seeded %d messages, want %d", len(got), len(snap.Messages))
+	}
+	last := got[len(got)-1]
+	if !last.FromHuman || last.Content != "line one\nline two" || last.ID != snap.Messages[len(snap.Messages)-1].ID {
+		t.Errorf("last message = %+v", last)
+	}
+	if len(room.GetAgents()) != 0 {
+		t.Error("import must not restore agents as live participants")
+	}
+	if groups := room.GetGroups(); len(groups["reviewers"]) != 2 {
+		t.Errorf("groups = %v", groups)
+	}
+	if next, _ := room.SendMessage("dev", "all", "after import", true, "normal", SendOptions{}); next.ID != last.ID+1 {
+		t.Errorf("next ID = %d, want %d", next.ID, last.ID+1)
+	}
+	if err := room.Seed(pr); err == nil {
+		t.Error("seeding a non-empty room should fail")
+	}
+}
+
+func TestReadJSONLRejectsBadInput(t *testing.T) {
+	msg := func(id int) string {
+		b, _ := json.Marshal(exportRecord{Type: "message", Message: &types.Message{ID: id, From: "a", To: "b"}})
+		return string(b)
+	}
+	header := `{"type":"room","version":1,"room":"r"}`
+	for name, input := range map[string]string{
+		"empty":          "",
+		"no header":      msg(1),
+		"ids decreasing": header + "\n" + msg(2) + "\n" + msg(1),
+		"unknown record": header + "\n" + `{"type":"agent"}`,
+		"future version": `{"type":"room","version":99}`,
+		"not json":       header + "\nnope",
+	} {
+		if _, err := ReadExport(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: ReadExport accepted %q", name, input)
+		}
+	}
+}
+
+func TestExportMarkdownTranscript(t *testing.T) {
+	var out strings.Builder
+	if err := writeMarkdown(&out, "backend", seededRoom(t)); err != nil {
+		t.Fatal(err)
+	}
+	md := out.String()
+	for _, want := range []string{
+		"# backend",
+		"- **dev** (developer)",
+		"- **user** (insan)",
+		"- dev ↔ qa: 2 mesaj",
+		"manager üzerinden, asıl alıcı: all",
+		"👤 user → herkes**",
+		"> line one\n> line two",
+		"_acil_",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("transcript missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestHandleExportImportRoom(t *testing.T) {
+	h, c := newTestHubClient()
+	h.getOrCreateRoom("src").SendMessage("dev", "all", "hello", true, "normal", SendOptions{})
+
+	h.handleRequest(c, types.Request{ID: "1", Type: "export_room", Room: "src"})
+	if resp := readResponse(t, c, "export_room"); resp.Success {
+		t.Fatal("export must require desktop auth")
+	}
+	c.clientType = "desktop"
+	c.desktopAuthed = true
+
+	h.handleRequest(c, types.Request{ID: "2", Type: "export_room", Room: "src", Data: mustRawJSON(t, map[string]any{"format": "jsonl"})})
+	resp := readResponse(t, c, "export_room")
+	var exported struct {
+		Data string `json:"data"`
+	}
+	json.Unmarshal(resp.Data, &exported)
+	if !resp.Success || exported.Data == "" {
+		t.Fatalf("export failed: %s", resp.Error)
+	}
+
+	h.handleRequest(c, types.Request{ID: "3", Type: "import_room", Room: "replay", Data: mustRawJSON(t, map[string]any{"data": exported.Data})})
+	if resp := readResponse(t, c, "import_room"); !resp.Success {
+		t.Fatalf("import failed: %s", resp.Error)
+	}
+	msgs := h.getOrCreateRoom("replay").GetMessages()
+	if len(msgs) != 2 || msgs[0].Content != "hello" || msgs[1].Type != "system" {
+		t.Errorf("replay room = %+v", msgs)
+	}
+
+	h.handleRequest(c, types.Request{ID: "4", Type: "import_room", Room: "src", Data: mustRawJSON(t, map[string]any{"data": exported.Data})})
+	if resp := readResponse(t, c, "import_room"); resp.Success {
+		t.Error("importing into a room with messages should fail")
+	}
+	h.handleRequest(c, types.Request{ID: "5", Type: "export_room", Room: "missing"})
+	if resp := readResponse(t, c, "export_room"); resp.Success {
+		t.Error("exporting a missing room should fail")
+	}
+}
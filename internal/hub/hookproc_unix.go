@@ -0,0 +1,21 @@
+//go:build !windows
+
+package hub
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// killTreeOnCancel starts cmd in its own process group and makes context
+// cancellation kill the whole group rather than just the shell.
+func killTreeOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package hub
+
+import "os/exec"
+
+// killTreeOnCancel leaves cancellation to the default process kill; WaitDelay
+// still stops children holding the output pipe from blocking the hook.
+func killTreeOnCancel(cmd *exec.Cmd) {}
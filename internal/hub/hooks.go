@@ -0,0 +1,409 @@
+package hub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"desktop/internal/types"
+)
+
+const (
+	defaultHookTimeout = 10 * time.Second
+	maxHookTimeout     = 2 * time.Minute
+	maxHookRetries     = 5
+	maxHooksPerRoom    = 50
+	hookWorkers        = 4
+	hookQueueSize      = 256
+	hookLogSize        = 200
+	hookOutputLimit    = 1024
+	// hookWaitDelay bounds how long a timed-out command's output is awaited
+	// after its process group is killed.
+	hookWaitDelay = 2 * time.Second
+)
+
+// hookClient posts to URL hooks without following redirects, which could
+// lead away from the local machine the URL was checked against.
+var hookClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Hook runs when a room event matches it: it either POSTs the event JSON to
+// a local URL or runs a local command with the event JSON on stdin. The
+// message filters (From, To, Contains, Priority) only match events that
+// carry a message (message_new, message_updated); an empty filter matches
+// everything.
+type Hook struct {
+	ID       int    `json:"id"`
+	Room     string `json:"room"`
+	Event    string `json:"event"` // event name, or "*" for every event
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	Contains string `json:"contains,omitempty"` // case-insensitive
+	Priority string `json:"priority,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Command  string `json:"command,omitempty"`
+	Timeout  string `json:"timeout,omitempty"` // Go duration, default 10s
+	Retries  int    `json:"retries,omitempty"`
+	// CreatedAt is set by the hub.
+	CreatedAt string `json:"created_at"`
+}
+
+// HookPayload is the JSON a hook receives.
+type HookPayload struct {
+	HookID    int             `json:"hook_id"`
+	Room      string          `json:"room"`
+	Event     string          `json:"event"`
+	Data      json.RawMessage `json:"data"`
+	Timestamp string          `json:"timestamp"`
+}
+
+// HookDelivery is one entry of the delivery log: the outcome of running a
+// hook for one event, after all retries.
+type HookDelivery struct {
+	HookID     int    `json:"hook_id"`
+	Room       string `json:"room"`
+	Event      string `json:"event"`
+	Attempts   int    `json:"attempts"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	Output     string `json:"output,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	At         string `json:"at"`
+}
+
+type hookJob struct {
+	hook    Hook
+	payload []byte
+	event   string
+}
+
+func (hk *Hook) record() (*int, *string) { return &hk.ID, &hk.Room }
+
+// hookStore holds the hooks of all rooms and runs them on a small worker
+// pool so slow endpoints and commands never block event broadcasting.
+type hookStore struct {
+	roomStore[Hook, *Hook]
+	log     []HookDelivery
+	queue   chan hookJob
+	backoff time.Duration // first retry delay, doubled per attempt
+	logger  *log.Logger
+}
+
+func newHookStore(path string, logger *log.Logger) *hookStore {
+	return &hookStore{
+		// The file is private: hooks run commands.
+		roomStore: roomStore[Hook, *Hook]{items: make(map[int]*Hook), nextID: 1, path: path, key: "hooks", perm: 0600},
+		queue:     make(chan hookJob, hookQueueSize),
+		backoff:   time.Second,
+		logger:    logger,
+	}
+}
+
+// validateHook checks a hook definition and normalizes its fields.
+func validateHook(hk *Hook) error {
+	hk.Event = strings.TrimSpace(hk.Event)
+	hk.URL = strings.TrimSpace(hk.URL)
+	hk.Command = strings.TrimSpace(hk.Command)
+	if hk.Event == "" {
+		return fmt.Errorf("event gerekli (örn. message_new, room_cleared veya *)")
+	}
+	if (hk.URL == "") == (hk.Command == "") {
+		return fmt.Errorf("url veya command alanlarından tam olarak biri verilmeli")
+	}
+	if hk.URL != "" {
+		if err := validateHookURL(hk.URL); err != nil {
+			return err
+		}
+	}
+	if hk.Timeout != "" {
+		d, err := time.ParseDuration(hk.Timeout)
+		if err != nil || d <= 0 || d > maxHookTimeout {
+			return fmt.Errorf("geçersiz timeout %q: 0 ile %s arasında bir süre bekleniyor", hk.Timeout, maxHookTimeout)
+		}
+	}
+	if hk.Retries < 0 || hk.Retries > maxHookRetries {
+		return fmt.Errorf("retries 0 ile %d arasında olmalı", maxHookRetries)
+	}
+	if hk.Priority != "" {
+		p, err := types.NormalizePriority(hk.Priority)
+		if err != nil {
+			return err
+		}
+		hk.Priority = p
+	}
+	return nil
+}
+
+// validateHookURL only allows http(s) URLs on the local machine: hooks are a
+// local integration point, not a way to ship room contents elsewhere.
+func validateHookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("geçersiz url %q: http(s) adresi bekleniyor", raw)
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("url yalnızca yerel makineyi gösterebilir (localhost, 127.0.0.1, ::1): %s", raw)
+}
+
+// add registers a hook and persists the store.
+func (s *hookStore) add(hk Hook) (Hook, error) {
+	if err := validateHook(&hk); err != nil {
+		return Hook{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.countLocked(hk.Room) >= maxHooksPerRoom {
+		return Hook{}, fmt.Errorf("bu odada en fazla %d hook olabilir", maxHooksPerRoom)
+	}
+
+	hk.CreatedAt = types.Timestamp()
+	s.insertLocked(&hk)
+	return hk, nil
+}
+
+// remove deletes a hook of a room.
+func (s *hookStore) remove(room string, id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[id]
+	if !ok || it.Room != room {
+		return false
+	}
+	delete(s.items, id)
+	s.saveLocked()
+	return true
+}
+
+// list returns the hooks of a room ordered by ID.
+func (s *hookStore) list(room string) []Hook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Hook
+	for _, it := range s.items {
+		if it.Room == room {
+			out = append(out, *it)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// deliveries returns the delivery log of a room, newest first.
+func (s *hookStore) deliveries(room string, limit int) []HookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []HookDelivery
+	for i := len(s.log) - 1; i >= 0 && (limit <= 0 || len(out) < limit); i-- {
+		if s.log[i].Room == room {
+			out = append(out, s.log[i])
+		}
+	}
+	return out
+}
+
+// dispatch queues every hook of the room that matches the event. It never
+// blocks: when the queue is full the delivery is dropped and logged.
+func (s *hookStore) dispatch(room, event string, data json.RawMessage) {
+	s.mu.Lock()
+	var matched []Hook
+	for _, it := range s.items {
+		if it.Room == room && it.matches(event, data) {
+			matched = append(matched, *it)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, hk := range matched {
+		payload, _ := json.Marshal(HookPayload{
+			HookID:    hk.ID,
+			Room:      room,
+			Event:     event,
+			Data:      data,
+			Timestamp: types.Timestamp(),
+		})
+		select {
+		case s.queue <- hookJob{hook: hk, payload: payload, event: event}:
+		default:
+			s.logger.Printf("[HOOK] queue full, dropping hook=%d room=%s event=%s", hk.ID, room, event)
+			s.record(HookDelivery{HookID: hk.ID, Room: room, Event: event, Error: "kuyruk dolu, teslimat atlandı", At: types.Timestamp()})
+		}
+	}
+}
+
+func (hk *Hook) matches(event string, data json.RawMessage) bool {
+	if hk.Event != "*" && hk.Event != event {
+		return false
+	}
+	if hk.From == "" && hk.To == "" && hk.Contains == "" && hk.Priority == "" {
+		return true
+	}
+	var payload struct {
+		Message *types.Message `json:"message"`
+	}
+	if json.Unmarshal(data, &payload) != nil || payload.Message == nil {
+		return false
+	}
+	m := payload.Message
+	if hk.From != "" && m.From != hk.From {
+		return false
+	}
+	if hk.To != "" && m.To != hk.To && !containsString(m.Recipients, hk.To) {
+		return false
+	}
+	if hk.Contains != "" && !strings.Contains(strings.ToLower(m.Content), strings.ToLower(hk.Contains)) {
+		return false
+	}
+	if hk.Priority != "" && m.Priority != hk.Priority {
+		return false
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// run starts the delivery workers; they stop when done is closed.
+func (s *hookStore) run(done <-chan struct{}) {
+	for i := 0; i < hookWorkers; i++ {
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				case job := <-s.queue:
+					s.deliver(done, job)
+				}
+			}
+		}()
+	}
+}
+
+// deliver runs one hook with retries and records the outcome.
+func (s *hookStore) deliver(done <-chan struct{}, job hookJob) {
+	timeout := defaultHookTimeout
+	if d, err := time.ParseDuration(job.hook.Timeout); err == nil && d > 0 {
+		timeout = d
+	}
+	start := time.Now()
+	entry := HookDelivery{HookID: job.hook.ID, Room: job.hook.Room, Event: job.event}
+	backoff := s.backoff
+	for attempt := 0; attempt <= job.hook.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-done:
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		entry.Attempts = attempt + 1
+		output, err := s.execute(job, timeout)
+		entry.Output = output
+		if err == nil {
+			entry.Success = true
+			entry.Error = ""
+			break
+		}
+		entry.Error = err.Error()
+	}
+	entry.DurationMS = time.Since(start).Milliseconds()
+	entry.At = types.Timestamp()
+	if !entry.Success {
+		s.logger.Printf("[HOOK] hook=%d room=%s event=%s failed after %d attempts: %s",
+			entry.HookID, entry.Room, entry.Event, entry.Attempts, entry.Error)
+	}
+	s.record(entry)
+}
+
+func (s *hookStore) execute(job hookJob, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if job.hook.URL != "" {
+		return postHook(ctx, job.hook.URL, job.payload)
+	}
+	return runHookCommand(ctx, job.hook, job.event, job.payload)
+}
+
+func postHook(ctx context.Context, target string, payload []byte) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := hookClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, hookOutputLimit))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return string(body), fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return string(body), nil
+}
+
+// runHookCommand runs the hook command through the system shell with the
+// payload on stdin and the event described in AGENT_CHAT_* variables. On
+// timeout the whole process tree is killed, so children that inherited the
+// output pipe cannot keep the hook worker waiting.
+func runHookCommand(ctx context.Context, hk Hook, event string, payload []byte) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", hk.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", hk.Command)
+	}
+	killTreeOnCancel(cmd)
+	cmd.WaitDelay = hookWaitDelay
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"AGENT_CHAT_ROOM="+hk.Room,
+		"AGENT_CHAT_EVENT="+event,
+		fmt.Sprintf("AGENT_CHAT_HOOK_ID=%d", hk.ID),
+	)
+	out, err := cmd.CombinedOutput()
+	if len(out) > hookOutputLimit {
+		out = out[:hookOutputLimit]
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return string(out), fmt.Errorf("zaman aşımı")
+	}
+	return string(out), err
+}
+
+func (s *hookStore) record(entry HookDelivery) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log = append(s.log, entry)
+	if len(s.log) > hookLogSize {
+		s.log = s.log[len(s.log)-hookLogSize:]
+	}
+}
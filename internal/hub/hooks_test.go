@@ -0,0 +1,257 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"desktop/internal/types"
+)
+
+func newTestHooks(t *testing.T) *hookStore {
+	t.Helper()
+	s := newHookStore("", log.New(io.Discard, "", 0))
+	s.backoff = time.Millisecond
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	s.run(done)
+	return s
+}
+
+// waitDelivery polls the delivery log until the room has n entries.
+func waitDelivery(t *testing.T, s *hookStore, room string, n int) []HookDelivery {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if got := s.deliveries(room, 0); len(got) >= n {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d deliveries in %s", n, room)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func messageEvent(t *testing.T, m types.Message) json.RawMessage {
+	return mustRawJSON(t, map[string]any{"message": m})
+}
+
+func TestHookMatches(t *testing.T) {
+	msg := messageEvent(t, types.Message{From: "dev", To: "user", Content: "Ready for REVIEW", Priority: "urgent"})
+	cases := []struct {
+		name  string
+		hook  Hook
+		event string
+		data  json.RawMessage
+		want  bool
+	}{
+		{"event only", Hook{Event: "room_cleared"}, "room_cleared", nil, true},
+		{"other event", Hook{Event: "room_cleared"}, "message_new", msg, false},
+		{"wildcard", Hook{Event: "*"}, "agent_joined", nil, true},
+		{"contains ignores case", Hook{Event: "message_new", Contains: "ready for review"}, "message_new", msg, true},
+		{"to and priority", Hook{Event: "message_new", To: "user", Priority: "urgent"}, "message_new", msg, true},
+		{"from mismatch", Hook{Event: "message_new", From: "qa"}, "message_new", msg, false},
+		{"filter without message", Hook{Event: "*", From: "dev"}, "room_cleared", nil, false},
+	}
+	for _, tc := range cases {
+		if got := tc.hook.matches(tc.event, tc.data); got != tc.want {
+			t.Errorf("%s: matches = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestValidateHook(t *testing.T) {
+	bad := []Hook{
+		{URL: "http://localhost/x"},
+		{Event: "message_new"},
+		{Event: "message_new", URL: "http://localhost/x", Command: "true"},
+		{Event: "message_new", URL: "http://example.com/hook"},
+		{Event: "message_new", URL: "file:///etc/passwd"},
+		{Event: "message_new", Command: "true", Timeout: "forever"},
+		{Event: "message_new", Command: "true", Retries: 9},
+	}
+	for _, hk := range bad {
+		if err := validateHook(&hk); err == nil {
+			t.Errorf("validateHook(%+v) = nil, want error", hk)
+		}
+	}
+	for _, hk := range []Hook{
+		{Event: "message_new", URL: "http://127.0.0.1:9000/hook"},
+		{Event: "*", URL: "http://[::1]/hook", Timeout: "2s", Retries: 2},
+		{Event: "room_cleared", Command: "say cleared"},
+	} {
+		if err := validateHook(&hk); err != nil {
+			t.Errorf("validateHook(%+v) = %v", hk, err)
+		}
+	}
+}
+
+func TestHookPostsEventAndRetries(t *testing.T) {
+	calls := make(chan HookPayload, 4)
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var p HookPayload
+		json.NewDecoder(r.Body).Decode(&p)
+		calls <- p
+	}))
+	defer srv.Close()
+
+	s := newTestHooks(t)
+	if _, err := s.add(Hook{Room: "r1", Event: "room_cleared", URL: srv.URL, Retries: 2}); err != nil {
+		t.Fatal(err)
+	}
+	s.dispatch("r1", "room_cleared", json.RawMessage(`{}`))
+	s.dispatch("r2", "room_cleared", json.RawMessage(`{}`))
+
+	select {
+	case p := <-calls:
+		if p.Room != "r1" || p.Event != "room_cleared" {
+			t.Errorf("payload = %+v", p)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("hook was not called")
+	}
+	entries := waitDelivery(t, s, "r1", 1)
+	if !entries[0].Success || entries[0].Attempts != 2 {
+		t.Errorf("delivery = %+v, want success on attempt 2", entries[0])
+	}
+	if got := s.deliveries("r2", 0); len(got) != 0 {
+		t.Errorf("r2 has no hooks but logged %v", got)
+	}
+}
+
+func TestHookRunsCommandWithEventOnStdin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses /bin/sh")
+	}
+	out := filepath.Join(t.TempDir(), "event.json")
+	s := newTestHooks(t)
+	if _, err := s.add(Hook{Room: "r1", Event: "message_new", Contains: "ready", Command: `cat > "` + out + `"; echo "$AGENT_CHAT_EVENT"`}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.add(Hook{Room: "r1", Event: "message_new", Command: "exit 3"}); err != nil {
+		t.Fatal(err)
+	}
+	s.dispatch("r1", "message_new", messageEvent(t, types.Message{ID: 4, From: "dev", To: "all", Content: "ready for review"}))
+
+	entries := waitDelivery(t, s, "r1", 2)
+	for _, d := range entries {
+		switch d.HookID {
+		case 1:
+			if !d.Success || strings.TrimSpace(d.Output) != "message_new" {
+				t.Errorf("command hook delivery = %+v", d)
+			}
+		case 2:
+			if d.Success || d.Error == "" {
+				t.Errorf("failing hook delivery = %+v", d)
+			}
+		}
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p HookPayload
+	if err := json.Unmarshal(data, &p); err != nil || p.HookID != 1 || !strings.Contains(string(p.Data), "ready for review") {
+		t.Errorf("stdin payload = %s (%v)", data, err)
+	}
+}
+
+func TestHookCommandTimeoutKillsChildren(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses /bin/sh")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	// The background sleep inherits the output pipe and outlives the shell.
+	_, err := runHookCommand(ctx, Hook{Room: "r1", Command: "sleep 30 & sleep 30"}, "message_new", nil)
+	if err == nil || time.Since(start) > hookWaitDelay+time.Second {
+		t.Fatalf("runHookCommand = %v after %s, want a timeout well before the children exit", err, time.Since(start))
+	}
+}
+
+func TestPostHookDoesNotFollowRedirects(t *testing.T) {
+	followed := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	}))
+	defer target.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+
+	if _, err := postHook(context.Background(), srv.URL, []byte(`{}`)); err == nil || followed {
+		t.Errorf("postHook = %v, followed = %v; want an error without following the redirect", err, followed)
+	}
+}
+
+func TestHookStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hub-hooks.json")
+	s := newHookStore(path, log.New(io.Discard, "", 0))
+	hk, err := s.add(Hook{Room: "r1", Event: "room_cleared", Command: "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := newHookStore(path, log.New(io.Discard, "", 0))
+	if err := loaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.list("r1"); len(got) != 1 || got[0].ID != hk.ID {
+		t.Fatalf("loaded hooks = %+v", got)
+	}
+	if next, _ := loaded.add(Hook{Room: "r1", Event: "*", Command: "true"}); next.ID <= hk.ID {
+		t.Errorf("next ID = %d, want > %d", next.ID, hk.ID)
+	}
+	if loaded.remove("r2", hk.ID) {
+		t.Error("remove from another room should fail")
+	}
+}
+
+func TestHandleHooks_RequireDesktopAuth(t *testing.T) {
+	h, c := newTestHubClient()
+	req := types.Request{ID: "1", Type: "add_hook", Room: "r1", Data: mustRawJSON(t, map[string]any{
+		"event": "room_cleared", "command": "true",
+	})}
+	h.handleRequest(c, req)
+	if resp := readResponse(t, c, "add_hook"); resp.Success {
+		t.Fatal("agent client must not add hooks")
+	}
+
+	c.clientType = "desktop"
+	c.desktopAuthed = true
+	h.handleRequest(c, req)
+	if resp := readResponse(t, c, "add_hook"); !resp.Success {
+		t.Fatalf("desktop add_hook failed: %s", resp.Error)
+	}
+	h.handleRequest(c, types.Request{ID: "2", Type: "list_hooks", Room: "r1"})
+	resp := readResponse(t, c, "list_hooks")
+	var data struct {
+		Hooks []Hook `json:"hooks"`
+	}
+	json.Unmarshal(resp.Data, &data)
+	if len(data.Hooks) != 1 || data.Hooks[0].Room != "r1" {
+		t.Fatalf("list_hooks = %s", resp.Data)
+	}
+	h.handleRequest(c, types.Request{ID: "3", Type: "remove_hook", Room: "r1", Data: mustRawJSON(t, map[string]any{"hook_id": data.Hooks[0].ID})})
+	if resp := readResponse(t, c, "remove_hook"); !resp.Success {
+		t.Fatalf("remove_hook failed: %s", resp.Error)
+	}
+}
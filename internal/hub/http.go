@@ -0,0 +1,321 @@
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"desktop/internal/types"
+	"desktop/internal/validation"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+	// sseKeepAlive is how often an idle event stream gets a comment line so
+	// proxies and clients do not time it out.
+	sseKeepAlive = 30 * time.Second
+)
+
+// registerHTTP mounts the REST and server-sent events API next to /ws. Every
+// route except /api/health requires the desktop token, as a bearer token or,
+// for EventSource clients that cannot set headers, a token query parameter.
+func (h *Hub) registerHTTP(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/health", h.handleHealth)
+	mux.Handle("GET /api/rooms", h.authorizeHTTP(h.handleHTTPRooms))
+	mux.Handle("GET /api/rooms/{room}/messages", h.authorizeHTTP(h.handleHTTPMessages))
+	mux.Handle("POST /api/rooms/{room}/messages", h.authorizeHTTP(h.handleHTTPSend))
+	mux.Handle("GET /api/rooms/{room}/agents", h.authorizeHTTP(h.handleHTTPAgents))
+	mux.Handle("GET /api/rooms/{room}/events", h.authorizeHTTP(h.handleHTTPEvents))
+	// Connectors authenticate their own inbound webhooks.
+	mux.HandleFunc("POST /api/connectors/{id}/inbound", h.handleConnectorInbound)
+}
+
+func (h *Hub) authorizeHTTP(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+		if !h.validateDesktopToken(token) {
+			writeHTTPError(w, http.StatusUnauthorized, "geçersiz veya eksik token")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// lookupRoom returns an existing room without creating it.
+func (h *Hub) lookupRoom(room string) (*RoomState, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	r, ok := h.rooms[room]
+	return r, ok
+}
+
+func (h *Hub) handleHealth(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	rooms, clients := len(h.rooms), len(h.clients)
+	h.mu.RUnlock()
+	writeHTTPJSON(w, http.StatusOK, map[string]any{"status": "ok", "rooms": rooms, "clients": clients})
+}
+
+func (h *Hub) handleHTTPRooms(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	infos := ListRoomInfos(h.rooms)
+	defaultRoom := h.defaultRoom
+	h.mu.RUnlock()
+
+	rooms := make([]map[string]any, 0, len(infos))
+	for _, info := range infos {
+		rooms = append(rooms, map[string]any{
+			"name":     info.Name,
+			"agents":   info.Agents,
+			"messages": info.Messages,
+			"default":  info.Name == defaultRoom,
+		})
+	}
+	writeHTTPJSON(w, http.StatusOK, map[string]any{"rooms": rooms})
+}
+
+// handleHTTPMessages returns one page of a room's messages. Without
+// parameters it returns the latest page; since_id pages forward from a
+// message ID and before_id pages backward. Reading does not mark anything
+// as read for agents.
+func (h *Hub) handleHTTPMessages(w http.ResponseWriter, r *http.Request) {
+	roomState, ok := h.lookupRoom(r.PathValue("room"))
+	if !ok {
+		writeHTTPError(w, http.StatusNotFound, "oda bulunamadı")
+		return
+	}
+	q := r.URL.Query()
+	sinceID, err := queryInt(q.Get("since_id"), 0)
+	if err != nil {
+		writeHTTPError(w, http.StatusBadRequest, "since_id geçersiz")
+		return
+	}
+	beforeID, err := queryInt(q.Get("before_id"), 0)
+	if err != nil {
+		writeHTTPError(w, http.StatusBadRequest, "before_id geçersiz")
+		return
+	}
+	limit, err := queryInt(q.Get("limit"), defaultPageSize)
+	if err != nil || limit < 1 {
+		writeHTTPError(w, http.StatusBadRequest, "limit geçersiz")
+		return
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	visible, _ := roomState.ReadAllMessages(0, 0)
+	page, hasMore := pageMessages(visible, sinceID, beforeID, limit)
+	writeHTTPJSON(w, http.StatusOK, map[string]any{"messages": page, "has_more": hasMore})
+}
+
+// pageMessages selects up to limit messages with since_id < ID < before_id
+// (zero means unbounded). Forward pages start at since_id; otherwise the page
+// ends at before_id. hasMore reports whether messages remain past the page in
+// the paging direction.
+func pageMessages(msgs []types.Message, sinceID, beforeID, limit int) ([]types.Message, bool) {
+	var window []types.Message
+	for _, m := range msgs {
+		if m.ID <= sinceID || (beforeID > 0 && m.ID >= beforeID) {
+			continue
+		}
+		window = append(window, m)
+	}
+	if window == nil {
+		window = []types.Message{}
+	}
+	if len(window) <= limit {
+		return window, false
+	}
+	if sinceID > 0 {
+		return window[:limit], true
+	}
+	return window[len(window)-limit:], true
+}
+
+func queryInt(s string, def int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
+
+// handleHTTPSend posts a message as a human participant, joining the room
+// under that name first. The body takes the send_message fields (from, to,
+// content, priority, expects_reply, deliver_at, ...) plus bypass_manager, and
+// goes through the same validation, rate limits and manager routing.
+func (h *Hub) handleHTTPSend(w http.ResponseWriter, r *http.Request) {
+	room := r.PathValue("room")
+	if err := validation.ValidateName(room); err != nil {
+		writeHTTPError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	body := map[string]any{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMsgSize)).Decode(&body); err != nil {
+		writeHTTPError(w, http.StatusBadRequest, "geçersiz JSON gövdesi")
+		return
+	}
+	from, _ := body["from"].(string)
+	if from == "" {
+		writeHTTPError(w, http.StatusBadRequest, "from alanı gerekli")
+		return
+	}
+	if err := validation.ValidateName(from); err != nil {
+		writeHTTPError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Reject a bad message before it can create the room or join the sender.
+	body["human"] = true
+	raw, _ := json.Marshal(body)
+	data := parseSendMessage(raw)
+	if _, _, err := data.checkFields(time.Now()); err != nil {
+		writeHTTPError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, _, err := h.roomOrEmpty(room).ResolveRecipients(data.To, from); err != nil {
+		writeHTTPError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	roomState := h.getOrCreateRoom(room)
+	sysMsg, agents, joined, err := roomState.JoinHuman(from)
+	if err != nil {
+		writeHTTPError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if joined {
+		h.logger.Printf("http: human=%q joined room=%q", from, room)
+		h.broadcastEvent(room, "message_new", map[string]any{"message": sysMsg})
+		h.broadcastEvent(room, "agent_joined", map[string]any{"agent_name": from, "agents": agents})
+	}
+
+	resp := h.handleLocal(types.Request{Type: "send_message", Room: room, Data: raw})
+	switch {
+	case resp.Success:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(resp.Data)
+	case resp.Code == types.ErrCodeRateLimited:
+		var limited struct {
+			RetryAfter int `json:"retry_after"`
+		}
+		json.Unmarshal(resp.Data, &limited)
+		w.Header().Set("Retry-After", strconv.Itoa(limited.RetryAfter))
+		writeHTTPError(w, http.StatusTooManyRequests, resp.Error)
+	default:
+		writeHTTPError(w, http.StatusBadRequest, resp.Error)
+	}
+}
+
+// handleLocal runs a request as an in-process desktop client and returns its
+// response. Handlers reply synchronously, so the response is already queued
+// when handleRequest returns.
+func (h *Hub) handleLocal(req types.Request) types.Response {
+	c := newClient(h, nil)
+	c.clientType = "desktop"
+	c.desktopAuthed = true
+	h.handleRequest(c, req)
+
+	var resp types.Response
+	select {
+	case data := <-c.send:
+		json.Unmarshal(data, &resp)
+	default:
+		resp.Error = "yanıt alınamadı"
+	}
+	return resp
+}
+
+func (h *Hub) handleHTTPAgents(w http.ResponseWriter, r *http.Request) {
+	roomState, ok := h.lookupRoom(r.PathValue("room"))
+	if !ok {
+		writeHTTPError(w, http.StatusNotFound, "oda bulunamadı")
+		return
+	}
+	writeHTTPJSON(w, http.StatusOK, map[string]any{"agents": roomState.GetAgents()})
+}
+
+// handleHTTPEvents streams a room's events (message_new, agent_joined, ...)
+// as server-sent events until the client disconnects or the hub shuts down.
+// The stream is a room subscriber like a WebSocket client, so it sees exactly
+// the events a subscribed desktop client would.
+func (h *Hub) handleHTTPEvents(w http.ResponseWriter, r *http.Request) {
+	room := r.PathValue("room")
+	if err := validation.ValidateName(room); err != nil {
+		writeHTTPError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeHTTPError(w, http.StatusInternalServerError, "streaming desteklenmiyor")
+		return
+	}
+
+	c := newClient(h, nil)
+	c.clientType = "desktop"
+	h.mu.Lock()
+	h.followLocked(c)
+	c.rooms[room] = true
+	if h.subs[room] == nil {
+		h.subs[room] = make(map[*Client]bool)
+	}
+	h.subs[room][c] = true
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		h.syncClientLocked(c)
+		for room := range c.rooms {
+			delete(h.subs[room], c)
+		}
+		h.unfollowLocked(c)
+		h.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case data := <-c.send:
+			var ev types.Event
+			if json.Unmarshal(data, &ev) != nil || ev.Event == "" {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Event, ev.Data)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeHTTPJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeHTTPError(w http.ResponseWriter, status int, msg string) {
+	writeHTTPJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,224 @@
+package hub
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"desktop/internal/types"
+)
+
+func newTestHTTPHub(t *testing.T) (*Hub, *httptest.Server) {
+	t.Helper()
+	h := New("", "default", log.New(io.Discard, "", 0))
+	h.desktopAuthToken = "secret"
+	mux := http.NewServeMux()
+	h.registerHTTP(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return h, srv
+}
+
+func doHTTP(t *testing.T, method, url, token, body string) (*http.Response, map[string]any) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	out := map[string]any{}
+	json.NewDecoder(resp.Body).Decode(&out)
+	return resp, out
+}
+
+func TestHTTP_RequiresToken(t *testing.T) {
+	_, srv := newTestHTTPHub(t)
+
+	if resp, _ := doHTTP(t, "GET", srv.URL+"/api/health", "", ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("health status = %d, want 200", resp.StatusCode)
+	}
+	for _, token := range []string{"", "wrong"} {
+		if resp, _ := doHTTP(t, "GET", srv.URL+"/api/rooms", token, ""); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("rooms with token %q: status = %d, want 401", token, resp.StatusCode)
+		}
+	}
+	if resp, _ := doHTTP(t, "GET", srv.URL+"/api/rooms?token=secret", "", ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("rooms with query token: status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestHTTP_PostThenReadMessages(t *testing.T) {
+	h, srv := newTestHTTPHub(t)
+	if _, _, err := h.getOrCreateRoom("r1").Join("bob", "dev"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, text := range []string{"one", "two", "three"} {
+		resp, body := doHTTP(t, "POST", srv.URL+"/api/rooms/r1/messages", "secret",
+			`{"from":"ops","to":"bob","content":"`+text+`"}`)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("post status = %d body=%v", resp.StatusCode, body)
+		}
+	}
+	if !h.getOrCreateRoom("r1").IsHuman("ops") {
+		t.Error("sender should have joined as a human")
+	}
+
+	_, body := doHTTP(t, "GET", srv.URL+"/api/rooms/r1/messages?limit=2", "secret", "")
+	msgs := body["messages"].([]any)
+	if len(msgs) != 2 || body["has_more"] != true {
+		t.Fatalf("latest page = %v", body)
+	}
+	last := msgs[1].(map[string]any)
+	if last["content"] != "three" || last["from_human"] != true {
+		t.Errorf("last message = %v", last)
+	}
+
+	first := msgs[0].(map[string]any)
+	_, body = doHTTP(t, "GET", srv.URL+"/api/rooms/r1/messages?before_id="+jsonNumber(first["id"]), "secret", "")
+	older := body["messages"].([]any)
+	if len(older) == 0 || body["has_more"] != false {
+		t.Fatalf("older page = %v", body)
+	}
+
+	if resp, _ := doHTTP(t, "GET", srv.URL+"/api/rooms/missing/messages", "secret", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing room status = %d, want 404", resp.StatusCode)
+	}
+	if resp, _ := doHTTP(t, "POST", srv.URL+"/api/rooms/r1/messages", "secret", `{"from":"bob","content":"x"}`); resp.StatusCode != http.StatusConflict {
+		t.Errorf("posting as an agent's name: status = %d, want 409", resp.StatusCode)
+	}
+}
+
+func TestHTTP_HidesRetractedAndValidatesFirst(t *testing.T) {
+	h, srv := newTestHTTPHub(t)
+	roomState := h.getOrCreateRoom("r1")
+	if _, _, err := roomState.Join("bob", "dev"); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := roomState.SendMessage("bob", "all", "oops", false, "normal", SendOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := roomState.RetractMessage(msg.ID, "bob", false); err != nil {
+		t.Fatal(err)
+	}
+	_, body := doHTTP(t, "GET", srv.URL+"/api/rooms/r1/messages", "secret", "")
+	for _, m := range body["messages"].([]any) {
+		if jsonNumber(m.(map[string]any)["id"]) == jsonNumber(msg.ID) {
+			t.Errorf("retracted message listed: %v", m)
+		}
+	}
+
+	// An invalid send neither creates the room nor joins the sender.
+	for _, payload := range []string{
+		`{"from":"ops","to":"@nope","content":"hi"}`,
+		`{"from":"ops","content":"hi","priority":"loud"}`,
+	} {
+		if resp, _ := doHTTP(t, "POST", srv.URL+"/api/rooms/fresh/messages", "secret", payload); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", payload, resp.StatusCode)
+		}
+	}
+	if _, ok := h.lookupRoom("fresh"); ok {
+		t.Error("invalid sends must not create the room")
+	}
+	if resp, _ := doHTTP(t, "POST", srv.URL+"/api/rooms/r1/messages", "secret", `{"from":"ops","to":"@nope","content":"hi"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown group: status = %d, want 400", resp.StatusCode)
+	}
+	if roomState.IsHuman("ops") {
+		t.Error("invalid sends must not join the sender")
+	}
+}
+
+func jsonNumber(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func TestHTTP_EventsStream(t *testing.T) {
+	h, srv := newTestHTTPHub(t)
+
+	req, _ := http.NewRequest("GET", srv.URL+"/api/rooms/r1/events?token=secret", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type = %q", ct)
+	}
+
+	msg := h.getOrCreateRoom("r1").PostSystem("hello stream")
+	h.broadcastEvent("r1", "message_new", map[string]any{"message": msg})
+
+	lines := make(chan string, 64)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	timeout := time.After(2 * time.Second)
+	sawEvent := false
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("stream closed")
+			}
+			if line == "event: message_new" {
+				sawEvent = true
+			}
+			if sawEvent && strings.HasPrefix(line, "data: ") && strings.Contains(line, "hello stream") {
+				return
+			}
+		case <-timeout:
+			t.Fatal("no message_new event received")
+		}
+	}
+}
+
+func TestPageMessages(t *testing.T) {
+	var msgs []types.Message
+	for i := 1; i <= 5; i++ {
+		msgs = append(msgs, types.Message{ID: i})
+	}
+	ids := func(page []types.Message) []int {
+		var out []int
+		for _, m := range page {
+			out = append(out, m.ID)
+		}
+		return out
+	}
+	cases := []struct {
+		since, before, limit int
+		want                 []int
+		more                 bool
+	}{
+		{0, 0, 2, []int{4, 5}, true},
+		{2, 0, 2, []int{3, 4}, true},
+		{3, 0, 5, []int{4, 5}, false},
+		{0, 4, 2, []int{2, 3}, true},
+		{0, 2, 5, []int{1}, false},
+	}
+	for _, tc := range cases {
+		page, more := pageMessages(msgs, tc.since, tc.before, tc.limit)
+		if got := ids(page); jsonNumber(got) != jsonNumber(tc.want) || more != tc.more {
+			t.Errorf("pageMessages(since=%d, before=%d, limit=%d) = %v, %v; want %v, %v",
+				tc.since, tc.before, tc.limit, got, more, tc.want, tc.more)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package hub
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -10,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"desktop/internal/types"
 
@@ -27,11 +29,20 @@ type Hub struct {
 	clients     map[*Client]bool
 	subs        map[string]map[*Client]bool // room → subscribed clients
 	roomManager map[string]string           // room → configured manager agent name
+	moves       map[string][]roomMove       // renamed or deleted room name → its moves, oldest first
+	roomEpoch   int                         // counts renames and deletes
+	followers   map[*Client]bool            // clients that follow moves on their own; see pruneMovesLocked
+	roomTTL     time.Duration               // idle rooms are archived after this long; 0 disables
 	defaultRoom string
 	// desktopAuthToken is a shared secret set by the desktop app when spawning the hub.
 	// It is required to identify as client_type=desktop.
 	desktopAuthToken string
 
+	scheduler  *scheduler
+	limiter    *rateLimiter
+	hooks      *hookStore
+	connectors *connectorManager
+
 	register   chan *Client
 	unregister chan *Client
 
@@ -40,18 +51,35 @@ type Hub struct {
 	done    chan struct{}
 
 	listener net.Listener
+
+	// stateMu serializes room state files: persistence, archive, restore,
+	// rename and delete. It is taken before mu.
+	stateMu sync.Mutex
 }
 
 // New creates a new Hub.
 func New(dataDir, defaultRoom string, logger *log.Logger) *Hub {
 	desktopAuthToken := strings.TrimSpace(os.Getenv("AGENT_CHAT_HUB_TOKEN"))
+	schedulePath, hooksPath, connectorsPath := "", "", ""
+	if dataDir != "" {
+		schedulePath = filepath.Join(dataDir, "hub-scheduled.json")
+		hooksPath = filepath.Join(dataDir, "hub-hooks.json")
+		connectorsPath = filepath.Join(dataDir, "hub-connectors.json")
+	}
 	return &Hub{
 		rooms:            make(map[string]*RoomState),
 		clients:          make(map[*Client]bool),
 		subs:             make(map[string]map[*Client]bool),
 		roomManager:      make(map[string]string),
+		moves:            make(map[string][]roomMove),
+		followers:        make(map[*Client]bool),
+		roomTTL:          DefaultRoomTTL,
 		defaultRoom:      defaultRoom,
 		desktopAuthToken: desktopAuthToken,
+		scheduler:        newScheduler(schedulePath),
+		limiter:          newRateLimiter(DefaultRateLimits),
+		hooks:            newHookStore(hooksPath, logger),
+		connectors:       newConnectorManager(connectorsPath, logger),
 		register:         make(chan *Client),
 		unregister:       make(chan *Client),
 		dataDir:          dataDir,
@@ -60,10 +88,19 @@ func New(dataDir, defaultRoom string, logger *log.Logger) *Hub {
 	}
 }
 
-// Run starts the WebSocket server. port=0 lets the OS assign a port.
+// Run starts the WebSocket server and the REST/SSE API (see http.go). port=0 lets the OS assign a port.
 // The actual port is written to ~/.agent-chat/hub.port.
 func (h *Hub) Run(port int) error {
 	h.loadPersistedState()
+	if err := h.scheduler.load(); err != nil {
+		h.logger.Printf("Failed to load scheduled messages: %v", err)
+	}
+	if err := h.hooks.load(); err != nil {
+		h.logger.Printf("Failed to load hooks: %v", err)
+	}
+	if err := h.connectors.load(); err != nil {
+		h.logger.Printf("Failed to load connectors: %v", err)
+	}
 
 	ln, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
 	if err != nil {
@@ -86,9 +123,24 @@ func (h *Hub) Run(port int) error {
 	// Start persistence loop
 	go h.persistLoop()
 
+	// Start scheduled message loop
+	go h.schedulerLoop()
+
+	// Start hook delivery workers
+	h.hooks.run(h.done)
+
+	// Start room connectors; they stop when the hub shuts down
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-h.done
+		cancel()
+	}()
+	h.connectors.start(ctx, h.deliverInbound)
+
 	// HTTP server
 	mux := http.NewServeMux()
 	mux.HandleFunc("/ws", h.handleWS)
+	h.registerHTTP(mux)
 
 	server := &http.Server{Handler: mux}
 	return server.Serve(ln)
@@ -150,6 +202,7 @@ func (h *Hub) runClientManager() {
 		case client := <-h.register:
 			h.mu.Lock()
 			h.clients[client] = true
+			h.followLocked(client)
 			h.mu.Unlock()
 			h.logger.Printf("Client connected (total: %d)", len(h.clients))
 
@@ -160,6 +213,7 @@ func (h *Hub) runClientManager() {
 				delete(h.clients, client)
 				close(client.send)
 				// Remove from room subscriptions
+				h.syncClientLocked(client)
 				for room := range client.rooms {
 					if subs, ok := h.subs[room]; ok {
 						delete(subs, client)
@@ -167,27 +221,44 @@ func (h *Hub) runClientManager() {
 				}
 				joinedRoom = client.joinedRoom
 				agentName = client.agentName
+				h.unfollowLocked(client)
 			}
 			h.mu.Unlock()
 
-			// If this client had joined as an agent, remove it immediately
-			// so name re-use and manager lock cleanup do not wait for stale timeout.
-			if joinedRoom != "" && agentName != "" {
-				roomState := h.getOrCreateRoom(joinedRoom)
-				if sysMsg, found := roomState.Leave(agentName); found {
-					agents := roomState.GetAgents()
-					h.broadcastEvent(joinedRoom, "message_new", map[string]any{"message": sysMsg})
-					h.broadcastEvent(joinedRoom, "agent_left", map[string]any{"agent_name": agentName, "agents": agents})
-				}
-			}
+			h.leaveOnDisconnect(joinedRoom, agentName)
 
 			h.logger.Printf("Client disconnected (total: %d)", len(h.clients))
 		}
 	}
 }
 
+// leaveOnDisconnect removes an agent whose connection closed right away, so
+// name re-use and manager lock cleanup do not wait for the stale timeout.
+func (h *Hub) leaveOnDisconnect(joinedRoom, agentName string) {
+	if joinedRoom == "" || agentName == "" {
+		return
+	}
+	roomState, ok := h.lookupRoom(joinedRoom)
+	if !ok {
+		return
+	}
+	if sysMsg, found := roomState.Leave(agentName); found {
+		agents := roomState.GetAgents()
+		h.broadcastEvent(joinedRoom, "message_new", map[string]any{"message": sysMsg})
+		h.broadcastEvent(joinedRoom, "agent_left", map[string]any{"agent_name": agentName, "agents": agents})
+	}
+}
+
 // getOrCreateRoom returns the room state, creating it if it doesn't exist.
+// An archived room is restored. Only joins (and imports) create rooms; other
+// requests use lookupRoom, requireRoom or roomOrEmpty.
 func (h *Hub) getOrCreateRoom(room string) *RoomState {
+	if r, ok := h.lookupRoom(room); ok {
+		return r
+	}
+
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -195,10 +266,31 @@ func (h *Hub) getOrCreateRoom(room string) *RoomState {
 		return r
 	}
 	r := NewRoomState()
+	if pr, ok := h.restoreArchived(room); ok {
+		r.restore(pr)
+	}
 	h.rooms[room] = r
 	return r
 }
 
+// requireRoom returns an existing room or replies that it does not exist.
+func (h *Hub) requireRoom(c *Client, req types.Request, room string) (*RoomState, bool) {
+	r, ok := h.lookupRoom(room)
+	if !ok {
+		c.sendError(req.ID, req.Type, fmt.Sprintf("oda bulunamadı: %s", room))
+	}
+	return r, ok
+}
+
+// roomOrEmpty returns a room for reading. A missing room reads as empty and
+// is not created.
+func (h *Hub) roomOrEmpty(room string) *RoomState {
+	if r, ok := h.lookupRoom(room); ok {
+		return r
+	}
+	return NewRoomState()
+}
+
 // resolveRoom returns the room name, using defaultRoom if empty.
 func (h *Hub) resolveRoom(room string) string {
 	if room == "" {
@@ -233,11 +325,21 @@ func (h *Hub) broadcastEvent(room, eventName string, data map[string]any) {
 		Data:  eventData,
 	}
 
+	// Copy the subscriber set: WebSocket disconnects and event streams
+	// remove themselves from it concurrently.
 	h.mu.RLock()
-	subs := h.subs[room]
+	subs := make([]*Client, 0, len(h.subs[room]))
+	for client := range h.subs[room] {
+		subs = append(subs, client)
+	}
 	h.mu.RUnlock()
 
-	for client := range subs {
+	for _, client := range subs {
 		client.sendJSON(event)
 	}
+
+	if msg, ok := data["message"].(types.Message); ok && eventName == "message_new" {
+		h.connectors.publish(room, msg)
+	}
+	h.hooks.dispatch(room, eventName, eventData)
 }
@@ -0,0 +1,446 @@
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"desktop/internal/types"
+	"desktop/internal/validation"
+)
+
+// DefaultRoomTTL is how long a room may sit idle (no active agent, no
+// subscriber, no new message) before the hub archives it.
+const DefaultRoomTTL = 30 * 24 * time.Hour
+
+const idleCheckInterval = time.Minute
+
+// SetRoomTTL sets how long an idle room stays loaded before it is archived.
+// Zero disables automatic archival.
+func (h *Hub) SetRoomTTL(ttl time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.roomTTL = ttl
+}
+
+// roomMove records a rename (to is the new name) or a delete (to is empty).
+// The epoch orders moves, so a client only follows the ones made after it
+// last synced and a name reused after a move is not confused with the old room.
+type roomMove struct {
+	to    string
+	epoch int
+}
+
+// followRoomLocked follows the moves made to a room after epoch since and
+// returns its current name, or false if it was deleted. The first move of a
+// name after since is the one that moved the room known by that name then;
+// later ones belong to rooms that reused the name. Callers must hold mu.
+func (h *Hub) followRoomLocked(room string, since int) (string, bool) {
+	for {
+		var next *roomMove
+		for i, m := range h.moves[room] {
+			if m.epoch > since {
+				next = &h.moves[room][i]
+				break
+			}
+		}
+		if next == nil {
+			return room, true
+		}
+		if next.to == "" {
+			return "", false
+		}
+		room, since = next.to, next.epoch
+	}
+}
+
+// recordMoveLocked records a rename or delete of room and prunes the moves
+// every client has followed. Callers must hold mu for writing.
+func (h *Hub) recordMoveLocked(room, to string) {
+	h.roomEpoch++
+	h.moves[room] = append(h.moves[room], roomMove{to: to, epoch: h.roomEpoch})
+	h.pruneMovesLocked()
+}
+
+// followLocked starts tracking a client's room epoch. A new client has no
+// rooms yet, so it starts out synced. Callers must hold mu for writing.
+func (h *Hub) followLocked(c *Client) {
+	c.roomEpoch = h.roomEpoch
+	h.followers[c] = true
+}
+
+// unfollowLocked stops tracking a client that synced for the last time.
+// Callers must hold mu for writing.
+func (h *Hub) unfollowLocked(c *Client) {
+	delete(h.followers, c)
+	h.pruneMovesLocked()
+}
+
+// pruneMovesLocked drops the moves every tracked client has synced past.
+// The latest rename of a name that is not in use again is kept, so agents
+// still using the old name reach the room. Callers must hold mu for writing.
+func (h *Hub) pruneMovesLocked() {
+	synced := h.roomEpoch
+	for c := range h.followers {
+		synced = min(synced, c.roomEpoch)
+	}
+	for name, history := range h.moves {
+		i := 0
+		for i < len(history) && history[i].epoch <= synced {
+			i++
+		}
+		kept := history[i:]
+		if _, live := h.rooms[name]; len(kept) == 0 && !live && history[len(history)-1].to != "" {
+			kept = history[len(history)-1:]
+		}
+		if len(kept) == 0 {
+			delete(h.moves, name)
+		} else if len(kept) < len(history) {
+			h.moves[name] = append([]roomMove(nil), kept...)
+		}
+	}
+}
+
+// renamedRoom maps a room name an agent still uses after rename_room to the
+// room's new name. The desktop app always uses current names.
+func (h *Hub) renamedRoom(room string) string {
+	name := h.resolveRoom(room)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if _, exists := h.rooms[name]; exists {
+		return room
+	}
+	history := h.moves[name]
+	if len(history) == 0 {
+		return room
+	}
+	// The name refers to the room that last had it.
+	if to, ok := h.followRoomLocked(name, history[len(history)-1].epoch-1); ok && to != name {
+		return to
+	}
+	return room
+}
+
+// syncClientRooms brings a client's subscriptions and joined room up to date
+// with the renames and deletes made since its last request. Rename and delete
+// never touch other clients' fields; each client catches up here on its own
+// request goroutine.
+func (h *Hub) syncClientRooms(c *Client) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	h.syncClientLocked(c)
+}
+
+// syncClientLocked is syncClientRooms for callers holding mu. It must only
+// run on the client's own request goroutine, or after it has exited.
+func (h *Hub) syncClientLocked(c *Client) {
+	if c.roomEpoch == h.roomEpoch {
+		return
+	}
+	if c.joinedRoom != "" {
+		if room, ok := h.followRoomLocked(c.joinedRoom, c.roomEpoch); ok {
+			c.joinedRoom = room
+		} else {
+			c.joinedRoom = ""
+			c.agentName = ""
+		}
+	}
+	rooms := make(map[string]bool, len(c.rooms))
+	for room := range c.rooms {
+		if to, ok := h.followRoomLocked(room, c.roomEpoch); ok {
+			rooms[to] = true
+		}
+	}
+	c.rooms = rooms
+	c.roomEpoch = h.roomEpoch
+}
+
+// restoreArchived moves an archived room back to the live state directory
+// and returns its state. Must be called with stateMu and mu held.
+func (h *Hub) restoreArchived(room string) (PersistedRoom, bool) {
+	path := h.archiveFile(room)
+	if path == "" {
+		return PersistedRoom{}, false
+	}
+	pr, err := readRoomFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			h.logger.Printf("Failed to read archived room %s: %v", room, err)
+		}
+		return PersistedRoom{}, false
+	}
+	if err := writeRoomFile(h.roomFile(room), pr); err != nil {
+		h.logger.Printf("Failed to restore archived room %s: %v", room, err)
+		return PersistedRoom{}, false
+	}
+	os.Remove(path)
+	h.logger.Printf("Restored archived room %s: %d messages", room, len(pr.Messages))
+	return pr, true
+}
+
+func (h *Hub) isArchived(room string) bool {
+	path := h.archiveFile(room)
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// archivedRooms lists archived rooms by name.
+func (h *Hub) archivedRooms() []RoomInfo {
+	if h.dataDir == "" {
+		return nil
+	}
+	dir := filepath.Join(h.dataDir, "hub-state", "archive")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var infos []RoomInfo
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), ".json")
+		pr, err := readRoomFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		infos = append(infos, RoomInfo{Name: name, Messages: len(pr.Messages)})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// archiveRoom unloads a room and moves its state to the archive; joining the
+// room again restores it. Rooms with an active agent are not archived. With
+// a non-zero idleBefore (automatic archival) the room must also have no
+// subscribers and no activity since then.
+func (h *Hub) archiveRoom(room string, idleBefore time.Time) error {
+	if h.dataDir == "" {
+		return fmt.Errorf("arşivleme için hub veri dizini gerekli")
+	}
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
+
+	h.mu.Lock()
+	r, ok := h.rooms[room]
+	if !ok {
+		h.mu.Unlock()
+		return fmt.Errorf("oda bulunamadı: %s", room)
+	}
+	since, busy := r.IdleSince()
+	if busy {
+		h.mu.Unlock()
+		return fmt.Errorf("'%s' odasında aktif agent var; arşivlenemez", room)
+	}
+	if !idleBefore.IsZero() && (len(h.subs[room]) > 0 || since.After(idleBefore)) {
+		h.mu.Unlock()
+		return fmt.Errorf("'%s' odası boşta değil", room)
+	}
+	delete(h.rooms, room)
+	h.mu.Unlock()
+
+	if err := writeRoomFile(h.archiveFile(room), r.Snapshot()); err != nil {
+		h.mu.Lock()
+		h.rooms[room] = r
+		h.mu.Unlock()
+		return fmt.Errorf("oda arşivlenemedi: %w", err)
+	}
+	os.Remove(h.roomFile(room))
+	return nil
+}
+
+// archiveIdleRooms archives every room idle for longer than the room TTL.
+// Rooms someone is subscribed to (the desktop app subscribes to its team
+// rooms) are left alone.
+func (h *Hub) archiveIdleRooms(now time.Time) {
+	h.mu.RLock()
+	ttl := h.roomTTL
+	cutoff := now.Add(-ttl)
+	var idle []string
+	if ttl > 0 {
+		for name, r := range h.rooms {
+			if len(h.subs[name]) > 0 {
+				continue
+			}
+			if since, busy := r.IdleSince(); !busy && !since.After(cutoff) {
+				idle = append(idle, name)
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	sort.Strings(idle)
+	for _, name := range idle {
+		if err := h.archiveRoom(name, cutoff); err != nil {
+			continue
+		}
+		h.logger.Printf("Archived room %s: idle for more than %s", name, ttl)
+	}
+}
+
+// deleteRoom removes a room, live or archived, with its persisted state,
+// manager setting, scheduled messages, hooks and connectors. Agents joined
+// to it have to join a room again.
+func (h *Hub) deleteRoom(room string) error {
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
+
+	_, live := h.lookupRoom(room)
+	if !live && !h.isArchived(room) {
+		return fmt.Errorf("oda bulunamadı: %s", room)
+	}
+	// Subscribers and hooks learn about it before they are dropped.
+	h.broadcastEvent(room, "room_deleted", map[string]any{})
+
+	// Clients still using the name drop it on their next request.
+	h.mu.Lock()
+	delete(h.subs, room)
+	delete(h.rooms, room)
+	delete(h.roomManager, room)
+	h.recordMoveLocked(room, "")
+	h.mu.Unlock()
+
+	if path := h.roomFile(room); path != "" {
+		os.Remove(path)
+		os.Remove(h.archiveFile(room))
+	}
+	h.scheduler.deleteRoom(room)
+	h.hooks.deleteRoom(room)
+	h.connectors.deleteRoom(room)
+	return nil
+}
+
+// renameRoom moves a live room, with its subscribers, joined agents,
+// manager setting, persisted state, scheduled messages, hooks and
+// connectors, to a new name. Agents still using the old name reach the
+// room until the hub restarts.
+func (h *Hub) renameRoom(from, to string) (*RoomState, error) {
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
+
+	h.mu.Lock()
+	r, ok := h.rooms[from]
+	if !ok {
+		h.mu.Unlock()
+		return nil, fmt.Errorf("oda bulunamadı: %s", from)
+	}
+	if _, taken := h.rooms[to]; taken || h.isArchived(to) {
+		h.mu.Unlock()
+		return nil, fmt.Errorf("'%s' adında bir oda zaten var", to)
+	}
+	delete(h.rooms, from)
+	h.rooms[to] = r
+	if subs := h.subs[from]; subs != nil {
+		delete(h.subs, from)
+		if h.subs[to] == nil {
+			h.subs[to] = make(map[*Client]bool)
+		}
+		for c := range subs {
+			h.subs[to][c] = true
+		}
+	}
+	if manager, ok := h.roomManager[from]; ok {
+		delete(h.roomManager, from)
+		h.roomManager[to] = manager
+	}
+	// Subscribers and joined agents follow the move on their next request.
+	h.recordMoveLocked(from, to)
+	h.mu.Unlock()
+
+	if err := writeRoomFile(h.roomFile(to), r.Snapshot()); err != nil {
+		h.logger.Printf("Failed to persist renamed room %s: %v", to, err)
+	} else if path := h.roomFile(from); path != "" {
+		os.Remove(path)
+		r.MarkClean()
+	}
+	h.scheduler.renameRoom(from, to)
+	h.hooks.renameRoom(from, to)
+	h.connectors.renameRoom(from, to)
+	return r, nil
+}
+
+// handleDeleteRoom deletes a room for good.
+func (h *Hub) handleDeleteRoom(c *Client, req types.Request) {
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "odayı yalnızca yetkili desktop istemcisi silebilir")
+		return
+	}
+	room := h.resolveRoom(req.Room)
+	if err := h.deleteRoom(room); err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+	h.logger.Printf("delete_room: room=%s", room)
+
+	text := fmt.Sprintf("\U0001f5d1\ufe0f '%s' odası silindi.", room)
+	respData, _ := json.Marshal(map[string]string{"text": text})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+}
+
+// handleRenameRoom gives a room a new name.
+func (h *Hub) handleRenameRoom(c *Client, req types.Request) {
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "odayı yalnızca yetkili desktop istemcisi yeniden adlandırabilir")
+		return
+	}
+	var data struct {
+		NewName string `json:"new_name"`
+	}
+	json.Unmarshal(req.Data, &data)
+
+	room := h.resolveRoom(req.Room)
+	newName := strings.TrimSpace(data.NewName)
+	if newName == "" {
+		c.sendError(req.ID, req.Type, "new_name gerekli")
+		return
+	}
+	if err := validation.ValidateName(newName); err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+	if newName == room {
+		c.sendError(req.ID, req.Type, "yeni ad mevcut adla aynı")
+		return
+	}
+	roomState, err := h.renameRoom(room, newName)
+	if err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+	h.logger.Printf("rename_room: room=%s new_name=%s", room, newName)
+
+	sysMsg := roomState.PostSystem(fmt.Sprintf("\u270f\ufe0f Oda '%s' iken '%s' olarak yeniden adlandırıldı.", room, newName))
+	text := fmt.Sprintf("\u270f\ufe0f '%s' odası '%s' olarak yeniden adlandırıldı.", room, newName)
+	respData, _ := json.Marshal(map[string]string{"text": text, "room": newName})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+
+	h.broadcastEvent(newName, "room_renamed", map[string]any{"old_name": room, "new_name": newName})
+	h.broadcastEvent(newName, "message_new", map[string]any{"message": sysMsg})
+}
+
+// handleArchiveRoom archives an idle room; it is restored when joined again.
+func (h *Hub) handleArchiveRoom(c *Client, req types.Request) {
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "odayı yalnızca yetkili desktop istemcisi arşivleyebilir")
+		return
+	}
+	room := h.resolveRoom(req.Room)
+	if err := h.archiveRoom(room, time.Time{}); err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+	h.logger.Printf("archive_room: room=%s", room)
+
+	text := fmt.Sprintf("\U0001f5c4\ufe0f '%s' odası arşivlendi. Bir agent katıldığında geri yüklenir.", room)
+	respData, _ := json.Marshal(map[string]string{"text": text})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+
+	h.broadcastEvent(room, "room_archived", map[string]any{})
+}
@@ -0,0 +1,350 @@
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"desktop/internal/types"
+)
+
+func newLifecycleHub(t *testing.T) (*Hub, *LocalClient) {
+	t.Helper()
+	h := New(t.TempDir(), "default", log.New(io.Discard, "", 0))
+	return h, h.NewLocalClient(true)
+}
+
+func joinAgent(t *testing.T, h *Hub, room, name string) *LocalClient {
+	t.Helper()
+	agent := h.NewLocalClient(false)
+	if resp := agent.Do(types.Request{ID: "join", Type: "join_room", Room: room, Data: mustRawJSON(t, map[string]any{"agent_name": name})}); !resp.Success {
+		t.Fatalf("join failed: %s", resp.Error)
+	}
+	return agent
+}
+
+func responseText(resp types.Response) string {
+	var data struct {
+		Text string `json:"text"`
+	}
+	json.Unmarshal(resp.Data, &data)
+	return data.Text
+}
+
+func TestReadRequestsDoNotCreateRooms(t *testing.T) {
+	h, desktop := newLifecycleHub(t)
+	for _, typ := range []string{"list_agents", "get_agents", "get_messages_raw", "get_last_message_id", "list_groups"} {
+		if resp := desktop.Do(types.Request{ID: typ, Type: typ, Room: "tpyo"}); !resp.Success {
+			t.Errorf("%s on a missing room failed: %s", typ, resp.Error)
+		}
+	}
+	if resp := desktop.Do(types.Request{ID: "c", Type: "clear_room", Room: "tpyo"}); resp.Success {
+		t.Error("clear_room on a missing room should fail")
+	}
+	if _, ok := h.lookupRoom("tpyo"); ok {
+		t.Fatal("read requests must not create a room")
+	}
+	if resp := desktop.Do(types.Request{ID: "j", Type: "join_room", Room: "../x", Data: mustRawJSON(t, map[string]any{"agent_name": "dev"})}); resp.Success {
+		t.Error("join_room must reject an invalid room name")
+	}
+}
+
+func TestRenameRoomMovesStateAndKeepsOldNameForAgents(t *testing.T) {
+	h, desktop := newLifecycleHub(t)
+	h.setConfiguredManager("old", "lead")
+	agent := joinAgent(t, h, "old", "dev")
+	if _, err := h.hooks.add(Hook{Room: "old", Event: "*", Command: "true"}); err != nil {
+		t.Fatal(err)
+	}
+	h.persistAll()
+
+	rename := func(c *LocalClient, room, newName string) types.Response {
+		return c.Do(types.Request{ID: "r", Type: "rename_room", Room: room, Data: mustRawJSON(t, map[string]any{"new_name": newName})})
+	}
+	if resp := rename(agent, "old", "new"); resp.Success {
+		t.Fatal("an agent client must not rename rooms")
+	}
+	joinAgent(t, h, "taken", "qa")
+	if resp := rename(desktop, "old", "taken"); resp.Success {
+		t.Fatal("renaming onto an existing room should fail")
+	}
+	if resp := rename(desktop, "old", "new"); !resp.Success {
+		t.Fatalf("rename failed: %s", resp.Error)
+	}
+
+	if _, ok := h.lookupRoom("old"); ok {
+		t.Error("old room still loaded")
+	}
+	if h.getConfiguredManager("new") != "lead" || h.getConfiguredManager("old") != "" {
+		t.Error("manager setting should follow the room")
+	}
+	if len(h.hooks.list("new")) != 1 || len(h.hooks.list("old")) != 0 {
+		t.Error("hooks should follow the room")
+	}
+	stateDir := filepath.Join(h.dataDir, "hub-state")
+	if _, err := os.Stat(filepath.Join(stateDir, "new.json")); err != nil {
+		t.Errorf("renamed room not persisted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, "old.json")); !os.IsNotExist(err) {
+		t.Errorf("old state file still there: %v", err)
+	}
+
+	// The agent's MCP server still sends with the old room name.
+	resp := agent.Do(types.Request{ID: "s", Type: "send_message", Room: "old", Data: mustRawJSON(t, map[string]any{"from": "dev", "content": "still here"})})
+	if !resp.Success {
+		t.Fatalf("send under the old name failed: %s", resp.Error)
+	}
+	msgs := h.roomOrEmpty("new").GetMessages()
+	if last := msgs[len(msgs)-1]; last.Content != "still here" {
+		t.Errorf("last message = %+v", last)
+	}
+	if _, ok := h.lookupRoom("old"); ok {
+		t.Error("sending under the old name must not recreate it")
+	}
+}
+
+func TestRoomMovesSurviveNameReuseAndArePruned(t *testing.T) {
+	h, desktop := newLifecycleHub(t)
+	stale := h.NewLocalClient(true)
+	stale.Do(types.Request{ID: "sub", Type: "subscribe", Data: mustRawJSON(t, map[string]any{"rooms": []string{"a"}})})
+	agent := joinAgent(t, h, "a", "dev")
+
+	// "a" is renamed, reused and renamed again before the stale client syncs.
+	if _, err := h.renameRoom("a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	h.getOrCreateRoom("a")
+	if _, err := h.renameRoom("a", "c"); err != nil {
+		t.Fatal(err)
+	}
+	stale.Do(types.Request{ID: "ping", Type: "list_rooms"})
+	if !stale.c.rooms["b"] || stale.c.rooms["c"] {
+		t.Fatalf("stale client follows to %v, want b", stale.c.rooms)
+	}
+	if got := h.renamedRoom("a"); got != "c" {
+		t.Errorf("renamedRoom(a) = %q, want the room that last had the name", got)
+	}
+
+	// Once every client synced past them, only the latest rename of each
+	// name is kept, and deletes go away.
+	if err := h.deleteRoom("b"); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []*LocalClient{desktop, stale, agent} {
+		c.Do(types.Request{ID: "ping", Type: "list_rooms"})
+	}
+	stale.Close()
+	h.mu.RLock()
+	moves := fmt.Sprint(h.moves)
+	h.mu.RUnlock()
+	if moves != "map[a:[{c 2}]]" {
+		t.Errorf("moves after pruning = %s", moves)
+	}
+}
+
+func TestRenameRoomWhileAgentsSend(t *testing.T) {
+	h, desktop := newLifecycleHub(t)
+	h.SetRateLimits(RateLimits{})
+	agent := joinAgent(t, h, "r0", "dev")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			agent.Do(types.Request{ID: "i", Type: "identify", Data: mustRawJSON(t, map[string]any{"room": "r0"})})
+			agent.Do(types.Request{ID: "s", Type: "send_message", Room: "r0", Data: mustRawJSON(t, map[string]any{"from": "dev", "content": "hi"})})
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		from, to := fmt.Sprintf("r%d", i), fmt.Sprintf("r%d", i+1)
+		if resp := desktop.Do(types.Request{ID: "r", Type: "rename_room", Room: from, Data: mustRawJSON(t, map[string]any{"new_name": to})}); !resp.Success {
+			t.Fatalf("rename %s: %s", from, resp.Error)
+		}
+	}
+	<-done
+
+	resp := agent.Do(types.Request{ID: "s", Type: "send_message", Room: "r0", Data: mustRawJSON(t, map[string]any{"from": "dev", "content": "last"})})
+	if !resp.Success {
+		t.Fatalf("send after renames failed: %s", resp.Error)
+	}
+	msgs := h.roomOrEmpty("r10").GetMessages()
+	if last := msgs[len(msgs)-1]; last.Content != "last" {
+		t.Errorf("last message = %+v", last)
+	}
+	if agent.c.joinedRoom != "r10" {
+		t.Errorf("joined room = %q, want r10", agent.c.joinedRoom)
+	}
+
+	// A room deleted and created again is a new room: an agent that joins
+	// the new one stays joined.
+	if resp := desktop.Do(types.Request{ID: "d", Type: "delete_room", Room: "r10"}); !resp.Success {
+		t.Fatal(resp.Error)
+	}
+	again := joinAgent(t, h, "r10", "qa")
+	if resp := again.Do(types.Request{ID: "s", Type: "send_message", Room: "r10", Data: mustRawJSON(t, map[string]any{"from": "qa", "content": "hi"})}); !resp.Success {
+		t.Errorf("agent of the recreated room could not send: %s", resp.Error)
+	}
+	if resp := agent.Do(types.Request{ID: "s", Type: "send_message", Room: "r10", Data: mustRawJSON(t, map[string]any{"from": "dev", "content": "hi"})}); resp.Success {
+		t.Error("an agent of the deleted room must join again")
+	}
+}
+
+func TestArchiveRoomAndRestoreOnJoin(t *testing.T) {
+	h, desktop := newLifecycleHub(t)
+	agent := joinAgent(t, h, "r1", "dev")
+	agent.Do(types.Request{ID: "s", Type: "send_message", Room: "r1", Data: mustRawJSON(t, map[string]any{"from": "dev", "content": "hello"})})
+
+	archive := func() types.Response {
+		return desktop.Do(types.Request{ID: "a", Type: "archive_room", Room: "r1"})
+	}
+	if resp := archive(); resp.Success {
+		t.Fatal("a room with an active agent should not be archived")
+	}
+	agent.Close()
+	if resp := archive(); !resp.Success {
+		t.Fatalf("archive failed: %s", resp.Error)
+	}
+	if _, ok := h.lookupRoom("r1"); ok {
+		t.Fatal("archived room still loaded")
+	}
+	list := desktop.Do(types.Request{ID: "l", Type: "list_rooms", Data: mustRawJSON(t, map[string]any{"archived": true})})
+	if text := responseText(list); !strings.Contains(text, "r1 - ") {
+		t.Errorf("archived list = %q", text)
+	}
+
+	joinAgent(t, h, "r1", "qa")
+	found := false
+	for _, m := range h.roomOrEmpty("r1").GetMessages() {
+		found = found || m.Content == "hello"
+	}
+	if !found {
+		t.Error("joining should restore the archived messages")
+	}
+	if h.isArchived("r1") {
+		t.Error("archive file should be gone after restore")
+	}
+}
+
+func TestArchiveIdleRooms(t *testing.T) {
+	h, desktop := newLifecycleHub(t)
+	h.SetRoomTTL(time.Hour)
+	joinAgent(t, h, "idle", "dev").Close()
+	joinAgent(t, h, "watched", "dev").Close()
+	desktop.Do(types.Request{ID: "sub", Type: "subscribe", Data: mustRawJSON(t, map[string]any{"rooms": []string{"watched"}})})
+
+	h.archiveIdleRooms(time.Now())
+	if _, ok := h.lookupRoom("idle"); !ok {
+		t.Fatal("a room idle for less than the TTL was archived")
+	}
+	h.archiveIdleRooms(time.Now().Add(2 * time.Hour))
+	if _, ok := h.lookupRoom("idle"); ok || !h.isArchived("idle") {
+		t.Error("idle room should be archived after the TTL")
+	}
+	if _, ok := h.lookupRoom("watched"); !ok {
+		t.Error("a subscribed room must not be archived")
+	}
+}
+
+func TestArchiveIdleRoomsAfterRestart(t *testing.T) {
+	h, _ := newLifecycleHub(t)
+	h.SetRoomTTL(time.Hour)
+	old := time.Now().Add(-2 * time.Hour)
+	stale := PersistedRoom{
+		Messages: []types.Message{{ID: 1, From: "dev", To: "all", Content: "bye", Type: "broadcast", Timestamp: types.FormatTimestamp(old)}},
+		Agents:   map[string]types.Agent{"dev": {Role: "developer", LastSeen: float64(old.Add(-time.Minute).Unix())}},
+	}
+	if err := writeRoomFile(h.roomFile("stale"), stale); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeRoomFile(h.roomFile("empty"), PersistedRoom{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Loading at startup must not reset the idle time of a quiet room.
+	h.loadPersistedState()
+	h.archiveIdleRooms(time.Now())
+	if _, ok := h.lookupRoom("stale"); ok || !h.isArchived("stale") {
+		t.Error("a room whose last activity is older than the TTL should be archived after a restart")
+	}
+	if _, ok := h.lookupRoom("empty"); !ok {
+		t.Error("a room without activity is idle from its load time")
+	}
+}
+
+func TestScheduledMessagesDoNotRestoreArchivedRooms(t *testing.T) {
+	h, _ := newLifecycleHub(t)
+	joinAgent(t, h, "r1", "dev").Close()
+	start := time.Now()
+	recurring, _ := h.scheduler.add(ScheduledMessage{Room: "r1", From: "dev", To: "all", Content: "standup", Schedule: "@every 1h", NextRun: start.Add(time.Minute)})
+	h.scheduler.add(ScheduledMessage{Room: "r1", From: "dev", To: "all", Content: "once", NextRun: start.Add(time.Minute)})
+	if err := h.archiveRoom("r1", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	h.fireDueScheduled(start.Add(2 * time.Minute))
+	if _, ok := h.lookupRoom("r1"); ok || !h.isArchived("r1") {
+		t.Fatal("a due scheduled message brought the archived room back")
+	}
+	pending := h.scheduler.list("r1", "")
+	if len(pending) != 2 {
+		t.Fatalf("scheduled messages of an archived room should be kept, got %d", len(pending))
+	}
+	for _, sm := range pending {
+		if sm.ID == recurring.ID && (sm.RunCount != 0 || !sm.NextRun.After(start.Add(2*time.Minute))) {
+			t.Errorf("recurring message should skip the run: %+v", sm)
+		}
+	}
+
+	// Once the room is back, the held one-shot message is delivered.
+	joinAgent(t, h, "r1", "qa")
+	h.fireDueScheduled(start.Add(3 * time.Minute))
+	msgs := h.roomOrEmpty("r1").GetMessages()
+	if last := msgs[len(msgs)-1]; last.Content != "once" {
+		t.Errorf("last message = %+v", last)
+	}
+}
+
+func TestDeleteRoom(t *testing.T) {
+	h, desktop := newLifecycleHub(t)
+	desktop.Do(types.Request{ID: "sub", Type: "subscribe", Data: mustRawJSON(t, map[string]any{"rooms": []string{"r1"}})})
+	agent := joinAgent(t, h, "r1", "dev")
+	h.scheduler.add(ScheduledMessage{Room: "r1", From: "dev", To: "all", Content: "later", NextRun: time.Now().Add(time.Hour)})
+	desktop.Events()
+
+	if resp := agent.Do(types.Request{ID: "d", Type: "delete_room", Room: "r1"}); resp.Success {
+		t.Fatal("an agent client must not delete rooms")
+	}
+	if resp := desktop.Do(types.Request{ID: "d", Type: "delete_room", Room: "r1"}); !resp.Success {
+		t.Fatalf("delete failed: %s", resp.Error)
+	}
+	if _, ok := h.lookupRoom("r1"); ok {
+		t.Error("deleted room still loaded")
+	}
+	if len(h.scheduler.list("r1", "")) != 0 {
+		t.Error("scheduled messages of a deleted room should be dropped")
+	}
+	if evs := desktop.Events(); len(evs) != 1 || evs[0].Event != "room_deleted" {
+		t.Errorf("events = %+v", evs)
+	}
+	if resp := agent.Do(types.Request{ID: "s", Type: "send_message", Room: "r1", Data: mustRawJSON(t, map[string]any{"from": "dev", "content": "hi"})}); resp.Success {
+		t.Error("an agent of a deleted room must join again before sending")
+	}
+
+	// Archived rooms can be deleted too.
+	joinAgent(t, h, "r2", "qa").Close()
+	if err := h.archiveRoom("r2", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if resp := desktop.Do(types.Request{ID: "d2", Type: "delete_room", Room: "r2"}); !resp.Success || h.isArchived("r2") {
+		t.Errorf("deleting an archived room: %+v", resp)
+	}
+	if resp := desktop.Do(types.Request{ID: "d3", Type: "delete_room", Room: "r2"}); resp.Success {
+		t.Error("deleting a missing room should fail")
+	}
+}
@@ -0,0 +1,98 @@
+package hub
+
+import (
+	"encoding/json"
+
+	"desktop/internal/types"
+)
+
+// LocalClient is an in-process connection to the hub, for running a hub
+// without a listener (replays). It behaves like a WebSocket client: it can
+// identify, join rooms as an agent and subscribe to events. Requests are
+// handled on the caller's goroutine.
+type LocalClient struct {
+	c      *Client
+	events []types.Event
+}
+
+// NewLocalClient returns an in-process client. A desktop client is
+// authorized for desktop-only requests, like an identified desktop app.
+func (h *Hub) NewLocalClient(desktop bool) *LocalClient {
+	c := newClient(h, nil)
+	if desktop {
+		c.clientType = "desktop"
+		c.desktopAuthed = true
+	}
+	h.mu.Lock()
+	h.followLocked(c)
+	h.mu.Unlock()
+	return &LocalClient{c: c}
+}
+
+// Do runs a request and returns its response. Events that arrive meanwhile
+// are kept for Events.
+func (l *LocalClient) Do(req types.Request) types.Response {
+	l.c.hub.handleRequest(l.c, req)
+	resp := types.Response{ID: req.ID, RequestType: req.Type, Error: "yanıt alınamadı"}
+	got := false
+	for _, data := range l.drain() {
+		var frame struct {
+			types.Response
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(data, &frame) != nil {
+			continue
+		}
+		if frame.Type == "event" {
+			var ev types.Event
+			json.Unmarshal(data, &ev)
+			l.events = append(l.events, ev)
+			continue
+		}
+		if !got && frame.ID == req.ID {
+			resp, got = frame.Response, true
+		}
+	}
+	return resp
+}
+
+// Events returns the events of subscribed rooms received since the last call.
+func (l *LocalClient) Events() []types.Event {
+	for _, data := range l.drain() {
+		var ev types.Event
+		if json.Unmarshal(data, &ev) == nil && ev.Type == "event" {
+			l.events = append(l.events, ev)
+		}
+	}
+	events := l.events
+	l.events = nil
+	return events
+}
+
+// Close disconnects the client: it is unsubscribed from every room and, if
+// it joined as an agent, leaves the room.
+func (l *LocalClient) Close() {
+	h := l.c.hub
+	h.mu.Lock()
+	h.syncClientLocked(l.c)
+	for room := range l.c.rooms {
+		delete(h.subs[room], l.c)
+	}
+	joinedRoom, agentName := l.c.joinedRoom, l.c.agentName
+	h.unfollowLocked(l.c)
+	h.mu.Unlock()
+	l.drain()
+	h.leaveOnDisconnect(joinedRoom, agentName)
+}
+
+func (l *LocalClient) drain() [][]byte {
+	var out [][]byte
+	for {
+		select {
+		case data := <-l.c.send:
+			out = append(out, data)
+		default:
+			return out
+		}
+	}
+}
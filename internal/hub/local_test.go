@@ -0,0 +1,45 @@
+package hub
+
+import (
+	"io"
+	"log"
+	"testing"
+
+	"desktop/internal/types"
+)
+
+func TestLocalClient_RequestsAndEvents(t *testing.T) {
+	h := New("", "default", log.New(io.Discard, "", 0))
+	desktop := h.NewLocalClient(true)
+	if resp := desktop.Do(types.Request{ID: "1", Type: "subscribe", Data: mustRawJSON(t, map[string]any{"rooms": []string{"r1"}})}); !resp.Success {
+		t.Fatalf("subscribe failed: %s", resp.Error)
+	}
+
+	agent := h.NewLocalClient(false)
+	if resp := agent.Do(types.Request{ID: "2", Type: "join_room", Room: "r1", Data: mustRawJSON(t, map[string]any{"agent_name": "dev"})}); !resp.Success {
+		t.Fatalf("join failed: %s", resp.Error)
+	}
+	resp := agent.Do(types.Request{ID: "3", Type: "send_message", Room: "r1", Data: mustRawJSON(t, map[string]any{"from": "dev", "content": "hi"})})
+	if !resp.Success || resp.RequestType != "send_message" {
+		t.Fatalf("send = %+v", resp)
+	}
+	if resp := agent.Do(types.Request{ID: "4", Type: "set_manager", Room: "r1"}); resp.Success {
+		t.Error("an agent client must not pass desktop-only requests")
+	}
+
+	var names []string
+	for _, ev := range desktop.Events() {
+		names = append(names, ev.Event)
+	}
+	if len(names) != 3 || names[0] != "message_new" || names[1] != "agent_joined" || names[2] != "message_new" {
+		t.Errorf("desktop events = %v", names)
+	}
+	if len(desktop.Events()) != 0 {
+		t.Error("Events should only return new events")
+	}
+
+	agent.Close()
+	if _, ok := h.getOrCreateRoom("r1").GetAgents()["dev"]; ok {
+		t.Error("closing an agent client should leave the room")
+	}
+}
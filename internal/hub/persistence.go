@@ -37,14 +37,7 @@ func (h *Hub) loadPersistedState() {
 		}
 
 		room := NewRoomState()
-		room.mu.Lock()
-		if pr.Messages != nil {
-			room.messages = pr.Messages
-		}
-		if pr.Agents != nil {
-			room.agents = pr.Agents
-		}
-		room.mu.Unlock()
+		room.restore(pr)
 
 		h.mu.Lock()
 		h.rooms[roomName] = room
@@ -60,12 +53,17 @@ func (h *Hub) persistLoop() {
 	ticker := time.NewTicker(persistInterval)
 	defer ticker.Stop()
 
+	idleTicker := time.NewTicker(idleCheckInterval)
+	defer idleTicker.Stop()
+
 	for {
 		select {
 		case <-h.done:
 			return
 		case <-ticker.C:
 			h.persistDirtyRooms()
+		case now := <-idleTicker.C:
+			h.archiveIdleRooms(now)
 		}
 	}
 }
@@ -94,38 +92,77 @@ func (h *Hub) persistDirtyRooms() {
 // persistAll writes all rooms to disk (called on shutdown).
 func (h *Hub) persistAll() {
 	h.mu.RLock()
-	defer h.mu.RUnlock()
-
+	rooms := make(map[string]*RoomState, len(h.rooms))
 	for name, room := range h.rooms {
+		rooms[name] = room
+	}
+	h.mu.RUnlock()
+
+	for name, room := range rooms {
 		h.persistRoom(name, room)
 	}
 }
 
 func (h *Hub) persistRoom(name string, room *RoomState) {
-	stateDir := filepath.Join(h.dataDir, "hub-state")
-	os.MkdirAll(stateDir, 0700)
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
 
-	snapshot := room.Snapshot()
-	data, err := json.MarshalIndent(snapshot, "", "  ")
-	if err != nil {
-		h.logger.Printf("Failed to marshal room %s: %v", name, err)
+	// A room deleted, renamed or archived since it was picked must not be
+	// written back under its old name.
+	if current, ok := h.lookupRoom(name); !ok || current != room {
+		return
+	}
+	if err := writeRoomFile(h.roomFile(name), room.Snapshot()); err != nil {
+		h.logger.Printf("Failed to persist room %s: %v", name, err)
 		return
 	}
 
-	// Atomic write: temp file + rename
-	tmpPath := filepath.Join(stateDir, name+".json.tmp")
-	finalPath := filepath.Join(stateDir, name+".json")
+	room.MarkClean()
+}
 
-	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
-		h.logger.Printf("Failed to write temp file for room %s: %v", name, err)
-		return
+// roomFile is where a loaded room is persisted; empty without a data dir.
+func (h *Hub) roomFile(room string) string {
+	if h.dataDir == "" {
+		return ""
 	}
+	return filepath.Join(h.dataDir, "hub-state", room+".json")
+}
+
+// archiveFile is where an archived room is kept until it is joined again.
+func (h *Hub) archiveFile(room string) string {
+	if h.dataDir == "" {
+		return ""
+	}
+	return filepath.Join(h.dataDir, "hub-state", "archive", room+".json")
+}
 
-	if err := os.Rename(tmpPath, finalPath); err != nil {
-		h.logger.Printf("Failed to rename temp file for room %s: %v", name, err)
+// writeRoomFile writes a room snapshot atomically: temp file + rename.
+func writeRoomFile(path string, snapshot PersistedRoom) error {
+	if path == "" {
+		return nil
+	}
+	os.MkdirAll(filepath.Dir(path), 0700)
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
 		os.Remove(tmpPath)
-		return
+		return err
 	}
+	return nil
+}
 
-	room.MarkClean()
+func readRoomFile(path string) (PersistedRoom, error) {
+	var pr PersistedRoom
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pr, err
+	}
+	err = json.Unmarshal(data, &pr)
+	return pr, err
 }
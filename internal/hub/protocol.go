@@ -4,7 +4,9 @@ import (
 	"crypto/subtle"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"desktop/internal/types"
 	"desktop/internal/validation"
@@ -12,6 +14,10 @@ import (
 
 // handleRequest dispatches a request to the appropriate room operation.
 func (h *Hub) handleRequest(c *Client, req types.Request) {
+	h.syncClientRooms(c)
+	if !c.isDesktopAuthorized() {
+		req.Room = h.renamedRoom(req.Room)
+	}
 	switch req.Type {
 	case "identify":
 		h.handleIdentify(c, req)
@@ -41,6 +47,52 @@ func (h *Hub) handleRequest(c *Client, req types.Request) {
 		h.handleGetAgents(c, req)
 	case "get_messages_raw":
 		h.handleGetMessagesRaw(c, req)
+	case "edit_message":
+		h.handleEditMessage(c, req)
+	case "retract_message":
+		h.handleRetractMessage(c, req)
+	case "find_agents":
+		h.handleFindAgents(c, req)
+	case "set_status":
+		h.handleSetStatus(c, req)
+	case "report_activity":
+		h.handleReportActivity(c, req)
+	case "post_system":
+		h.handlePostSystem(c, req)
+	case "get_rate_stats":
+		h.handleGetRateStats(c, req)
+	case "set_group":
+		h.handleSetGroup(c, req)
+	case "list_groups":
+		h.handleListGroups(c, req)
+	case "list_scheduled":
+		h.handleListScheduled(c, req)
+	case "cancel_scheduled":
+		h.handleCancelScheduled(c, req)
+	case "add_hook":
+		h.handleAddHook(c, req)
+	case "remove_hook":
+		h.handleRemoveHook(c, req)
+	case "list_hooks":
+		h.handleListHooks(c, req)
+	case "hook_log":
+		h.handleHookLog(c, req)
+	case "add_connector":
+		h.handleAddConnector(c, req)
+	case "remove_connector":
+		h.handleRemoveConnector(c, req)
+	case "list_connectors":
+		h.handleListConnectors(c, req)
+	case "export_room":
+		h.handleExportRoom(c, req)
+	case "import_room":
+		h.handleImportRoom(c, req)
+	case "delete_room":
+		h.handleDeleteRoom(c, req)
+	case "rename_room":
+		h.handleRenameRoom(c, req)
+	case "archive_room":
+		h.handleArchiveRoom(c, req)
 	default:
 		c.sendError(req.ID, req.Type, fmt.Sprintf("unknown request type: %s", req.Type))
 	}
@@ -106,237 +158,1315 @@ func (h *Hub) validateDesktopToken(token string) bool {
 	if len(t) != len(hubToken) {
 		return false
 	}
-	return subtle.ConstantTimeCompare([]byte(t), []byte(hubToken)) == 1
-}
+	return subtle.ConstantTimeCompare([]byte(t), []byte(hubToken)) == 1
+}
+
+func (c *Client) isDesktopAuthorized() bool {
+	return c.clientType == "desktop" && c.desktopAuthed
+}
+
+func (h *Hub) handleSetManager(c *Client, req types.Request) {
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "yalnızca yetkili desktop istemcisi manager atayabilir")
+		return
+	}
+
+	var data struct {
+		ManagerAgent string `json:"manager_agent"`
+	}
+	if err := json.Unmarshal(req.Data, &data); err != nil {
+		c.sendError(req.ID, req.Type, "invalid set_manager payload")
+		return
+	}
+	managerAgent := strings.TrimSpace(data.ManagerAgent)
+	if managerAgent != "" {
+		if err := validation.ValidateName(managerAgent); err != nil {
+			c.sendError(req.ID, req.Type, err.Error())
+			return
+		}
+	}
+
+	room := h.resolveRoom(req.Room)
+	h.setConfiguredManager(room, managerAgent)
+
+	// The setting is kept for a room that does not exist yet; the room is
+	// created when the team's agents join.
+	if roomState, ok := h.lookupRoom(room); ok {
+		roomState.ResetManagerLockIfDifferent(managerAgent)
+	}
+
+	var text string
+	if managerAgent == "" {
+		text = fmt.Sprintf("'%s' odası için manager ataması temizlendi.", room)
+	} else {
+		text = fmt.Sprintf("'%s' odası manager'ı '%s' olarak ayarlandı.", room, managerAgent)
+	}
+	respData, _ := json.Marshal(map[string]string{"text": text})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+}
+
+func (h *Hub) handleSubscribe(c *Client, req types.Request) {
+	var data struct {
+		Rooms []string `json:"rooms"`
+	}
+	json.Unmarshal(req.Data, &data)
+
+	h.mu.Lock()
+	h.syncClientLocked(c)
+	for _, room := range data.Rooms {
+		c.rooms[room] = true
+		if h.subs[room] == nil {
+			h.subs[room] = make(map[*Client]bool)
+		}
+		h.subs[room][c] = true
+	}
+	h.mu.Unlock()
+
+	h.logger.Printf("Client subscribed to rooms: %v", data.Rooms)
+
+	resp := types.Response{ID: req.ID, RequestType: req.Type, Success: true}
+	resp.Data, _ = json.Marshal(map[string]bool{"ok": true})
+	c.sendJSON(resp)
+}
+
+func (h *Hub) handleJoinRoom(c *Client, req types.Request) {
+	var data struct {
+		AgentName    string              `json:"agent_name"`
+		Role         string              `json:"role"`
+		Capabilities *types.Capabilities `json:"capabilities"`
+		Human        bool                `json:"human"`
+	}
+	json.Unmarshal(req.Data, &data)
+
+	room := h.resolveRoom(req.Room)
+
+	// Joining is the only request that creates a room.
+	if err := validation.ValidateName(room); err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+	if err := validation.ValidateName(data.AgentName); err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+	if data.Human {
+		h.joinHuman(c, req, room, data.AgentName)
+		return
+	}
+	if c.agentName != "" && c.agentName != data.AgentName {
+		c.sendError(req.ID, req.Type, fmt.Sprintf("bu bağlantı '%s' olarak join oldu; farklı adla join olamaz", c.agentName))
+		return
+	}
+	if c.joinedRoom != "" && c.joinedRoom != room {
+		c.sendError(req.ID, req.Type, fmt.Sprintf("zaten '%s' odasına katıldınız; farklı odaya join olamaz", c.joinedRoom))
+		return
+	}
+	if len(data.Role) > maxFieldLength {
+		c.sendError(req.ID, req.Type, fmt.Sprintf("role too long: %d chars, max %d", len(data.Role), maxFieldLength))
+		return
+	}
+	if data.Capabilities != nil {
+		for _, v := range data.Capabilities.All() {
+			if err := validation.ValidateName(v); err != nil {
+				c.sendError(req.ID, req.Type, err.Error())
+				return
+			}
+		}
+	}
+	role := strings.ToLower(strings.TrimSpace(data.Role))
+	if role == "manager" {
+		configuredManager := h.getConfiguredManager(room)
+		if configuredManager == "" {
+			c.sendError(req.ID, req.Type, "manager rolü atanmadı; önce desktop üzerinden manager belirlenmeli")
+			return
+		}
+		if data.AgentName != configuredManager {
+			c.sendError(req.ID, req.Type, fmt.Sprintf("manager rolü yalnızca '%s' agent'ına atanabilir", configuredManager))
+			return
+		}
+	}
+
+	h.logger.Printf("join_room: agent=%q role=%q room=%q", data.AgentName, data.Role, room)
+
+	roomState := h.getOrCreateRoom(room)
+	sysMsg, agents, err := roomState.JoinWithCapabilities(data.AgentName, data.Role, data.Capabilities)
+	if err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+
+	// Also subscribe the client to this room
+	h.mu.Lock()
+	h.syncClientLocked(c)
+	c.rooms[room] = true
+	c.agentName = data.AgentName
+	c.joinedRoom = room
+	if h.subs[room] == nil {
+		h.subs[room] = make(map[*Client]bool)
+	}
+	h.subs[room][c] = true
+	h.mu.Unlock()
+
+	// Build response text
+	var otherAgents []string
+	for name := range agents {
+		if name != data.AgentName {
+			otherAgents = append(otherAgents, name)
+		}
+	}
+
+	var text string
+	if len(otherAgents) > 0 {
+		text = fmt.Sprintf("\u2705 '%s' olarak '%s' odasına katıldın. Odadaki diğer agent'lar: %s", data.AgentName, room, strings.Join(otherAgents, ", "))
+	} else {
+		text = fmt.Sprintf("\u2705 '%s' olarak '%s' odasına katıldın. Şu an odada başka agent yok.", data.AgentName, room)
+	}
+
+	respData, _ := json.Marshal(map[string]any{"text": text, "agents": agents})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+
+	// Broadcast events
+	h.broadcastEvent(room, "message_new", map[string]any{"message": sysMsg})
+	h.broadcastEvent(room, "agent_joined", map[string]any{"agent_name": data.AgentName, "agents": agents})
+}
+
+// joinHuman adds the desktop user to a room as a human participant. Unlike
+// join_room for agents the connection is not bound to the room or the name:
+// one desktop client takes part in every team room.
+func (h *Hub) joinHuman(c *Client, req types.Request, room, name string) {
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "insan katılımcıyı yalnızca yetkili desktop istemcisi ekleyebilir")
+		return
+	}
+
+	roomState := h.getOrCreateRoom(room)
+	sysMsg, agents, joined, err := roomState.JoinHuman(name)
+	if err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	h.syncClientLocked(c)
+	c.rooms[room] = true
+	if h.subs[room] == nil {
+		h.subs[room] = make(map[*Client]bool)
+	}
+	h.subs[room][c] = true
+	h.mu.Unlock()
+
+	text := fmt.Sprintf("\u2705 '%s' olarak '%s' odasına insan katılımcı olarak katıldın.", name, room)
+	respData, _ := json.Marshal(map[string]any{"text": text, "agents": agents})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+
+	if joined {
+		h.logger.Printf("join_room: human=%q room=%q", name, room)
+		h.broadcastEvent(room, "message_new", map[string]any{"message": sysMsg})
+		h.broadcastEvent(room, "agent_joined", map[string]any{"agent_name": name, "agents": agents})
+	}
+}
+
+// recipientList accepts "to" as either a JSON string ("all", "backend",
+// "backend,db,@reviewers") or a JSON array of agent names and "@group" references.
+type recipientList []string
+
+func (r *recipientList) UnmarshalJSON(b []byte) error {
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		var spec string
+		if err := json.Unmarshal(b, &spec); err != nil {
+			return err
+		}
+		list = types.SplitRecipients(spec)
+	} else {
+		var cleaned []string
+		for _, e := range list {
+			if e = strings.TrimSpace(e); e != "" {
+				cleaned = append(cleaned, e)
+			}
+		}
+		list = cleaned
+	}
+	*r = list
+	return nil
+}
+
+// sendMessageData is the body of a send_message request.
+type sendMessageData struct {
+	From         string        `json:"from"`
+	To           recipientList `json:"to"`
+	Content      string        `json:"content"`
+	ExpectsReply bool          `json:"expects_reply"`
+	Priority     string        `json:"priority"`
+	DeliverAt    string        `json:"deliver_at"`
+	Delay        string        `json:"delay"`
+	Schedule     string        `json:"schedule"`
+	ExpiresAt    string        `json:"expires_at"`
+	TTL          string        `json:"ttl"`
+	// Human sends come from the desktop user, who joined the room with
+	// join_room(human=true); they may skip the manager gateway.
+	Human         bool `json:"human"`
+	BypassManager bool `json:"bypass_manager"`
+	// Via is set by connectors bridging a human in from an external chat.
+	Via string `json:"via"`
+}
+
+// parseSendMessage decodes a send_message body over its defaults.
+func parseSendMessage(raw json.RawMessage) sendMessageData {
+	data := sendMessageData{To: recipientList{"all"}, ExpectsReply: true, Priority: "normal"}
+	json.Unmarshal(raw, &data)
+	if len(data.To) == 0 {
+		data.To = recipientList{"all"}
+	}
+	return data
+}
+
+// checkFields validates the parts of a send that depend on neither the sender
+// nor the room, normalizing the priority. It returns the first delivery time
+// (zero for an immediate send) and the resolved expiry.
+func (d *sendMessageData) checkFields(now time.Time) (time.Time, string, error) {
+	if err := validation.ValidateRecipients(d.To); err != nil {
+		return time.Time{}, "", err
+	}
+	if len(d.Content) > maxFieldLength {
+		return time.Time{}, "", fmt.Errorf("content too long: %d chars, max %d", len(d.Content), maxFieldLength)
+	}
+	priority, err := types.NormalizePriority(d.Priority)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	d.Priority = priority
+
+	firstRun, err := resolveFirstRun(now, d.DeliverAt, d.Delay, d.Schedule)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	if strings.TrimSpace(d.Schedule) != "" && strings.TrimSpace(d.ExpiresAt) != "" {
+		return time.Time{}, "", fmt.Errorf("expires_at tekrarlayan mesajlarla kullanılamaz, ttl kullanın")
+	}
+	expiryBase := now
+	if !firstRun.IsZero() {
+		expiryBase = firstRun
+	}
+	expiresAt, err := resolveExpiry(expiryBase, d.ExpiresAt, d.TTL)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return firstRun, expiresAt, nil
+}
+
+func (h *Hub) handleSendMessage(c *Client, req types.Request) {
+	data := parseSendMessage(req.Data)
+	room := h.resolveRoom(req.Room)
+
+	if data.Human {
+		if !c.isDesktopAuthorized() {
+			c.sendError(req.ID, req.Type, "insan mesajlarını yalnızca yetkili desktop istemcisi gönderebilir")
+			return
+		}
+		if err := validation.ValidateName(data.From); err != nil {
+			c.sendError(req.ID, req.Type, err.Error())
+			return
+		}
+		if !h.roomOrEmpty(room).IsHuman(data.From) {
+			c.sendError(req.ID, req.Type, fmt.Sprintf("'%s' bu odada insan katılımcı değil; önce join_room(human=true) çağırın", data.From))
+			return
+		}
+	} else {
+		if data.BypassManager {
+			c.sendError(req.ID, req.Type, "bypass_manager yalnızca insan katılımcılar için kullanılabilir")
+			return
+		}
+		data.Via = ""
+		if c.joinedRoom == "" || c.agentName == "" {
+			c.sendError(req.ID, req.Type, "önce join_room çağırmalısınız")
+			return
+		}
+		if c.joinedRoom != room {
+			c.sendError(req.ID, req.Type, fmt.Sprintf("yalnızca katıldığınız odada mesaj gönderebilirsiniz: %s", c.joinedRoom))
+			return
+		}
+
+		if err := validation.ValidateName(data.From); err != nil {
+			c.sendError(req.ID, req.Type, err.Error())
+			return
+		}
+		if data.From != c.agentName {
+			c.sendError(req.ID, req.Type, "from_agent yalnızca kendi adınız olabilir")
+			return
+		}
+	}
+	now := time.Now()
+	firstRun, expiresAt, err := data.checkFields(now)
+	if err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+
+	h.logger.Printf("send_message: from=%q to=%q room=%q priority=%s expects_reply=%v contentLen=%d",
+		data.From, strings.Join(data.To, ","), room, data.Priority, data.ExpectsReply, len(data.Content))
+
+	roomState, ok := h.requireRoom(c, req, room)
+	if !ok {
+		return
+	}
+
+	// Resolve groups up front so unknown groups are rejected even for scheduled
+	// messages; scheduled messages re-resolve at delivery time.
+	to, recipients, err := roomState.ResolveRecipients(data.To, data.From)
+	if err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+
+	// Only requests that pass validation spend rate-limit tokens.
+	if retry, scope, ok := h.limiter.allow(room, data.From, len(data.Content), now); !ok {
+		h.logger.Printf("send_message: rate limited from=%q room=%q scope=%s retry_after=%s", data.From, room, scope, retry.Round(time.Second))
+		c.sendRateLimited(req.ID, req.Type, scope, retry)
+		return
+	}
+
+	if !firstRun.IsZero() {
+		roomState.TouchManagerHeartbeat(data.From)
+		sm, err := h.scheduler.add(ScheduledMessage{
+			Room:          room,
+			From:          data.From,
+			To:            strings.Join(data.To, ","),
+			Content:       data.Content,
+			ExpectsReply:  data.ExpectsReply,
+			Priority:      data.Priority,
+			NextRun:       firstRun,
+			Schedule:      strings.TrimSpace(data.Schedule),
+			ExpiresAt:     strings.TrimSpace(data.ExpiresAt),
+			TTL:           strings.TrimSpace(data.TTL),
+			FromHuman:     data.Human,
+			BypassManager: data.BypassManager,
+		})
+		if err != nil {
+			c.sendError(req.ID, req.Type, err.Error())
+			return
+		}
+		h.logger.Printf("send_message: scheduled id=%d room=%s next_run=%s schedule=%q",
+			sm.ID, room, sm.NextRun.Format(time.RFC3339), sm.Schedule)
+
+		text := fmt.Sprintf("\u23f0 Mesaj zamanlandı, gönderim: %s (zamanlama ID: %d)",
+			sm.NextRun.Format("2006-01-02 15:04:05"), sm.ID)
+		if sm.Schedule != "" {
+			text = fmt.Sprintf("\U0001f501 Tekrarlayan mesaj zamanlandı (%s), ilk gönderim: %s (zamanlama ID: %d)",
+				sm.Schedule, sm.NextRun.Format("2006-01-02 15:04:05"), sm.ID)
+		}
+		respData, _ := json.Marshal(map[string]any{"text": text, "scheduled_id": sm.ID, "next_run": sm.NextRun.Format(time.RFC3339)})
+		c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+		return
+	}
+
+	activeManager := roomState.GetActiveManagerAndTouch(data.From)
+	if data.BypassManager {
+		activeManager = ""
+	}
+
+	msg, intercepted, err := h.routeMessage(roomState, activeManager, types.Message{
+		From:         data.From,
+		To:           to,
+		Recipients:   recipients,
+		Content:      data.Content,
+		ExpectsReply: data.ExpectsReply,
+		Priority:     data.Priority,
+		ExpiresAt:    expiresAt,
+		FromHuman:    data.Human,
+		Via:          data.Via,
+	})
+	if err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+
+	h.logger.Printf("send_message: id=%d saved to room=%s", msg.ID, room)
+
+	var text string
+	if intercepted {
+		text = fmt.Sprintf("\U0001f4e4 Mesaj manager '%s' agent'ına iletildi, onay bekliyor (ID: %d)", activeManager, msg.ID)
+	} else if to == "all" {
+		text = fmt.Sprintf("\U0001f4e4 Mesaj tüm agent'lara gönderildi (ID: %d)", msg.ID)
+	} else if len(recipients) > 0 {
+		text = fmt.Sprintf("\U0001f4e4 Mesaj %d agent'a gönderildi: %s (ID: %d)", len(recipients), strings.Join(recipients, ", "), msg.ID)
+	} else if capability, ok := strings.CutPrefix(data.To[0], types.CapabilityPrefix); ok {
+		text = fmt.Sprintf("\U0001f4e4 Mesaj '%s' yeteneği için seçilen '%s' agent'ına gönderildi (ID: %d)", capability, to, msg.ID)
+	} else {
+		text = fmt.Sprintf("\U0001f4e4 Mesaj '%s' agent'ına gönderildi (ID: %d)", to, msg.ID)
+	}
+
+	respData, _ := json.Marshal(map[string]any{"text": text, "message_id": msg.ID})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+
+	// Broadcast event
+	h.broadcastEvent(room, "message_new", map[string]any{"message": msg})
+}
+
+// routeMessage stores a draft message in the room, redirecting it to the active
+// manager when one is set and the sender is not the manager. It reports
+// whether the message was intercepted by the manager.
+func (h *Hub) routeMessage(roomState *RoomState, activeManager string, draft types.Message) (types.Message, bool, error) {
+	opts := SendOptions{ExpiresAt: draft.ExpiresAt, Recipients: draft.Recipients, FromHuman: draft.FromHuman, Via: draft.Via}
+	to := draft.To
+	intercepted := false
+	if activeManager != "" && draft.From != activeManager {
+		intercepted = true
+		opts.OriginalTo = draft.To
+		opts.RoutedByManager = true
+		opts.Recipients = nil
+		to = activeManager
+	}
+
+	msg, err := roomState.SendMessage(draft.From, to, draft.Content, draft.ExpectsReply, draft.Priority, opts)
+	if err != nil {
+		return types.Message{}, false, err
+	}
+	return msg, intercepted, nil
+}
+
+// resolveExpiry turns an absolute expires_at or a relative ttl into a message
+// expiry timestamp, measured from `from`. Empty inputs mean no expiry.
+func resolveExpiry(from time.Time, expiresAt, ttl string) (string, error) {
+	expiresAt = strings.TrimSpace(expiresAt)
+	ttl = strings.TrimSpace(ttl)
+
+	switch {
+	case expiresAt != "" && ttl != "":
+		return "", fmt.Errorf("expires_at ve ttl birlikte kullanılamaz")
+	case expiresAt != "":
+		t, err := types.ParseTime(expiresAt)
+		if err != nil {
+			return "", err
+		}
+		if !t.After(from) {
+			return "", fmt.Errorf("expires_at gelecekte olmalı: %s", expiresAt)
+		}
+		return types.FormatTimestamp(t), nil
+	case ttl != "":
+		d, err := parseDelay(ttl)
+		if err != nil {
+			return "", err
+		}
+		if d <= 0 {
+			return "", fmt.Errorf("ttl pozitif olmalı: %s", ttl)
+		}
+		return types.FormatTimestamp(from.Add(d)), nil
+	}
+	return "", nil
+}
+
+// resolveMessageActor returns the name recorded for an edit/retract and whether
+// the caller may modify other agents' messages (active manager or desktop).
+func (h *Hub) resolveMessageActor(c *Client, req types.Request, room string) (string, bool, bool) {
+	if c.isDesktopAuthorized() && c.agentName == "" {
+		return "desktop", true, true
+	}
+	if c.joinedRoom == "" || c.agentName == "" {
+		c.sendError(req.ID, req.Type, "önce join_room çağırmalısınız")
+		return "", false, false
+	}
+	if c.joinedRoom != room {
+		c.sendError(req.ID, req.Type, fmt.Sprintf("yalnızca katıldığınız odada işlem yapabilirsiniz: %s", c.joinedRoom))
+		return "", false, false
+	}
+	roomState := h.roomOrEmpty(room)
+	isManager := roomState.GetActiveManagerAndTouch(c.agentName) == c.agentName
+	return c.agentName, isManager, true
+}
+
+// handleEditMessage replaces the content of an existing message, keeping the
+// previous content in its edit history.
+func (h *Hub) handleEditMessage(c *Client, req types.Request) {
+	var data struct {
+		MessageID int    `json:"message_id"`
+		Content   string `json:"content"`
+	}
+	json.Unmarshal(req.Data, &data)
+
+	room := h.resolveRoom(req.Room)
+	actor, privileged, ok := h.resolveMessageActor(c, req, room)
+	if !ok {
+		return
+	}
+	if strings.TrimSpace(data.Content) == "" {
+		c.sendError(req.ID, req.Type, "content boş olamaz")
+		return
+	}
+	if len(data.Content) > maxFieldLength {
+		c.sendError(req.ID, req.Type, fmt.Sprintf("content too long: %d chars, max %d", len(data.Content), maxFieldLength))
+		return
+	}
+
+	roomState, ok := h.requireRoom(c, req, room)
+	if !ok {
+		return
+	}
+	msg, err := roomState.EditMessage(data.MessageID, actor, data.Content, privileged)
+	if err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+	h.logger.Printf("edit_message: id=%d room=%s by=%s edits=%d", msg.ID, room, actor, len(msg.Edits))
+
+	text := fmt.Sprintf("\u270f\ufe0f Mesaj düzenlendi (ID: %d)", msg.ID)
+	respData, _ := json.Marshal(map[string]any{"text": text, "message_id": msg.ID})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+
+	h.broadcastEvent(room, "message_updated", map[string]any{"message": msg})
+}
+
+// handleRetractMessage hides a message from further reads.
+func (h *Hub) handleRetractMessage(c *Client, req types.Request) {
+	var data struct {
+		MessageID int `json:"message_id"`
+	}
+	json.Unmarshal(req.Data, &data)
+
+	room := h.resolveRoom(req.Room)
+	actor, privileged, ok := h.resolveMessageActor(c, req, room)
+	if !ok {
+		return
+	}
+
+	roomState, ok := h.requireRoom(c, req, room)
+	if !ok {
+		return
+	}
+	msg, err := roomState.RetractMessage(data.MessageID, actor, privileged)
+	if err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+	h.logger.Printf("retract_message: id=%d room=%s by=%s", msg.ID, room, actor)
+
+	text := fmt.Sprintf("\u21a9\ufe0f Mesaj geri çekildi (ID: %d)", msg.ID)
+	respData, _ := json.Marshal(map[string]any{"text": text, "message_id": msg.ID})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+
+	h.broadcastEvent(room, "message_retracted", map[string]any{"message": msg.Redacted()})
+}
+
+// handleFindAgents lists active agents matching a capability, best match first.
+func (h *Hub) handleFindAgents(c *Client, req types.Request) {
+	var data struct {
+		Capability string `json:"capability"`
+	}
+	json.Unmarshal(req.Data, &data)
+
+	room := h.resolveRoom(req.Room)
+
+	if !c.isDesktopAuthorized() {
+		if c.agentName == "" || c.joinedRoom == "" {
+			c.sendError(req.ID, req.Type, "önce join_room çağırmalısınız")
+			return
+		}
+		if c.joinedRoom != room {
+			c.sendError(req.ID, req.Type, fmt.Sprintf("yalnızca katıldığınız odadan sorgulama yapabilirsiniz: %s", c.joinedRoom))
+			return
+		}
+	}
+	capability := strings.TrimPrefix(strings.TrimSpace(data.Capability), types.CapabilityPrefix)
+	if capability == "" {
+		c.sendError(req.ID, req.Type, "capability gerekli")
+		return
+	}
+
+	roomState := h.roomOrEmpty(room)
+	if c.agentName != "" {
+		roomState.TouchManagerHeartbeat(c.agentName)
+	}
+	matches := roomState.FindAgents(capability, "")
+
+	var sb strings.Builder
+	if len(matches) == 0 {
+		fmt.Fprintf(&sb, "\U0001f50d '%s' yeteneğine sahip aktif agent yok.", sanitize(capability))
+	} else {
+		fmt.Fprintf(&sb, "\U0001f50d '%s' için agent'lar (%d), en uygun önce:\n\n", sanitize(capability), len(matches))
+		for _, m := range matches {
+			fmt.Fprintf(&sb, "  \u2022 %s", sanitize(m.Name))
+			if m.Agent.Role != "" {
+				fmt.Fprintf(&sb, " - %s", sanitize(m.Agent.Role))
+			}
+			if m.Agent.Capabilities != nil {
+				fmt.Fprintf(&sb, " [%s]", strings.Join(m.Agent.Capabilities.All(), ", "))
+			}
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "\nİpucu: send_message(to_agent=\"%s%s\") en uygun agent'a gönderir.", types.CapabilityPrefix, sanitize(capability))
+	}
+
+	if matches == nil {
+		matches = []AgentMatch{}
+	}
+	respData, _ := json.Marshal(map[string]any{"text": sb.String(), "agents": matches})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+}
+
+// handleSetGroup defines, replaces or deletes (empty members) a recipient group.
+// Only the active manager or the desktop app may change groups.
+func (h *Hub) handleSetGroup(c *Client, req types.Request) {
+	var data struct {
+		Group   string   `json:"group"`
+		Members []string `json:"members"`
+	}
+	json.Unmarshal(req.Data, &data)
+
+	room := h.resolveRoom(req.Room)
+	roomState, ok := h.requireRoom(c, req, room)
+	if !ok {
+		return
+	}
+
+	if !c.isDesktopAuthorized() {
+		if c.agentName == "" || c.joinedRoom == "" {
+			c.sendError(req.ID, req.Type, "önce join_room çağırmalısınız")
+			return
+		}
+		if c.joinedRoom != room {
+			c.sendError(req.ID, req.Type, fmt.Sprintf("yalnızca katıldığınız odada işlem yapabilirsiniz: %s", c.joinedRoom))
+			return
+		}
+		if roomState.GetActiveManagerAndTouch(c.agentName) != c.agentName {
+			c.sendError(req.ID, req.Type, "yalnızca aktif manager veya yetkili desktop grup tanımlayabilir")
+			return
+		}
+	}
+
+	group := strings.TrimPrefix(strings.TrimSpace(data.Group), "@")
+	if group == "" || group == "all" {
+		c.sendError(req.ID, req.Type, "geçerli bir grup adı gerekli")
+		return
+	}
+	if err := validation.ValidateName(group); err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+	var members []string
+	seen := make(map[string]bool)
+	for _, m := range data.Members {
+		m = strings.TrimSpace(m)
+		if m == "" || seen[m] {
+			continue
+		}
+		if err := validation.ValidateName(m); err != nil {
+			c.sendError(req.ID, req.Type, err.Error())
+			return
+		}
+		seen[m] = true
+		members = append(members, m)
+	}
+
+	roomState.SetGroup(group, members)
+	h.logger.Printf("set_group: room=%s group=%s members=%v", room, group, members)
+
+	var text string
+	if len(members) == 0 {
+		text = fmt.Sprintf("\U0001f5d1\ufe0f @%s grubu silindi", group)
+	} else {
+		text = fmt.Sprintf("\U0001f465 @%s grubu güncellendi: %s", group, strings.Join(members, ", "))
+	}
+	respData, _ := json.Marshal(map[string]string{"text": text})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+
+	h.broadcastEvent(room, "groups_updated", map[string]any{"groups": roomState.GetGroups()})
+}
+
+// handleListGroups lists the recipient groups defined in a room.
+func (h *Hub) handleListGroups(c *Client, req types.Request) {
+	room := h.resolveRoom(req.Room)
+
+	if !c.isDesktopAuthorized() {
+		if c.agentName == "" || c.joinedRoom == "" {
+			c.sendError(req.ID, req.Type, "önce join_room çağırmalısınız")
+			return
+		}
+		if c.joinedRoom != room {
+			c.sendError(req.ID, req.Type, fmt.Sprintf("yalnızca katıldığınız odadan sorgulama yapabilirsiniz: %s", c.joinedRoom))
+			return
+		}
+	}
+
+	groups := h.roomOrEmpty(room).GetGroups()
+
+	var sb strings.Builder
+	if len(groups) == 0 {
+		sb.WriteString("\U0001f4ad Bu odada tanımlı grup yok.")
+	} else {
+		names := make([]string, 0, len(groups))
+		for name := range groups {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(&sb, "\U0001f465 Gruplar (%d):\n\n", len(groups))
+		for _, name := range names {
+			fmt.Fprintf(&sb, "  \u2022 @%s: %s\n", name, strings.Join(groups[name], ", "))
+		}
+	}
+
+	respData, _ := json.Marshal(map[string]any{"text": sb.String(), "groups": groups})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+}
+
+// handleSetStatus records an agent's self-reported status (enum + free text +
+// optional ETA). Agents set their own status; the desktop may set any agent's.
+func (h *Hub) handleSetStatus(c *Client, req types.Request) {
+	var data struct {
+		AgentName string `json:"agent_name"`
+		Status    string `json:"status"`
+		Text      string `json:"text"`
+		ETA       string `json:"eta"`
+	}
+	json.Unmarshal(req.Data, &data)
+
+	room := h.resolveRoom(req.Room)
+
+	if err := validation.ValidateName(data.AgentName); err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+	if !c.isDesktopAuthorized() {
+		if c.agentName == "" || c.joinedRoom == "" {
+			c.sendError(req.ID, req.Type, "önce join_room çağırmalısınız")
+			return
+		}
+		if data.AgentName != c.agentName {
+			c.sendError(req.ID, req.Type, "yalnızca kendi durumunuzu güncelleyebilirsiniz")
+			return
+		}
+		if c.joinedRoom != room {
+			c.sendError(req.ID, req.Type, fmt.Sprintf("yalnızca katıldığınız odada işlem yapabilirsiniz: %s", c.joinedRoom))
+			return
+		}
+	}
+
+	status, err := types.NormalizeStatus(data.Status)
+	if err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+	text := strings.TrimSpace(data.Text)
+	if len(text) > maxStatusTextLength {
+		c.sendError(req.ID, req.Type, fmt.Sprintf("status text too long: %d chars, max %d", len(text), maxStatusTextLength))
+		return
+	}
+	eta, err := resolveStatusETA(time.Now(), data.ETA)
+	if err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+
+	roomState, ok := h.requireRoom(c, req, room)
+	if !ok {
+		return
+	}
+	agent, agents, err := roomState.SetStatus(data.AgentName, status, text, eta)
+	if err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+	roomState.TouchManagerHeartbeat(data.AgentName)
+	h.logger.Printf("set_status: room=%s agent=%s status=%s eta=%s", room, data.AgentName, status, eta)
+
+	respText := fmt.Sprintf("📍 Durum güncellendi: %s", formatStatus(agent))
+	respData, _ := json.Marshal(map[string]any{"text": respText, "agent": agent})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+
+	h.broadcastEvent(room, "agent_status_changed", map[string]any{
+		"agent_name": data.AgentName,
+		"agent":      agent,
+		"agents":     agents,
+	})
+}
+
+// handleReportActivity records a PTY activity heartbeat for an agent hosted by
+// the desktop app. Only authorized desktop clients may report activity.
+func (h *Hub) handleReportActivity(c *Client, req types.Request) {
+	var data struct {
+		AgentName string `json:"agent_name"`
+		Activity  string `json:"activity"`
+	}
+	json.Unmarshal(req.Data, &data)
+
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "report_activity yalnızca yetkili desktop istemcisi tarafından çağrılabilir")
+		return
+	}
+	if data.AgentName == "" {
+		c.sendError(req.ID, req.Type, "agent_name gerekli")
+		return
+	}
+	if err := validation.ValidateName(data.AgentName); err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+	if !types.IsValidActivity(data.Activity) {
+		c.sendError(req.ID, req.Type, fmt.Sprintf("geçersiz activity %q: yalnızca %q, %q veya %q olabilir",
+			data.Activity, types.ActivityBusy, types.ActivityIdle, types.ActivityPromptWaiting))
+		return
+	}
+
+	room := h.resolveRoom(req.Room)
+	roomState, ok := h.requireRoom(c, req, room)
+	if !ok {
+		return
+	}
+	agent, changed, err := roomState.ReportActivity(data.AgentName, data.Activity)
+	if err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+
+	respData, _ := json.Marshal(map[string]any{"text": "ok", "agent": agent})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+
+	if changed {
+		h.logger.Printf("report_activity: room=%s agent=%s activity=%s", room, data.AgentName, data.Activity)
+		h.broadcastEvent(room, "agent_status_changed", map[string]any{
+			"agent_name": data.AgentName,
+			"agent":      agent,
+			"agents":     roomState.GetAgents(),
+		})
+	}
+}
+
+// handlePostSystem lets the desktop app post a SYSTEM message to a room, e.g.
+// to explain why the orchestrator paused notifications.
+func (h *Hub) handlePostSystem(c *Client, req types.Request) {
+	var data struct {
+		Content string `json:"content"`
+	}
+	json.Unmarshal(req.Data, &data)
+
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "post_system yalnızca yetkili desktop istemcisi tarafından çağrılabilir")
+		return
+	}
+	content := strings.TrimSpace(data.Content)
+	if content == "" {
+		c.sendError(req.ID, req.Type, "content gerekli")
+		return
+	}
+	if len(content) > maxFieldLength {
+		c.sendError(req.ID, req.Type, fmt.Sprintf("content too long: %d chars, max %d", len(content), maxFieldLength))
+		return
+	}
+
+	room := h.resolveRoom(req.Room)
+	roomState, ok := h.requireRoom(c, req, room)
+	if !ok {
+		return
+	}
+	msg := roomState.PostSystem(content)
+
+	respData, _ := json.Marshal(map[string]any{"text": fmt.Sprintf("Sistem mesajı gönderildi (ID: %d)", msg.ID), "message": msg})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+
+	h.broadcastEvent(room, "message_new", map[string]any{"message": msg})
+}
+
+// handleGetRateStats returns a room's rate limiting counters (desktop only).
+func (h *Hub) handleGetRateStats(c *Client, req types.Request) {
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "get_rate_stats yalnızca yetkili desktop istemcisi tarafından çağrılabilir")
+		return
+	}
+	room := h.resolveRoom(req.Room)
+	stats := h.limiter.snapshot(room)
+
+	text := fmt.Sprintf("'%s' odası: %d mesaj kabul edildi, %d mesaj hız sınırına takıldı", room, stats.Total.Allowed, stats.Total.Limited)
+	respData, _ := json.Marshal(map[string]any{"text": text, "stats": stats})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+}
+
+// resolveStatusETA turns an absolute time or a relative duration ("15m") into
+// a status ETA timestamp. Empty input means no ETA.
+func resolveStatusETA(from time.Time, eta string) (string, error) {
+	eta = strings.TrimSpace(eta)
+	if eta == "" {
+		return "", nil
+	}
+	if d, err := parseDelay(eta); err == nil {
+		if d <= 0 {
+			return "", fmt.Errorf("eta pozitif olmalı: %s", eta)
+		}
+		return types.FormatTimestamp(from.Add(d)), nil
+	}
+	t, err := types.ParseTime(eta)
+	if err != nil {
+		return "", fmt.Errorf("geçersiz eta %q: süre (15m) veya zaman (2006-01-02T15:04:05) bekleniyor", eta)
+	}
+	return types.FormatTimestamp(t), nil
+}
+
+// formatStatus renders an agent's status as "working (migration yazıyor, ETA 14:30)".
+func formatStatus(agent types.Agent) string {
+	status := agent.Status
+	if status == "" {
+		status = types.StatusAvailable
+	}
+	var details []string
+	if agent.StatusText != "" {
+		details = append(details, sanitize(agent.StatusText))
+	}
+	if agent.StatusETA != "" {
+		details = append(details, "ETA "+parseTimestamp(agent.StatusETA))
+	}
+	if len(details) == 0 {
+		return status
+	}
+	return fmt.Sprintf("%s (%s)", status, strings.Join(details, ", "))
+}
+
+// handleListScheduled lists pending scheduled messages. Agents see their own;
+// the active manager and the desktop app see every scheduled message in the room.
+func (h *Hub) handleListScheduled(c *Client, req types.Request) {
+	room := h.resolveRoom(req.Room)
+
+	owner := ""
+	if !c.isDesktopAuthorized() {
+		if c.joinedRoom == "" || c.agentName == "" {
+			c.sendError(req.ID, req.Type, "önce join_room çağırmalısınız")
+			return
+		}
+		if c.joinedRoom != room {
+			c.sendError(req.ID, req.Type, fmt.Sprintf("yalnızca katıldığınız odadan sorgulama yapabilirsiniz: %s", c.joinedRoom))
+			return
+		}
+		roomState := h.roomOrEmpty(room)
+		roomState.TouchManagerHeartbeat(c.agentName)
+		if roomState.GetActiveManager() != c.agentName {
+			owner = c.agentName
+		}
+	}
+
+	items := h.scheduler.list(room, owner)
+
+	var sb strings.Builder
+	if len(items) == 0 {
+		sb.WriteString("\U0001f4ed Zamanlanmış mesaj yok.")
+	} else {
+		fmt.Fprintf(&sb, "\u23f0 Zamanlanmış mesajlar (%d):\n\n", len(items))
+		for _, it := range items {
+			target := it.To
+			if target == "all" {
+				target = "HERKESE"
+			}
+			fmt.Fprintf(&sb, "  [%d] %s \u2192 %s @ %s", it.ID, sanitize(it.From), sanitize(target), it.NextRun.Format("2006-01-02 15:04:05"))
+			if it.Schedule != "" {
+				fmt.Fprintf(&sb, " (tekrar: %s)", it.Schedule)
+			}
+			fmt.Fprintf(&sb, "\n      %s\n", sanitize(it.Content))
+		}
+	}
+
+	if items == nil {
+		items = []ScheduledMessage{}
+	}
+	respData, _ := json.Marshal(map[string]any{"text": sb.String(), "scheduled": items})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+}
+
+// handleCancelScheduled removes a pending scheduled message. Only its sender,
+// the active manager or the desktop app may cancel it.
+func (h *Hub) handleCancelScheduled(c *Client, req types.Request) {
+	var data struct {
+		ScheduledID int `json:"scheduled_id"`
+	}
+	json.Unmarshal(req.Data, &data)
+
+	room := h.resolveRoom(req.Room)
+
+	sm, ok := h.scheduler.get(data.ScheduledID)
+	if !ok || sm.Room != room {
+		c.sendError(req.ID, req.Type, fmt.Sprintf("zamanlanmış mesaj bulunamadı: %d", data.ScheduledID))
+		return
+	}
+
+	if !c.isDesktopAuthorized() {
+		if c.joinedRoom == "" || c.agentName == "" {
+			c.sendError(req.ID, req.Type, "önce join_room çağırmalısınız")
+			return
+		}
+		if c.joinedRoom != room {
+			c.sendError(req.ID, req.Type, fmt.Sprintf("yalnızca katıldığınız odada işlem yapabilirsiniz: %s", c.joinedRoom))
+			return
+		}
+		roomState := h.roomOrEmpty(room)
+		roomState.TouchManagerHeartbeat(c.agentName)
+		if sm.From != c.agentName && roomState.GetActiveManager() != c.agentName {
+			c.sendError(req.ID, req.Type, "yalnızca gönderen, aktif manager veya yetkili desktop zamanlanmış mesajı iptal edebilir")
+			return
+		}
+	}
+
+	if !h.scheduler.cancel(sm.ID) {
+		c.sendError(req.ID, req.Type, fmt.Sprintf("zamanlanmış mesaj bulunamadı: %d", data.ScheduledID))
+		return
+	}
+	h.logger.Printf("cancel_scheduled: id=%d room=%s", sm.ID, room)
+
+	text := fmt.Sprintf("\U0001f6ab Zamanlanmış mesaj iptal edildi (zamanlama ID: %d)", sm.ID)
+	respData, _ := json.Marshal(map[string]string{"text": text})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+}
+
+// handleAddHook registers a room hook. Hooks run local commands, so only the
+// desktop app may manage them.
+func (h *Hub) handleAddHook(c *Client, req types.Request) {
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "hook'ları yalnızca yetkili desktop istemcisi yönetebilir")
+		return
+	}
+	var hk Hook
+	if err := json.Unmarshal(req.Data, &hk); err != nil {
+		c.sendError(req.ID, req.Type, "geçersiz hook tanımı")
+		return
+	}
+	hk.Room = h.resolveRoom(req.Room)
+	hk, err := h.hooks.add(hk)
+	if err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
+	}
+	h.logger.Printf("add_hook: id=%d room=%s event=%s", hk.ID, hk.Room, hk.Event)
 
-func (c *Client) isDesktopAuthorized() bool {
-	return c.clientType == "desktop" && c.desktopAuthed
+	text := fmt.Sprintf("\U0001fa9d Hook eklendi (hook ID: %d, olay: %s)", hk.ID, hk.Event)
+	respData, _ := json.Marshal(map[string]any{"text": text, "hook": hk})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
 }
 
-func (h *Hub) handleSetManager(c *Client, req types.Request) {
+func (h *Hub) handleRemoveHook(c *Client, req types.Request) {
 	if !c.isDesktopAuthorized() {
-		c.sendError(req.ID, req.Type, "yalnızca yetkili desktop istemcisi manager atayabilir")
+		c.sendError(req.ID, req.Type, "hook'ları yalnızca yetkili desktop istemcisi yönetebilir")
 		return
 	}
-
 	var data struct {
-		ManagerAgent string `json:"manager_agent"`
-	}
-	if err := json.Unmarshal(req.Data, &data); err != nil {
-		c.sendError(req.ID, req.Type, "invalid set_manager payload")
-		return
-	}
-	managerAgent := strings.TrimSpace(data.ManagerAgent)
-	if managerAgent != "" {
-		if err := validation.ValidateName(managerAgent); err != nil {
-			c.sendError(req.ID, req.Type, err.Error())
-			return
-		}
+		HookID int `json:"hook_id"`
 	}
+	json.Unmarshal(req.Data, &data)
 
 	room := h.resolveRoom(req.Room)
-	h.setConfiguredManager(room, managerAgent)
-
-	roomState := h.getOrCreateRoom(room)
-	roomState.ResetManagerLockIfDifferent(managerAgent)
-
-	var text string
-	if managerAgent == "" {
-		text = fmt.Sprintf("'%s' odası için manager ataması temizlendi.", room)
-	} else {
-		text = fmt.Sprintf("'%s' odası manager'ı '%s' olarak ayarlandı.", room, managerAgent)
+	if !h.hooks.remove(room, data.HookID) {
+		c.sendError(req.ID, req.Type, fmt.Sprintf("hook bulunamadı: %d", data.HookID))
+		return
 	}
+	h.logger.Printf("remove_hook: id=%d room=%s", data.HookID, room)
+
+	text := fmt.Sprintf("\U0001f6ab Hook silindi (hook ID: %d)", data.HookID)
 	respData, _ := json.Marshal(map[string]string{"text": text})
 	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
 }
 
-func (h *Hub) handleSubscribe(c *Client, req types.Request) {
-	var data struct {
-		Rooms []string `json:"rooms"`
+func (h *Hub) handleListHooks(c *Client, req types.Request) {
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "hook'ları yalnızca yetkili desktop istemcisi yönetebilir")
+		return
 	}
-	json.Unmarshal(req.Data, &data)
+	room := h.resolveRoom(req.Room)
+	hooks := h.hooks.list(room)
 
-	h.mu.Lock()
-	for _, room := range data.Rooms {
-		c.rooms[room] = true
-		if h.subs[room] == nil {
-			h.subs[room] = make(map[*Client]bool)
+	var sb strings.Builder
+	if len(hooks) == 0 {
+		sb.WriteString("\U0001f4ed Bu odada hook yok.")
+	} else {
+		fmt.Fprintf(&sb, "\U0001fa9d Hook'lar (%d):\n\n", len(hooks))
+		for _, hk := range hooks {
+			target := "POST " + hk.URL
+			if hk.Command != "" {
+				target = "$ " + hk.Command
+			}
+			fmt.Fprintf(&sb, "  [%d] %s \u2192 %s\n", hk.ID, hk.Event, target)
 		}
-		h.subs[room][c] = true
 	}
-	h.mu.Unlock()
-
-	h.logger.Printf("Client subscribed to rooms: %v", data.Rooms)
 
-	resp := types.Response{ID: req.ID, RequestType: req.Type, Success: true}
-	resp.Data, _ = json.Marshal(map[string]bool{"ok": true})
-	c.sendJSON(resp)
+	if hooks == nil {
+		hooks = []Hook{}
+	}
+	respData, _ := json.Marshal(map[string]any{"text": sb.String(), "hooks": hooks})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
 }
 
-func (h *Hub) handleJoinRoom(c *Client, req types.Request) {
+// handleHookLog returns the room's recent hook deliveries, newest first.
+func (h *Hub) handleHookLog(c *Client, req types.Request) {
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "hook'ları yalnızca yetkili desktop istemcisi yönetebilir")
+		return
+	}
 	var data struct {
-		AgentName string `json:"agent_name"`
-		Role      string `json:"role"`
+		Limit int `json:"limit"`
 	}
 	json.Unmarshal(req.Data, &data)
 
-	room := h.resolveRoom(req.Room)
+	deliveries := h.hooks.deliveries(h.resolveRoom(req.Room), data.Limit)
+	if deliveries == nil {
+		deliveries = []HookDelivery{}
+	}
+	respData, _ := json.Marshal(map[string]any{"deliveries": deliveries})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+}
 
-	if err := validation.ValidateName(data.AgentName); err != nil {
-		c.sendError(req.ID, req.Type, err.Error())
+// handleAddConnector bridges a room to an external chat system (desktop only).
+// The response carries the stored settings, including generated secrets the
+// external side needs.
+func (h *Hub) handleAddConnector(c *Client, req types.Request) {
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "connector'ları yalnızca yetkili desktop istemcisi yönetebilir")
 		return
 	}
-	if c.agentName != "" && c.agentName != data.AgentName {
-		c.sendError(req.ID, req.Type, fmt.Sprintf("bu bağlantı '%s' olarak join oldu; farklı adla join olamaz", c.agentName))
+	var cfg ConnectorConfig
+	if err := json.Unmarshal(req.Data, &cfg); err != nil {
+		c.sendError(req.ID, req.Type, "geçersiz connector tanımı")
 		return
 	}
-	if c.joinedRoom != "" && c.joinedRoom != room {
-		c.sendError(req.ID, req.Type, fmt.Sprintf("zaten '%s' odasına katıldınız; farklı odaya join olamaz", c.joinedRoom))
+	cfg.Room = h.resolveRoom(req.Room)
+	cfg, err := h.connectors.add(cfg)
+	if err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
 		return
 	}
-	if len(data.Role) > maxFieldLength {
-		c.sendError(req.ID, req.Type, fmt.Sprintf("role too long: %d chars, max %d", len(data.Role), maxFieldLength))
+	h.logger.Printf("add_connector: id=%d room=%s kind=%s", cfg.ID, cfg.Room, cfg.Kind)
+
+	text := fmt.Sprintf("\U0001f309 Connector eklendi (connector ID: %d, tür: %s)", cfg.ID, cfg.Kind)
+	respData, _ := json.Marshal(map[string]any{"text": text, "connector": cfg})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+}
+
+func (h *Hub) handleRemoveConnector(c *Client, req types.Request) {
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "connector'ları yalnızca yetkili desktop istemcisi yönetebilir")
 		return
 	}
-	role := strings.ToLower(strings.TrimSpace(data.Role))
-	if role == "manager" {
-		configuredManager := h.getConfiguredManager(room)
-		if configuredManager == "" {
-			c.sendError(req.ID, req.Type, "manager rolü atanmadı; önce desktop üzerinden manager belirlenmeli")
-			return
-		}
-		if data.AgentName != configuredManager {
-			c.sendError(req.ID, req.Type, fmt.Sprintf("manager rolü yalnızca '%s' agent'ına atanabilir", configuredManager))
-			return
-		}
+	var data struct {
+		ConnectorID int `json:"connector_id"`
 	}
+	json.Unmarshal(req.Data, &data)
 
-	h.logger.Printf("join_room: agent=%q role=%q room=%q", data.AgentName, data.Role, room)
-
-	roomState := h.getOrCreateRoom(room)
-	sysMsg, agents, err := roomState.Join(data.AgentName, data.Role)
-	if err != nil {
-		c.sendError(req.ID, req.Type, err.Error())
+	room := h.resolveRoom(req.Room)
+	if !h.connectors.remove(room, data.ConnectorID) {
+		c.sendError(req.ID, req.Type, fmt.Sprintf("connector bulunamadı: %d", data.ConnectorID))
 		return
 	}
+	h.logger.Printf("remove_connector: id=%d room=%s", data.ConnectorID, room)
 
-	// Also subscribe the client to this room
-	h.mu.Lock()
-	c.rooms[room] = true
-	c.agentName = data.AgentName
-	c.joinedRoom = room
-	if h.subs[room] == nil {
-		h.subs[room] = make(map[*Client]bool)
-	}
-	h.subs[room][c] = true
-	h.mu.Unlock()
+	text := fmt.Sprintf("\U0001f6ab Connector silindi (connector ID: %d)", data.ConnectorID)
+	respData, _ := json.Marshal(map[string]string{"text": text})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+}
 
-	// Build response text
-	var otherAgents []string
-	for name := range agents {
-		if name != data.AgentName {
-			otherAgents = append(otherAgents, name)
-		}
+func (h *Hub) handleListConnectors(c *Client, req types.Request) {
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "connector'ları yalnızca yetkili desktop istemcisi yönetebilir")
+		return
 	}
+	room := h.resolveRoom(req.Room)
+	connectors := h.connectors.list(room)
 
-	var text string
-	if len(otherAgents) > 0 {
-		text = fmt.Sprintf("\u2705 '%s' olarak '%s' odasına katıldın. Odadaki diğer agent'lar: %s", data.AgentName, room, strings.Join(otherAgents, ", "))
+	var sb strings.Builder
+	if len(connectors) == 0 {
+		sb.WriteString("\U0001f4ed Bu odada connector yok.")
 	} else {
-		text = fmt.Sprintf("\u2705 '%s' olarak '%s' odasına katıldın. Şu an odada başka agent yok.", data.AgentName, room)
+		fmt.Fprintf(&sb, "\U0001f309 Connector'lar (%d):\n\n", len(connectors))
+		for _, cfg := range connectors {
+			fmt.Fprintf(&sb, "  [%d] %s", cfg.ID, cfg.Kind)
+			if target := cfg.Settings["url"]; target != "" {
+				fmt.Fprintf(&sb, " \u2192 %s", target)
+			}
+			fmt.Fprintf(&sb, " (gelen: /api/connectors/%d/inbound)\n", cfg.ID)
+		}
 	}
 
-	respData, _ := json.Marshal(map[string]any{"text": text, "agents": agents})
+	if connectors == nil {
+		connectors = []ConnectorConfig{}
+	}
+	respData, _ := json.Marshal(map[string]any{"text": sb.String(), "connectors": connectors})
 	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
-
-	// Broadcast events
-	h.broadcastEvent(room, "message_new", map[string]any{"message": sysMsg})
-	h.broadcastEvent(room, "agent_joined", map[string]any{"agent_name": data.AgentName, "agents": agents})
 }
 
-func (h *Hub) handleSendMessage(c *Client, req types.Request) {
+// handleExportRoom returns a room as JSONL (full metadata, importable) or as
+// a Markdown transcript.
+func (h *Hub) handleExportRoom(c *Client, req types.Request) {
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "odayı yalnızca yetkili desktop istemcisi dışa aktarabilir")
+		return
+	}
 	var data struct {
-		From         string `json:"from"`
-		To           string `json:"to"`
-		Content      string `json:"content"`
-		ExpectsReply bool   `json:"expects_reply"`
-		Priority     string `json:"priority"`
-	}
-	// Defaults
-	data.To = "all"
-	data.ExpectsReply = true
-	data.Priority = "normal"
+		Format string `json:"format"`
+	}
 	json.Unmarshal(req.Data, &data)
+	if data.Format == "" {
+		data.Format = ExportJSONL
+	}
 
 	room := h.resolveRoom(req.Room)
-
-	if c.joinedRoom == "" || c.agentName == "" {
-		c.sendError(req.ID, req.Type, "önce join_room çağırmalısınız")
+	roomState, ok := h.lookupRoom(room)
+	if !ok {
+		c.sendError(req.ID, req.Type, fmt.Sprintf("oda bulunamadı: %s", room))
 		return
 	}
-	if c.joinedRoom != room {
-		c.sendError(req.ID, req.Type, fmt.Sprintf("yalnızca katıldığınız odada mesaj gönderebilirsiniz: %s", c.joinedRoom))
+	snap := roomState.Snapshot()
+
+	var out strings.Builder
+	var err error
+	switch data.Format {
+	case ExportJSONL:
+		err = writeJSONL(&out, room, snap)
+	case ExportMarkdown:
+		err = writeMarkdown(&out, room, snap)
+	default:
+		c.sendError(req.ID, req.Type, fmt.Sprintf("geçersiz format %q: jsonl veya markdown bekleniyor", data.Format))
 		return
 	}
-
-	if err := validation.ValidateName(data.From); err != nil {
+	if err != nil {
 		c.sendError(req.ID, req.Type, err.Error())
 		return
 	}
-	if data.From != c.agentName {
-		c.sendError(req.ID, req.Type, "from_agent yalnızca kendi adınız olabilir")
+	h.logger.Printf("export_room: room=%s format=%s messages=%d", room, data.Format, len(snap.Messages))
+
+	respData, _ := json.Marshal(map[string]any{"format": data.Format, "data": out.String(), "messages": len(snap.Messages)})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+}
+
+// handleImportRoom seeds an empty room from a JSONL export, for example to
+// replay a conversation under a new room name.
+func (h *Hub) handleImportRoom(c *Client, req types.Request) {
+	if !c.isDesktopAuthorized() {
+		c.sendError(req.ID, req.Type, "odayı yalnızca yetkili desktop istemcisi içe aktarabilir")
 		return
 	}
-	if data.To != "all" {
-		if err := validation.ValidateName(data.To); err != nil {
-			c.sendError(req.ID, req.Type, err.Error())
-			return
-		}
+	var data struct {
+		Format string `json:"format"`
+		Data   string `json:"data"`
 	}
-	if len(data.Content) > maxFieldLength {
-		c.sendError(req.ID, req.Type, fmt.Sprintf("content too long: %d chars, max %d", len(data.Content), maxFieldLength))
+	json.Unmarshal(req.Data, &data)
+	if data.Format != "" && data.Format != ExportJSONL {
+		c.sendError(req.ID, req.Type, "yalnızca jsonl biçimi içe aktarılabilir")
 		return
 	}
 
-	h.logger.Printf("send_message: from=%q to=%q room=%q priority=%s expects_reply=%v contentLen=%d",
-		data.From, data.To, room, data.Priority, data.ExpectsReply, len(data.Content))
-
-	roomState := h.getOrCreateRoom(room)
-
-	activeManager := roomState.GetActiveManagerAndTouch(data.From)
-
-	to := data.To
-	opts := SendOptions{}
-	intercepted := false
-	if activeManager != "" && data.From != activeManager {
-		intercepted = true
-		opts.OriginalTo = data.To
-		opts.RoutedByManager = true
-		to = activeManager
+	room := h.resolveRoom(req.Room)
+	if err := validation.ValidateName(room); err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
 	}
-
-	msg, err := roomState.SendMessage(data.From, to, data.Content, data.ExpectsReply, data.Priority, opts)
+	pr, err := ReadExport(strings.NewReader(data.Data))
 	if err != nil {
 		c.sendError(req.ID, req.Type, err.Error())
 		return
 	}
-
-	h.logger.Printf("send_message: id=%d saved to room=%s", msg.ID, room)
-
-	var text string
-	if intercepted {
-		text = fmt.Sprintf("\U0001f4e4 Mesaj manager '%s' agent'ına iletildi, onay bekliyor (ID: %d)", activeManager, msg.ID)
-	} else if data.To == "all" {
-		text = fmt.Sprintf("\U0001f4e4 Mesaj tüm agent'lara gönderildi (ID: %d)", msg.ID)
-	} else {
-		text = fmt.Sprintf("\U0001f4e4 Mesaj '%s' agent'ına gönderildi (ID: %d)", data.To, msg.ID)
+	roomState := h.getOrCreateRoom(room)
+	if err := roomState.Seed(pr); err != nil {
+		c.sendError(req.ID, req.Type, err.Error())
+		return
 	}
+	h.logger.Printf("import_room: room=%s messages=%d", room, len(pr.Messages))
 
-	respData, _ := json.Marshal(map[string]any{"text": text, "message_id": msg.ID})
+	sysMsg := roomState.PostSystem(fmt.Sprintf("\U0001f4e5 %d mesaj içe aktarıldı", len(pr.Messages)))
+	text := fmt.Sprintf("\U0001f4e5 '%s' odasına %d mesaj içe aktarıldı", room, len(pr.Messages))
+	respData, _ := json.Marshal(map[string]any{"text": text, "messages": len(pr.Messages)})
 	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
 
-	// Broadcast event
-	h.broadcastEvent(room, "message_new", map[string]any{"message": msg})
+	h.broadcastEvent(room, "message_new", map[string]any{"message": sysMsg})
 }
 
 func (h *Hub) handleGetMessages(c *Client, req types.Request) {
@@ -369,9 +1499,10 @@ func (h *Hub) handleGetMessages(c *Client, req types.Request) {
 		return
 	}
 
-	roomState := h.getOrCreateRoom(room)
+	roomState := h.roomOrEmpty(room)
 	roomState.TouchManagerHeartbeat(c.agentName)
-	filtered, totalCount := roomState.ReadMessages(data.AgentName, data.SinceID, data.Limit, data.UnreadOnly)
+	filtered, totalCount, nextID := roomState.ReadMessages(data.AgentName, data.SinceID, data.Limit, data.UnreadOnly)
+	h.emitMessagesRead(room, data.AgentName, filtered, nextID)
 
 	if len(filtered) == 0 {
 		respData, _ := json.Marshal(map[string]string{"text": "\U0001f4ed Yeni mesaj yok."})
@@ -381,7 +1512,7 @@ func (h *Hub) handleGetMessages(c *Client, req types.Request) {
 
 	var sb strings.Builder
 	if data.Limit > 0 && totalCount > data.Limit {
-		fmt.Fprintf(&sb, "\U0001f4ec Son %d mesaj (toplam %d):\n\n", data.Limit, totalCount)
+		fmt.Fprintf(&sb, "\U0001f4ec %d mesaj (toplam %d). Kalanlar için since_id=%d ile tekrar okuyun:\n\n", len(filtered), totalCount, nextID)
 	} else {
 		fmt.Fprintf(&sb, "\U0001f4ec %d mesaj:\n\n", len(filtered))
 	}
@@ -391,20 +1522,60 @@ func (h *Hub) handleGetMessages(c *Client, req types.Request) {
 		if msg.Type == "system" {
 			fmt.Fprintf(&sb, "[%s] %s\n", ts, sanitize(msg.Content))
 		} else if msg.To == "all" {
-			fmt.Fprintf(&sb, "[%s] %s \u2192 HERKESE: %s\n", ts, sanitize(msg.From), sanitize(msg.Content))
+			fmt.Fprintf(&sb, "[%s] %s \u2192 HERKESE: %s\n", ts, senderName(msg), sanitize(msg.Content))
 		} else if msg.OriginalTo != "" && msg.OriginalTo != msg.To {
 			fmt.Fprintf(&sb, "[%s] %s \u2192 %s (orijinal: %s): %s\n",
-				ts, sanitize(msg.From), sanitize(msg.To), sanitize(msg.OriginalTo), sanitize(msg.Content))
+				ts, senderName(msg), sanitize(msg.To), sanitize(msg.OriginalTo), sanitize(msg.Content))
+		} else {
+			fmt.Fprintf(&sb, "[%s] %s \u2192 %s: %s\n", ts, senderName(msg), sanitize(msg.To), sanitize(msg.Content))
+		}
+		if msg.EditedAt != "" {
+			fmt.Fprintf(&sb, "  (ID: %d, düzenlendi %s)\n\n", msg.ID, parseTimestamp(msg.EditedAt))
 		} else {
-			fmt.Fprintf(&sb, "[%s] %s \u2192 %s: %s\n", ts, sanitize(msg.From), sanitize(msg.To), sanitize(msg.Content))
+			fmt.Fprintf(&sb, "  (ID: %d)\n\n", msg.ID)
 		}
-		fmt.Fprintf(&sb, "  (ID: %d)\n\n", msg.ID)
 	}
 
 	respData, _ := json.Marshal(map[string]string{"text": sb.String()})
 	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
 }
 
+// senderName renders a message's sender for agents, marking messages a
+// person wrote so agents know a human is speaking.
+func senderName(msg types.Message) string {
+	if msg.FromHuman {
+		return sanitize(msg.From) + " (insan)"
+	}
+	return sanitize(msg.From)
+}
+
+// emitMessagesRead tells subscribers which messages an agent has just read,
+// so the desktop can confirm that its notifications were acted on. When the
+// read returned every message for the agent up to some ID, up_to_id is that
+// ID; it must come from the read itself so that a message stored after it is
+// not marked read.
+func (h *Hub) emitMessagesRead(room, agentName string, read []types.Message, upTo int) {
+	ids := make([]int, 0, len(read))
+	for _, msg := range read {
+		ids = append(ids, msg.ID)
+	}
+	h.broadcastEvent(room, "messages_read", map[string]any{
+		"agent_name":  agentName,
+		"message_ids": ids,
+		"up_to_id":    upTo,
+	})
+}
+
+func highestID(msgs []types.Message) int {
+	highest := 0
+	for _, msg := range msgs {
+		if msg.ID > highest {
+			highest = msg.ID
+		}
+	}
+	return highest
+}
+
 func (h *Hub) handleGetAllMessages(c *Client, req types.Request) {
 	var data struct {
 		SinceID int `json:"since_id"`
@@ -414,7 +1585,7 @@ func (h *Hub) handleGetAllMessages(c *Client, req types.Request) {
 	json.Unmarshal(req.Data, &data)
 
 	room := h.resolveRoom(req.Room)
-	roomState := h.getOrCreateRoom(room)
+	roomState := h.roomOrEmpty(room)
 
 	// Only the active manager or authorized desktop app can read all messages.
 	if c.agentName == "" {
@@ -435,6 +1606,13 @@ func (h *Hub) handleGetAllMessages(c *Client, req types.Request) {
 		roomState.TouchManagerHeartbeat(c.agentName)
 	}
 	filtered, totalCount := roomState.ReadAllMessages(data.SinceID, data.Limit)
+	if c.agentName != "" {
+		upTo := 0
+		if len(filtered) >= totalCount {
+			upTo = highestID(filtered)
+		}
+		h.emitMessagesRead(room, c.agentName, filtered, upTo)
+	}
 
 	if len(filtered) == 0 {
 		respData, _ := json.Marshal(map[string]string{"text": "\U0001f4ed Yeni mesaj yok."})
@@ -460,9 +1638,9 @@ func (h *Hub) handleGetAllMessages(c *Client, req types.Request) {
 			}
 			if msg.OriginalTo != "" && msg.OriginalTo != msg.To {
 				fmt.Fprintf(&sb, "[%s] #%d %s \u2192 %s (orijinal: %s): %s\n",
-					ts, msg.ID, sanitize(msg.From), sanitize(msg.To), sanitize(msg.OriginalTo), sanitize(contentPreview))
+					ts, msg.ID, senderName(msg), sanitize(msg.To), sanitize(msg.OriginalTo), sanitize(contentPreview))
 			} else {
-				fmt.Fprintf(&sb, "[%s] #%d %s \u2192 %s: %s\n", ts, msg.ID, sanitize(msg.From), sanitize(msg.To), sanitize(contentPreview))
+				fmt.Fprintf(&sb, "[%s] #%d %s \u2192 %s: %s\n", ts, msg.ID, senderName(msg), sanitize(msg.To), sanitize(contentPreview))
 			}
 		}
 		sb.WriteString("\n")
@@ -479,7 +1657,7 @@ func (h *Hub) handleListAgents(c *Client, req types.Request) {
 	json.Unmarshal(req.Data, &data)
 
 	room := h.resolveRoom(req.Room)
-	roomState := h.getOrCreateRoom(room)
+	roomState := h.roomOrEmpty(room)
 	if c.agentName != "" {
 		roomState.TouchManagerHeartbeat(c.agentName)
 	}
@@ -498,10 +1676,19 @@ func (h *Hub) handleListAgents(c *Client, req types.Request) {
 		if name == data.AgentName {
 			marker = " (sen)"
 		}
+		if info.Human {
+			marker += " (insan)"
+		}
 		fmt.Fprintf(&sb, "  \u2022 %s%s", sanitize(name), marker)
 		if info.Role != "" {
 			fmt.Fprintf(&sb, " - %s", sanitize(info.Role))
 		}
+		if info.Capabilities != nil {
+			fmt.Fprintf(&sb, "\n    Yetenekler: %s", strings.Join(info.Capabilities.All(), ", "))
+		}
+		if info.Status != "" {
+			fmt.Fprintf(&sb, "\n    Durum: %s", formatStatus(info))
+		}
 		joined := strings.Split(info.JoinedAt, "T")[0]
 		fmt.Fprintf(&sb, "\n    Katılım: %s\n", joined)
 	}
@@ -522,20 +1709,24 @@ func (h *Hub) handleLeaveRoom(c *Client, req types.Request) {
 		c.sendError(req.ID, req.Type, err.Error())
 		return
 	}
-	if c.agentName == "" || c.joinedRoom == "" {
-		c.sendError(req.ID, req.Type, "önce join_room çağırmalısınız")
-		return
-	}
-	if data.AgentName != c.agentName {
-		c.sendError(req.ID, req.Type, "yalnızca kendi adınızla leave_room çağırabilirsiniz")
-		return
-	}
-	if c.joinedRoom != room {
-		c.sendError(req.ID, req.Type, fmt.Sprintf("yalnızca katıldığınız odadan ayrılabilirsiniz: %s", c.joinedRoom))
-		return
+	roomState := h.roomOrEmpty(room)
+	// The desktop removes its human participants without having joined.
+	humanLeave := c.isDesktopAuthorized() && roomState.IsHuman(data.AgentName)
+	if !humanLeave {
+		if c.agentName == "" || c.joinedRoom == "" {
+			c.sendError(req.ID, req.Type, "önce join_room çağırmalısınız")
+			return
+		}
+		if data.AgentName != c.agentName {
+			c.sendError(req.ID, req.Type, "yalnızca kendi adınızla leave_room çağırabilirsiniz")
+			return
+		}
+		if c.joinedRoom != room {
+			c.sendError(req.ID, req.Type, fmt.Sprintf("yalnızca katıldığınız odadan ayrılabilirsiniz: %s", c.joinedRoom))
+			return
+		}
 	}
 
-	roomState := h.getOrCreateRoom(room)
 	sysMsg, found := roomState.Leave(data.AgentName)
 
 	if !found {
@@ -546,8 +1737,10 @@ func (h *Hub) handleLeaveRoom(c *Client, req types.Request) {
 
 	respData, _ := json.Marshal(map[string]string{"text": fmt.Sprintf("\U0001f44b '%s' odadan ayrıldı.", data.AgentName)})
 	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
-	c.agentName = ""
-	c.joinedRoom = ""
+	if !humanLeave {
+		c.agentName = ""
+		c.joinedRoom = ""
+	}
 
 	agents := roomState.GetAgents()
 	h.broadcastEvent(room, "message_new", map[string]any{"message": sysMsg})
@@ -556,6 +1749,10 @@ func (h *Hub) handleLeaveRoom(c *Client, req types.Request) {
 
 func (h *Hub) handleClearRoom(c *Client, req types.Request) {
 	room := h.resolveRoom(req.Room)
+	roomState, ok := h.requireRoom(c, req, room)
+	if !ok {
+		return
+	}
 
 	// Only authorized desktop app or active manager can clear a room.
 	if !c.isDesktopAuthorized() {
@@ -568,7 +1765,6 @@ func (h *Hub) handleClearRoom(c *Client, req types.Request) {
 			return
 		}
 
-		roomState := h.getOrCreateRoom(room)
 		activeManager := roomState.GetActiveManager()
 		if activeManager == "" || c.agentName != activeManager {
 			c.sendError(req.ID, req.Type, "yalnızca aktif manager veya yetkili desktop odayı temizleyebilir")
@@ -577,7 +1773,6 @@ func (h *Hub) handleClearRoom(c *Client, req types.Request) {
 		roomState.TouchManagerHeartbeat(c.agentName)
 	}
 
-	roomState := h.getOrCreateRoom(room)
 	roomState.Clear()
 
 	text := fmt.Sprintf("\U0001f9f9 '%s' odası temizlendi. Tüm mesajlar ve agent kayıtları silindi.", room)
@@ -611,7 +1806,7 @@ func (h *Hub) handleGetLastMessageID(c *Client, req types.Request) {
 		}
 	}
 
-	roomState := h.getOrCreateRoom(room)
+	roomState := h.roomOrEmpty(room)
 	if c.agentName != "" {
 		roomState.TouchManagerHeartbeat(c.agentName)
 	}
@@ -633,7 +1828,7 @@ func (h *Hub) handleGetAgents(c *Client, req types.Request) {
 	}
 
 	room := h.resolveRoom(req.Room)
-	roomState := h.getOrCreateRoom(room)
+	roomState := h.roomOrEmpty(room)
 	agents := roomState.GetAgents()
 
 	respData, _ := json.Marshal(map[string]any{"agents": agents})
@@ -648,7 +1843,7 @@ func (h *Hub) handleGetMessagesRaw(c *Client, req types.Request) {
 	}
 
 	room := h.resolveRoom(req.Room)
-	roomState := h.getOrCreateRoom(room)
+	roomState := h.roomOrEmpty(room)
 	messages := roomState.GetMessages()
 
 	respData, _ := json.Marshal(map[string]any{"messages": messages})
@@ -656,6 +1851,15 @@ func (h *Hub) handleGetMessagesRaw(c *Client, req types.Request) {
 }
 
 func (h *Hub) handleListRooms(c *Client, req types.Request) {
+	var data struct {
+		Archived bool `json:"archived"`
+	}
+	json.Unmarshal(req.Data, &data)
+	if data.Archived {
+		h.listArchivedRooms(c, req)
+		return
+	}
+
 	h.mu.RLock()
 	infos := ListRoomInfos(h.rooms)
 	defaultRoom := h.defaultRoom
@@ -680,3 +1884,22 @@ func (h *Hub) handleListRooms(c *Client, req types.Request) {
 	respData, _ := json.Marshal(map[string]string{"text": sb.String()})
 	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
 }
+
+// listArchivedRooms answers list_rooms(archived=true).
+func (h *Hub) listArchivedRooms(c *Client, req types.Request) {
+	infos := h.archivedRooms()
+	if len(infos) == 0 {
+		respData, _ := json.Marshal(map[string]string{"text": "\U0001f5c4\ufe0f Arşivlenmiş oda yok."})
+		c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\U0001f5c4\ufe0f Arşivlenmiş odalar (%d):\n\n", len(infos))
+	for _, r := range infos {
+		fmt.Fprintf(&sb, "  \u2022 %s - %d mesaj\n", r.Name, r.Messages)
+	}
+
+	respData, _ := json.Marshal(map[string]string{"text": sb.String()})
+	c.sendJSON(types.Response{ID: req.ID, RequestType: req.Type, Success: true, Data: respData})
+}
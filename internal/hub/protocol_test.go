@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io"
 	"log"
+	"strings"
 	"testing"
 	"time"
 
@@ -491,3 +492,767 @@ func TestHandleSetManager_RequiresDesktopAuth(t *testing.T) {
 		t.Fatalf("expected configured manager to be manager, got %q", got)
 	}
 }
+
+func TestHandleSendMessage_PriorityValidated(t *testing.T) {
+	h, c := newTestHubClient()
+
+	h.handleRequest(c, types.Request{
+		ID:   "join-1",
+		Type: "join_room",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "alice"}),
+	})
+	_ = readResponse(t, c, "join_room")
+
+	h.handleRequest(c, types.Request{
+		ID:   "msg-bad",
+		Type: "send_message",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{
+			"from":     "alice",
+			"to":       "bob",
+			"content":  "hi",
+			"priority": "critical",
+		}),
+	})
+	if resp := readResponse(t, c, "send_message"); resp.Success {
+		t.Fatalf("expected unknown priority to be rejected")
+	}
+
+	h.handleRequest(c, types.Request{
+		ID:   "msg-ok",
+		Type: "send_message",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{
+			"from":     "alice",
+			"to":       "bob",
+			"content":  "hi",
+			"priority": " URGENT ",
+		}),
+	})
+	if resp := readResponse(t, c, "send_message"); !resp.Success {
+		t.Fatalf("expected normalized priority to be accepted: %s", resp.Error)
+	}
+	messages := h.getOrCreateRoom("r1").GetMessages()
+	if last := messages[len(messages)-1]; last.Priority != "urgent" {
+		t.Fatalf("expected stored priority=urgent, got %q", last.Priority)
+	}
+}
+
+func TestHandleSendMessage_DelayedIsScheduled(t *testing.T) {
+	h, c := newTestHubClient()
+
+	h.handleRequest(c, types.Request{
+		ID:   "join-1",
+		Type: "join_room",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "alice"}),
+	})
+	_ = readResponse(t, c, "join_room")
+
+	h.handleRequest(c, types.Request{
+		ID:   "msg-both",
+		Type: "send_message",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{
+			"from":       "alice",
+			"to":         "bob",
+			"content":    "run e2e",
+			"delay":      "10m",
+			"deliver_at": "2099-01-01T10:00:00",
+		}),
+	})
+	if resp := readResponse(t, c, "send_message"); resp.Success {
+		t.Fatalf("expected deliver_at+delay to be rejected")
+	}
+
+	roomState := h.getOrCreateRoom("r1")
+	before := len(roomState.GetMessages())
+
+	h.handleRequest(c, types.Request{
+		ID:   "msg-delay",
+		Type: "send_message",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{
+			"from":    "alice",
+			"to":      "bob",
+			"content": "run e2e",
+			"delay":   "10m",
+		}),
+	})
+	resp := readResponse(t, c, "send_message")
+	if !resp.Success {
+		t.Fatalf("expected scheduled send success, got error=%s", resp.Error)
+	}
+	if got := len(roomState.GetMessages()); got != before {
+		t.Fatalf("scheduled message must not be stored before delivery, got %d messages (was %d)", got, before)
+	}
+
+	pending := h.scheduler.list("r1", "")
+	if len(pending) != 1 {
+		t.Fatalf("expected 1 scheduled message, got %d", len(pending))
+	}
+
+	// Not yet due.
+	h.fireDueScheduled(time.Now())
+	if got := len(roomState.GetMessages()); got != before {
+		t.Fatalf("message fired too early")
+	}
+
+	h.fireDueScheduled(pending[0].NextRun)
+	messages := roomState.GetMessages()
+	if len(messages) != before+1 {
+		t.Fatalf("expected scheduled message to be delivered")
+	}
+	if last := messages[len(messages)-1]; last.From != "alice" || last.To != "bob" || last.Content != "run e2e" {
+		t.Fatalf("unexpected delivered message: %+v", last)
+	}
+	if left := h.scheduler.list("r1", ""); len(left) != 0 {
+		t.Fatalf("one-shot message should be removed after delivery, got %d", len(left))
+	}
+}
+
+func TestHandleSendMessage_RecurringRearms(t *testing.T) {
+	h, c := newTestHubClient()
+
+	h.handleRequest(c, types.Request{
+		ID:   "join-1",
+		Type: "join_room",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "alice"}),
+	})
+	_ = readResponse(t, c, "join_room")
+
+	h.handleRequest(c, types.Request{
+		ID:   "msg-every",
+		Type: "send_message",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{
+			"from":     "alice",
+			"content":  "status?",
+			"schedule": "@every 1h",
+		}),
+	})
+	if resp := readResponse(t, c, "send_message"); !resp.Success {
+		t.Fatalf("expected recurring send success, got error=%s", resp.Error)
+	}
+
+	first := h.scheduler.list("r1", "")[0]
+	h.fireDueScheduled(first.NextRun)
+
+	pending := h.scheduler.list("r1", "")
+	if len(pending) != 1 {
+		t.Fatalf("recurring message should stay scheduled, got %d", len(pending))
+	}
+	if !pending[0].NextRun.After(first.NextRun) || pending[0].RunCount != 1 {
+		t.Fatalf("expected next run to advance, got %+v", pending[0])
+	}
+}
+
+func TestHandleCancelScheduled_RequiresOwnerOrManager(t *testing.T) {
+	h, alice := newTestHubClient()
+	bob := &Client{hub: h, send: make(chan []byte, 64), rooms: make(map[string]bool)}
+
+	for _, tc := range []struct {
+		c    *Client
+		name string
+	}{{alice, "alice"}, {bob, "bob"}} {
+		h.handleRequest(tc.c, types.Request{
+			ID:   "join-" + tc.name,
+			Type: "join_room",
+			Room: "r1",
+			Data: mustRawJSON(t, map[string]any{"agent_name": tc.name}),
+		})
+		_ = readResponse(t, tc.c, "join_room")
+	}
+
+	h.handleRequest(alice, types.Request{
+		ID:   "msg-delay",
+		Type: "send_message",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"from": "alice", "content": "later", "delay": "300"}),
+	})
+	_ = readResponse(t, alice, "send_message")
+	id := h.scheduler.list("r1", "")[0].ID
+
+	// bob sees nothing of alice's and cannot cancel it.
+	h.handleRequest(bob, types.Request{ID: "list-bob", Type: "list_scheduled", Room: "r1"})
+	var listed struct {
+		Scheduled []ScheduledMessage `json:"scheduled"`
+	}
+	json.Unmarshal(readResponse(t, bob, "list_scheduled").Data, &listed)
+	if len(listed.Scheduled) != 0 {
+		t.Fatalf("bob should not see alice's scheduled messages")
+	}
+
+	h.handleRequest(bob, types.Request{
+		ID:   "cancel-bob",
+		Type: "cancel_scheduled",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"scheduled_id": id}),
+	})
+	if resp := readResponse(t, bob, "cancel_scheduled"); resp.Success {
+		t.Fatalf("expected non-owner cancel to be rejected")
+	}
+
+	h.handleRequest(alice, types.Request{
+		ID:   "cancel-alice",
+		Type: "cancel_scheduled",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"scheduled_id": id}),
+	})
+	if resp := readResponse(t, alice, "cancel_scheduled"); !resp.Success {
+		t.Fatalf("expected owner cancel success, got error=%s", resp.Error)
+	}
+	if left := h.scheduler.list("r1", ""); len(left) != 0 {
+		t.Fatalf("expected scheduled message to be removed")
+	}
+}
+
+func TestHandleRetractMessage_SenderOrManagerOnly(t *testing.T) {
+	h, alice := newTestHubClient()
+	bob := &Client{hub: h, send: make(chan []byte, 64), rooms: make(map[string]bool)}
+
+	for _, tc := range []struct {
+		c    *Client
+		name string
+	}{{alice, "alice"}, {bob, "bob"}} {
+		h.handleRequest(tc.c, types.Request{
+			ID:   "join-" + tc.name,
+			Type: "join_room",
+			Room: "r1",
+			Data: mustRawJSON(t, map[string]any{"agent_name": tc.name}),
+		})
+		_ = readResponse(t, tc.c, "join_room")
+	}
+
+	h.handleRequest(alice, types.Request{
+		ID:   "msg-1",
+		Type: "send_message",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"from": "alice", "to": "bob", "content": "hold off on merging", "ttl": "2h"}),
+	})
+	resp := readResponse(t, alice, "send_message")
+	var sent struct {
+		MessageID int `json:"message_id"`
+	}
+	json.Unmarshal(resp.Data, &sent)
+
+	roomState := h.getOrCreateRoom("r1")
+	if msgs := roomState.GetMessages(); msgs[len(msgs)-1].ExpiresAt == "" {
+		t.Fatalf("expected ttl to set expires_at")
+	}
+
+	h.handleRequest(bob, types.Request{
+		ID:   "retract-bob",
+		Type: "retract_message",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"message_id": sent.MessageID}),
+	})
+	if resp := readResponse(t, bob, "retract_message"); resp.Success {
+		t.Fatalf("expected non-sender retract to be rejected")
+	}
+
+	h.handleRequest(alice, types.Request{
+		ID:   "edit-alice",
+		Type: "edit_message",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"message_id": sent.MessageID, "content": "merge is fine now"}),
+	})
+	if resp := readResponse(t, alice, "edit_message"); !resp.Success {
+		t.Fatalf("expected sender edit success, got error=%s", resp.Error)
+	}
+
+	desktop := h.NewLocalClient(true)
+	desktop.Do(types.Request{ID: "sub", Type: "subscribe", Data: mustRawJSON(t, map[string]any{"rooms": []string{"r1"}})})
+	desktop.Events()
+
+	h.handleRequest(alice, types.Request{
+		ID:   "retract-alice",
+		Type: "retract_message",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"message_id": sent.MessageID}),
+	})
+	if resp := readResponse(t, alice, "retract_message"); !resp.Success {
+		t.Fatalf("expected sender retract success, got error=%s", resp.Error)
+	}
+
+	got, _, _ := roomState.ReadMessages("bob", 0, 0, true)
+	for _, m := range got {
+		if m.ID == sent.MessageID {
+			t.Fatalf("retracted message should not be readable")
+		}
+	}
+
+	// Subscribers learn which message was retracted, not what it said.
+	evs := desktop.Events()
+	if len(evs) != 1 || evs[0].Event != "message_retracted" {
+		t.Fatalf("events = %+v", evs)
+	}
+	var ev struct {
+		Message types.Message `json:"message"`
+	}
+	json.Unmarshal(evs[0].Data, &ev)
+	if ev.Message.ID != sent.MessageID || !ev.Message.Retracted || ev.Message.Content != "" || len(ev.Message.Edits) != 0 {
+		t.Fatalf("retraction event leaks the message: %+v", ev.Message)
+	}
+}
+
+func TestHandleSendMessage_ListAndGroupRecipients(t *testing.T) {
+	h, manager := newTestHubClient()
+	h.setConfiguredManager("r1", "manager")
+
+	h.handleRequest(manager, types.Request{
+		ID:   "join-mgr",
+		Type: "join_room",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "manager", "role": "manager"}),
+	})
+	_ = readResponse(t, manager, "join_room")
+
+	h.handleRequest(manager, types.Request{
+		ID:   "group-1",
+		Type: "set_group",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"group": "reviewers", "members": []string{"db", "qa"}}),
+	})
+	if resp := readResponse(t, manager, "set_group"); !resp.Success {
+		t.Fatalf("expected manager set_group success, got error=%s", resp.Error)
+	}
+
+	h.handleRequest(manager, types.Request{
+		ID:   "msg-list",
+		Type: "send_message",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{
+			"from":    "manager",
+			"to":      []string{"backend", "@reviewers"},
+			"content": "schema change incoming",
+		}),
+	})
+	if resp := readResponse(t, manager, "send_message"); !resp.Success {
+		t.Fatalf("expected list send success, got error=%s", resp.Error)
+	}
+	messages := h.getOrCreateRoom("r1").GetMessages()
+	last := messages[len(messages)-1]
+	if strings.Join(last.Recipients, ",") != "backend,db,qa" {
+		t.Fatalf("expected resolved recipients backend,db,qa, got %v", last.Recipients)
+	}
+
+	h.handleRequest(manager, types.Request{
+		ID:   "msg-csv",
+		Type: "send_message",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"from": "manager", "to": "backend, @nope", "content": "x"}),
+	})
+	if resp := readResponse(t, manager, "send_message"); resp.Success {
+		t.Fatalf("expected unknown group to be rejected")
+	}
+
+	// Non-manager agents cannot define groups.
+	alice := &Client{hub: h, send: make(chan []byte, 64), rooms: make(map[string]bool)}
+	h.handleRequest(alice, types.Request{
+		ID:   "join-alice",
+		Type: "join_room",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "alice"}),
+	})
+	_ = readResponse(t, alice, "join_room")
+	h.handleRequest(alice, types.Request{
+		ID:   "group-alice",
+		Type: "set_group",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"group": "mine", "members": []string{"alice"}}),
+	})
+	if resp := readResponse(t, alice, "set_group"); resp.Success {
+		t.Fatalf("expected non-manager set_group to be rejected")
+	}
+}
+
+func TestHandleFindAgents_UsesJoinCapabilities(t *testing.T) {
+	h, c := newTestHubClient()
+
+	h.handleRequest(c, types.Request{
+		ID:   "join-1",
+		Type: "join_room",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{
+			"agent_name":   "dba",
+			"capabilities": map[string]any{"areas": []string{"db"}, "tools": []string{"psql"}},
+		}),
+	})
+	if resp := readResponse(t, c, "join_room"); !resp.Success {
+		t.Fatalf("expected join success, got error=%s", resp.Error)
+	}
+
+	h.handleRequest(c, types.Request{
+		ID:   "find-1",
+		Type: "find_agents",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"capability": "capability:DB"}),
+	})
+	resp := readResponse(t, c, "find_agents")
+	if !resp.Success {
+		t.Fatalf("expected find_agents success, got error=%s", resp.Error)
+	}
+	var data struct {
+		Agents []AgentMatch `json:"agents"`
+	}
+	json.Unmarshal(resp.Data, &data)
+	if len(data.Agents) != 1 || data.Agents[0].Name != "dba" {
+		t.Fatalf("expected dba to match, got %+v", data.Agents)
+	}
+}
+
+func TestHandleSetStatus_OwnAgentOnlyAndBroadcasts(t *testing.T) {
+	h, c := newTestHubClient()
+
+	h.handleRequest(c, types.Request{
+		ID:   "join-1",
+		Type: "join_room",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "backend"}),
+	})
+	if resp := readResponse(t, c, "join_room"); !resp.Success {
+		t.Fatalf("expected join success, got error=%s", resp.Error)
+	}
+
+	h.handleRequest(c, types.Request{
+		ID:   "status-other",
+		Type: "set_status",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "frontend", "status": "working"}),
+	})
+	if resp := readResponse(t, c, "set_status"); resp.Success {
+		t.Fatalf("expected setting another agent's status to fail")
+	}
+
+	h.handleRequest(c, types.Request{
+		ID:   "status-bad",
+		Type: "set_status",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "backend", "status": "napping"}),
+	})
+	if resp := readResponse(t, c, "set_status"); resp.Success {
+		t.Fatalf("expected unknown status to be rejected")
+	}
+
+	watcher := &Client{hub: h, send: make(chan []byte, 64), rooms: make(map[string]bool)}
+	h.mu.Lock()
+	h.subs["r1"] = map[*Client]bool{watcher: true}
+	h.mu.Unlock()
+
+	h.handleRequest(c, types.Request{
+		ID:   "status-1",
+		Type: "set_status",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "backend", "status": "Waiting-Review", "text": "PR #12", "eta": "30m"}),
+	})
+	resp := readResponse(t, c, "set_status")
+	if !resp.Success {
+		t.Fatalf("expected set_status success, got error=%s", resp.Error)
+	}
+
+	agent := h.getOrCreateRoom("r1").GetAgents()["backend"]
+	if agent.Status != types.StatusWaitingReview || agent.StatusText != "PR #12" || agent.StatusETA == "" {
+		t.Fatalf("expected status to be recorded, got %+v", agent)
+	}
+
+	select {
+	case raw := <-watcher.send:
+		var ev types.Event
+		if err := json.Unmarshal(raw, &ev); err != nil || ev.Event != "agent_status_changed" {
+			t.Fatalf("expected agent_status_changed event, got %s", raw)
+		}
+	default:
+		t.Fatalf("expected agent_status_changed to be broadcast")
+	}
+
+	h.handleRequest(c, types.Request{
+		ID:   "list-1",
+		Type: "list_agents",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "backend"}),
+	})
+	var listData struct {
+		Text string `json:"text"`
+	}
+	json.Unmarshal(readResponse(t, c, "list_agents").Data, &listData)
+	if !strings.Contains(listData.Text, "Durum: waiting_review (PR #12, ETA") {
+		t.Fatalf("expected status in list_agents, got %q", listData.Text)
+	}
+}
+
+func TestHandleReportActivity_DesktopOnlyRefreshesLiveness(t *testing.T) {
+	h, agentClient := newTestHubClient()
+	h.desktopAuthToken = "desktop-secret"
+
+	h.handleRequest(agentClient, types.Request{
+		ID:   "join-1",
+		Type: "join_room",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "backend"}),
+	})
+	if resp := readResponse(t, agentClient, "join_room"); !resp.Success {
+		t.Fatalf("expected join success, got error=%s", resp.Error)
+	}
+
+	report := func(c *Client, activity string) types.Response {
+		h.handleRequest(c, types.Request{
+			ID:   "act",
+			Type: "report_activity",
+			Room: "r1",
+			Data: mustRawJSON(t, map[string]any{"agent_name": "backend", "activity": activity}),
+		})
+		return readResponse(t, c, "report_activity")
+	}
+
+	if resp := report(agentClient, types.ActivityBusy); resp.Success {
+		t.Fatalf("expected report_activity from an agent client to fail")
+	}
+
+	desktop := &Client{hub: h, send: make(chan []byte, 64), rooms: make(map[string]bool)}
+	h.handleRequest(desktop, types.Request{
+		ID:   "id-desktop",
+		Type: "identify",
+		Data: mustRawJSON(t, map[string]any{"client_type": "desktop", "auth_token": "desktop-secret"}),
+	})
+	if resp := readResponse(t, desktop, "identify"); !resp.Success {
+		t.Fatalf("expected desktop identify to succeed: %s", resp.Error)
+	}
+
+	// Pretend the agent has been silent on the hub for longer than the stale timeout.
+	roomState := h.getOrCreateRoom("r1")
+	roomState.mu.Lock()
+	agent := roomState.agents["backend"]
+	agent.LastSeen = types.Now() - staleTimeout - 10
+	roomState.agents["backend"] = agent
+	roomState.mu.Unlock()
+
+	if resp := report(desktop, "sleeping"); resp.Success {
+		t.Fatalf("expected unknown activity to be rejected")
+	}
+	if resp := report(desktop, types.ActivityBusy); !resp.Success {
+		t.Fatalf("expected desktop report_activity to succeed: %s", resp.Error)
+	}
+
+	agents := roomState.ListAgents("")
+	if got, ok := agents["backend"]; !ok || got.Activity != types.ActivityBusy {
+		t.Fatalf("expected busy agent to survive stale cleanup, got %+v", agents)
+	}
+}
+
+func TestHandlePostSystem_DesktopOnly(t *testing.T) {
+	h, agentClient := newTestHubClient()
+	h.desktopAuthToken = "desktop-secret"
+
+	post := func(c *Client, content string) types.Response {
+		h.handleRequest(c, types.Request{
+			ID:   "sys",
+			Type: "post_system",
+			Room: "r1",
+			Data: mustRawJSON(t, map[string]any{"content": content}),
+		})
+		return readResponse(t, c, "post_system")
+	}
+
+	if resp := post(agentClient, "loop"); resp.Success {
+		t.Fatalf("expected post_system from an agent client to fail")
+	}
+
+	desktop := &Client{hub: h, send: make(chan []byte, 64), rooms: make(map[string]bool)}
+	h.handleRequest(desktop, types.Request{
+		ID:   "id-desktop",
+		Type: "identify",
+		Data: mustRawJSON(t, map[string]any{"client_type": "desktop", "auth_token": "desktop-secret"}),
+	})
+	if resp := readResponse(t, desktop, "identify"); !resp.Success {
+		t.Fatalf("expected desktop identify to succeed: %s", resp.Error)
+	}
+
+	if resp := post(desktop, "  "); resp.Success {
+		t.Fatalf("expected empty content to be rejected")
+	}
+	if resp := post(desktop, "Döngü algılandı"); resp.Success {
+		t.Fatalf("expected post_system to a missing room to fail")
+	}
+	h.getOrCreateRoom("r1")
+	if resp := post(desktop, "Döngü algılandı"); !resp.Success {
+		t.Fatalf("expected desktop post_system to succeed: %s", resp.Error)
+	}
+
+	msgs := h.getOrCreateRoom("r1").GetMessages()
+	if len(msgs) != 1 || msgs[0].Type != "system" || msgs[0].From != "SYSTEM" || msgs[0].Content != "Döngü algılandı" {
+		t.Fatalf("expected one system message, got %+v", msgs)
+	}
+}
+
+func TestHandleGetMessages_EmitsMessagesRead(t *testing.T) {
+	h, c := newTestHubClient()
+	join := func(cl *Client, name string) {
+		h.handleRequest(cl, types.Request{
+			ID:   "join-" + name,
+			Type: "join_room",
+			Room: "r1",
+			Data: mustRawJSON(t, map[string]any{"agent_name": name}),
+		})
+		if resp := readResponse(t, cl, "join_room"); !resp.Success {
+			t.Fatalf("expected join success, got error=%s", resp.Error)
+		}
+	}
+	join(c, "backend")
+	sender := &Client{hub: h, send: make(chan []byte, 64), rooms: make(map[string]bool)}
+	join(sender, "frontend")
+
+	h.handleRequest(sender, types.Request{
+		ID:   "send-1",
+		Type: "send_message",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"from": "frontend", "to": "backend", "content": "API ready?"}),
+	})
+	if resp := readResponse(t, sender, "send_message"); !resp.Success {
+		t.Fatalf("expected send success, got error=%s", resp.Error)
+	}
+
+	watcher := &Client{hub: h, send: make(chan []byte, 64), rooms: make(map[string]bool)}
+	h.mu.Lock()
+	h.subs["r1"] = map[*Client]bool{watcher: true}
+	h.mu.Unlock()
+
+	h.handleRequest(c, types.Request{
+		ID:   "read-1",
+		Type: "get_messages",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "backend"}),
+	})
+	if resp := readResponse(t, c, "get_messages"); !resp.Success {
+		t.Fatalf("expected get_messages success, got error=%s", resp.Error)
+	}
+
+	select {
+	case raw := <-watcher.send:
+		var ev types.Event
+		if err := json.Unmarshal(raw, &ev); err != nil || ev.Event != "messages_read" {
+			t.Fatalf("expected messages_read event, got %s", raw)
+		}
+		var data struct {
+			AgentName  string `json:"agent_name"`
+			MessageIDs []int  `json:"message_ids"`
+			UpToID     int    `json:"up_to_id"`
+		}
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			t.Fatal(err)
+		}
+		last := h.getOrCreateRoom("r1").GetLastMessageID("")
+		if data.AgentName != "backend" || len(data.MessageIDs) == 0 || data.UpToID != last {
+			t.Fatalf("unexpected messages_read payload %+v (last id %d)", data, last)
+		}
+	default:
+		t.Fatal("expected messages_read event")
+	}
+}
+
+func TestHandleHumanParticipant(t *testing.T) {
+	h, agentClient := newTestHubClient()
+	h.desktopAuthToken = "desktop-secret"
+	h.setConfiguredManager("r1", "manager")
+
+	joinHuman := func(c *Client, name string) types.Response {
+		h.handleRequest(c, types.Request{
+			ID:   "join-human",
+			Type: "join_room",
+			Room: "r1",
+			Data: mustRawJSON(t, map[string]any{"agent_name": name, "human": true}),
+		})
+		return readResponse(t, c, "join_room")
+	}
+	sendHuman := func(c *Client, bypass bool) types.Response {
+		h.handleRequest(c, types.Request{
+			ID:   "send-human",
+			Type: "send_message",
+			Room: "r1",
+			Data: mustRawJSON(t, map[string]any{
+				"from": "user", "to": "alice", "content": "please review", "human": true, "bypass_manager": bypass,
+			}),
+		})
+		return readResponse(t, c, "send_message")
+	}
+
+	if resp := joinHuman(agentClient, "user"); resp.Success {
+		t.Fatalf("expected human join from an agent client to fail")
+	}
+
+	desktop := &Client{hub: h, send: make(chan []byte, 64), rooms: make(map[string]bool)}
+	h.handleRequest(desktop, types.Request{
+		ID:   "id-desktop",
+		Type: "identify",
+		Data: mustRawJSON(t, map[string]any{"client_type": "desktop", "auth_token": "desktop-secret"}),
+	})
+	_ = readResponse(t, desktop, "identify")
+
+	if resp := sendHuman(desktop, false); resp.Success {
+		t.Fatalf("expected human send before join to fail")
+	}
+	if resp := joinHuman(desktop, "user"); !resp.Success {
+		t.Fatalf("expected desktop human join to succeed: %s", resp.Error)
+	}
+	if resp := joinHuman(desktop, "user"); !resp.Success {
+		t.Fatalf("expected repeated human join to be a no-op: %s", resp.Error)
+	}
+	if desktop.joinedRoom != "" || desktop.agentName != "" {
+		t.Fatalf("human join must not bind the desktop connection, got room=%q agent=%q", desktop.joinedRoom, desktop.agentName)
+	}
+
+	manager := &Client{hub: h, send: make(chan []byte, 64), rooms: make(map[string]bool)}
+	h.handleRequest(manager, types.Request{
+		ID:   "join-mgr",
+		Type: "join_room",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "manager", "role": "manager"}),
+	})
+	_ = readResponse(t, manager, "join_room")
+
+	roomState := h.getOrCreateRoom("r1")
+	if resp := sendHuman(desktop, false); !resp.Success {
+		t.Fatalf("expected human send to succeed: %s", resp.Error)
+	}
+	msgs := roomState.GetMessages()
+	last := msgs[len(msgs)-1]
+	if last.To != "manager" || !last.FromHuman {
+		t.Fatalf("expected human message routed via manager with from_human, got %+v", last)
+	}
+
+	if resp := sendHuman(desktop, true); !resp.Success {
+		t.Fatalf("expected bypassing send to succeed: %s", resp.Error)
+	}
+	msgs = roomState.GetMessages()
+	last = msgs[len(msgs)-1]
+	if last.To != "alice" || last.RoutedByManager || !last.FromHuman {
+		t.Fatalf("expected human message delivered directly, got %+v", last)
+	}
+
+	if !roomState.GetAgents()["user"].Human {
+		t.Fatalf("expected human flag on the participant")
+	}
+}
+
+func TestHandleSendMessage_BypassManagerRequiresHuman(t *testing.T) {
+	h, c := newTestHubClient()
+	h.handleRequest(c, types.Request{
+		ID:   "join",
+		Type: "join_room",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "alice"}),
+	})
+	_ = readResponse(t, c, "join_room")
+
+	h.handleRequest(c, types.Request{
+		ID:   "send",
+		Type: "send_message",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"from": "alice", "to": "bob", "content": "hi", "bypass_manager": true}),
+	})
+	if resp := readResponse(t, c, "send_message"); resp.Success {
+		t.Fatalf("expected bypass_manager from an agent to be rejected")
+	}
+}
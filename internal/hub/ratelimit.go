@@ -0,0 +1,179 @@
+package hub
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"desktop/internal/types"
+)
+
+// RateLimits configures send_message throttling. Zero rates disable a limit.
+type RateLimits struct {
+	AgentPerMinute      float64 // messages an agent may post per minute
+	AgentBurst          int
+	RoomPerMinute       float64 // messages all agents of a room may post per minute
+	RoomBurst           int
+	AgentBytesPerMinute float64 // content volume quota per agent
+	AgentBytesBurst     int
+}
+
+// DefaultRateLimits leave room for busy but healthy teams while stopping an
+// agent stuck in a loop long before it evicts the room's history.
+var DefaultRateLimits = RateLimits{
+	AgentPerMinute:      30,
+	AgentBurst:          20,
+	RoomPerMinute:       120,
+	RoomBurst:           60,
+	AgentBytesPerMinute: 64000,
+	AgentBytesBurst:     2 * maxFieldLength,
+}
+
+// Rate limit scopes reported with rate_limited errors.
+const (
+	rateScopeAgent  = "agent"
+	rateScopeRoom   = "room"
+	rateScopeVolume = "volume"
+)
+
+// tokenBucket refills continuously at a fixed rate up to its burst size.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time, perMinute float64, burst int) {
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else if elapsed := now.Sub(b.last).Minutes(); elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+elapsed*perMinute)
+	}
+	b.last = now
+}
+
+// wait returns how long until n tokens are available (0 if they are).
+func (b *tokenBucket) wait(n, perMinute float64) time.Duration {
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / perMinute * float64(time.Minute))
+}
+
+// rateLimiter throttles send_message per agent, per room and by volume.
+type rateLimiter struct {
+	mu     sync.Mutex
+	limits RateLimits
+	agents map[string]*tokenBucket // room + "\x00" + agent
+	volume map[string]*tokenBucket // room + "\x00" + agent
+	rooms  map[string]*tokenBucket
+	stats  map[string]*types.RateStats
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	return &rateLimiter{
+		limits: limits,
+		agents: make(map[string]*tokenBucket),
+		volume: make(map[string]*tokenBucket),
+		rooms:  make(map[string]*tokenBucket),
+		stats:  make(map[string]*types.RateStats),
+	}
+}
+
+func bucketFor(m map[string]*tokenBucket, key string) *tokenBucket {
+	b := m[key]
+	if b == nil {
+		b = &tokenBucket{}
+		m[key] = b
+	}
+	return b
+}
+
+// allow takes tokens for one message of size bytes, or reports which scope is
+// exhausted and when to retry. Nothing is consumed when any scope refuses.
+func (rl *rateLimiter) allow(room, agent string, size int, now time.Time) (time.Duration, string, bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	l := rl.limits
+	key := room + "\x00" + agent
+	type check struct {
+		bucket    *tokenBucket
+		perMinute float64
+		burst     int
+		n         float64
+		scope     string
+	}
+	var checks []check
+	if l.AgentPerMinute > 0 {
+		checks = append(checks, check{bucketFor(rl.agents, key), l.AgentPerMinute, l.AgentBurst, 1, rateScopeAgent})
+	}
+	if l.RoomPerMinute > 0 {
+		checks = append(checks, check{bucketFor(rl.rooms, room), l.RoomPerMinute, l.RoomBurst, 1, rateScopeRoom})
+	}
+	if l.AgentBytesPerMinute > 0 && size > 0 {
+		checks = append(checks, check{bucketFor(rl.volume, key), l.AgentBytesPerMinute, l.AgentBytesBurst, float64(size), rateScopeVolume})
+	}
+
+	var retry time.Duration
+	scope := ""
+	for _, c := range checks {
+		c.bucket.refill(now, c.perMinute, c.burst)
+		if w := c.bucket.wait(c.n, c.perMinute); w > retry {
+			retry, scope = w, c.scope
+		}
+	}
+
+	stats := rl.statsLocked(room)
+	agentStats := stats.Agents[agent]
+	if scope != "" {
+		at := now.Format(time.RFC3339)
+		stats.Total.Limited++
+		stats.Total.LastLimited = at
+		agentStats.Limited++
+		agentStats.LastLimited = at
+		stats.Agents[agent] = agentStats
+		return retry, scope, false
+	}
+	for _, c := range checks {
+		c.bucket.tokens -= c.n
+	}
+	stats.Total.Allowed++
+	stats.Total.Bytes += int64(size)
+	agentStats.Allowed++
+	agentStats.Bytes += int64(size)
+	stats.Agents[agent] = agentStats
+	return 0, "", true
+}
+
+func (rl *rateLimiter) statsLocked(room string) *types.RateStats {
+	s := rl.stats[room]
+	if s == nil {
+		s = &types.RateStats{Room: room, Agents: make(map[string]types.RateCounter)}
+		rl.stats[room] = s
+	}
+	return s
+}
+
+// snapshot returns a copy of a room's counters.
+func (rl *rateLimiter) snapshot(room string) types.RateStats {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	s := rl.statsLocked(room)
+	out := types.RateStats{Room: room, Total: s.Total, Agents: make(map[string]types.RateCounter, len(s.Agents))}
+	for name, c := range s.Agents {
+		out.Agents[name] = c
+	}
+	return out
+}
+
+// setLimits replaces the limits; existing buckets keep their tokens.
+func (rl *rateLimiter) setLimits(limits RateLimits) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.limits = limits
+}
+
+// SetRateLimits replaces the hub's send_message rate limits.
+func (h *Hub) SetRateLimits(limits RateLimits) {
+	h.limiter.setLimits(limits)
+}
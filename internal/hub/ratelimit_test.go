@@ -0,0 +1,107 @@
+package hub
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"desktop/internal/types"
+)
+
+func TestRateLimiter_BucketsRefillAndReportScope(t *testing.T) {
+	rl := newRateLimiter(RateLimits{AgentPerMinute: 60, AgentBurst: 2, RoomPerMinute: 600, RoomBurst: 3, AgentBytesPerMinute: 600, AgentBytesBurst: 100})
+	now := time.Unix(1_700_000_000, 0)
+
+	for i := 0; i < 2; i++ {
+		if _, _, ok := rl.allow("r1", "a", 10, now); !ok {
+			t.Fatalf("message %d should be allowed within the burst", i+1)
+		}
+	}
+	retry, scope, ok := rl.allow("r1", "a", 10, now)
+	if ok || scope != rateScopeAgent || retry != time.Second {
+		t.Fatalf("expected agent limit with 1s retry, got ok=%v scope=%s retry=%s", ok, scope, retry)
+	}
+
+	// Another agent still has its own bucket, but the room burst (3) is now spent.
+	if _, _, ok := rl.allow("r1", "b", 10, now); !ok {
+		t.Fatal("other agent should be allowed")
+	}
+	if _, scope, ok := rl.allow("r1", "c", 10, now); ok || scope != rateScopeRoom {
+		t.Fatalf("expected room limit, got ok=%v scope=%s", ok, scope)
+	}
+
+	// Volume: a message larger than the remaining byte budget is refused.
+	if _, scope, ok := rl.allow("r2", "a", 150, now); ok || scope != rateScopeVolume {
+		t.Fatalf("expected volume limit, got ok=%v scope=%s", ok, scope)
+	}
+
+	// One second later the agent bucket has refilled one token.
+	if _, _, ok := rl.allow("r1", "a", 10, now.Add(time.Second)); !ok {
+		t.Fatal("expected refill after retry_after")
+	}
+
+	stats := rl.snapshot("r1")
+	if stats.Total.Allowed != 4 || stats.Total.Limited != 2 || stats.Agents["a"].Limited != 1 || stats.Agents["a"].Bytes != 30 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestHandleSendMessage_RateLimited(t *testing.T) {
+	h, c := newTestHubClient()
+	h.SetRateLimits(RateLimits{AgentPerMinute: 1, AgentBurst: 2})
+
+	h.handleRequest(c, types.Request{
+		ID:   "join-1",
+		Type: "join_room",
+		Room: "r1",
+		Data: mustRawJSON(t, map[string]any{"agent_name": "looper"}),
+	})
+	if resp := readResponse(t, c, "join_room"); !resp.Success {
+		t.Fatalf("expected join success, got error=%s", resp.Error)
+	}
+
+	send := func() types.Response {
+		h.handleRequest(c, types.Request{
+			ID:   "send",
+			Type: "send_message",
+			Room: "r1",
+			Data: mustRawJSON(t, map[string]any{"from": "looper", "to": "all", "content": "again"}),
+		})
+		return readResponse(t, c, "send_message")
+	}
+	// Requests rejected by validation do not spend tokens.
+	for i := 0; i < 3; i++ {
+		h.handleRequest(c, types.Request{
+			ID:   "bad",
+			Type: "send_message",
+			Room: "r1",
+			Data: mustRawJSON(t, map[string]any{"from": "looper", "content": "later", "delay": "soon"}),
+		})
+		if resp := readResponse(t, c, "send_message"); resp.Success || resp.Code == types.ErrCodeRateLimited {
+			t.Fatalf("expected a validation error, got success=%v code=%q", resp.Success, resp.Code)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if resp := send(); !resp.Success {
+			t.Fatalf("send %d should succeed: %s", i+1, resp.Error)
+		}
+	}
+	resp := send()
+	if resp.Success || resp.Code != types.ErrCodeRateLimited {
+		t.Fatalf("expected rate_limited, got success=%v code=%q error=%q", resp.Success, resp.Code, resp.Error)
+	}
+	var data struct {
+		RetryAfter int    `json:"retry_after"`
+		Scope      string `json:"scope"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.RetryAfter < 1 || data.RetryAfter > 60 || data.Scope != rateScopeAgent || !strings.Contains(resp.Error, "saniye") {
+		t.Fatalf("unexpected rate limit payload %+v error=%q", data, resp.Error)
+	}
+	if n := len(h.getOrCreateRoom("r1").GetMessages()); n != 3 { // join system message + 2 sends
+		t.Fatalf("expected throttled message not to be stored, got %d messages", n)
+	}
+}
@@ -11,11 +11,12 @@ import (
 )
 
 const (
-	maxMessagesInRoom  = 500
-	truncateToMessages = 300
-	maxFieldLength     = 32000
-	staleTimeout       = 300 // seconds
-	managerTimeoutSec  = 300
+	maxMessagesInRoom   = 500
+	truncateToMessages  = 300
+	maxFieldLength      = 32000
+	maxStatusTextLength = 500
+	staleTimeout        = 300 // seconds
+	managerTimeoutSec   = 300
 )
 
 // RoomState holds in-memory state for a single chat room.
@@ -26,13 +27,19 @@ type RoomState struct {
 	dirty           bool
 	managerAgent    string
 	managerLastSeen float64
+	groups          map[string][]string     // group name → member agent names
+	loadedAt        time.Time               // creation or load time, the idle baseline of an empty room
+	readAhead       map[string]map[int]bool // agent → urgent message IDs returned ahead of its read cursor
 }
 
 // NewRoomState creates an empty room.
 func NewRoomState() *RoomState {
 	return &RoomState{
-		messages: []types.Message{},
-		agents:   make(map[string]types.Agent),
+		messages:  []types.Message{},
+		agents:    make(map[string]types.Agent),
+		groups:    make(map[string][]string),
+		loadedAt:  time.Now(),
+		readAhead: make(map[string]map[int]bool),
 	}
 }
 
@@ -40,12 +47,17 @@ func NewRoomState() *RoomState {
 type PersistedRoom struct {
 	Messages []types.Message        `json:"messages"`
 	Agents   map[string]types.Agent `json:"agents"`
+	Groups   map[string][]string    `json:"groups,omitempty"`
 }
 
 // SendOptions carries optional routing metadata.
 type SendOptions struct {
 	OriginalTo      string
 	RoutedByManager bool
+	ExpiresAt       string
+	Recipients      []string
+	FromHuman       bool
+	Via             string
 }
 
 // nextID returns the next message ID.
@@ -58,6 +70,11 @@ func (r *RoomState) nextID() int {
 
 // Join adds an agent to the room, returning the system message and current agents.
 func (r *RoomState) Join(agentName, role string) (types.Message, map[string]types.Agent, error) {
+	return r.JoinWithCapabilities(agentName, role, nil)
+}
+
+// JoinWithCapabilities adds an agent with declared capabilities to the room.
+func (r *RoomState) JoinWithCapabilities(agentName, role string, caps *types.Capabilities) (types.Message, map[string]types.Agent, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -75,16 +92,27 @@ func (r *RoomState) Join(agentName, role string) (types.Message, map[string]type
 		r.managerLastSeen = types.Now()
 	}
 
+	var agentCaps *types.Capabilities
+	if caps != nil {
+		if normalized := caps.Normalize(); !normalized.IsEmpty() {
+			agentCaps = &normalized
+		}
+	}
+
 	r.agents[agentName] = types.Agent{
-		Role:     role,
-		JoinedAt: types.Timestamp(),
-		LastSeen: types.Now(),
+		Role:         role,
+		JoinedAt:     types.Timestamp(),
+		LastSeen:     types.Now(),
+		Capabilities: agentCaps,
 	}
 
 	content := fmt.Sprintf("\U0001f7e2 %s odaya katıldı", agentName)
 	if role != "" {
 		content += fmt.Sprintf(" (Rol: %s)", role)
 	}
+	if agentCaps != nil {
+		content += fmt.Sprintf(" [%s]", strings.Join(agentCaps.All(), ", "))
+	}
 
 	sysMsg := types.Message{
 		ID:        r.nextID(),
@@ -101,6 +129,48 @@ func (r *RoomState) Join(agentName, role string) (types.Message, map[string]type
 	return sysMsg, agentsCopy, nil
 }
 
+// JoinHuman adds a human participant to the room. Joining again under the
+// same name is a no-op (joined reports false); an agent already using the
+// name is an error.
+func (r *RoomState) JoinHuman(name string) (sysMsg types.Message, agents map[string]types.Agent, joined bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cleanupStaleLocked()
+
+	if existing, exists := r.agents[name]; exists {
+		if !existing.Human {
+			return types.Message{}, nil, false, fmt.Errorf("agent adı '%s' bu odada zaten kullanımda", name)
+		}
+		return types.Message{}, r.copyAgentsLocked(), false, nil
+	}
+
+	r.agents[name] = types.Agent{
+		Role:     "human",
+		JoinedAt: types.Timestamp(),
+		LastSeen: types.Now(),
+		Human:    true,
+	}
+	sysMsg = types.Message{
+		ID:        r.nextID(),
+		From:      "SYSTEM",
+		To:        "all",
+		Content:   fmt.Sprintf("\U0001f464 %s (insan) odaya katıldı", name),
+		Timestamp: types.Timestamp(),
+		Type:      "system",
+	}
+	r.messages = append(r.messages, sysMsg)
+	r.dirty = true
+	return sysMsg, r.copyAgentsLocked(), true, nil
+}
+
+// IsHuman reports whether name is a human participant of the room.
+func (r *RoomState) IsHuman(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.agents[name].Human
+}
+
 // SendMessage adds a message to the room.
 func (r *RoomState) SendMessage(from, to, content string, expectsReply bool, priority string, opts SendOptions) (types.Message, error) {
 	r.mu.Lock()
@@ -128,6 +198,10 @@ func (r *RoomState) SendMessage(from, to, content string, expectsReply bool, pri
 		RoutedByManager: opts.RoutedByManager,
 		ExpectsReply:    expectsReply,
 		Priority:        priority,
+		ExpiresAt:       opts.ExpiresAt,
+		Recipients:      opts.Recipients,
+		FromHuman:       opts.FromHuman,
+		Via:             opts.Via,
 	}
 	r.messages = append(r.messages, msg)
 
@@ -140,8 +214,33 @@ func (r *RoomState) SendMessage(from, to, content string, expectsReply bool, pri
 	return msg, nil
 }
 
-// ReadMessages returns filtered messages for an agent.
-func (r *RoomState) ReadMessages(agentName string, sinceID, limit int, unreadOnly bool) ([]types.Message, int) {
+// PostSystem adds a SYSTEM message visible to every agent in the room.
+func (r *RoomState) PostSystem(content string) types.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg := types.Message{
+		ID:        r.nextID(),
+		From:      "SYSTEM",
+		To:        "all",
+		Content:   content,
+		Timestamp: types.Timestamp(),
+		Type:      "system",
+	}
+	r.messages = append(r.messages, msg)
+	if len(r.messages) > maxMessagesInRoom {
+		r.messages = r.messages[len(r.messages)-truncateToMessages:]
+	}
+	r.dirty = true
+	return msg
+}
+
+// ReadMessages returns filtered messages for an agent, the total number of
+// unread messages and a continuation cursor: every message for the agent with
+// an ID up to the cursor has been returned, so passing it as sinceID on the
+// next read loses nothing. Urgent messages returned ahead of the cursor are
+// remembered and not returned again.
+func (r *RoomState) ReadMessages(agentName string, sinceID, limit int, unreadOnly bool) ([]types.Message, int, int) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -152,25 +251,71 @@ func (r *RoomState) ReadMessages(agentName string, sinceID, limit int, unreadOnl
 		r.dirty = true
 	}
 
+	now := time.Now()
+	ahead := r.readAhead[agentName]
 	var filtered []types.Message
 	for _, msg := range r.messages {
-		if msg.ID <= sinceID {
+		if msg.ID <= sinceID || !msg.IsVisible(now) || ahead[msg.ID] {
 			continue
 		}
 		if unreadOnly && msg.From == agentName {
 			continue
 		}
-		if msg.To == "all" || msg.To == agentName || msg.Type == "system" {
+		if msg.IsAddressedTo(agentName) || msg.Type == "system" {
 			filtered = append(filtered, msg)
 		}
 	}
 
 	totalCount := len(filtered)
+	next := sinceID
+	if n := len(r.messages); n > 0 && r.messages[n-1].ID > next {
+		next = r.messages[n-1].ID
+	}
 	if limit > 0 && len(filtered) > limit {
-		filtered = filtered[len(filtered)-limit:]
+		filtered, next = oldestWithUrgent(filtered, limit)
 	}
+	for id := range ahead {
+		if id <= next {
+			delete(ahead, id)
+		}
+	}
+	for _, msg := range filtered {
+		if msg.ID > next {
+			if ahead == nil {
+				ahead = make(map[int]bool)
+				r.readAhead[agentName] = ahead
+			}
+			ahead[msg.ID] = true
+		}
+	}
+	return orderByPriority(filtered), totalCount, next
+}
 
-	return filtered, totalCount
+// oldestWithUrgent keeps the oldest limit messages plus every newer urgent
+// one, so urgent messages are never truncated away, and returns the ID of the
+// last message in the contiguous part as the continuation cursor.
+func oldestWithUrgent(msgs []types.Message, limit int) ([]types.Message, int) {
+	kept := make([]types.Message, limit, len(msgs))
+	copy(kept, msgs[:limit])
+	for _, msg := range msgs[limit:] {
+		if msg.Priority == types.PriorityUrgent {
+			kept = append(kept, msg)
+		}
+	}
+	return kept, msgs[limit-1].ID
+}
+
+// orderByPriority sorts messages urgent → normal → low, keeping ID order within
+// the same priority.
+func orderByPriority(msgs []types.Message) []types.Message {
+	sort.SliceStable(msgs, func(i, j int) bool {
+		ri, rj := types.PriorityRank(msgs[i].Priority), types.PriorityRank(msgs[j].Priority)
+		if ri != rj {
+			return ri < rj
+		}
+		return msgs[i].ID < msgs[j].ID
+	})
+	return msgs
 }
 
 // ReadAllMessages returns all messages after sinceID, optionally limited.
@@ -178,9 +323,10 @@ func (r *RoomState) ReadAllMessages(sinceID, limit int) ([]types.Message, int) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	now := time.Now()
 	var filtered []types.Message
 	for _, m := range r.messages {
-		if m.ID > sinceID {
+		if m.ID > sinceID && m.IsVisible(now) {
 			filtered = append(filtered, m)
 		}
 	}
@@ -193,6 +339,255 @@ func (r *RoomState) ReadAllMessages(sinceID, limit int) ([]types.Message, int) {
 	return filtered, totalCount
 }
 
+// EditMessage replaces a message's content, keeping the previous content in its
+// edit history. Unless privileged, only the original sender may edit.
+func (r *RoomState) EditMessage(id int, editor, content string, privileged bool) (types.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx, err := r.findEditableLocked(id, editor, privileged)
+	if err != nil {
+		return types.Message{}, err
+	}
+
+	msg := &r.messages[idx]
+	now := types.Timestamp()
+	msg.Edits = append(msg.Edits, types.MessageEdit{
+		Content:  msg.Content,
+		EditedAt: now,
+		EditedBy: editor,
+	})
+	msg.Content = content
+	msg.EditedAt = now
+	r.dirty = true
+	return *msg, nil
+}
+
+// RetractMessage marks a message as retracted so it is no longer returned by
+// reads. Unless privileged, only the original sender may retract.
+func (r *RoomState) RetractMessage(id int, by string, privileged bool) (types.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx, err := r.findEditableLocked(id, by, privileged)
+	if err != nil {
+		return types.Message{}, err
+	}
+
+	msg := &r.messages[idx]
+	msg.Retracted = true
+	msg.RetractedAt = types.Timestamp()
+	msg.RetractedBy = by
+	r.dirty = true
+	return *msg, nil
+}
+
+// findEditableLocked returns the index of a message that `by` may modify.
+// Must be called with mu held.
+func (r *RoomState) findEditableLocked(id int, by string, privileged bool) (int, error) {
+	for i := range r.messages {
+		if r.messages[i].ID != id {
+			continue
+		}
+		msg := r.messages[i]
+		if msg.Type == "system" {
+			return 0, fmt.Errorf("sistem mesajları değiştirilemez")
+		}
+		if msg.Retracted {
+			return 0, fmt.Errorf("mesaj zaten geri çekilmiş: %d", id)
+		}
+		if !privileged && msg.From != by {
+			return 0, fmt.Errorf("yalnızca gönderen veya aktif manager mesajı değiştirebilir")
+		}
+		return i, nil
+	}
+	return 0, fmt.Errorf("mesaj bulunamadı: %d", id)
+}
+
+// ResolveRecipients expands a recipient list (agent names and "@group"
+// references) into the message's To field and resolved recipient list.
+// A single plain agent name is kept as a regular direct message.
+func (r *RoomState) ResolveRecipients(entries []string, from string) (string, []string, error) {
+	if len(entries) == 0 || (len(entries) == 1 && entries[0] == "all") {
+		return "all", nil, nil
+	}
+	if len(entries) == 1 && !strings.HasPrefix(entries[0], "@") && !strings.HasPrefix(entries[0], types.CapabilityPrefix) {
+		return entries[0], nil, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var recipients []string
+	add := func(name string) {
+		if name == from || seen[name] {
+			return
+		}
+		seen[name] = true
+		recipients = append(recipients, name)
+	}
+	for _, e := range entries {
+		if e == "all" {
+			return "", nil, fmt.Errorf("\"all\" diğer alıcılarla birlikte kullanılamaz")
+		}
+		if capability, ok := strings.CutPrefix(e, types.CapabilityPrefix); ok {
+			name, err := r.bestAgentForLocked(capability, from)
+			if err != nil {
+				return "", nil, err
+			}
+			if len(entries) == 1 {
+				return name, nil, nil
+			}
+			add(name)
+			continue
+		}
+		if group, ok := strings.CutPrefix(e, "@"); ok {
+			members, exists := r.groups[group]
+			if !exists {
+				return "", nil, fmt.Errorf("grup bulunamadı: @%s", group)
+			}
+			for _, m := range members {
+				add(m)
+			}
+			continue
+		}
+		add(e)
+	}
+	if len(recipients) == 0 {
+		return "", nil, fmt.Errorf("alıcı listesi boş: %s", strings.Join(entries, ","))
+	}
+	return strings.Join(entries, ","), recipients, nil
+}
+
+// AgentMatch is an agent ranked by how well it matches a capability.
+type AgentMatch struct {
+	Name  string      `json:"name"`
+	Score int         `json:"score"`
+	Agent types.Agent `json:"agent"`
+}
+
+// FindAgents returns active agents matching a capability, best match first.
+// Available agents rank ahead of busy ones, and blocked or away agents last.
+// Agents whose role mentions the capability match with the lowest score.
+func (r *RoomState) FindAgents(capability, exclude string) []AgentMatch {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.findAgentsLocked(capability, exclude)
+}
+
+func (r *RoomState) findAgentsLocked(capability, exclude string) []AgentMatch {
+	capability = strings.ToLower(strings.TrimSpace(capability))
+	if capability == "" {
+		return nil
+	}
+
+	now := types.Now()
+	var matches []AgentMatch
+	for name, agent := range r.agents {
+		if name == exclude || now-agent.LastSeen >= float64(staleTimeout) {
+			continue
+		}
+		score := 0
+		if agent.Capabilities != nil {
+			score = agent.Capabilities.Score(capability)
+		}
+		if score == 0 && strings.Contains(strings.ToLower(agent.Role), capability) {
+			score = 1
+		}
+		if score > 0 {
+			matches = append(matches, AgentMatch{Name: name, Score: score, Agent: agent})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		ri, rj := types.StatusRank(matches[i].Agent.Status), types.StatusRank(matches[j].Agent.Status)
+		if ri != rj {
+			return ri < rj
+		}
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if matches[i].Agent.LastSeen != matches[j].Agent.LastSeen {
+			return matches[i].Agent.LastSeen > matches[j].Agent.LastSeen
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	return matches
+}
+
+// bestAgentForLocked picks the best agent for a capability. Must be called with mu held.
+func (r *RoomState) bestAgentForLocked(capability, exclude string) (string, error) {
+	matches := r.findAgentsLocked(capability, exclude)
+	if len(matches) == 0 {
+		return "", fmt.Errorf("'%s' yeteneğine sahip aktif agent bulunamadı", capability)
+	}
+	return matches[0].Name, nil
+}
+
+// SetStatus records an agent's self-reported status and refreshes its last_seen.
+// eta is an already-formatted timestamp or empty.
+func (r *RoomState) SetStatus(agentName, status, text, eta string) (types.Agent, map[string]types.Agent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	agent, ok := r.agents[agentName]
+	if !ok {
+		return types.Agent{}, nil, fmt.Errorf("agent '%s' bu odada değil", agentName)
+	}
+	agent.Status = status
+	agent.StatusText = text
+	agent.StatusETA = eta
+	agent.StatusUpdatedAt = types.Timestamp()
+	agent.LastSeen = types.Now()
+	r.agents[agentName] = agent
+	r.dirty = true
+
+	return agent, r.copyAgentsLocked(), nil
+}
+
+// ReportActivity records a PTY activity heartbeat from the desktop app. Every
+// heartbeat refreshes last_seen so agents busy on long tool runs are not reaped
+// as stale. changed reports whether the activity state differs from before.
+func (r *RoomState) ReportActivity(agentName, activity string) (agent types.Agent, changed bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	agent, ok := r.agents[agentName]
+	if !ok {
+		return types.Agent{}, false, fmt.Errorf("agent '%s' bu odada değil", agentName)
+	}
+	changed = agent.Activity != activity
+	agent.Activity = activity
+	agent.ActivityAt = types.Timestamp()
+	agent.LastSeen = types.Now()
+	r.agents[agentName] = agent
+	r.dirty = true
+	if r.getActiveManagerLocked() == agentName {
+		r.managerLastSeen = agent.LastSeen
+	}
+
+	return agent, changed, nil
+}
+
+// SetGroup defines or replaces a recipient group. Empty members deletes it.
+func (r *RoomState) SetGroup(name string, members []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(members) == 0 {
+		delete(r.groups, name)
+	} else {
+		r.groups[name] = append([]string(nil), members...)
+	}
+	r.dirty = true
+}
+
+// GetGroups returns a snapshot of the room's recipient groups.
+func (r *RoomState) GetGroups() map[string][]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.copyGroupsLocked()
+}
+
 // ListAgents returns active agents, cleaning up stale ones.
 func (r *RoomState) ListAgents(agentName string) map[string]types.Agent {
 	r.mu.Lock()
@@ -221,6 +616,7 @@ func (r *RoomState) Leave(agentName string) (types.Message, bool) {
 	}
 
 	delete(r.agents, agentName)
+	delete(r.readAhead, agentName)
 	if r.managerAgent == agentName {
 		r.managerAgent = ""
 		r.managerLastSeen = 0
@@ -292,11 +688,32 @@ func (r *RoomState) Clear() {
 	defer r.mu.Unlock()
 	r.messages = []types.Message{}
 	r.agents = make(map[string]types.Agent)
+	r.readAhead = make(map[string]map[int]bool)
 	r.managerAgent = ""
 	r.managerLastSeen = 0
 	r.dirty = true
 }
 
+// Seed fills an empty room with imported messages and groups. Imported
+// agents are not restored: they join again when they reconnect.
+func (r *RoomState) Seed(pr PersistedRoom) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.messages) > 0 {
+		return fmt.Errorf("oda boş değil (%d mesaj); içe aktarma yalnızca boş bir odaya yapılabilir", len(r.messages))
+	}
+	msgs := pr.Messages
+	if len(msgs) > maxMessagesInRoom {
+		msgs = msgs[len(msgs)-maxMessagesInRoom:]
+	}
+	r.messages = append([]types.Message{}, msgs...)
+	for name, members := range pr.Groups {
+		r.groups[name] = append([]string(nil), members...)
+	}
+	r.dirty = true
+	return nil
+}
+
 // GetLastMessageID returns the highest message ID.
 func (r *RoomState) GetLastMessageID(agentName string) int {
 	r.mu.Lock()
@@ -332,6 +749,21 @@ func (r *RoomState) GetMessages() []types.Message {
 	return out
 }
 
+// restore loads persisted state into a fresh room.
+func (r *RoomState) restore(pr PersistedRoom) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if pr.Messages != nil {
+		r.messages = pr.Messages
+	}
+	if pr.Agents != nil {
+		r.agents = pr.Agents
+	}
+	if pr.Groups != nil {
+		r.groups = pr.Groups
+	}
+}
+
 // Snapshot returns the current room state for persistence.
 func (r *RoomState) Snapshot() PersistedRoom {
 	r.mu.RLock()
@@ -341,6 +773,7 @@ func (r *RoomState) Snapshot() PersistedRoom {
 	return PersistedRoom{
 		Messages: msgs,
 		Agents:   r.copyAgentsLocked(),
+		Groups:   r.copyGroupsLocked(),
 	}
 }
 
@@ -358,6 +791,36 @@ func (r *RoomState) MarkClean() {
 	r.dirty = false
 }
 
+// IdleSince returns when the room last saw activity: its newest message or
+// an agent heartbeat. Only a room without either falls back to its creation
+// or load time, so restarting the hub does not reset a room's idle time.
+// busy is true while an agent (not a human) is still active in the room.
+func (r *RoomState) IdleSince() (since time.Time, busy bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := types.Now()
+	for _, info := range r.agents {
+		if info.Human {
+			continue
+		}
+		if now-info.LastSeen < float64(staleTimeout) {
+			return time.Time{}, true
+		}
+		if seen := time.Unix(0, int64(info.LastSeen*1e9)); seen.After(since) {
+			since = seen
+		}
+	}
+	if n := len(r.messages); n > 0 {
+		if t, err := types.ParseTime(r.messages[n-1].Timestamp); err == nil && t.After(since) {
+			since = t
+		}
+	}
+	if since.IsZero() {
+		since = r.loadedAt
+	}
+	return since, false
+}
+
 // Info returns agent count and message count for listing.
 func (r *RoomState) Info() (agentCount, messageCount int) {
 	r.mu.RLock()
@@ -390,7 +853,7 @@ func ListRoomInfos(rooms map[string]*RoomState) []RoomInfo {
 func (r *RoomState) cleanupStaleLocked() {
 	now := float64(time.Now().UnixNano()) / 1e9
 	for name, info := range r.agents {
-		if now-info.LastSeen >= float64(staleTimeout) {
+		if !info.Human && now-info.LastSeen >= float64(staleTimeout) {
 			delete(r.agents, name)
 			r.dirty = true
 		}
@@ -424,6 +887,14 @@ func (r *RoomState) copyAgentsLocked() map[string]types.Agent {
 	return cp
 }
 
+func (r *RoomState) copyGroupsLocked() map[string][]string {
+	cp := make(map[string][]string, len(r.groups))
+	for k, v := range r.groups {
+		cp[k] = append([]string(nil), v...)
+	}
+	return cp
+}
+
 func (r *RoomState) getActiveManagerLocked() string {
 	r.clearManagerIfStale()
 	return r.managerAgent
@@ -1,7 +1,9 @@
 package hub
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"desktop/internal/types"
 )
@@ -71,3 +73,237 @@ func TestRoomSendMessage_InterceptionMetadata(t *testing.T) {
 		t.Fatalf("expected to=manager, got %q", msg.To)
 	}
 }
+
+func TestRoomReadMessages_OrderedByPriority(t *testing.T) {
+	r := NewRoomState()
+
+	if _, _, err := r.Join("alice", "developer"); err != nil {
+		t.Fatalf("join should succeed: %v", err)
+	}
+	r.SendMessage("bob", "alice", "low one", true, "low", SendOptions{})
+	r.SendMessage("bob", "alice", "normal one", true, "normal", SendOptions{})
+	r.SendMessage("bob", "alice", "urgent one", true, "urgent", SendOptions{})
+
+	msgs, total, _ := r.ReadMessages("alice", 1, 0, true)
+	if total != 3 {
+		t.Fatalf("expected 3 messages, got %d", total)
+	}
+	want := []string{"urgent one", "normal one", "low one"}
+	for i, w := range want {
+		if msgs[i].Content != w {
+			t.Fatalf("position %d: want %q, got %q", i, w, msgs[i].Content)
+		}
+	}
+}
+
+func TestRoomReadMessages_LimitKeepsUrgent(t *testing.T) {
+	r := NewRoomState()
+
+	r.SendMessage("bob", "alice", "low 1", true, "low", SendOptions{})
+	r.SendMessage("bob", "alice", "normal 1", true, "normal", SendOptions{})
+	r.SendMessage("bob", "alice", "normal 2", true, "normal", SendOptions{})
+	r.SendMessage("bob", "alice", "urgent new", true, "urgent", SendOptions{})
+	r.SendMessage("bob", "alice", "low 2", true, "low", SendOptions{})
+
+	msgs, total, next := r.ReadMessages("alice", 0, 2, true)
+	if total != 5 {
+		t.Fatalf("expected total 5, got %d", total)
+	}
+	if got := contents(msgs); got != "urgent new,normal 1,low 1" {
+		t.Fatalf("first read = %s", got)
+	}
+
+	// Paging from the cursor delivers every message exactly once.
+	seen := map[string]int{}
+	for _, m := range msgs {
+		seen[m.Content]++
+	}
+	for i := 0; i < 3; i++ {
+		msgs, _, next = r.ReadMessages("alice", next, 2, true)
+		for _, m := range msgs {
+			seen[m.Content]++
+		}
+	}
+	for _, c := range []string{"low 1", "normal 1", "normal 2", "urgent new", "low 2"} {
+		if seen[c] != 1 {
+			t.Errorf("%q delivered %d times", c, seen[c])
+		}
+	}
+}
+
+func contents(msgs []types.Message) string {
+	var parts []string
+	for _, m := range msgs {
+		parts = append(parts, m.Content)
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestRoomReadMessages_HidesExpiredAndRetracted(t *testing.T) {
+	r := NewRoomState()
+
+	past := types.FormatTimestamp(time.Now().Add(-time.Minute))
+	future := types.FormatTimestamp(time.Now().Add(time.Hour))
+	expired, _ := r.SendMessage("alice", "bob", "hold off on merging", true, "normal", SendOptions{ExpiresAt: past})
+	live, _ := r.SendMessage("alice", "bob", "review PR 12", true, "normal", SendOptions{ExpiresAt: future})
+	retracted, _ := r.SendMessage("alice", "bob", "deploy now", true, "normal", SendOptions{})
+
+	if _, err := r.RetractMessage(retracted.ID, "alice", false); err != nil {
+		t.Fatalf("retract should succeed: %v", err)
+	}
+
+	got, total, _ := r.ReadMessages("bob", 0, 0, true)
+	if total != 1 || len(got) != 1 || got[0].ID != live.ID {
+		t.Fatalf("expected only message %d to be visible, got %+v", live.ID, got)
+	}
+
+	all, _ := r.ReadAllMessages(0, 0)
+	for _, m := range all {
+		if m.ID == expired.ID || m.ID == retracted.ID {
+			t.Fatalf("read_all_messages should not return expired/retracted message %d", m.ID)
+		}
+	}
+}
+
+func TestRoomEditMessage_KeepsHistoryAndChecksSender(t *testing.T) {
+	r := NewRoomState()
+
+	msg, _ := r.SendMessage("alice", "bob", "v1", true, "normal", SendOptions{})
+
+	if _, err := r.EditMessage(msg.ID, "bob", "hijack", false); err == nil {
+		t.Fatalf("non-sender edit should be rejected")
+	}
+	if _, err := r.EditMessage(msg.ID, "alice", "v2", false); err != nil {
+		t.Fatalf("sender edit should succeed: %v", err)
+	}
+	edited, err := r.EditMessage(msg.ID, "manager", "v3", true)
+	if err != nil {
+		t.Fatalf("privileged edit should succeed: %v", err)
+	}
+
+	if edited.Content != "v3" || edited.EditedAt == "" {
+		t.Fatalf("unexpected edited message: %+v", edited)
+	}
+	if len(edited.Edits) != 2 || edited.Edits[0].Content != "v1" || edited.Edits[1].EditedBy != "manager" {
+		t.Fatalf("unexpected edit history: %+v", edited.Edits)
+	}
+
+	if _, err := r.RetractMessage(msg.ID, "alice", false); err != nil {
+		t.Fatalf("retract should succeed: %v", err)
+	}
+	if _, err := r.EditMessage(msg.ID, "alice", "v4", false); err == nil {
+		t.Fatalf("editing a retracted message should fail")
+	}
+}
+
+func TestRoomReadMessages_HonorsRecipients(t *testing.T) {
+	r := NewRoomState()
+	r.SetGroup("reviewers", []string{"db", "qa", "manager"})
+
+	to, recipients, err := r.ResolveRecipients([]string{"backend", "@reviewers"}, "manager")
+	if err != nil {
+		t.Fatalf("resolve should succeed: %v", err)
+	}
+	if to != "backend,@reviewers" {
+		t.Fatalf("unexpected to=%q", to)
+	}
+	if want := []string{"backend", "db", "qa"}; strings.Join(recipients, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected recipients %v (sender excluded), got %v", want, recipients)
+	}
+
+	msg, _ := r.SendMessage("manager", to, "please review", true, "normal", SendOptions{Recipients: recipients})
+
+	for _, agent := range []string{"backend", "db", "qa"} {
+		got, _, _ := r.ReadMessages(agent, 0, 0, true)
+		if len(got) != 1 || got[0].ID != msg.ID {
+			t.Fatalf("%s should see the group message, got %+v", agent, got)
+		}
+	}
+	if got, _, _ := r.ReadMessages("frontend", 0, 0, true); len(got) != 0 {
+		t.Fatalf("frontend should not see the group message, got %+v", got)
+	}
+
+	if _, _, err := r.ResolveRecipients([]string{"@unknown"}, "manager"); err == nil {
+		t.Fatalf("unknown group should be rejected")
+	}
+	if to, recipients, _ := r.ResolveRecipients([]string{"backend"}, "manager"); to != "backend" || recipients != nil {
+		t.Fatalf("single recipient should stay a direct message, got to=%q recipients=%v", to, recipients)
+	}
+}
+
+func TestRoomFindAgents_RanksByCapability(t *testing.T) {
+	r := NewRoomState()
+
+	r.JoinWithCapabilities("backend", "Backend API", &types.Capabilities{Languages: []string{"Go", "SQL"}, Areas: []string{"api"}})
+	r.JoinWithCapabilities("dba", "Database", &types.Capabilities{Areas: []string{"DB"}, Tools: []string{"psql"}})
+	r.Join("ops", "db migrations")
+
+	matches := r.FindAgents("db", "")
+	if len(matches) != 2 || matches[0].Name != "dba" || matches[1].Name != "ops" {
+		t.Fatalf("expected dba (area) then ops (role), got %+v", matches)
+	}
+	if got := r.FindAgents("sql", ""); len(got) != 1 || got[0].Name != "backend" {
+		t.Fatalf("expected backend for sql, got %+v", got)
+	}
+
+	to, recipients, err := r.ResolveRecipients([]string{"capability:db"}, "manager")
+	if err != nil || to != "dba" || recipients != nil {
+		t.Fatalf("expected capability:db to resolve to dba, got to=%q recipients=%v err=%v", to, recipients, err)
+	}
+	if _, _, err := r.ResolveRecipients([]string{"capability:rust"}, "manager"); err == nil {
+		t.Fatalf("expected unknown capability to be rejected")
+	}
+}
+
+func TestRoomFindAgents_PrefersAvailableAgents(t *testing.T) {
+	r := NewRoomState()
+
+	r.JoinWithCapabilities("dba-1", "", &types.Capabilities{Areas: []string{"db"}})
+	r.JoinWithCapabilities("dba-2", "", &types.Capabilities{Areas: []string{"db"}})
+	r.Join("ops", "db migrations")
+
+	if _, _, err := r.SetStatus("dba-1", types.StatusBlocked, "waiting on credentials", ""); err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	if _, _, err := r.SetStatus("dba-2", types.StatusTesting, "", ""); err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+
+	matches := r.FindAgents("db", "")
+	if len(matches) != 3 || matches[0].Name != "ops" || matches[1].Name != "dba-2" || matches[2].Name != "dba-1" {
+		t.Fatalf("expected ops (available), dba-2 (busy), dba-1 (blocked), got %+v", matches)
+	}
+	if _, _, err := r.SetStatus("ghost", types.StatusAway, "", ""); err == nil {
+		t.Fatalf("expected set status for unknown agent to fail")
+	}
+}
+
+func TestRoomStateHumansDoNotGoStale(t *testing.T) {
+	r := NewRoomState()
+	if _, _, _, err := r.JoinHuman("user"); err != nil {
+		t.Fatalf("JoinHuman: %v", err)
+	}
+	r.Join("alice", "")
+
+	r.mu.Lock()
+	for name, a := range r.agents {
+		a.LastSeen -= staleTimeout + 1
+		r.agents[name] = a
+	}
+	r.mu.Unlock()
+
+	agents := r.ListAgents("")
+	if _, ok := agents["alice"]; ok {
+		t.Fatalf("expected stale agent to be removed")
+	}
+	if _, ok := agents["user"]; !ok {
+		t.Fatalf("expected human participant to stay")
+	}
+	if _, _, _, err := r.JoinHuman("user"); err != nil {
+		t.Fatalf("repeated JoinHuman: %v", err)
+	}
+	r.Join("bob", "")
+	if _, _, _, err := r.JoinHuman("bob"); err == nil {
+		t.Fatalf("expected JoinHuman to refuse an agent's name")
+	}
+}
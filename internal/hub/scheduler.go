@@ -0,0 +1,290 @@
+package hub
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"desktop/internal/types"
+)
+
+const (
+	schedulerTick       = 1 * time.Second
+	maxScheduleAhead    = 365 * 24 * time.Hour
+	maxScheduledPerRoom = 100
+)
+
+// ScheduledMessage is a message held by the hub until its delivery time.
+// Recurring messages carry a Schedule spec and are re-armed after each run.
+type ScheduledMessage struct {
+	ID           int       `json:"id"`
+	Room         string    `json:"room"`
+	From         string    `json:"from"`
+	To           string    `json:"to"`
+	Content      string    `json:"content"`
+	ExpectsReply bool      `json:"expects_reply"`
+	Priority     string    `json:"priority"`
+	NextRun      time.Time `json:"next_run"`
+	Schedule     string    `json:"schedule,omitempty"`
+	ExpiresAt    string    `json:"expires_at,omitempty"`
+	TTL          string    `json:"ttl,omitempty"`
+	// FromHuman and BypassManager carry a human participant's send options.
+	FromHuman     bool   `json:"from_human,omitempty"`
+	BypassManager bool   `json:"bypass_manager,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	RunCount      int    `json:"run_count"`
+}
+
+func (sm *ScheduledMessage) record() (*int, *string) { return &sm.ID, &sm.Room }
+
+// scheduler holds pending scheduled messages for all rooms.
+type scheduler struct {
+	roomStore[ScheduledMessage, *ScheduledMessage]
+}
+
+func newScheduler(path string) *scheduler {
+	return &scheduler{roomStore[ScheduledMessage, *ScheduledMessage]{
+		items:  make(map[int]*ScheduledMessage),
+		nextID: 1,
+		path:   path,
+		key:    "messages",
+		perm:   0644,
+	}}
+}
+
+// add registers a new scheduled message and persists the scheduler.
+func (s *scheduler) add(sm ScheduledMessage) (ScheduledMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.countLocked(sm.Room) >= maxScheduledPerRoom {
+		return ScheduledMessage{}, fmt.Errorf("bu odada en fazla %d zamanlanmış mesaj olabilir", maxScheduledPerRoom)
+	}
+
+	sm.CreatedAt = types.Timestamp()
+	s.insertLocked(&sm)
+	return sm, nil
+}
+
+// list returns scheduled messages of a room ordered by next run.
+// If from is non-empty only that sender's messages are returned.
+func (s *scheduler) list(room, from string) []ScheduledMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []ScheduledMessage
+	for _, it := range s.items {
+		if it.Room != room || (from != "" && it.From != from) {
+			continue
+		}
+		out = append(out, *it)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].NextRun.Equal(out[j].NextRun) {
+			return out[i].NextRun.Before(out[j].NextRun)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// get returns a copy of a scheduled message.
+func (s *scheduler) get(id int) (ScheduledMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[id]
+	if !ok {
+		return ScheduledMessage{}, false
+	}
+	return *it, true
+}
+
+// cancel removes a scheduled message.
+func (s *scheduler) cancel(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.items[id]; !ok {
+		return false
+	}
+	delete(s.items, id)
+	s.saveLocked()
+	return true
+}
+
+// popDue returns messages due at `now`. One-shot messages are removed and
+// recurring messages are re-armed to their next run. Messages for rooms that
+// are not loaded (archived) are held: one-shot messages wait for the room to
+// come back, recurring ones skip this run.
+func (s *scheduler) popDue(now time.Time, loaded func(room string) bool) []ScheduledMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []ScheduledMessage
+	changed := false
+	for id, it := range s.items {
+		if it.NextRun.After(now) {
+			continue
+		}
+		live := loaded(it.Room)
+		if !live && it.Schedule == "" {
+			continue
+		}
+		changed = true
+		if live {
+			it.RunCount++
+			due = append(due, *it)
+		}
+
+		var next time.Time
+		if it.Schedule != "" {
+			if sched, err := parseSchedule(it.Schedule); err == nil {
+				next = sched.Next(now)
+			}
+		}
+		if next.IsZero() {
+			delete(s.items, id)
+		} else {
+			it.NextRun = next
+		}
+	}
+	if changed {
+		s.saveLocked()
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+	return due
+}
+
+// parseDelay accepts a Go duration ("10m", "1h30m") or a plain number of seconds.
+func parseDelay(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("geçersiz delay %q: \"10m\", \"1h30m\" veya saniye bekleniyor", s)
+	}
+	return d, nil
+}
+
+// resolveFirstRun computes when a scheduled message should first fire.
+// It returns the zero time when the message should be sent immediately.
+func resolveFirstRun(now time.Time, deliverAt, delay, spec string) (time.Time, error) {
+	deliverAt = strings.TrimSpace(deliverAt)
+	delay = strings.TrimSpace(delay)
+	spec = strings.TrimSpace(spec)
+
+	if deliverAt != "" && delay != "" {
+		return time.Time{}, fmt.Errorf("deliver_at ve delay birlikte kullanılamaz")
+	}
+
+	var first time.Time
+	switch {
+	case deliverAt != "":
+		t, err := types.ParseTime(deliverAt)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if !t.After(now) {
+			return time.Time{}, fmt.Errorf("deliver_at gelecekte olmalı: %s", deliverAt)
+		}
+		first = t
+	case delay != "":
+		d, err := parseDelay(delay)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("delay pozitif olmalı: %s", delay)
+		}
+		first = now.Add(d)
+	}
+
+	if spec != "" {
+		sched, err := parseSchedule(spec)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if first.IsZero() {
+			first = sched.Next(now)
+			if first.IsZero() {
+				return time.Time{}, fmt.Errorf("zamanlama %q hiçbir zaman tetiklenmiyor", spec)
+			}
+		}
+	}
+
+	if !first.IsZero() && first.Sub(now) > maxScheduleAhead {
+		return time.Time{}, fmt.Errorf("mesaj en fazla %d gün ileriye zamanlanabilir", int(maxScheduleAhead.Hours()/24))
+	}
+	return first, nil
+}
+
+// schedulerLoop fires due scheduled messages until the hub shuts down.
+func (h *Hub) schedulerLoop() {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.done:
+			return
+		case now := <-ticker.C:
+			h.fireDueScheduled(now)
+		}
+	}
+}
+
+// fireDueScheduled delivers all scheduled messages due at `now` as regular
+// room messages, applying manager routing at delivery time.
+func (h *Hub) fireDueScheduled(now time.Time) {
+	loaded := func(room string) bool {
+		_, ok := h.lookupRoom(room)
+		return ok
+	}
+	for _, sm := range h.scheduler.popDue(now, loaded) {
+		// TTL is measured from the actual delivery time.
+		expiresAt := ""
+		if sm.TTL != "" || sm.ExpiresAt != "" {
+			var err error
+			expiresAt, err = resolveExpiry(now, sm.ExpiresAt, sm.TTL)
+			if err != nil {
+				h.logger.Printf("scheduled message %d skipped: %v", sm.ID, err)
+				continue
+			}
+		}
+
+		// Firing must not bring an archived room back.
+		roomState, ok := h.lookupRoom(sm.Room)
+		if !ok {
+			h.logger.Printf("scheduled message %d skipped: room %s is not loaded", sm.ID, sm.Room)
+			continue
+		}
+		to, recipients, err := roomState.ResolveRecipients(types.SplitRecipients(sm.To), sm.From)
+		if err != nil {
+			h.logger.Printf("scheduled message %d skipped: %v", sm.ID, err)
+			continue
+		}
+		activeManager := roomState.GetActiveManager()
+		if sm.BypassManager {
+			activeManager = ""
+		}
+		msg, _, err := h.routeMessage(roomState, activeManager, types.Message{
+			From:         sm.From,
+			To:           to,
+			Recipients:   recipients,
+			Content:      sm.Content,
+			ExpectsReply: sm.ExpectsReply,
+			Priority:     sm.Priority,
+			ExpiresAt:    expiresAt,
+			FromHuman:    sm.FromHuman,
+		})
+		if err != nil {
+			h.logger.Printf("scheduled message %d failed: %v", sm.ID, err)
+			continue
+		}
+		h.logger.Printf("scheduled message %d delivered: id=%d room=%s", sm.ID, msg.ID, sm.Room)
+		h.broadcastEvent(sm.Room, "message_new", map[string]any{"message": msg})
+	}
+}
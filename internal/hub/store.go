@@ -0,0 +1,171 @@
+package hub
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// roomRecord is an item of a roomStore: it exposes its ID and room so the
+// store can number, move and drop it.
+type roomRecord[T any] interface {
+	*T
+	record() (id *int, room *string)
+}
+
+// roomStore keeps numbered per-room items (scheduled messages, hooks,
+// connectors) and persists them to one JSON file as {"next_id": N, key: [...]}.
+// Owners embed it and guard their own state with the same mu.
+type roomStore[T any, P roomRecord[T]] struct {
+	mu     sync.Mutex
+	items  map[int]P
+	nextID int
+	path   string // empty disables persistence (tests)
+	key    string // JSON field holding the items
+	perm   os.FileMode
+}
+
+// countLocked returns how many items belong to room. Must be called with mu
+// held.
+func (s *roomStore[T, P]) countLocked(room string) int {
+	n := 0
+	for _, it := range s.items {
+		if _, r := it.record(); *r == room {
+			n++
+		}
+	}
+	return n
+}
+
+// insertLocked numbers a new item, stores it and persists the store. Must be
+// called with mu held.
+func (s *roomStore[T, P]) insertLocked(it P) {
+	id, _ := it.record()
+	*id = s.nextID
+	s.nextID++
+	s.items[*id] = it
+	s.saveLocked()
+}
+
+// renameRoom moves the items of a renamed room.
+func (s *roomStore[T, P]) renameRoom(from, to string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.renameRoomLocked(from, to)
+}
+
+// renameRoomLocked moves the items of a renamed room and returns them. Must
+// be called with mu held.
+func (s *roomStore[T, P]) renameRoomLocked(from, to string) []P {
+	var moved []P
+	for _, it := range s.items {
+		if _, room := it.record(); *room == from {
+			*room = to
+			moved = append(moved, it)
+		}
+	}
+	if len(moved) > 0 {
+		s.saveLocked()
+	}
+	return moved
+}
+
+// deleteRoom drops the items of a deleted room.
+func (s *roomStore[T, P]) deleteRoom(room string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteRoomLocked(room)
+}
+
+// deleteRoomLocked drops the items of a deleted room and returns them. Must
+// be called with mu held.
+func (s *roomStore[T, P]) deleteRoomLocked(room string) []P {
+	var dropped []P
+	for id, it := range s.items {
+		if _, r := it.record(); *r == room {
+			delete(s.items, id)
+			dropped = append(dropped, it)
+		}
+	}
+	if len(dropped) > 0 {
+		s.saveLocked()
+	}
+	return dropped
+}
+
+func (s *roomStore[T, P]) load() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	var nextID int
+	var items []P
+	if raw, ok := file["next_id"]; ok {
+		if err := json.Unmarshal(raw, &nextID); err != nil {
+			return err
+		}
+	}
+	if raw, ok := file[s.key]; ok {
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, it := range items {
+		if it == nil {
+			continue
+		}
+		id, _ := it.record()
+		s.items[*id] = it
+		if *id >= s.nextID {
+			s.nextID = *id + 1
+		}
+	}
+	if nextID > s.nextID {
+		s.nextID = nextID
+	}
+	return nil
+}
+
+// saveLocked writes the store atomically. Must be called with mu held.
+func (s *roomStore[T, P]) saveLocked() {
+	if s.path == "" {
+		return
+	}
+	items := make([]P, 0, len(s.items))
+	for _, it := range s.items {
+		items = append(items, it)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, _ := items[i].record()
+		b, _ := items[j].record()
+		return *a < *b
+	})
+
+	data, err := json.MarshalIndent(map[string]any{"next_id": s.nextID, s.key: items}, "", "  ")
+	if err != nil {
+		return
+	}
+	os.MkdirAll(filepath.Dir(s.path), 0700)
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, s.perm); err != nil {
+		return
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+	}
+}
@@ -0,0 +1,44 @@
+package hub
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRoomStoreRenameDeleteAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hub-schedule.json")
+	// Files written before the shared store keep loading.
+	if err := os.WriteFile(path, []byte(`{"next_id": 7, "messages": [{"id": 3, "room": "old"}, {"id": 5, "room": "other"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := newScheduler(path)
+	if err := s.load(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.renameRoom("old", "new")
+	s.deleteRoom("other")
+	sm, err := s.add(ScheduledMessage{Room: "new", NextRun: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sm.ID != 7 {
+		t.Errorf("new ID = %d, want 7", sm.ID)
+	}
+
+	loaded := newScheduler(path)
+	if err := loaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.list("new", ""); len(got) != 2 {
+		t.Errorf("renamed room holds %+v, want 2 messages", got)
+	}
+	if got := loaded.list("other", ""); len(got) != 0 {
+		t.Errorf("deleted room still holds %+v", got)
+	}
+	if loaded.nextID != 8 {
+		t.Errorf("nextID = %d, want 8", loaded.nextID)
+	}
+}
@@ -101,6 +101,13 @@ func (c *HubClient) Close() {
 	}
 }
 
+// Connected reports whether the connection to the hub is still open.
+func (c *HubClient) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil && !c.closed
+}
+
 // SetEventHandler sets the function called when an event is received.
 func (c *HubClient) SetEventHandler(fn func(types.Event)) {
 	c.onEvent = fn
@@ -260,27 +267,165 @@ func (c *HubClient) SetManager(room, managerAgent string) error {
 	return nil
 }
 
-// JoinRoom joins a room.
-func (c *HubClient) JoinRoom(room, agentName, role string) (*types.Response, error) {
-	data, _ := json.Marshal(map[string]string{
+// JoinRoom joins a room. caps may be nil.
+func (c *HubClient) JoinRoom(room, agentName, role string, caps *types.Capabilities) (*types.Response, error) {
+	payload := map[string]any{
 		"agent_name": agentName,
 		"role":       role,
-	})
+	}
+	if caps != nil && !caps.IsEmpty() {
+		payload["capabilities"] = caps
+	}
+	data, _ := json.Marshal(payload)
 	return c.Send(types.Request{Type: "join_room", Room: room, Data: data})
 }
 
+// JoinHuman adds a human participant to a room (desktop only). Unlike
+// JoinRoom it does not bind the connection to the room.
+func (c *HubClient) JoinHuman(room, name string) error {
+	data, _ := json.Marshal(map[string]any{"agent_name": name, "human": true})
+	resp, err := c.Send(types.Request{Type: "join_room", Room: room, Data: data})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("join_room failed: %s", resp.Error)
+	}
+	return nil
+}
+
+// FindAgents lists agents matching a capability.
+func (c *HubClient) FindAgents(room, capability string) (*types.Response, error) {
+	data, _ := json.Marshal(map[string]string{"capability": capability})
+	return c.Send(types.Request{Type: "find_agents", Room: room, Data: data})
+}
+
+// SetStatus reports an agent's status, free-text detail and optional ETA.
+func (c *HubClient) SetStatus(room, agentName, status, text, eta string) (*types.Response, error) {
+	data, _ := json.Marshal(map[string]string{
+		"agent_name": agentName,
+		"status":     status,
+		"text":       text,
+		"eta":        eta,
+	})
+	return c.Send(types.Request{Type: "set_status", Room: room, Data: data})
+}
+
+// ReportActivity sends a PTY activity heartbeat (busy/idle/prompt_waiting)
+// for an agent hosted by the desktop app.
+func (c *HubClient) ReportActivity(room, agentName, activity string) error {
+	data, _ := json.Marshal(map[string]string{
+		"agent_name": agentName,
+		"activity":   activity,
+	})
+	resp, err := c.Send(types.Request{Type: "report_activity", Room: room, Data: data})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("report_activity failed: %s", resp.Error)
+	}
+	return nil
+}
+
+// PostSystem posts a SYSTEM message to a room (desktop only).
+func (c *HubClient) PostSystem(room, content string) error {
+	data, _ := json.Marshal(map[string]string{"content": content})
+	resp, err := c.Send(types.Request{Type: "post_system", Room: room, Data: data})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("post_system failed: %s", resp.Error)
+	}
+	return nil
+}
+
+// SendOptions holds optional delivery settings for SendMessage.
+type SendOptions struct {
+	DeliverAt string // absolute delivery time (RFC3339 or local ISO)
+	Delay     string // relative delay ("10m", "1h30m" or seconds)
+	Schedule  string // recurring schedule (cron expression or @every/@daily...)
+	ExpiresAt string // absolute expiry time after which the message is hidden
+	TTL       string // relative expiry measured from delivery ("2h", seconds)
+
+	// Human sends as a human participant added with JoinHuman (desktop only).
+	Human bool
+	// BypassManager delivers a human's message without the manager gateway.
+	BypassManager bool
+}
+
 // SendMessage sends a message to a room.
-func (c *HubClient) SendMessage(room, from, to, content string, expectsReply bool, priority string) (*types.Response, error) {
-	data, _ := json.Marshal(map[string]any{
+func (c *HubClient) SendMessage(room, from, to, content string, expectsReply bool, priority string, opts SendOptions) (*types.Response, error) {
+	payload := map[string]any{
 		"from":          from,
 		"to":            to,
 		"content":       content,
 		"expects_reply": expectsReply,
 		"priority":      priority,
-	})
+	}
+	if opts.DeliverAt != "" {
+		payload["deliver_at"] = opts.DeliverAt
+	}
+	if opts.Delay != "" {
+		payload["delay"] = opts.Delay
+	}
+	if opts.Schedule != "" {
+		payload["schedule"] = opts.Schedule
+	}
+	if opts.ExpiresAt != "" {
+		payload["expires_at"] = opts.ExpiresAt
+	}
+	if opts.TTL != "" {
+		payload["ttl"] = opts.TTL
+	}
+	if opts.Human {
+		payload["human"] = true
+	}
+	if opts.BypassManager {
+		payload["bypass_manager"] = true
+	}
+	data, _ := json.Marshal(payload)
 	return c.Send(types.Request{Type: "send_message", Room: room, Data: data})
 }
 
+// SetGroup defines or replaces a recipient group. Empty members deletes it.
+func (c *HubClient) SetGroup(room, group string, members []string) (*types.Response, error) {
+	if members == nil {
+		members = []string{}
+	}
+	data, _ := json.Marshal(map[string]any{"group": group, "members": members})
+	return c.Send(types.Request{Type: "set_group", Room: room, Data: data})
+}
+
+// ListGroups lists the recipient groups of a room.
+func (c *HubClient) ListGroups(room string) (*types.Response, error) {
+	return c.Send(types.Request{Type: "list_groups", Room: room})
+}
+
+// EditMessage replaces the content of a message.
+func (c *HubClient) EditMessage(room string, messageID int, content string) (*types.Response, error) {
+	data, _ := json.Marshal(map[string]any{"message_id": messageID, "content": content})
+	return c.Send(types.Request{Type: "edit_message", Room: room, Data: data})
+}
+
+// RetractMessage retracts a message.
+func (c *HubClient) RetractMessage(room string, messageID int) (*types.Response, error) {
+	data, _ := json.Marshal(map[string]any{"message_id": messageID})
+	return c.Send(types.Request{Type: "retract_message", Room: room, Data: data})
+}
+
+// ListScheduled lists pending scheduled messages in a room.
+func (c *HubClient) ListScheduled(room string) (*types.Response, error) {
+	return c.Send(types.Request{Type: "list_scheduled", Room: room})
+}
+
+// CancelScheduled cancels a pending scheduled message.
+func (c *HubClient) CancelScheduled(room string, scheduledID int) (*types.Response, error) {
+	data, _ := json.Marshal(map[string]any{"scheduled_id": scheduledID})
+	return c.Send(types.Request{Type: "cancel_scheduled", Room: room, Data: data})
+}
+
 // GetMessages reads messages from a room.
 func (c *HubClient) GetMessages(room, agentName string, sinceID, limit int, unreadOnly bool) (*types.Response, error) {
 	data, _ := json.Marshal(map[string]any{
@@ -324,17 +469,109 @@ func (c *HubClient) GetLastMessageID(room, agentName string) (*types.Response, e
 	return c.Send(types.Request{Type: "get_last_message_id", Room: room, Data: data})
 }
 
+// AddHook registers a room hook (desktop only). hook holds the hook fields:
+// event, url or command, and the optional filters, timeout and retries.
+func (c *HubClient) AddHook(room string, hook map[string]any) (*types.Response, error) {
+	data, _ := json.Marshal(hook)
+	return c.Send(types.Request{Type: "add_hook", Room: room, Data: data})
+}
+
+// RemoveHook deletes a room hook.
+func (c *HubClient) RemoveHook(room string, hookID int) (*types.Response, error) {
+	data, _ := json.Marshal(map[string]any{"hook_id": hookID})
+	return c.Send(types.Request{Type: "remove_hook", Room: room, Data: data})
+}
+
+// ListHooks lists a room's hooks.
+func (c *HubClient) ListHooks(room string) (*types.Response, error) {
+	return c.Send(types.Request{Type: "list_hooks", Room: room})
+}
+
+// HookLog returns a room's recent hook deliveries, newest first.
+func (c *HubClient) HookLog(room string, limit int) (*types.Response, error) {
+	data, _ := json.Marshal(map[string]any{"limit": limit})
+	return c.Send(types.Request{Type: "hook_log", Room: room, Data: data})
+}
+
+// AddConnector bridges a room to an external chat system (desktop only).
+func (c *HubClient) AddConnector(room, kind string, settings map[string]string) (*types.Response, error) {
+	data, _ := json.Marshal(map[string]any{"kind": kind, "settings": settings})
+	return c.Send(types.Request{Type: "add_connector", Room: room, Data: data})
+}
+
+// RemoveConnector stops and deletes a room connector.
+func (c *HubClient) RemoveConnector(room string, connectorID int) (*types.Response, error) {
+	data, _ := json.Marshal(map[string]any{"connector_id": connectorID})
+	return c.Send(types.Request{Type: "remove_connector", Room: room, Data: data})
+}
+
+// ListConnectors lists a room's connectors.
+func (c *HubClient) ListConnectors(room string) (*types.Response, error) {
+	return c.Send(types.Request{Type: "list_connectors", Room: room})
+}
+
+// ExportRoom returns a room as JSON Lines ("jsonl") or a Markdown
+// transcript ("markdown") (desktop only).
+func (c *HubClient) ExportRoom(room, format string) (string, error) {
+	data, _ := json.Marshal(map[string]any{"format": format})
+	resp, err := c.Send(types.Request{Type: "export_room", Room: room, Data: data})
+	if err != nil {
+		return "", err
+	}
+	if !resp.Success {
+		return "", fmt.Errorf("%s", resp.Error)
+	}
+	var out struct {
+		Data string `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Data, &out); err != nil {
+		return "", err
+	}
+	return out.Data, nil
+}
+
+// ImportRoom seeds an empty room from a JSON Lines export (desktop only).
+func (c *HubClient) ImportRoom(room, export string) (*types.Response, error) {
+	data, _ := json.Marshal(map[string]any{"data": export})
+	return c.Send(types.Request{Type: "import_room", Room: room, Data: data})
+}
+
 // ListRooms lists all rooms.
 func (c *HubClient) ListRooms() (*types.Response, error) {
 	return c.Send(types.Request{Type: "list_rooms"})
 }
 
+// ListArchivedRooms lists archived rooms.
+func (c *HubClient) ListArchivedRooms() (*types.Response, error) {
+	data, _ := json.Marshal(map[string]bool{"archived": true})
+	return c.Send(types.Request{Type: "list_rooms", Data: data})
+}
+
+// DeleteRoom deletes a live or archived room for good (desktop only).
+func (c *HubClient) DeleteRoom(room string) (*types.Response, error) {
+	return c.Send(types.Request{Type: "delete_room", Room: room})
+}
+
+// RenameRoom gives a room a new name (desktop only).
+func (c *HubClient) RenameRoom(room, newName string) (*types.Response, error) {
+	data, _ := json.Marshal(map[string]string{"new_name": newName})
+	return c.Send(types.Request{Type: "rename_room", Room: room, Data: data})
+}
+
+// ArchiveRoom archives an idle room; joining it again restores it (desktop only).
+func (c *HubClient) ArchiveRoom(room string) (*types.Response, error) {
+	return c.Send(types.Request{Type: "archive_room", Room: room})
+}
+
 // GetAgentsRaw returns raw agent data for a room.
 func (c *HubClient) GetAgentsRaw(room string) (map[string]types.Agent, error) {
 	resp, err := c.Send(types.Request{Type: "get_agents", Room: room})
 	if err != nil {
 		return nil, err
 	}
+	if !resp.Success {
+		return nil, fmt.Errorf("get_agents failed: %s", resp.Error)
+	}
 	var data struct {
 		Agents map[string]types.Agent `json:"agents"`
 	}
@@ -344,12 +581,33 @@ func (c *HubClient) GetAgentsRaw(room string) (map[string]types.Agent, error) {
 	return data.Agents, nil
 }
 
+// GetRateStats returns a room's send_message rate limiting counters (desktop only).
+func (c *HubClient) GetRateStats(room string) (types.RateStats, error) {
+	resp, err := c.Send(types.Request{Type: "get_rate_stats", Room: room})
+	if err != nil {
+		return types.RateStats{}, err
+	}
+	if !resp.Success {
+		return types.RateStats{}, fmt.Errorf("get_rate_stats failed: %s", resp.Error)
+	}
+	var data struct {
+		Stats types.RateStats `json:"stats"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		return types.RateStats{}, err
+	}
+	return data.Stats, nil
+}
+
 // GetMessagesRaw returns raw message data for a room.
 func (c *HubClient) GetMessagesRaw(room string) ([]types.Message, error) {
 	resp, err := c.Send(types.Request{Type: "get_messages_raw", Room: room})
 	if err != nil {
 		return nil, err
 	}
+	if !resp.Success {
+		return nil, fmt.Errorf("get_messages_raw failed: %s", resp.Error)
+	}
 	var data struct {
 		Messages []types.Message `json:"messages"`
 	}
@@ -61,6 +61,9 @@ func (app *MCPServerApp) registerTools() {
 Args:
     agent_name: Unique name for this agent (e.g., "backend", "frontend", "mobile")
     role: Optional role description (e.g., "Backend API Developer")
+    languages: Optional comma-separated languages you work in (e.g., "go,sql")
+    areas: Optional comma-separated areas you own (e.g., "db,backend")
+    tools: Optional comma-separated tools you can use (e.g., "docker,psql")
     room: Room name (empty = default room from AGENT_CHAT_ROOM env or "default")
 
 Returns:
@@ -68,7 +71,8 @@ Returns:
 
 Notes:
     - Agent names must be unique per room; duplicate names are rejected
-    - role="manager" claims manager lock for the room (only one active manager)`),
+    - role="manager" claims manager lock for the room (only one active manager)
+    - Declared capabilities let others reach you via find_agents or to_agent="capability:<name>"`),
 		mcp.WithDestructiveHintAnnotation(false),
 		mcp.WithString("agent_name",
 			mcp.Required(),
@@ -77,6 +81,15 @@ Notes:
 		mcp.WithString("role",
 			mcp.Description("Optional role description (e.g., \"Backend API Developer\")"),
 		),
+		mcp.WithString("languages",
+			mcp.Description("Optional comma-separated languages you work in (e.g., \"go,sql\")"),
+		),
+		mcp.WithString("areas",
+			mcp.Description("Optional comma-separated areas you own (e.g., \"db,backend\")"),
+		),
+		mcp.WithString("tools",
+			mcp.Description("Optional comma-separated tools you can use (e.g., \"docker,psql\")"),
+		),
 		mcp.WithString("room",
 			mcp.Description("Room name (empty = default room from AGENT_CHAT_ROOM env or \"default\")"),
 		),
@@ -89,17 +102,29 @@ Notes:
 Args:
     from_agent: Your agent name
     content: Message content
-    to_agent: Target agent name or "all" for broadcast (default: "all")
+    to_agent: Target agent, comma-separated agents/groups (e.g., "backend,db,@reviewers"), or "all" for broadcast (default: "all")
     expects_reply: Set False for acknowledgments/thanks to prevent infinite loops (default: True)
     priority: "urgent", "normal", or "low" (default: "normal")
+    deliver_at: Optional delivery time (e.g., "2025-01-15T14:30:00" or RFC3339)
+    delay: Optional delay before delivery (e.g., "10m", "1h30m", or seconds)
+    schedule: Optional recurring schedule: 5-field cron ("0 9 * * 1-5") or "@every 30m", "@hourly", "@daily", "@weekly"
+    expires_at: Optional time after which the message is hidden from reads
+    ttl: Optional lifetime measured from delivery (e.g., "30m", "2h", or seconds)
     room: Room name (empty = default room)
 
 Returns:
-    Confirmation that message was sent
+    Confirmation that message was sent, or the scheduled ID for delayed messages
 
 Notes:
     - from_agent must match the name you joined with via join_room
-    - If a manager is active in the room, non-manager messages are first routed to manager`),
+    - If a manager is active in the room, non-manager messages are first routed to manager
+    - "@name" addresses every member of a room group (see set_group / list_groups)
+    - "capability:<name>" addresses the best matching active agent (see find_agents)
+    - "urgent" notifies the recipient immediately; "low" is only seen on the recipient's next read
+    - deliver_at and delay cannot be combined; either may be combined with schedule to set the first run
+    - Scheduled messages are held by the hub; see list_scheduled / cancel_scheduled
+    - Use expires_at/ttl for time-bound instructions ("hold off on merging") so they do not linger
+    - Sending is rate limited per agent and per room; a rate-limited error says how many seconds to wait`),
 		mcp.WithDestructiveHintAnnotation(false),
 		mcp.WithString("from_agent",
 			mcp.Required(),
@@ -110,7 +135,7 @@ Notes:
 			mcp.Description("Message content"),
 		),
 		mcp.WithString("to_agent",
-			mcp.Description("Target agent name or \"all\" for broadcast (default: \"all\")"),
+			mcp.Description("Target agent, comma-separated agents/groups (e.g., \"backend,db,@reviewers\"), or \"all\" for broadcast (default: \"all\")"),
 		),
 		mcp.WithBoolean("expects_reply",
 			mcp.Description("Set False for acknowledgments/thanks to prevent infinite loops (default: True)"),
@@ -118,11 +143,185 @@ Notes:
 		mcp.WithString("priority",
 			mcp.Description(fmt.Sprintf("\"urgent\", \"normal\", or \"low\" (default: \"normal\")")),
 		),
+		mcp.WithString("deliver_at",
+			mcp.Description("Optional delivery time (e.g., \"2025-01-15T14:30:00\" or RFC3339)"),
+		),
+		mcp.WithString("delay",
+			mcp.Description("Optional delay before delivery (e.g., \"10m\", \"1h30m\", or seconds)"),
+		),
+		mcp.WithString("schedule",
+			mcp.Description("Optional recurring schedule: cron (\"0 9 * * 1-5\") or \"@every 30m\", \"@hourly\", \"@daily\", \"@weekly\""),
+		),
+		mcp.WithString("expires_at",
+			mcp.Description("Optional time after which the message is hidden from reads"),
+		),
+		mcp.WithString("ttl",
+			mcp.Description("Optional lifetime measured from delivery (e.g., \"30m\", \"2h\", or seconds)"),
+		),
 		mcp.WithString("room",
 			mcp.Description("Room name (empty = default room)"),
 		),
 	), h.sendMessage)
 
+	// find_agents
+	app.server.AddTool(mcp.NewTool("find_agents",
+		mcp.WithDescription(`Find agents in the room that declared a capability.
+
+Args:
+    capability: Language, area or tool to look for (e.g., "db", "go", "docker")
+    room: Room name (empty = default room)
+
+Returns:
+    Matching active agents, best match first
+
+Notes:
+    - Areas rank above languages, which rank above tools; roles are a weak fallback
+    - send_message(to_agent="capability:db") delivers to the best match directly`),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("capability",
+			mcp.Required(),
+			mcp.Description("Language, area or tool to look for (e.g., \"db\", \"go\", \"docker\")"),
+		),
+		mcp.WithString("room",
+			mcp.Description("Room name (empty = default room)"),
+		),
+	), h.findAgents)
+
+	// set_status
+	app.server.AddTool(mcp.NewTool("set_status",
+		mcp.WithDescription(`Tell the room what you are doing right now.
+
+Args:
+    agent_name: Your agent name
+    status: One of "available", "thinking", "working", "testing", "waiting_review", "blocked", "away"
+    text: Optional free-text detail (e.g., "running integration tests")
+    eta: Optional time you expect to change status, as a duration ("15m") or time ("2025-01-15T14:30:00")
+    room: Room name (empty = default room)
+
+Returns:
+    Confirmation with the recorded status
+
+Notes:
+    - Status is shown in list_agents and in the desktop terminal header
+    - Capability routing prefers "available" agents and ranks "blocked"/"away" ones last
+    - Set "available" again when you are done so others can reach you`),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("agent_name",
+			mcp.Required(),
+			mcp.Description("Your agent name"),
+		),
+		mcp.WithString("status",
+			mcp.Required(),
+			mcp.Description("\"available\", \"thinking\", \"working\", \"testing\", \"waiting_review\", \"blocked\" or \"away\""),
+		),
+		mcp.WithString("text",
+			mcp.Description("Optional free-text detail (e.g., \"waiting for db migration review\")"),
+		),
+		mcp.WithString("eta",
+			mcp.Description("Optional ETA as a duration (\"15m\") or time (\"2025-01-15T14:30:00\")"),
+		),
+		mcp.WithString("room",
+			mcp.Description("Room name (empty = default room)"),
+		),
+	), h.setStatus)
+
+	// set_group
+	app.server.AddTool(mcp.NewTool("set_group",
+		mcp.WithDescription(`Define a named recipient group for the room (manager only).
+
+Args:
+    group: Group name, used as "@group" in send_message to_agent (e.g., "reviewers")
+    members: Comma-separated agent names (e.g., "backend,db"); empty deletes the group
+    room: Room name (empty = default room)
+
+Returns:
+    Confirmation message
+
+Notes:
+    - Only the active manager can define groups`),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("group",
+			mcp.Required(),
+			mcp.Description("Group name, used as \"@group\" in send_message to_agent (e.g., \"reviewers\")"),
+		),
+		mcp.WithString("members",
+			mcp.Description("Comma-separated agent names (e.g., \"backend,db\"); empty deletes the group"),
+		),
+		mcp.WithString("room",
+			mcp.Description("Room name (empty = default room)"),
+		),
+	), h.setGroup)
+
+	// list_groups
+	app.server.AddTool(mcp.NewTool("list_groups",
+		mcp.WithDescription(`List recipient groups defined in the room.
+
+Args:
+    room: Room name (empty = default room)
+
+Returns:
+    Groups with their members`),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("room",
+			mcp.Description("Room name (empty = default room)"),
+		),
+	), h.listGroups)
+
+	// edit_message
+	app.server.AddTool(mcp.NewTool("edit_message",
+		mcp.WithDescription(`Edit the content of a message you sent.
+
+Args:
+    message_id: ID of the message to edit
+    content: New message content
+    room: Room name (empty = default room)
+
+Returns:
+    Confirmation message
+
+Notes:
+    - Only the sender or the active manager can edit a message
+    - Previous content is kept in the message's edit history
+    - Recipients are told the message changed`),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithNumber("message_id",
+			mcp.Required(),
+			mcp.Description("ID of the message to edit"),
+		),
+		mcp.WithString("content",
+			mcp.Required(),
+			mcp.Description("New message content"),
+		),
+		mcp.WithString("room",
+			mcp.Description("Room name (empty = default room)"),
+		),
+	), h.editMessage)
+
+	// retract_message
+	app.server.AddTool(mcp.NewTool("retract_message",
+		mcp.WithDescription(`Retract a message so agents no longer see it.
+
+Args:
+    message_id: ID of the message to retract
+    room: Room name (empty = default room)
+
+Returns:
+    Confirmation message
+
+Notes:
+    - Only the sender or the active manager can retract a message
+    - Recipients that were already notified are told to disregard it`),
+		mcp.WithNumber("message_id",
+			mcp.Required(),
+			mcp.Description("ID of the message to retract"),
+		),
+		mcp.WithString("room",
+			mcp.Description("Room name (empty = default room)"),
+		),
+	), h.retractMessage)
+
 	// read_messages
 	app.server.AddTool(mcp.NewTool("read_messages",
 		mcp.WithDescription(`Read messages from the chat room.
@@ -131,7 +330,9 @@ Args:
     agent_name: Your agent name (to filter relevant messages)
     since_id: Only get messages after this ID (default: 0 for all)
     unread_only: If True, only show messages not from yourself (default: True)
-    limit: Maximum number of messages to return (default: 10, 0 for unlimited)
+    limit: Maximum number of messages to return (default: 10, 0 for unlimited).
+        The oldest unread messages come first, plus every urgent one; when
+        more are waiting the reply names the since_id to read the rest with.
     room: Room name (empty = default room)
 
 Returns:
@@ -196,6 +397,47 @@ Returns:
 		),
 	), h.leaveRoom)
 
+	// list_scheduled
+	app.server.AddTool(mcp.NewTool("list_scheduled",
+		mcp.WithDescription(`List pending scheduled and recurring messages.
+
+Args:
+    room: Room name (empty = default room)
+
+Returns:
+    Scheduled messages with their IDs and next delivery time
+
+Notes:
+    - Agents see their own scheduled messages; the active manager sees all`),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("room",
+			mcp.Description("Room name (empty = default room)"),
+		),
+	), h.listScheduled)
+
+	// cancel_scheduled
+	app.server.AddTool(mcp.NewTool("cancel_scheduled",
+		mcp.WithDescription(`Cancel a pending scheduled or recurring message.
+
+Args:
+    scheduled_id: ID returned by send_message or list_scheduled
+    room: Room name (empty = default room)
+
+Returns:
+    Confirmation message
+
+Notes:
+    - Only the sender or the active manager can cancel a scheduled message`),
+		mcp.WithNumber("scheduled_id",
+			mcp.Required(),
+			mcp.Description("ID returned by send_message or list_scheduled"),
+		),
+		mcp.WithString("room",
+			mcp.Description("Room name (empty = default room)"),
+		),
+	), h.cancelScheduled)
+
 	// clear_room
 	app.server.AddTool(mcp.NewTool("clear_room",
 		mcp.WithDescription(`Clear all messages and agents from the room. Use with caution!
@@ -28,13 +28,23 @@ func (s *Storage) resolveRoom(room string) string {
 }
 
 // JoinRoom joins a room via the hub.
-func (s *Storage) JoinRoom(room, agentName, role string) (*types.Response, error) {
-	return s.client.JoinRoom(s.resolveRoom(room), agentName, role)
+func (s *Storage) JoinRoom(room, agentName, role string, caps *types.Capabilities) (*types.Response, error) {
+	return s.client.JoinRoom(s.resolveRoom(room), agentName, role, caps)
+}
+
+// FindAgents finds agents by capability via the hub.
+func (s *Storage) FindAgents(room, capability string) (*types.Response, error) {
+	return s.client.FindAgents(s.resolveRoom(room), capability)
+}
+
+// SetStatus reports an agent's status via the hub.
+func (s *Storage) SetStatus(room, agentName, status, text, eta string) (*types.Response, error) {
+	return s.client.SetStatus(s.resolveRoom(room), agentName, status, text, eta)
 }
 
 // SendMessage sends a message via the hub.
-func (s *Storage) SendMessage(room, from, to, content string, expectsReply bool, priority string) (*types.Response, error) {
-	return s.client.SendMessage(s.resolveRoom(room), from, to, content, expectsReply, priority)
+func (s *Storage) SendMessage(room, from, to, content string, expectsReply bool, priority string, opts hubclient.SendOptions) (*types.Response, error) {
+	return s.client.SendMessage(s.resolveRoom(room), from, to, content, expectsReply, priority, opts)
 }
 
 // GetMessages reads messages via the hub.
@@ -57,6 +67,36 @@ func (s *Storage) LeaveRoom(room, agentName string) (*types.Response, error) {
 	return s.client.LeaveRoom(s.resolveRoom(room), agentName)
 }
 
+// SetGroup sets a recipient group via the hub.
+func (s *Storage) SetGroup(room, group string, members []string) (*types.Response, error) {
+	return s.client.SetGroup(s.resolveRoom(room), group, members)
+}
+
+// ListGroups lists recipient groups via the hub.
+func (s *Storage) ListGroups(room string) (*types.Response, error) {
+	return s.client.ListGroups(s.resolveRoom(room))
+}
+
+// EditMessage edits a message via the hub.
+func (s *Storage) EditMessage(room string, messageID int, content string) (*types.Response, error) {
+	return s.client.EditMessage(s.resolveRoom(room), messageID, content)
+}
+
+// RetractMessage retracts a message via the hub.
+func (s *Storage) RetractMessage(room string, messageID int) (*types.Response, error) {
+	return s.client.RetractMessage(s.resolveRoom(room), messageID)
+}
+
+// ListScheduled lists scheduled messages via the hub.
+func (s *Storage) ListScheduled(room string) (*types.Response, error) {
+	return s.client.ListScheduled(s.resolveRoom(room))
+}
+
+// CancelScheduled cancels a scheduled message via the hub.
+func (s *Storage) CancelScheduled(room string, scheduledID int) (*types.Response, error) {
+	return s.client.CancelScheduled(s.resolveRoom(room), scheduledID)
+}
+
 // ClearRoom clears a room via the hub.
 func (s *Storage) ClearRoom(room string) (*types.Response, error) {
 	return s.client.ClearRoom(s.resolveRoom(room))
@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 
+	"desktop/internal/hubclient"
+	"desktop/internal/types"
 	"desktop/internal/validation"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -42,6 +45,11 @@ func (h *toolHandlers) joinRoom(_ context.Context, request mcp.CallToolRequest)
 	}
 	role := request.GetString("role", "")
 	room := request.GetString("room", "")
+	caps := types.Capabilities{
+		Languages: types.SplitRecipients(request.GetString("languages", "")),
+		Areas:     types.SplitRecipients(request.GetString("areas", "")),
+		Tools:     types.SplitRecipients(request.GetString("tools", "")),
+	}
 
 	if err := validation.ValidateName(agentName); err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
@@ -52,10 +60,15 @@ func (h *toolHandlers) joinRoom(_ context.Context, request mcp.CallToolRequest)
 	if len(role) > maxFieldLength {
 		return mcp.NewToolResultError(fmt.Sprintf("role too long: %d chars, max %d", len(role), maxFieldLength)), nil
 	}
+	for _, v := range caps.All() {
+		if err := validation.ValidateName(v); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
 
-	h.logger.Printf("join_room: agent=%q role=%q room=%q", agentName, role, room)
+	h.logger.Printf("join_room: agent=%q role=%q room=%q capabilities=%v", agentName, role, room, caps.All())
 
-	resp, err := h.storage.JoinRoom(room, agentName, role)
+	resp, err := h.storage.JoinRoom(room, agentName, role, &caps)
 	if err != nil {
 		h.logger.Printf("join_room: hub error: %v", err)
 		return mcp.NewToolResultError(err.Error()), nil
@@ -80,14 +93,19 @@ func (h *toolHandlers) sendMessage(_ context.Context, request mcp.CallToolReques
 	expectsReply := request.GetBool("expects_reply", true)
 	priority := request.GetString("priority", "normal")
 	room := request.GetString("room", "")
+	opts := hubclient.SendOptions{
+		DeliverAt: request.GetString("deliver_at", ""),
+		Delay:     request.GetString("delay", ""),
+		Schedule:  request.GetString("schedule", ""),
+		ExpiresAt: request.GetString("expires_at", ""),
+		TTL:       request.GetString("ttl", ""),
+	}
 
 	if err := validation.ValidateName(fromAgent); err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	if toAgent != "all" {
-		if err := validation.ValidateName(toAgent); err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
-		}
+	if err := validation.ValidateRecipients(types.SplitRecipients(toAgent)); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 	if err := validation.ValidateName(room); err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
@@ -95,11 +113,21 @@ func (h *toolHandlers) sendMessage(_ context.Context, request mcp.CallToolReques
 	if len(content) > maxFieldLength {
 		return mcp.NewToolResultError(fmt.Sprintf("content too long: %d chars, max %d", len(content), maxFieldLength)), nil
 	}
+	priority, err = types.NormalizePriority(priority)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if opts.DeliverAt != "" && opts.Delay != "" {
+		return mcp.NewToolResultError("deliver_at ve delay birlikte kullanılamaz"), nil
+	}
+	if opts.ExpiresAt != "" && opts.TTL != "" {
+		return mcp.NewToolResultError("expires_at ve ttl birlikte kullanılamaz"), nil
+	}
 
-	h.logger.Printf("send_message: from=%q to=%q room=%q priority=%s expects_reply=%v contentLen=%d",
-		fromAgent, toAgent, room, priority, expectsReply, len(content))
+	h.logger.Printf("send_message: from=%q to=%q room=%q priority=%s expects_reply=%v contentLen=%d deliver_at=%q delay=%q schedule=%q",
+		fromAgent, toAgent, room, priority, expectsReply, len(content), opts.DeliverAt, opts.Delay, opts.Schedule)
 
-	resp, err := h.storage.SendMessage(room, fromAgent, toAgent, content, expectsReply, priority)
+	resp, err := h.storage.SendMessage(room, fromAgent, toAgent, content, expectsReply, priority, opts)
 	if err != nil {
 		h.logger.Printf("send_message: hub error: %v", err)
 		return mcp.NewToolResultError(err.Error()), nil
@@ -196,6 +224,229 @@ func (h *toolHandlers) leaveRoom(_ context.Context, request mcp.CallToolRequest)
 	return mcp.NewToolResultText(extractText(resp.Data)), nil
 }
 
+func (h *toolHandlers) findAgents(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	capability, err := request.RequireString("capability")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	room := request.GetString("room", "")
+
+	if err := validation.ValidateName(strings.TrimPrefix(capability, types.CapabilityPrefix)); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := validation.ValidateName(room); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	h.logger.Printf("find_agents: capability=%q room=%q", capability, room)
+
+	resp, err := h.storage.FindAgents(room, capability)
+	if err != nil {
+		h.logger.Printf("find_agents: hub error: %v", err)
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !resp.Success {
+		return mcp.NewToolResultError(resp.Error), nil
+	}
+
+	return mcp.NewToolResultText(extractText(resp.Data)), nil
+}
+
+func (h *toolHandlers) setStatus(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	agentName, err := request.RequireString("agent_name")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	status, err := request.RequireString("status")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	text := request.GetString("text", "")
+	eta := request.GetString("eta", "")
+	room := request.GetString("room", "")
+
+	if err := validation.ValidateName(agentName); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if _, err := types.NormalizeStatus(status); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := validation.ValidateName(room); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	h.logger.Printf("set_status: agent=%q status=%q eta=%q room=%q", agentName, status, eta, room)
+
+	resp, err := h.storage.SetStatus(room, agentName, status, text, eta)
+	if err != nil {
+		h.logger.Printf("set_status: hub error: %v", err)
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !resp.Success {
+		return mcp.NewToolResultError(resp.Error), nil
+	}
+
+	return mcp.NewToolResultText(extractText(resp.Data)), nil
+}
+
+func (h *toolHandlers) setGroup(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	group, err := request.RequireString("group")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	members := types.SplitRecipients(request.GetString("members", ""))
+	room := request.GetString("room", "")
+
+	if err := validation.ValidateName(strings.TrimPrefix(group, "@")); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	for _, m := range members {
+		if err := validation.ValidateName(m); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	if err := validation.ValidateName(room); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	h.logger.Printf("set_group: group=%q members=%v room=%q", group, members, room)
+
+	resp, err := h.storage.SetGroup(room, group, members)
+	if err != nil {
+		h.logger.Printf("set_group: hub error: %v", err)
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !resp.Success {
+		return mcp.NewToolResultError(resp.Error), nil
+	}
+
+	return mcp.NewToolResultText(extractText(resp.Data)), nil
+}
+
+func (h *toolHandlers) listGroups(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	room := request.GetString("room", "")
+
+	if err := validation.ValidateName(room); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	h.logger.Printf("list_groups: room=%q", room)
+
+	resp, err := h.storage.ListGroups(room)
+	if err != nil {
+		h.logger.Printf("list_groups: hub error: %v", err)
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !resp.Success {
+		return mcp.NewToolResultError(resp.Error), nil
+	}
+
+	return mcp.NewToolResultText(extractText(resp.Data)), nil
+}
+
+func (h *toolHandlers) editMessage(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	messageID, err := request.RequireInt("message_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	content, err := request.RequireString("content")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	room := request.GetString("room", "")
+
+	if err := validation.ValidateName(room); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(content) > maxFieldLength {
+		return mcp.NewToolResultError(fmt.Sprintf("content too long: %d chars, max %d", len(content), maxFieldLength)), nil
+	}
+
+	h.logger.Printf("edit_message: id=%d room=%q contentLen=%d", messageID, room, len(content))
+
+	resp, err := h.storage.EditMessage(room, messageID, content)
+	if err != nil {
+		h.logger.Printf("edit_message: hub error: %v", err)
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !resp.Success {
+		return mcp.NewToolResultError(resp.Error), nil
+	}
+
+	return mcp.NewToolResultText(extractText(resp.Data)), nil
+}
+
+func (h *toolHandlers) retractMessage(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	messageID, err := request.RequireInt("message_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	room := request.GetString("room", "")
+
+	if err := validation.ValidateName(room); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	h.logger.Printf("retract_message: id=%d room=%q", messageID, room)
+
+	resp, err := h.storage.RetractMessage(room, messageID)
+	if err != nil {
+		h.logger.Printf("retract_message: hub error: %v", err)
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !resp.Success {
+		return mcp.NewToolResultError(resp.Error), nil
+	}
+
+	return mcp.NewToolResultText(extractText(resp.Data)), nil
+}
+
+func (h *toolHandlers) listScheduled(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	room := request.GetString("room", "")
+
+	if err := validation.ValidateName(room); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	h.logger.Printf("list_scheduled: room=%q", room)
+
+	resp, err := h.storage.ListScheduled(room)
+	if err != nil {
+		h.logger.Printf("list_scheduled: hub error: %v", err)
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !resp.Success {
+		return mcp.NewToolResultError(resp.Error), nil
+	}
+
+	return mcp.NewToolResultText(extractText(resp.Data)), nil
+}
+
+func (h *toolHandlers) cancelScheduled(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	scheduledID, err := request.RequireInt("scheduled_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	room := request.GetString("room", "")
+
+	if err := validation.ValidateName(room); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	h.logger.Printf("cancel_scheduled: id=%d room=%q", scheduledID, room)
+
+	resp, err := h.storage.CancelScheduled(room, scheduledID)
+	if err != nil {
+		h.logger.Printf("cancel_scheduled: hub error: %v", err)
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !resp.Success {
+		return mcp.NewToolResultError(resp.Error), nil
+	}
+
+	return mcp.NewToolResultText(extractText(resp.Data)), nil
+}
+
 func (h *toolHandlers) clearRoom(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	room := request.GetString("room", "")
 
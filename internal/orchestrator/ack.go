@@ -0,0 +1,234 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	ptymgr "desktop/internal/pty"
+)
+
+const (
+	// ReadAckTimeout is how long an agent has to read its messages after a
+	// notification before it is reminded. Each reminder doubles the wait.
+	ReadAckTimeout = 2 * time.Minute
+	// MaxDeliveryAttempts is how many notifications (the first one included)
+	// an unread message gets before it is escalated to the desktop.
+	MaxDeliveryAttempts = 4
+	// maxDeliveryRecords bounds the per-room delivery history.
+	maxDeliveryRecords = 200
+)
+
+// Per-message delivery states.
+const (
+	DeliveryNotified = "notified"
+	DeliveryRetrying = "retrying"
+	DeliveryRead     = "read"
+	DeliveryFailed   = "failed"
+)
+
+// MessageDelivery is the delivery state of one message for one recipient.
+type MessageDelivery struct {
+	MessageID  int    `json:"message_id"`
+	Agent      string `json:"agent"`
+	From       string `json:"from"`
+	State      string `json:"state"`
+	Attempts   int    `json:"attempts"`
+	NotifiedAt string `json:"notified_at"`
+	ReadAt     string `json:"read_at,omitempty"`
+}
+
+// DeliveryFailure is reported when an agent leaves messages unread after
+// MaxDeliveryAttempts notifications.
+type DeliveryFailure struct {
+	ChatDir    string `json:"chat_dir"`
+	Agent      string `json:"agent"`
+	MessageIDs []int  `json:"message_ids"`
+	Attempts   int    `json:"attempts"`
+}
+
+// readWait tracks the notified messages an agent has not read yet.
+type readWait struct {
+	sessionID string
+	pending   map[int]*MessageDelivery
+	attempts  int
+	timer     Timer
+}
+
+// SetDeliveryFailureHandler registers a callback for escalated deliveries.
+// It runs on its own goroutine.
+func (o *Orchestrator) SetDeliveryFailureHandler(fn func(DeliveryFailure)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.failureHandler = fn
+}
+
+// trackNotifiedLocked records that an agent was notified about messages and
+// arms the read-acknowledgement timer. Callers must hold o.mu.
+func (o *Orchestrator) trackNotifiedLocked(chatDir, agentName, sessionID string, notes []pendingNotification) {
+	key := chatDir + ":" + agentName
+	now := o.now()
+	w := o.readWaits[key]
+	for _, n := range notes {
+		if n.msgID <= 0 || n.msgID <= o.readUpTo[key] {
+			continue
+		}
+		if w == nil {
+			w = &readWait{sessionID: sessionID, pending: make(map[int]*MessageDelivery), attempts: 1}
+			o.readWaits[key] = w
+		}
+		if _, ok := w.pending[n.msgID]; ok {
+			continue
+		}
+		rec := &MessageDelivery{MessageID: n.msgID, Agent: agentName, From: n.from, State: DeliveryNotified, Attempts: 1, NotifiedAt: now.Format(time.RFC3339)}
+		w.pending[n.msgID] = rec
+		o.appendRecordLocked(chatDir, rec)
+	}
+	if w != nil && w.timer == nil && len(w.pending) > 0 {
+		w.sessionID = sessionID
+		w.timer = o.afterFunc(ReadAckTimeout, func() {
+			o.retryUnread(chatDir, agentName)
+		})
+	}
+}
+
+func (o *Orchestrator) appendRecordLocked(chatDir string, rec *MessageDelivery) {
+	records := append(o.deliveryRecords[chatDir], rec)
+	if over := len(records) - maxDeliveryRecords; over > 0 {
+		records = records[over:]
+	}
+	o.deliveryRecords[chatDir] = records
+}
+
+// AckRead marks messages as read by an agent: the listed IDs, and every
+// message up to upToID when it is non-zero.
+func (o *Orchestrator) AckRead(chatDir, agentName string, ids []int, upToID int) {
+	key := chatDir + ":" + agentName
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if upToID > o.readUpTo[key] {
+		o.readUpTo[key] = upToID
+	}
+	w := o.readWaits[key]
+	if w == nil {
+		return
+	}
+	read := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		read[id] = true
+	}
+	now := o.now().Format(time.RFC3339)
+	for id, rec := range w.pending {
+		if read[id] || id <= upToID {
+			rec.State = DeliveryRead
+			rec.ReadAt = now
+			delete(w.pending, id)
+		}
+	}
+	if len(w.pending) == 0 {
+		o.stopReadWaitLocked(key)
+	}
+}
+
+// forgetMessageLocked stops waiting for a message that no longer needs to be
+// read (e.g. retracted). Callers must hold o.mu.
+func (o *Orchestrator) forgetMessageLocked(chatDir, agentName string, msgID int) {
+	key := chatDir + ":" + agentName
+	if w := o.readWaits[key]; w != nil {
+		delete(w.pending, msgID)
+		if len(w.pending) == 0 {
+			o.stopReadWaitLocked(key)
+		}
+	}
+}
+
+func (o *Orchestrator) stopReadWaitLocked(key string) {
+	if w := o.readWaits[key]; w != nil && w.timer != nil {
+		w.timer.Stop()
+	}
+	delete(o.readWaits, key)
+}
+
+// retryUnread reminds an agent about messages it has not read, doubling the
+// wait each time, and escalates after MaxDeliveryAttempts notifications.
+func (o *Orchestrator) retryUnread(chatDir, agentName string) {
+	key := chatDir + ":" + agentName
+	o.mu.Lock()
+	w := o.readWaits[key]
+	if w == nil || len(w.pending) == 0 {
+		o.mu.Unlock()
+		return
+	}
+	w.timer = nil
+
+	// Delivery settings that hold notifications also hold reminders.
+	if until, _, held := o.holdLocked(key, o.now()); held {
+		wait := ReadAckTimeout
+		if !until.IsZero() {
+			wait = until.Sub(o.now())
+		}
+		w.timer = o.afterFunc(wait, func() { o.retryUnread(chatDir, agentName) })
+		o.mu.Unlock()
+		return
+	}
+
+	ids := make([]int, 0, len(w.pending))
+	senders := make(map[string]struct{})
+	for id, rec := range w.pending {
+		ids = append(ids, id)
+		senders[rec.From] = struct{}{}
+	}
+	sort.Ints(ids)
+
+	if w.attempts >= MaxDeliveryAttempts {
+		for _, rec := range w.pending {
+			rec.State = DeliveryFailed
+		}
+		failure := DeliveryFailure{ChatDir: chatDir, Agent: agentName, MessageIDs: ids, Attempts: w.attempts}
+		delete(o.readWaits, key)
+		handler := o.failureHandler
+		o.mu.Unlock()
+		log.Printf("[ORCH] Delivery failed agent=%s messages=%v after %d notifications", agentName, ids, failure.Attempts)
+		if handler != nil {
+			go handler(failure)
+		}
+		return
+	}
+
+	w.attempts++
+	for _, rec := range w.pending {
+		rec.State = DeliveryRetrying
+		rec.Attempts = w.attempts
+	}
+	backoff := ReadAckTimeout << (w.attempts - 1)
+	w.timer = o.afterFunc(backoff, func() { o.retryUnread(chatDir, agentName) })
+	sessionID, attempt := w.sessionID, w.attempts
+	o.lastNotified[key] = o.now()
+	o.mu.Unlock()
+
+	senderList := make([]string, 0, len(senders))
+	for s := range senders {
+		senderList = append(senderList, s)
+	}
+	sort.Strings(senderList)
+	prompt := fmt.Sprintf("[agent-chat] Reminder: %d unread messages from %s. read_messages(\"%s\") to read and respond.",
+		len(ids), strings.Join(senderList, ", "), agentName)
+	log.Printf("[ORCH] Unread reminder %d/%d agent=%s session=%s", attempt, MaxDeliveryAttempts, agentName, ptymgr.ShortID(sessionID))
+	o.deliver(sessionID, prompt, false)
+}
+
+// DeliveryStates returns the recent per-message delivery states of a room,
+// oldest first.
+func (o *Orchestrator) DeliveryStates(chatDir string) []MessageDelivery {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	records := o.deliveryRecords[chatDir]
+	out := make([]MessageDelivery, len(records))
+	for i, rec := range records {
+		out[i] = *rec
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package orchestrator
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// stopReadTimer cancels the pending ack timer so tests drive retries by hand.
+func stopReadTimer(o *Orchestrator, key string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if w := o.readWaits[key]; w != nil && w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+func TestAckRead_ClearsPendingDelivery(t *testing.T) {
+	o, _ := newTestOrchestrator()
+	key := "/rooms/t:agent-1"
+
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false, 5)
+	stopReadTimer(o, key)
+
+	states := o.DeliveryStates("/rooms/t")
+	if len(states) != 1 || states[0].MessageID != 5 || states[0].State != DeliveryNotified {
+		t.Fatalf("expected message 5 notified, got %+v", states)
+	}
+
+	o.AckRead("/rooms/t", "agent-1", []int{5}, 0)
+	states = o.DeliveryStates("/rooms/t")
+	if states[0].State != DeliveryRead || states[0].ReadAt == "" {
+		t.Fatalf("expected message 5 read, got %+v", states[0])
+	}
+	o.mu.Lock()
+	_, waiting := o.readWaits[key]
+	o.mu.Unlock()
+	if waiting {
+		t.Error("read wait should be cleared once everything is read")
+	}
+
+	// Messages already read before the notification lands are not tracked.
+	o.AckRead("/rooms/t", "agent-1", nil, 9)
+	o.mu.Lock()
+	o.lastNotified[key] = time.Time{}
+	o.mu.Unlock()
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false, 8)
+	if n := len(o.DeliveryStates("/rooms/t")); n != 1 {
+		t.Fatalf("expected no record for an already read message, got %d records", n)
+	}
+}
+
+func TestRetryUnread_RemindsThenEscalates(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	key := "/rooms/t:agent-1"
+	failures := make(chan DeliveryFailure, 1)
+	o.SetDeliveryFailureHandler(func(f DeliveryFailure) { failures <- f })
+
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false, 3)
+	stopReadTimer(o, key)
+
+	for attempt := 2; attempt <= MaxDeliveryAttempts; attempt++ {
+		o.retryUnread("/rooms/t", "agent-1")
+		stopReadTimer(o, key)
+		last := (*sent)[len(*sent)-1].text
+		if !strings.Contains(last, "Reminder: 1 unread messages from agent-2") {
+			t.Fatalf("attempt %d: expected reminder, got %q", attempt, last)
+		}
+		if st := o.DeliveryStates("/rooms/t")[0]; st.State != DeliveryRetrying || st.Attempts != attempt {
+			t.Fatalf("attempt %d: unexpected state %+v", attempt, st)
+		}
+	}
+	if len(*sent) != MaxDeliveryAttempts {
+		t.Fatalf("expected %d notifications, got %d", MaxDeliveryAttempts, len(*sent))
+	}
+
+	o.retryUnread("/rooms/t", "agent-1")
+	select {
+	case f := <-failures:
+		if f.Agent != "agent-1" || len(f.MessageIDs) != 1 || f.MessageIDs[0] != 3 || f.Attempts != MaxDeliveryAttempts {
+			t.Fatalf("unexpected failure %+v", f)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected escalation")
+	}
+	if st := o.DeliveryStates("/rooms/t")[0]; st.State != DeliveryFailed {
+		t.Fatalf("expected failed state, got %+v", st)
+	}
+	if len(*sent) != MaxDeliveryAttempts {
+		t.Fatal("escalation must not send another reminder")
+	}
+}
@@ -0,0 +1,310 @@
+package orchestrator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"desktop/internal/team"
+	"desktop/internal/types"
+)
+
+// Analysis actions.
+const (
+	ActionSkip   = "skip"
+	ActionNotify = "notify"
+)
+
+// AckMaxOtherWords is how many non-acknowledgment words a message may contain
+// and still be treated as an acknowledgment.
+const AckMaxOtherWords = 3
+
+// ACK patterns - short acknowledgment messages to skip.
+// Patterns match whole words; a trailing "*" matches any word suffix.
+var ACKPatterns = []string{
+	"tesekkur*", "sagol*", "eyvallah", "tamam", "tamamdir", "anladim", "anlasildi",
+	"ok", "okay", "oldu", "super", "harika", "mukemmel", "guzel", "rica ederim",
+	"bir sey degil", "thanks", "thank you", "thx", "got it", "perfect", "great",
+	"gorusuruz", "iyi calismalar", "evet", "hayir", "peki", "olur", "elbette",
+}
+
+// Question patterns - these should always be notified.
+var QuestionPatterns = []string{
+	"?", "nasil", "neden", "niye", "ne zaman", "nerede", "kim", "hangi",
+	"yapabilir mi*", "mumkun mu", "var mi", "bilir mi*", "ister mi*",
+	"how", "what", "why", "when", "where", "who", "which", "can you", "could you",
+}
+
+// AnalysisResult represents the decision about a message
+type AnalysisResult struct {
+	Action     string `json:"action"` // "skip" or "notify"
+	Reason     string `json:"reason"`
+	IsQuestion bool   `json:"is_question"`
+}
+
+// Analyzer decides whether a message should nudge its recipients' terminals.
+type Analyzer interface {
+	Analyze(msg types.Message) AnalysisResult
+}
+
+// HeuristicAnalyzer is the default analyzer: it skips short acknowledgments,
+// always notifies questions and honors message priority.
+type HeuristicAnalyzer struct{}
+
+// Analyze implements Analyzer.
+func (HeuristicAnalyzer) Analyze(msg types.Message) AnalysisResult {
+	switch msg.Priority {
+	case types.PriorityLow:
+		return AnalysisResult{Action: ActionSkip, Reason: "Low priority (delivered on next read)"}
+	case types.PriorityUrgent:
+		return AnalysisResult{Action: ActionNotify, Reason: "Urgent priority", IsQuestion: matchesAny(msg.Content, QuestionPatterns)}
+	}
+
+	content := msg.Content
+	expectsReply := msg.ExpectsReply
+
+	isQuestion := matchesAny(content, QuestionPatterns)
+	isShort := len([]rune(content)) < AckMsgMaxLength
+	isAck := isShort && !isQuestion && isMostlyAck(content)
+
+	// Decision
+	if isAck && !expectsReply {
+		return AnalysisResult{Action: ActionSkip, Reason: "Acknowledgment (expects_reply=false)", IsQuestion: false}
+	} else if isAck {
+		return AnalysisResult{Action: ActionSkip, Reason: "Short acknowledgment message", IsQuestion: false}
+	} else if isQuestion {
+		return AnalysisResult{Action: ActionNotify, Reason: "Question - response needed", IsQuestion: true}
+	} else if expectsReply {
+		return AnalysisResult{Action: ActionNotify, Reason: "Response expected", IsQuestion: false}
+	}
+	return AnalysisResult{Action: ActionNotify, Reason: "Informational", IsQuestion: false}
+}
+
+// AnalyzeMessage analyzes a message with the default heuristic.
+func AnalyzeMessage(msg types.Message) AnalysisResult {
+	return HeuristicAnalyzer{}.Analyze(msg)
+}
+
+// RuleAnalyzer applies per-team rules in order; the first matching rule
+// decides. Messages no rule matches are passed to Fallback.
+type RuleAnalyzer struct {
+	rules    []compiledRule
+	Fallback Analyzer
+}
+
+type compiledRule struct {
+	name     string
+	patterns []pattern
+	sender   string
+	priority string
+	action   string
+}
+
+// NewRuleAnalyzer validates and compiles team rules. A nil fallback means
+// HeuristicAnalyzer.
+func NewRuleAnalyzer(rules []team.AnalysisRule, fallback Analyzer) (*RuleAnalyzer, error) {
+	if fallback == nil {
+		fallback = HeuristicAnalyzer{}
+	}
+	ra := &RuleAnalyzer{Fallback: fallback}
+	for i, r := range rules {
+		name := strings.TrimSpace(r.Name)
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+		action := strings.ToLower(strings.TrimSpace(r.Action))
+		if action != ActionSkip && action != ActionNotify {
+			return nil, fmt.Errorf("%s: action must be %q or %q, got %q", name, ActionSkip, ActionNotify, r.Action)
+		}
+		cr := compiledRule{
+			name:   name,
+			sender: strings.TrimSpace(r.Sender),
+			action: action,
+		}
+		if r.Priority != "" {
+			p, err := types.NormalizePriority(r.Priority)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			cr.priority = p
+		}
+		for _, raw := range r.Patterns {
+			p, err := compilePattern(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			cr.patterns = append(cr.patterns, p)
+		}
+		if len(cr.patterns) == 0 && cr.sender == "" && cr.priority == "" {
+			return nil, fmt.Errorf("%s: at least one of patterns, sender or priority is required", name)
+		}
+		ra.rules = append(ra.rules, cr)
+	}
+	return ra, nil
+}
+
+// Analyze implements Analyzer.
+func (a *RuleAnalyzer) Analyze(msg types.Message) AnalysisResult {
+	for _, r := range a.rules {
+		if r.matches(msg) {
+			return AnalysisResult{
+				Action:     r.action,
+				Reason:     "Rule: " + r.name,
+				IsQuestion: matchesAny(msg.Content, QuestionPatterns),
+			}
+		}
+	}
+	return a.Fallback.Analyze(msg)
+}
+
+func (r compiledRule) matches(msg types.Message) bool {
+	if r.sender != "" && r.sender != "*" && !strings.EqualFold(r.sender, msg.From) {
+		return false
+	}
+	if r.priority != "" {
+		priority := msg.Priority
+		if priority == "" {
+			priority = types.PriorityNormal
+		}
+		if priority != r.priority {
+			return false
+		}
+	}
+	if len(r.patterns) == 0 {
+		return true
+	}
+	tokens := tokenize(msg.Content)
+	for _, p := range r.patterns {
+		if p.match(msg.Content, tokens) {
+			return true
+		}
+	}
+	return false
+}
+
+// pattern is a compiled match expression. Plain patterns match a sequence of
+// whole words (a trailing "*" allows a suffix on the last word); patterns
+// without any word characters (e.g. "?") match as substrings; "re:" patterns
+// are regular expressions over the folded, lowercased content.
+type pattern struct {
+	words  []string
+	prefix bool
+	substr string
+	re     *regexp.Regexp
+}
+
+func compilePattern(raw string) (pattern, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return pattern{}, fmt.Errorf("empty pattern")
+	}
+	if expr, ok := strings.CutPrefix(raw, "re:"); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return pattern{}, fmt.Errorf("invalid pattern %q: %w", raw, err)
+		}
+		return pattern{re: re}, nil
+	}
+	prefix := strings.HasSuffix(raw, "*")
+	words := tokenize(strings.TrimSuffix(raw, "*"))
+	if len(words) == 0 {
+		return pattern{substr: raw}, nil
+	}
+	return pattern{words: words, prefix: prefix}, nil
+}
+
+func (p pattern) match(content string, tokens []string) bool {
+	switch {
+	case p.re != nil:
+		return p.re.MatchString(foldText(content))
+	case p.substr != "":
+		return strings.Contains(content, p.substr)
+	}
+	return len(p.wordMatches(tokens)) > 0
+}
+
+// wordMatches returns the start index of every occurrence of a word pattern.
+func (p pattern) wordMatches(tokens []string) []int {
+	var starts []int
+	n := len(p.words)
+	for i := 0; n > 0 && i+n <= len(tokens); i++ {
+		ok := true
+		for j, w := range p.words {
+			tok := tokens[i+j]
+			if j == n-1 && p.prefix {
+				ok = strings.HasPrefix(tok, w)
+			} else {
+				ok = tok == w
+			}
+			if !ok {
+				break
+			}
+		}
+		if ok {
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
+
+// isMostlyAck reports whether content is an acknowledgment: it contains ACK
+// words and at most AckMaxOtherWords other words ("thanks, looks good" but not
+// "great news: CI is green on main").
+func isMostlyAck(content string) bool {
+	tokens := tokenize(content)
+	covered := make([]bool, len(tokens))
+	found := false
+	for _, raw := range ACKPatterns {
+		p, err := compilePattern(raw)
+		if err != nil || len(p.words) == 0 {
+			continue
+		}
+		for _, start := range p.wordMatches(tokens) {
+			found = true
+			for k := start; k < start+len(p.words); k++ {
+				covered[k] = true
+			}
+		}
+	}
+	if !found {
+		return false
+	}
+	other := 0
+	for _, c := range covered {
+		if !c {
+			other++
+		}
+	}
+	return other <= AckMaxOtherWords
+}
+
+// matchesAny reports whether content matches any of the raw patterns.
+func matchesAny(content string, raw []string) bool {
+	tokens := tokenize(content)
+	for _, r := range raw {
+		p, err := compilePattern(r)
+		if err == nil && p.match(content, tokens) {
+			return true
+		}
+	}
+	return false
+}
+
+// turkishFold maps Turkish letters to their ASCII counterparts so "teşekkürler"
+// and "tesekkurler" match the same pattern.
+var turkishFold = strings.NewReplacer(
+	"ç", "c", "Ç", "c", "ğ", "g", "Ğ", "g", "ı", "i", "İ", "i",
+	"ö", "o", "Ö", "o", "ş", "s", "Ş", "s", "ü", "u", "Ü", "u",
+)
+
+func foldText(s string) string {
+	return strings.ToLower(turkishFold.Replace(s))
+}
+
+// tokenize splits folded text into lowercase words of letters and digits.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(foldText(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,106 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"desktop/internal/team"
+	"desktop/internal/types"
+)
+
+type corpusCase struct {
+	Content      string `json:"content"`
+	ExpectsReply bool   `json:"expects_reply"`
+	Priority     string `json:"priority"`
+	Want         string `json:"want"`
+	Question     bool   `json:"question"`
+	Note         string `json:"note"`
+}
+
+func TestHeuristicAnalyzer_Corpus(t *testing.T) {
+	data, err := os.ReadFile("testdata/analysis_corpus.json")
+	if err != nil {
+		t.Fatalf("read corpus: %v", err)
+	}
+	var corpus []corpusCase
+	if err := json.Unmarshal(data, &corpus); err != nil {
+		t.Fatalf("parse corpus: %v", err)
+	}
+
+	for _, c := range corpus {
+		msg := types.Message{From: "alice", To: "bob", Content: c.Content, ExpectsReply: c.ExpectsReply, Priority: c.Priority}
+		r := HeuristicAnalyzer{}.Analyze(msg)
+		if r.Action != c.Want {
+			t.Errorf("%q: want %s, got %s (%s) %s", c.Content, c.Want, r.Action, r.Reason, c.Note)
+		}
+		if c.Question && !r.IsQuestion {
+			t.Errorf("%q: expected to be detected as a question", c.Content)
+		}
+	}
+}
+
+func TestRuleAnalyzer_FirstMatchWinsAndFallsBack(t *testing.T) {
+	ra, err := NewRuleAnalyzer([]team.AnalysisRule{
+		{Name: "ci bot noise", Sender: "ci", Patterns: []string{"build passed", "re:^coverage \\d+%"}, Action: "skip"},
+		{Name: "manager fyi", Sender: "manager", Priority: "low", Action: "notify"},
+		{Name: "lgtm", Patterns: []string{"lgtm*"}, Action: "skip"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("compile rules: %v", err)
+	}
+
+	cases := []struct {
+		msg  types.Message
+		want string
+	}{
+		{types.Message{From: "ci", Content: "Build passed on main", ExpectsReply: true}, ActionSkip},
+		{types.Message{From: "ci", Content: "Coverage 81% (+2)", ExpectsReply: true}, ActionSkip},
+		{types.Message{From: "ci", Content: "Build failed: 3 tests", ExpectsReply: true}, ActionNotify},
+		{types.Message{From: "manager", Content: "FYI: sprint ends Friday", Priority: "low"}, ActionNotify},
+		{types.Message{From: "alice", Content: "FYI: sprint ends Friday", Priority: "low"}, ActionSkip},
+		{types.Message{From: "alice", Content: "LGTM!! merge when ready", ExpectsReply: true}, ActionSkip},
+		{types.Message{From: "alice", Content: "tamam", ExpectsReply: false}, ActionSkip},
+	}
+	for _, c := range cases {
+		if got := ra.Analyze(c.msg); got.Action != c.want {
+			t.Errorf("%s: %q: want %s, got %s (%s)", c.msg.From, c.msg.Content, c.want, got.Action, got.Reason)
+		}
+	}
+}
+
+func TestNewRuleAnalyzer_RejectsInvalidRules(t *testing.T) {
+	invalid := [][]team.AnalysisRule{
+		{{Patterns: []string{"x"}, Action: "ignore"}},
+		{{Patterns: []string{"re:("}, Action: "skip"}},
+		{{Priority: "critical", Action: "skip"}},
+		{{Action: "skip"}},
+	}
+	for i, rules := range invalid {
+		if _, err := NewRuleAnalyzer(rules, nil); err == nil {
+			t.Errorf("case %d: expected rules %+v to be rejected", i, rules)
+		}
+	}
+}
+
+func TestProcessMessage_UsesTeamAnalyzer(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	o.RegisterAgent("/rooms/t", "bob", "sess-bob")
+
+	ra, err := NewRuleAnalyzer([]team.AnalysisRule{{Sender: "ci", Action: "skip"}}, nil)
+	if err != nil {
+		t.Fatalf("compile rules: %v", err)
+	}
+	o.SetAnalyzer("/rooms/t", ra)
+
+	o.ProcessMessage("/rooms/t", types.Message{From: "ci", To: "bob", Content: "Nightly build finished", Type: "direct", ExpectsReply: true})
+	if len(*sent) != 0 {
+		t.Fatalf("expected team rule to skip ci message, got %d notifications", len(*sent))
+	}
+
+	o.SetAnalyzer("/rooms/t", nil)
+	o.ProcessMessage("/rooms/t", types.Message{From: "ci", To: "bob", Content: "Nightly build finished", Type: "direct", ExpectsReply: true})
+	if len(*sent) != 1 {
+		t.Fatalf("expected default analyzer to notify after rules were cleared, got %d", len(*sent))
+	}
+}
@@ -0,0 +1,55 @@
+package orchestrator
+
+import "time"
+
+// Clock is the orchestrator's time source for cooldowns, batching, delivery
+// settings, loop detection and read reminders. Replays drive it by hand so a
+// recorded run's timing is reproduced independent of wall time.
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending AfterFunc call.
+type Timer interface {
+	Stop() bool
+}
+
+// SetClock replaces the orchestrator's time source. nil restores wall time.
+// Set it before any message is processed.
+func (o *Orchestrator) SetClock(c Clock) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.clock = c
+}
+
+// SetTerminalSink sends notifications to fn instead of the PTY sessions, for
+// runs without real terminals (replays). Sessions count as idle and ready.
+func (o *Orchestrator) SetTerminalSink(fn func(sessionID, text string)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sendFunc = fn
+	o.activityFunc = func(string) string { return "" }
+	o.readyFunc = func(string) bool { return true }
+}
+
+func (o *Orchestrator) now() time.Time {
+	if o.clock == nil {
+		return time.Now()
+	}
+	return o.clock.Now()
+}
+
+func (o *Orchestrator) afterFunc(d time.Duration, f func()) Timer {
+	if o.clock == nil {
+		return time.AfterFunc(d, f)
+	}
+	return o.clock.AfterFunc(d, f)
+}
+
+// sleep blocks for d on the orchestrator's clock.
+func (o *Orchestrator) sleep(d time.Duration) {
+	done := make(chan struct{})
+	o.afterFunc(d, func() { close(done) })
+	<-done
+}
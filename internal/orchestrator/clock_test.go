@@ -0,0 +1,100 @@
+package orchestrator
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// manualClock only moves when the test advances it.
+type manualClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+}
+
+type manualTimer struct {
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+func (t *manualTimer) Stop() bool {
+	was := !t.stopped
+	t.stopped = true
+	return was
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &manualTimer{at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// pending counts the timers that have not fired yet.
+func (c *manualClock) pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+func (c *manualClock) advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	timers := c.timers
+	c.timers = nil
+	var due []*manualTimer
+	for _, t := range timers {
+		if t.stopped {
+			continue
+		}
+		if t.at.After(c.now) {
+			c.timers = append(c.timers, t)
+			continue
+		}
+		t.stopped = true
+		due = append(due, t)
+	}
+	c.mu.Unlock()
+	for _, t := range due {
+		t.f()
+	}
+}
+
+func TestClock_CooldownFollowsInjectedTime(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	clock := &manualClock{now: time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)}
+	o.SetClock(clock)
+
+	o.notifyAgent("/rooms/t", "dev", "sess-1", "qa", false, 1)
+	o.notifyAgent("/rooms/t", "dev", "sess-1", "lead", false, 2)
+	if len(*sent) != 1 {
+		t.Fatalf("second notification inside the cooldown should be batched, sent %d", len(*sent))
+	}
+
+	// Wall time passing does not flush the batch; the injected clock does.
+	clock.advance(NotifyCooldown - time.Millisecond)
+	if len(*sent) != 1 {
+		t.Fatalf("batch flushed before the cooldown ended: %d sent", len(*sent))
+	}
+	clock.advance(time.Millisecond)
+	if len(*sent) != 2 || !strings.Contains((*sent)[1].text, "lead") {
+		t.Fatalf("batch not flushed at the end of the cooldown: %+v", *sent)
+	}
+
+	o.mu.Lock()
+	last := o.lastNotified["/rooms/t:dev"]
+	o.mu.Unlock()
+	if now := clock.Now(); !last.Equal(now) {
+		t.Errorf("lastNotified = %v, want the injected time %v", last, now)
+	}
+}
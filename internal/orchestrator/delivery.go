@@ -0,0 +1,226 @@
+package orchestrator
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	ptymgr "desktop/internal/pty"
+)
+
+const (
+	// DeliveryPollInterval is how often a queued notification re-checks
+	// whether its CLI is at the input prompt.
+	DeliveryPollInterval = 250 * time.Millisecond
+	// MaxDeliveryWait is how long a notification waits for the input prompt
+	// before it is delivered anyway.
+	MaxDeliveryWait = 60 * time.Second
+	// MaxUrgentDeliveryWait is the shorter fallback for urgent notifications.
+	MaxUrgentDeliveryWait = 10 * time.Second
+)
+
+// queuedNotification is a notification waiting for its CLI to be ready.
+type queuedNotification struct {
+	text     string
+	urgent   bool
+	enqueued time.Time
+}
+
+// sessionQueue holds the notifications waiting for one PTY session.
+type sessionQueue struct {
+	items   []queuedNotification
+	running bool // a delivery worker owns this queue
+	worker  int  // generation of the owning worker
+}
+
+// DeliveryMetrics summarizes notification delivery latency.
+type DeliveryMetrics struct {
+	Delivered     int     `json:"delivered"`
+	Forced        int     `json:"forced"` // delivered after the max-wait fallback
+	Dropped       int     `json:"dropped"`
+	Pending       int     `json:"pending"`
+	AvgLatencyMs  float64 `json:"avg_latency_ms"`
+	MaxLatencyMs  float64 `json:"max_latency_ms"`
+	LastLatencyMs float64 `json:"last_latency_ms"`
+}
+
+// deliveryState is the orchestrator's per-session delivery queue.
+type deliveryState struct {
+	mu           sync.Mutex
+	queues       map[string]*sessionQueue // sessionID → queue
+	generation   int                      // last worker generation handed out
+	delivered    int
+	forced       int
+	dropped      int
+	totalLatency time.Duration
+	maxLatency   time.Duration
+	lastLatency  time.Duration
+}
+
+func newDeliveryState() *deliveryState {
+	return &deliveryState{queues: make(map[string]*sessionQueue)}
+}
+
+// isReady reports whether the session's CLI is at its input prompt.
+func (o *Orchestrator) isReady(sessionID string) bool {
+	if o.readyFunc != nil {
+		return o.readyFunc(sessionID)
+	}
+	if o.ptyManager == nil {
+		return true
+	}
+	return o.ptyManager.ReadyForInput(sessionID)
+}
+
+// deliver queues a notification for a session and releases it once the CLI is
+// at its input prompt. When nothing is queued and the CLI is ready it is sent
+// right away on the caller's goroutine. Urgent notifications jump the queue.
+func (o *Orchestrator) deliver(sessionID, text string, urgent bool) {
+	d := o.delivery
+	item := queuedNotification{text: text, urgent: urgent, enqueued: o.now()}
+
+	d.mu.Lock()
+	q := d.queues[sessionID]
+	if q == nil {
+		q = &sessionQueue{}
+		d.queues[sessionID] = q
+	}
+	if len(q.items) == 0 && !q.running && o.isReady(sessionID) {
+		d.mu.Unlock()
+		o.sendToTerminal(sessionID, text)
+		o.recordDelivery(0, false)
+		return
+	}
+	if urgent {
+		pos := 0
+		for pos < len(q.items) && q.items[pos].urgent {
+			pos++
+		}
+		q.items = append(q.items[:pos], append([]queuedNotification{item}, q.items[pos:]...)...)
+	} else {
+		q.items = append(q.items, item)
+	}
+	startWorker := !q.running
+	if startWorker {
+		d.generation++
+		q.worker = d.generation
+		q.running = true
+	}
+	worker := q.worker
+	pending := len(q.items)
+	d.mu.Unlock()
+
+	log.Printf("[ORCH] Notification queued session=%s urgent=%v pending=%d", ptymgr.ShortID(sessionID), urgent, pending)
+	if startWorker {
+		go o.deliveryWorker(sessionID, worker)
+	}
+}
+
+// deliveryWorker drains a session's queue, one notification per ready prompt.
+// It exits once the queue is dropped or owned by a newer worker.
+func (o *Orchestrator) deliveryWorker(sessionID string, worker int) {
+	d := o.delivery
+	for {
+		d.mu.Lock()
+		q := d.queues[sessionID]
+		if q == nil || q.worker != worker {
+			d.mu.Unlock()
+			return
+		}
+		if len(q.items) == 0 {
+			q.running = false
+			d.mu.Unlock()
+			return
+		}
+		head := q.items[0]
+		d.mu.Unlock()
+
+		maxWait := MaxDeliveryWait
+		if head.urgent {
+			maxWait = MaxUrgentDeliveryWait
+		}
+		ready := o.isReady(sessionID)
+		waited := o.now().Sub(head.enqueued)
+		if !ready && waited < maxWait {
+			if o.ptyManager != nil && o.ptyManager.GetSession(sessionID) == nil {
+				o.dropQueue(sessionID)
+				return
+			}
+			o.sleep(DeliveryPollInterval)
+			continue
+		}
+
+		d.mu.Lock()
+		q = d.queues[sessionID]
+		if q == nil || q.worker != worker || len(q.items) == 0 || q.items[0] != head {
+			// Queue was dropped or reordered by an urgent notification; re-evaluate.
+			d.mu.Unlock()
+			continue
+		}
+		q.items = q.items[1:]
+		d.mu.Unlock()
+
+		if !ready {
+			log.Printf("[ORCH] CLI not at prompt after %s, delivering anyway session=%s", waited.Round(time.Second), ptymgr.ShortID(sessionID))
+		}
+		o.sendToTerminal(sessionID, head.text)
+		o.recordDelivery(waited, !ready)
+	}
+}
+
+// dropQueue discards a session's queued notifications (session closed).
+func (o *Orchestrator) dropQueue(sessionID string) {
+	d := o.delivery
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if q, ok := d.queues[sessionID]; ok {
+		d.dropped += len(q.items)
+		if len(q.items) > 0 {
+			log.Printf("[ORCH] Dropped %d queued notifications for closed session=%s", len(q.items), ptymgr.ShortID(sessionID))
+		}
+		q.items = nil
+		q.running = false
+		delete(d.queues, sessionID)
+	}
+}
+
+func (o *Orchestrator) recordDelivery(latency time.Duration, forced bool) {
+	d := o.delivery
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.delivered++
+	if forced {
+		d.forced++
+	}
+	d.totalLatency += latency
+	d.lastLatency = latency
+	if latency > d.maxLatency {
+		d.maxLatency = latency
+	}
+}
+
+// DeliveryMetrics returns notification delivery counters and latency.
+func (o *Orchestrator) DeliveryMetrics() DeliveryMetrics {
+	d := o.delivery
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	m := DeliveryMetrics{
+		Delivered:     d.delivered,
+		Forced:        d.forced,
+		Dropped:       d.dropped,
+		MaxLatencyMs:  durationMs(d.maxLatency),
+		LastLatencyMs: durationMs(d.lastLatency),
+	}
+	if d.delivered > 0 {
+		m.AvgLatencyMs = durationMs(d.totalLatency / time.Duration(d.delivered))
+	}
+	for _, q := range d.queues {
+		m.Pending += len(q.items)
+	}
+	return m
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,141 @@
+package orchestrator
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeliver_ImmediateWhenReady(t *testing.T) {
+	o, sent := newTestOrchestrator()
+
+	o.deliver("sess-1", "hello", false)
+
+	if len(*sent) != 1 || (*sent)[0].text != "hello" {
+		t.Fatalf("expected immediate delivery, got %+v", *sent)
+	}
+	if m := o.DeliveryMetrics(); m.Delivered != 1 || m.Pending != 0 {
+		t.Fatalf("unexpected metrics: %+v", m)
+	}
+}
+
+func TestDeliver_QueuesUntilPromptAndUrgentJumpsAhead(t *testing.T) {
+	var mu sync.Mutex
+	var texts []string
+	o, _ := newTestOrchestrator()
+	o.sendFunc = func(sessionID, text string) {
+		mu.Lock()
+		texts = append(texts, text)
+		mu.Unlock()
+	}
+	var ready atomic.Bool
+	o.readyFunc = func(string) bool { return ready.Load() }
+
+	o.deliver("sess-1", "first", false)
+	o.deliver("sess-1", "second", false)
+	o.deliver("sess-1", "urgent", true)
+
+	time.Sleep(2 * DeliveryPollInterval)
+	mu.Lock()
+	early := len(texts)
+	mu.Unlock()
+	if early != 0 {
+		t.Fatalf("expected nothing delivered while CLI is not at its prompt, got %d", early)
+	}
+	if m := o.DeliveryMetrics(); m.Pending != 3 {
+		t.Fatalf("expected 3 pending, got %+v", m)
+	}
+
+	ready.Store(true)
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if o.DeliveryMetrics().Delivered == 3 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(texts) != 3 || texts[0] != "urgent" || texts[1] != "first" || texts[2] != "second" {
+		t.Fatalf("expected urgent first then FIFO, got %v", texts)
+	}
+	if m := o.DeliveryMetrics(); m.Forced != 0 || m.MaxLatencyMs <= 0 {
+		t.Fatalf("expected measured, unforced deliveries, got %+v", m)
+	}
+}
+
+func TestUnregisterAgent_DropsQueuedNotifications(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	o.readyFunc = func(string) bool { return false }
+	o.RegisterAgent("/rooms/t", "bob", "sess-bob")
+
+	o.deliver("sess-bob", "queued", false)
+	o.UnregisterAgent("/rooms/t", "bob")
+
+	time.Sleep(2 * DeliveryPollInterval)
+	if len(*sent) != 0 {
+		t.Fatalf("expected queued notification to be dropped, got %+v", *sent)
+	}
+	if m := o.DeliveryMetrics(); m.Dropped != 1 || m.Pending != 0 {
+		t.Fatalf("unexpected metrics after unregister: %+v", m)
+	}
+}
+
+func TestDeliver_StaleWorkerExitsAfterDrop(t *testing.T) {
+	var mu sync.Mutex
+	var texts []string
+	o, _ := newTestOrchestrator()
+	o.sendFunc = func(sessionID, text string) {
+		mu.Lock()
+		texts = append(texts, text)
+		mu.Unlock()
+	}
+	var ready atomic.Bool
+	o.readyFunc = func(string) bool { return ready.Load() }
+	clock := &manualClock{now: time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)}
+	o.SetClock(clock)
+
+	waitPending := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for clock.pending() != n {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d sleeping workers, got %d", n, clock.pending())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// The first worker is asleep when its queue is dropped and a second
+	// worker takes over the session.
+	o.deliver("sess-1", "dropped", false)
+	waitPending(1)
+	o.dropQueue("sess-1")
+	o.deliver("sess-1", "kept", false)
+	waitPending(2)
+
+	// Only the current worker polls again.
+	clock.advance(DeliveryPollInterval)
+	waitPending(1)
+	time.Sleep(20 * time.Millisecond)
+	if n := clock.pending(); n != 1 {
+		t.Fatalf("stale worker still polling: %d sleeping workers", n)
+	}
+
+	// The fallback deadline follows the injected clock, not wall time.
+	clock.advance(MaxDeliveryWait)
+	deadline := time.Now().Add(2 * time.Second)
+	for o.DeliveryMetrics().Delivered == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(texts) != 1 || texts[0] != "kept" {
+		t.Fatalf("expected only the current notification, got %v", texts)
+	}
+	if m := o.DeliveryMetrics(); m.Forced != 1 || m.Dropped != 1 {
+		t.Fatalf("unexpected metrics: %+v", m)
+	}
+}
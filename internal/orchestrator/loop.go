@@ -0,0 +1,240 @@
+package orchestrator
+
+import (
+	"log"
+	"time"
+
+	"desktop/internal/types"
+)
+
+const (
+	// LoopWindow is how far back direct messages between a pair are considered.
+	LoopWindow = 2 * time.Minute
+	// LoopMaxExchanges trips the breaker when a pair exchanges this many
+	// direct messages within LoopWindow.
+	LoopMaxExchanges = 12
+	// LoopSimilarRepeats trips the breaker when this many near-identical
+	// messages (or acknowledgments) bounce between a pair within LoopWindow.
+	LoopSimilarRepeats = 4
+	// LoopSimilarity is the word-set overlap at which two messages count as
+	// repeats of each other.
+	LoopSimilarity = 0.8
+	// LoopPauseDuration is how long notifications between a looping pair stay paused.
+	LoopPauseDuration = 10 * time.Minute
+)
+
+// Loop reasons.
+const (
+	LoopReasonRate     = "rate"
+	LoopReasonRepeated = "repeated"
+)
+
+// LoopAlert describes a conversation loop the circuit breaker stopped.
+type LoopAlert struct {
+	ChatDir     string    `json:"chat_dir"`
+	AgentA      string    `json:"agent_a"`
+	AgentB      string    `json:"agent_b"`
+	Reason      string    `json:"reason"` // "rate" or "repeated"
+	Messages    int       `json:"messages"`
+	PausedUntil time.Time `json:"paused_until"`
+}
+
+// pairExchange is one direct message between a pair of agents.
+type pairExchange struct {
+	from  string
+	words map[string]bool
+	ack   bool
+	at    time.Time
+}
+
+// SetLoopHandler registers a callback for tripped circuit breakers. It runs on
+// its own goroutine so it may call back into the hub.
+func (o *Orchestrator) SetLoopHandler(fn func(LoopAlert)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.loopHandler = fn
+}
+
+// ResumePair closes the circuit breaker for a pair before its pause expires.
+func (o *Orchestrator) ResumePair(chatDir, agentA, agentB string) bool {
+	key := pairKey(chatDir, agentA, agentB)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, ok := o.pairPaused[key]
+	delete(o.pairPaused, key)
+	delete(o.pairHistory, key)
+	return ok
+}
+
+// PausedPairs returns the loop alerts still in effect for a chat directory.
+func (o *Orchestrator) PausedPairs(chatDir string) []LoopAlert {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	now := o.now()
+	var alerts []LoopAlert
+	for key, alert := range o.pairPaused {
+		if now.After(alert.PausedUntil) {
+			delete(o.pairPaused, key)
+			continue
+		}
+		if alert.ChatDir == chatDir {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+// pairKey identifies an unordered pair of agents in a chat directory.
+func pairKey(chatDir, a, b string) string {
+	if b < a {
+		a, b = b, a
+	}
+	return chatDir + ":" + a + "<>" + b
+}
+
+// directPair returns the two agents of a one-to-one message. Messages
+// from humans never count towards a loop, nor do messages the active manager
+// intercepted: every agent talks to the manager while one is set, so its
+// traffic is routing rather than a conversation between two agents.
+func directPair(msg types.Message) (string, string, bool) {
+	if msg.Type == "system" || msg.FromHuman || msg.RoutedByManager || msg.From == "" || msg.To == "" || msg.To == "all" || len(msg.Recipients) > 0 || msg.From == msg.To {
+		return "", "", false
+	}
+	return msg.From, msg.To, true
+}
+
+// pairPausedLocked reports whether the breaker for a message's pair is open.
+// Callers must hold o.mu.
+func (o *Orchestrator) pairPausedLocked(chatDir string, msg types.Message, now time.Time) bool {
+	a, b, ok := directPair(msg)
+	if !ok {
+		return false
+	}
+	key := pairKey(chatDir, a, b)
+	alert, paused := o.pairPaused[key]
+	if !paused {
+		return false
+	}
+	if now.After(alert.PausedUntil) {
+		delete(o.pairPaused, key)
+		return false
+	}
+	return true
+}
+
+// checkLoop records a direct message and reports whether its pair is paused,
+// tripping the breaker (and calling the loop handler) when the pair exchanges
+// messages too fast or keeps repeating itself.
+func (o *Orchestrator) checkLoop(chatDir string, msg types.Message) bool {
+	a, b, ok := directPair(msg)
+	if !ok {
+		return false
+	}
+	now := o.now()
+	key := pairKey(chatDir, a, b)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.humans[chatDir+":"+a] || o.humans[chatDir+":"+b] {
+		return false
+	}
+	if o.pairPausedLocked(chatDir, msg, now) {
+		return true
+	}
+
+	var history []pairExchange
+	for _, e := range o.pairHistory[key] {
+		if now.Sub(e.at) < LoopWindow {
+			history = append(history, e)
+		}
+	}
+	entry := pairExchange{from: msg.From, words: wordSet(msg.Content), ack: isMostlyAck(msg.Content), at: now}
+	history = append(history, entry)
+	o.pairHistory[key] = history
+
+	reason := ""
+	count := len(history)
+	if count >= LoopMaxExchanges && bothSides(history) {
+		reason = LoopReasonRate
+	} else {
+		var similar []pairExchange
+		for _, e := range history {
+			if similarExchange(e, entry) {
+				similar = append(similar, e)
+			}
+		}
+		if len(similar) >= LoopSimilarRepeats && bothSides(similar) {
+			reason, count = LoopReasonRepeated, len(similar)
+		}
+	}
+	if reason == "" {
+		return false
+	}
+
+	if b < a {
+		a, b = b, a
+	}
+	alert := LoopAlert{ChatDir: chatDir, AgentA: a, AgentB: b, Reason: reason, Messages: count, PausedUntil: now.Add(LoopPauseDuration)}
+	o.pairPaused[key] = alert
+	delete(o.pairHistory, key)
+	o.dropPairPendingLocked(chatDir, a, b)
+	log.Printf("[ORCH] Loop detected chatDir=%s pair=%s<>%s reason=%s messages=%d, notifications paused until %s",
+		chatDir, a, b, reason, count, alert.PausedUntil.Format("15:04:05"))
+	if o.loopHandler != nil {
+		go o.loopHandler(alert)
+	}
+	return true
+}
+
+// dropPairPendingLocked removes batched notifications the pair owes each
+// other. Callers must hold o.mu.
+func (o *Orchestrator) dropPairPendingLocked(chatDir, a, b string) {
+	for _, p := range [][2]string{{a, b}, {b, a}} {
+		key := chatDir + ":" + p[0]
+		var kept []pendingNotification
+		for _, n := range o.pendingMsgs[key] {
+			if n.from != p[1] {
+				kept = append(kept, n)
+			}
+		}
+		if len(kept) == 0 {
+			delete(o.pendingMsgs, key)
+		} else {
+			o.pendingMsgs[key] = kept
+		}
+	}
+}
+
+func bothSides(history []pairExchange) bool {
+	for _, e := range history[1:] {
+		if e.from != history[0].from {
+			return true
+		}
+	}
+	return false
+}
+
+func similarExchange(x, y pairExchange) bool {
+	if x.ack && y.ack {
+		return true
+	}
+	if len(x.words) == 0 || len(y.words) == 0 {
+		return false
+	}
+	shared := 0
+	for w := range x.words {
+		if y.words[w] {
+			shared++
+		}
+	}
+	union := len(x.words) + len(y.words) - shared
+	return float64(shared)/float64(union) >= LoopSimilarity
+}
+
+func wordSet(content string) map[string]bool {
+	words := make(map[string]bool)
+	for _, t := range tokenize(content) {
+		words[t] = true
+	}
+	return words
+}
@@ -0,0 +1,96 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"desktop/internal/types"
+)
+
+func TestCheckLoop_AckPingPongTripsBreaker(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	o.RegisterAgent("/rooms/t", "alice", "sess-aaaaaaaa")
+	o.RegisterAgent("/rooms/t", "bob", "sess-bbbbbbbb")
+
+	alerts := make(chan LoopAlert, 1)
+	o.SetLoopHandler(func(a LoopAlert) { alerts <- a })
+
+	replies := []string{"thanks!", "thank you, great", "perfect, thanks", "great, got it", "ok thanks"}
+	for i, content := range replies {
+		from, to := "alice", "bob"
+		if i%2 == 1 {
+			from, to = to, from
+		}
+		o.ProcessMessage("/rooms/t", types.Message{ID: i + 1, From: from, To: to, Content: content, Type: "direct", ExpectsReply: true})
+	}
+
+	select {
+	case a := <-alerts:
+		if a.AgentA != "alice" || a.AgentB != "bob" || a.Reason != LoopReasonRepeated {
+			t.Fatalf("unexpected alert %+v", a)
+		}
+		if time.Until(a.PausedUntil) <= 0 {
+			t.Fatalf("expected pause in the future, got %v", a.PausedUntil)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected loop alert")
+	}
+
+	// Paused: further direct messages between the pair do not notify.
+	before := len(*sent)
+	o.mu.Lock()
+	o.lastNotified = make(map[string]time.Time)
+	o.mu.Unlock()
+	o.ProcessMessage("/rooms/t", types.Message{ID: 10, From: "bob", To: "alice", Content: "Can you review the migration?", Type: "direct"})
+	if len(*sent) != before {
+		t.Fatalf("expected no notification while paused, got %+v", (*sent)[before:])
+	}
+	if got := o.PausedPairs("/rooms/t"); len(got) != 1 {
+		t.Fatalf("expected one paused pair, got %+v", got)
+	}
+
+	// Resuming restores delivery.
+	if !o.ResumePair("/rooms/t", "bob", "alice") {
+		t.Fatal("expected ResumePair to find the paused pair")
+	}
+	o.ProcessMessage("/rooms/t", types.Message{ID: 11, From: "bob", To: "alice", Content: "Can you review the migration?", Type: "direct"})
+	if len(*sent) != before+1 {
+		t.Fatalf("expected notification after resume, got %d", len(*sent)-before)
+	}
+}
+
+func TestCheckLoop_DistinctConversationDoesNotTrip(t *testing.T) {
+	o, _ := newTestOrchestrator()
+	contents := []string{
+		"Can you add the users table migration?",
+		"Done, migration 0042 adds users with email index.",
+		"Great. Please also expose GET /users in the API.",
+		"Added the handler; tests cover pagination.",
+		"How do we handle soft-deleted users?",
+		"They are filtered by deleted_at IS NULL in the query.",
+	}
+	for i, c := range contents {
+		from, to := "alice", "bob"
+		if i%2 == 1 {
+			from, to = to, from
+		}
+		if o.checkLoop("/rooms/t", types.Message{ID: i + 1, From: from, To: to, Content: c, Type: "direct"}) {
+			t.Fatalf("message %d should not trip the breaker", i+1)
+		}
+	}
+	// Broadcasts are never part of a pair.
+	for i := 0; i < LoopMaxExchanges+1; i++ {
+		if o.checkLoop("/rooms/t", types.Message{From: "alice", To: "all", Content: "status", Type: "broadcast"}) {
+			t.Fatal("broadcasts must not trip the breaker")
+		}
+	}
+	// Neither does traffic with the active manager: agents' messages reach it
+	// routed, so only the manager's side would be counted.
+	for i := 0; i < LoopMaxExchanges+1; i++ {
+		routed := types.Message{From: "carol", To: "lead", OriginalTo: "dave", RoutedByManager: true, Content: "thanks!", Type: "direct"}
+		reply := types.Message{From: "lead", To: "carol", Content: "thanks!", Type: "direct"}
+		if o.checkLoop("/rooms/t", routed) || o.checkLoop("/rooms/t", reply) {
+			t.Fatal("manager-routed exchanges must not trip the breaker")
+		}
+	}
+}
@@ -3,6 +3,7 @@ package orchestrator
 import (
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -17,31 +18,14 @@ const (
 	// If an agent was notified within this window, subsequent messages are
 	// batched into a single "N new messages" notification.
 	NotifyCooldown = 3 * time.Second
+	// BusyPollInterval is how often a notification deferred because the CLI
+	// was busy re-checks the PTY activity.
+	BusyPollInterval = 1 * time.Second
+	// MaxBusyDefer caps how long a notification waits for a busy CLI to go
+	// idle before it is delivered anyway.
+	MaxBusyDefer = 2 * time.Minute
 )
 
-// ACK patterns - short acknowledgment messages to skip
-var ACKPatterns = []string{
-	"tesekkur", "sagol", "eyvallah", "tamam", "anladim", "ok", "oldu",
-	"super", "harika", "mukemmel", "guzel", "rica ederim", "bir sey degil",
-	"thanks", "thank you", "got it", "okay", "perfect", "great",
-	"tamamdir", "anlasildi", "gorusuruz", "iyi calismalar",
-	"evet", "hayir", "peki", "olur", "elbette",
-}
-
-// Question patterns - these should always be notified
-var QuestionPatterns = []string{
-	"?", "nasil", "neden", "ne zaman", "nerede", "kim", "hangi",
-	"yapabilir mi", "mumkun mu", "var mi", "bilir mi", "ister mi",
-	"how", "what", "when", "where", "who", "which", "can you", "could you",
-}
-
-// AnalysisResult represents the decision about a message
-type AnalysisResult struct {
-	Action     string `json:"action"` // "skip" or "notify"
-	Reason     string `json:"reason"`
-	IsQuestion bool   `json:"is_question"`
-}
-
 // AgentSession maps agent name to PTY session ID
 type AgentSession struct {
 	AgentName string
@@ -57,27 +41,83 @@ type Orchestrator struct {
 	// Per-agent cooldown tracking: key = "chatDir:agentName"
 	mu            sync.Mutex
 	lastNotified  map[string]time.Time
-	pendingTimers map[string]*time.Timer
+	pendingTimers map[string]Timer
 	pendingMsgs   map[string][]pendingNotification
+	deferredSince map[string]time.Time // first deferral while the CLI was busy
+	analyzers     map[string]Analyzer  // chatDir → team analyzer (default: heuristic)
+	policies      map[string]*deliveryPolicy
+	sentLog       map[string][]time.Time // recent notification times for budgets
+	pairHistory   map[string][]pairExchange
+	pairPaused    map[string]LoopAlert // pairKey → open circuit breaker
+	loopHandler   func(LoopAlert)
+	humans        map[string]bool // "chatDir:name" → sender is a human participant
+
+	// Read acknowledgement: key → unread notified messages
+	readWaits       map[string]*readWait
+	readUpTo        map[string]int                // key → highest message ID known read
+	deliveryRecords map[string][]*MessageDelivery // chatDir → recent delivery states
+	failureHandler  func(DeliveryFailure)
 
 	// sendFunc overrides sendToTerminal for testing. If nil, the real PTY path is used.
 	sendFunc func(sessionID, text string)
+	// activityFunc overrides the PTY activity lookup for testing.
+	activityFunc func(sessionID string) string
+	// readyFunc overrides the PTY input-prompt check for testing.
+	readyFunc func(sessionID string) bool
+
+	delivery *deliveryState
+	clock    Clock // nil: wall time
 }
 
 // pendingNotification holds info about a message waiting in the cooldown window.
 type pendingNotification struct {
-	from string
+	from   string
+	msgID  int
+	folded int // older held notifications merged into this one
 }
 
 // New creates a new orchestrator
 func New(ptyManager *ptymgr.Manager) *Orchestrator {
 	return &Orchestrator{
-		ptyManager:    ptyManager,
-		agentSessions: make(map[string]map[string]string),
-		lastNotified:  make(map[string]time.Time),
-		pendingTimers: make(map[string]*time.Timer),
-		pendingMsgs:   make(map[string][]pendingNotification),
+		ptyManager:      ptyManager,
+		agentSessions:   make(map[string]map[string]string),
+		lastNotified:    make(map[string]time.Time),
+		pendingTimers:   make(map[string]Timer),
+		pendingMsgs:     make(map[string][]pendingNotification),
+		deferredSince:   make(map[string]time.Time),
+		analyzers:       make(map[string]Analyzer),
+		policies:        make(map[string]*deliveryPolicy),
+		sentLog:         make(map[string][]time.Time),
+		pairHistory:     make(map[string][]pairExchange),
+		pairPaused:      make(map[string]LoopAlert),
+		humans:          make(map[string]bool),
+		readWaits:       make(map[string]*readWait),
+		readUpTo:        make(map[string]int),
+		deliveryRecords: make(map[string][]*MessageDelivery),
+		delivery:        newDeliveryState(),
+	}
+}
+
+// SetAnalyzer sets the message analyzer for a chat directory. nil restores
+// the default HeuristicAnalyzer.
+func (o *Orchestrator) SetAnalyzer(chatDir string, analyzer Analyzer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if analyzer == nil {
+		delete(o.analyzers, chatDir)
+		return
+	}
+	o.analyzers[chatDir] = analyzer
+}
+
+// analyzerFor returns the analyzer configured for a chat directory.
+func (o *Orchestrator) analyzerFor(chatDir string) Analyzer {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if a, ok := o.analyzers[chatDir]; ok {
+		return a
 	}
+	return HeuristicAnalyzer{}
 }
 
 // RegisterAgent registers an agent's PTY session for a chat directory
@@ -96,6 +136,9 @@ func (o *Orchestrator) UnregisterAgent(chatDir, agentName string) {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 	if sessions, ok := o.agentSessions[chatDir]; ok {
+		if sessionID, ok := sessions[agentName]; ok {
+			o.dropQueue(sessionID)
+		}
 		delete(sessions, agentName)
 	}
 	// F007: Clean up cooldown tracking for this agent
@@ -106,48 +149,108 @@ func (o *Orchestrator) UnregisterAgent(chatDir, agentName string) {
 		delete(o.pendingTimers, key)
 	}
 	delete(o.pendingMsgs, key)
+	delete(o.deferredSince, key)
+	delete(o.sentLog, key)
+	o.stopReadWaitLocked(key)
+	delete(o.readUpTo, key)
 }
 
-// AnalyzeMessage analyzes a message and decides what action to take
-func AnalyzeMessage(msg types.Message) AnalysisResult {
-	content := msg.Content
-	contentLower := strings.ToLower(content)
-	expectsReply := msg.ExpectsReply
-
-	// Is it a question?
-	isQuestion := false
-	for _, p := range QuestionPatterns {
-		if strings.Contains(contentLower, p) {
-			isQuestion = true
-			break
+// RenameChatDir moves every registration and per-agent state of a chat
+// directory to a new name (the hub renamed the room). Pending flushes and
+// read reminders are re-armed under the new name; calling it again after the
+// move is a no-op.
+func (o *Orchestrator) RenameChatDir(oldDir, newDir string) {
+	if oldDir == newDir {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if sessions, ok := o.agentSessions[oldDir]; ok {
+		delete(o.agentSessions, oldDir)
+		if o.agentSessions[newDir] == nil {
+			o.agentSessions[newDir] = make(map[string]string)
+		}
+		for agentName, sessionID := range sessions {
+			o.agentSessions[newDir][agentName] = sessionID
+		}
+	}
+	if a, ok := o.analyzers[oldDir]; ok {
+		delete(o.analyzers, oldDir)
+		o.analyzers[newDir] = a
+	}
+	if records, ok := o.deliveryRecords[oldDir]; ok {
+		delete(o.deliveryRecords, oldDir)
+		o.deliveryRecords[newDir] = append(o.deliveryRecords[newDir], records...)
+	}
+
+	rekeyChatDir(o.lastNotified, oldDir, newDir)
+	rekeyChatDir(o.pendingMsgs, oldDir, newDir)
+	rekeyChatDir(o.deferredSince, oldDir, newDir)
+	rekeyChatDir(o.policies, oldDir, newDir)
+	rekeyChatDir(o.sentLog, oldDir, newDir)
+	rekeyChatDir(o.pairHistory, oldDir, newDir)
+	rekeyChatDir(o.humans, oldDir, newDir)
+	rekeyChatDir(o.readUpTo, oldDir, newDir)
+	for key, alert := range rekeyChatDir(o.pairPaused, oldDir, newDir) {
+		alert.ChatDir = newDir
+		o.pairPaused[key] = alert
+	}
+
+	// Timer callbacks captured the old name; stop them and arm new ones.
+	prefix := newDir + ":"
+	for key, timer := range rekeyChatDir(o.pendingTimers, oldDir, newDir) {
+		timer.Stop()
+		agentName := strings.TrimPrefix(key, prefix)
+		sessionID := o.agentSessions[newDir][agentName]
+		wait := NotifyCooldown - o.now().Sub(o.lastNotified[key])
+		o.pendingTimers[key] = o.afterFunc(max(wait, 0), func() {
+			o.flushPending(newDir, agentName, sessionID)
+		})
+	}
+	for key, w := range rekeyChatDir(o.readWaits, oldDir, newDir) {
+		if w.timer == nil {
+			continue
 		}
+		w.timer.Stop()
+		agentName := strings.TrimPrefix(key, prefix)
+		w.timer = o.afterFunc(ReadAckTimeout, func() {
+			o.retryUnread(newDir, agentName)
+		})
 	}
+	log.Printf("[ORCH] RenameChatDir: %s -> %s", oldDir, newDir)
+}
 
-	// Is it a short acknowledgment?
-	isShort := len([]rune(content)) < AckMsgMaxLength
-	hasAck := false
-	for _, p := range ACKPatterns {
-		if strings.Contains(contentLower, p) {
-			hasAck = true
-			break
+// rekeyChatDir moves the "chatDir:..." entries of a per-agent map from one
+// chat directory to another and returns the moved entries by their new key.
+// Callers must hold o.mu.
+func rekeyChatDir[V any](m map[string]V, oldDir, newDir string) map[string]V {
+	moved := make(map[string]V)
+	for key, v := range m {
+		if rest, ok := strings.CutPrefix(key, oldDir+":"); ok {
+			delete(m, key)
+			moved[newDir+":"+rest] = v
 		}
 	}
-	isAck := isShort && hasAck && !isQuestion
+	for key, v := range moved {
+		m[key] = v
+	}
+	return moved
+}
 
-	// Decision
-	if isAck && !expectsReply {
-		return AnalysisResult{Action: "skip", Reason: "Acknowledgment (expects_reply=false)", IsQuestion: false}
-	} else if isAck {
-		return AnalysisResult{Action: "skip", Reason: "Short acknowledgment message", IsQuestion: false}
-	} else if isQuestion {
-		return AnalysisResult{Action: "notify", Reason: "Question - response needed", IsQuestion: true}
-	} else if expectsReply {
-		return AnalysisResult{Action: "notify", Reason: "Response expected", IsQuestion: false}
+// isBusy reports whether the session's CLI is currently producing output.
+func (o *Orchestrator) isBusy(sessionID string) bool {
+	if o.activityFunc != nil {
+		return o.activityFunc(sessionID) == types.ActivityBusy
+	}
+	if o.ptyManager == nil {
+		return false
 	}
-	return AnalysisResult{Action: "notify", Reason: "Informational", IsQuestion: false}
+	return o.ptyManager.Activity(sessionID) == types.ActivityBusy
 }
 
-// sendToTerminal writes a short notification to a PTY.
+// sendToTerminal writes a short notification to a PTY. Callers go through
+// deliver so the text only lands when the CLI is at its input prompt.
 // No user content is included — the agent reads the full message via MCP.
 func (o *Orchestrator) sendToTerminal(sessionID string, text string) {
 	if o.sendFunc != nil {
@@ -164,48 +267,62 @@ func (o *Orchestrator) sendToTerminal(sessionID string, text string) {
 	log.Printf("[ORCH] sendToTerminal: cli=%s agent=%s textLen=%d",
 		session.CLIType, session.AgentName, len(text))
 
-	switch session.CLIType {
-	case "copilot":
-		// Send Focus In so Copilot's Ink TUI accepts input even if the
-		// terminal pane is not visually focused.
-		o.ptyManager.Write(sessionID, []byte("\x1b[I"))
-		time.Sleep(50 * time.Millisecond)
-		// Copilot (Ink/React TUI): simulate keyboard input character by character.
-		for _, c := range text {
-			o.ptyManager.Write(sessionID, []byte(string(c)))
-			time.Sleep(5 * time.Millisecond)
-		}
-		time.Sleep(100 * time.Millisecond)
-		o.ptyManager.Write(sessionID, []byte("\r"))
-	default:
-		// Claude/Gemini: bracketed paste
-		const (
-			bracketOpen  = "\x1b[200~"
-			bracketClose = "\x1b[201~"
-		)
-		o.ptyManager.Write(sessionID, []byte(bracketOpen+text+bracketClose))
-		time.Sleep(200 * time.Millisecond)
-		o.ptyManager.Write(sessionID, []byte("\r"))
+	if err := o.ptyManager.Inject(sessionID, text); err != nil {
+		log.Printf("[ORCH] sendToTerminal: write failed agent=%s: %v", session.AgentName, err)
 	}
 }
 
 // notifyAgent sends a notification to an agent with cooldown/batching.
-// If the agent was recently notified, subsequent messages are batched.
-func (o *Orchestrator) notifyAgent(chatDir, agentName, sessionID, fromAgent string, isBroadcast bool) {
+// If the agent was recently notified, or its CLI is busy, subsequent messages
+// are batched until the cooldown ends and the CLI is idle. The agent's
+// delivery settings may drop (muted sender) or hold the notification longer.
+func (o *Orchestrator) notifyAgent(chatDir, agentName, sessionID, fromAgent string, isBroadcast bool, msgID int) {
 	key := chatDir + ":" + agentName
+	busy := o.isBusy(sessionID)
 
 	o.mu.Lock()
+	if o.isMutedLocked(key, fromAgent) {
+		o.mu.Unlock()
+		log.Printf("[ORCH] Notification dropped for agent=%s (muted sender=%s)", agentName, fromAgent)
+		return
+	}
+	if until, reason, held := o.holdLocked(key, o.now()); held {
+		o.appendHeldLocked(key, pendingNotification{from: fromAgent, msgID: msgID})
+		if _, exists := o.pendingTimers[key]; !exists || until.IsZero() {
+			o.holdPendingLocked(chatDir, agentName, sessionID, until)
+		}
+		pendingCount := len(o.pendingMsgs[key])
+		o.mu.Unlock()
+		log.Printf("[ORCH] Notification held for agent=%s (%s), pending=%d", agentName, reason, pendingCount)
+		return
+	}
 	last := o.lastNotified[key]
-	elapsed := time.Since(last)
+	elapsed := o.now().Sub(last)
+
+	if busy {
+		o.pendingMsgs[key] = append(o.pendingMsgs[key], pendingNotification{from: fromAgent, msgID: msgID})
+		if _, ok := o.deferredSince[key]; !ok {
+			o.deferredSince[key] = o.now()
+		}
+		if _, exists := o.pendingTimers[key]; !exists {
+			o.pendingTimers[key] = o.afterFunc(BusyPollInterval, func() {
+				o.flushPending(chatDir, agentName, sessionID)
+			})
+		}
+		pendingCount := len(o.pendingMsgs[key])
+		o.mu.Unlock()
+		log.Printf("[ORCH] Notification deferred for agent=%s (CLI busy), pending=%d", agentName, pendingCount)
+		return
+	}
 
 	if elapsed < NotifyCooldown {
 		// Within cooldown — batch this notification
-		o.pendingMsgs[key] = append(o.pendingMsgs[key], pendingNotification{from: fromAgent})
+		o.pendingMsgs[key] = append(o.pendingMsgs[key], pendingNotification{from: fromAgent, msgID: msgID})
 
 		// Start flush timer if not already running
 		if _, exists := o.pendingTimers[key]; !exists {
 			remaining := NotifyCooldown - elapsed
-			o.pendingTimers[key] = time.AfterFunc(remaining, func() {
+			o.pendingTimers[key] = o.afterFunc(remaining, func() {
 				o.flushPending(chatDir, agentName, sessionID)
 			})
 		}
@@ -216,55 +333,129 @@ func (o *Orchestrator) notifyAgent(chatDir, agentName, sessionID, fromAgent stri
 	}
 
 	// Outside cooldown — send immediately
-	o.lastNotified[key] = time.Now()
+	o.recordSentLocked(key, o.now())
+	o.trackNotifiedLocked(chatDir, agentName, sessionID, []pendingNotification{{from: fromAgent, msgID: msgID}})
+	sender := o.senderLabelLocked(chatDir, fromAgent)
 	o.mu.Unlock()
 
 	var prompt string
 	if isBroadcast {
-		prompt = fmt.Sprintf("[agent-chat] Broadcast from %s. read_messages(\"%s\") to read and respond.", fromAgent, agentName)
+		prompt = fmt.Sprintf("[agent-chat] Broadcast from %s. read_messages(\"%s\") to read and respond.", sender, agentName)
 	} else {
-		prompt = fmt.Sprintf("[agent-chat] New message from %s. read_messages(\"%s\") to read and respond.", fromAgent, agentName)
+		prompt = fmt.Sprintf("[agent-chat] New message from %s. read_messages(\"%s\") to read and respond.", sender, agentName)
 	}
 	log.Printf("[ORCH] Notifying agent=%s session=%s", agentName, ptymgr.ShortID(sessionID))
-	o.sendToTerminal(sessionID, prompt)
+	o.deliver(sessionID, prompt, false)
+}
+
+// notifyAgentUrgent sends an urgent notification immediately, bypassing the
+// cooldown window, busy deferral and the agent's delivery settings, mutes
+// included. Messages already batched for the agent are flushed by their own
+// timer as usual.
+func (o *Orchestrator) notifyAgentUrgent(chatDir, agentName, sessionID, fromAgent string, isBroadcast bool, msgID int) {
+	key := chatDir + ":" + agentName
+
+	o.mu.Lock()
+	if o.isMutedLocked(key, fromAgent) {
+		log.Printf("[ORCH] Urgent notification from muted sender=%s delivered to agent=%s", fromAgent, agentName)
+	}
+	o.recordSentLocked(key, o.now())
+	o.trackNotifiedLocked(chatDir, agentName, sessionID, []pendingNotification{{from: fromAgent, msgID: msgID}})
+	sender := o.senderLabelLocked(chatDir, fromAgent)
+	o.mu.Unlock()
+
+	var prompt string
+	if isBroadcast {
+		prompt = fmt.Sprintf("[agent-chat] URGENT broadcast from %s. read_messages(\"%s\") to read and respond now.", sender, agentName)
+	} else {
+		prompt = fmt.Sprintf("[agent-chat] URGENT message from %s. read_messages(\"%s\") to read and respond now.", sender, agentName)
+	}
+	log.Printf("[ORCH] Urgent notify agent=%s session=%s", agentName, ptymgr.ShortID(sessionID))
+	o.deliver(sessionID, prompt, true)
+}
+
+// senderLabelLocked names a sender in notifications, marking human
+// participants so agents know a person is waiting on them.
+func (o *Orchestrator) senderLabelLocked(chatDir, from string) string {
+	if o.humans[chatDir+":"+from] {
+		return from + " (human)"
+	}
+	return from
 }
 
-// flushPending sends a batched notification for accumulated messages.
+// flushPending sends a batched notification for accumulated messages. While
+// the CLI is busy the flush is re-armed, up to MaxBusyDefer; while the agent's
+// delivery settings hold notifications it waits for the hold to end.
 func (o *Orchestrator) flushPending(chatDir, agentName, sessionID string) {
 	key := chatDir + ":" + agentName
+	busy := o.isBusy(sessionID)
 
 	o.mu.Lock()
-	pending := o.pendingMsgs[key]
+	if len(o.pendingMsgs[key]) > 0 {
+		if until, reason, held := o.holdLocked(key, o.now()); held {
+			delete(o.pendingTimers, key)
+			o.holdPendingLocked(chatDir, agentName, sessionID, until)
+			o.mu.Unlock()
+			log.Printf("[ORCH] Flush held for agent=%s (%s)", agentName, reason)
+			return
+		}
+	}
+	if len(o.pendingMsgs[key]) > 0 && busy {
+		since, ok := o.deferredSince[key]
+		if !ok {
+			since = o.now()
+			o.deferredSince[key] = since
+		}
+		if o.now().Sub(since) < MaxBusyDefer {
+			o.pendingTimers[key] = o.afterFunc(BusyPollInterval, func() {
+				o.flushPending(chatDir, agentName, sessionID)
+			})
+			o.mu.Unlock()
+			return
+		}
+		log.Printf("[ORCH] CLI still busy after %s, delivering anyway agent=%s", MaxBusyDefer, agentName)
+	}
+	var pending []pendingNotification
+	for _, p := range o.pendingMsgs[key] {
+		if !o.isMutedLocked(key, p.from) {
+			pending = append(pending, p)
+		}
+	}
 	delete(o.pendingMsgs, key)
 	delete(o.pendingTimers, key)
-	o.lastNotified[key] = time.Now()
-	o.mu.Unlock()
-
+	delete(o.deferredSince, key)
 	if len(pending) == 0 {
+		o.mu.Unlock()
 		return
 	}
+	o.recordSentLocked(key, o.now())
+	o.trackNotifiedLocked(chatDir, agentName, sessionID, pending)
 
 	// Collect unique senders
+	count := 0
 	senders := make(map[string]struct{})
 	for _, p := range pending {
-		senders[p.from] = struct{}{}
+		count += 1 + p.folded
+		senders[o.senderLabelLocked(chatDir, p.from)] = struct{}{}
 	}
+	o.mu.Unlock()
 	senderList := make([]string, 0, len(senders))
 	for s := range senders {
 		senderList = append(senderList, s)
 	}
+	sort.Strings(senderList)
 
 	prompt := fmt.Sprintf("[agent-chat] %d new messages from %s. read_messages(\"%s\") to read and respond.",
-		len(pending), strings.Join(senderList, ", "), agentName)
+		count, strings.Join(senderList, ", "), agentName)
 
-	log.Printf("[ORCH] Flushing %d batched notifications for agent=%s", len(pending), agentName)
-	o.sendToTerminal(sessionID, prompt)
+	log.Printf("[ORCH] Flushing %d batched notifications for agent=%s", count, agentName)
+	o.deliver(sessionID, prompt, false)
 }
 
 // ProcessMessage processes a single message and notifies relevant agents
 func (o *Orchestrator) ProcessMessage(chatDir string, msg types.Message) {
-	log.Printf("[ORCH] ProcessMessage: chatDir=%s from=%s to=%s type=%s expects_reply=%v content_len=%d",
-		chatDir, msg.From, msg.To, msg.Type, msg.ExpectsReply, len(msg.Content))
+	log.Printf("[ORCH] ProcessMessage: chatDir=%s from=%s to=%s type=%s priority=%s expects_reply=%v content_len=%d",
+		chatDir, msg.From, msg.To, msg.Type, msg.Priority, msg.ExpectsReply, len(msg.Content))
 
 	// Skip system messages
 	if msg.Type == "system" {
@@ -272,7 +463,30 @@ func (o *Orchestrator) ProcessMessage(chatDir string, msg types.Message) {
 		return
 	}
 
-	// Manager-routed messages must always notify the manager target, even for ACK-like content.
+	if msg.FromHuman {
+		o.mu.Lock()
+		o.humans[chatDir+":"+msg.From] = true
+		o.mu.Unlock()
+	}
+
+	// Looping pairs are paused by the circuit breaker.
+	if o.checkLoop(chatDir, msg) {
+		log.Printf("[ORCH] Skipping notification: pair %s<>%s paused (loop)", msg.From, msg.To)
+		return
+	}
+
+	if !o.shouldNotify(chatDir, msg) {
+		return
+	}
+
+	// Urgent messages bypass cooldown batching.
+	urgent := msg.Priority == types.PriorityUrgent
+	notify := o.notifyAgent
+	if urgent {
+		notify = o.notifyAgentUrgent
+	}
+
+	// Manager-routed messages notify the manager target, even for ACK-like content.
 	if msg.RoutedByManager {
 		o.mu.Lock()
 		sessions := o.agentSessions[chatDir]
@@ -290,19 +504,13 @@ func (o *Orchestrator) ProcessMessage(chatDir string, msg types.Message) {
 		target := msg.To
 		if sessionID, ok := sessionsCopy[target]; ok {
 			log.Printf("[ORCH] Manager-routed notify: from=%s manager=%s original_to=%s", msg.From, target, msg.OriginalTo)
-			o.notifyAgent(chatDir, target, sessionID, msg.From, false)
+			notify(chatDir, target, sessionID, msg.From, false, msg.ID)
 		} else {
 			log.Printf("[ORCH] Manager-routed target not found: %s", target)
 		}
 		return
 	}
 
-	analysis := AnalyzeMessage(msg)
-	log.Printf("[ORCH] Analysis: action=%s reason=%s", analysis.Action, analysis.Reason)
-	if analysis.Action == "skip" {
-		return
-	}
-
 	// Snapshot sessions under lock to avoid race with RegisterAgent/UnregisterAgent
 	o.mu.Lock()
 	sessions := o.agentSessions[chatDir]
@@ -327,17 +535,156 @@ func (o *Orchestrator) ProcessMessage(chatDir string, msg types.Message) {
 		// Broadcast - notify everyone except sender
 		for agent, sessionID := range sessionsCopy {
 			if agent != fromAgent {
-				o.notifyAgent(chatDir, agent, sessionID, fromAgent, true)
+				notify(chatDir, agent, sessionID, fromAgent, true, msg.ID)
+			}
+		}
+	} else if len(msg.Recipients) > 0 {
+		// Multi-recipient / group message - notify each resolved recipient
+		for _, agent := range msg.Recipients {
+			if sessionID, ok := sessionsCopy[agent]; ok && agent != fromAgent {
+				notify(chatDir, agent, sessionID, fromAgent, false, msg.ID)
+			} else if !ok {
+				log.Printf("[ORCH] Recipient agent=%s not found in sessions", agent)
 			}
 		}
 	} else if sessionID, ok := sessionsCopy[toAgent]; ok {
 		// Direct message - notify target only
-		o.notifyAgent(chatDir, toAgent, sessionID, fromAgent, false)
+		notify(chatDir, toAgent, sessionID, fromAgent, false, msg.ID)
 	} else {
 		log.Printf("[ORCH] Target agent=%s not found in sessions", toAgent)
 	}
 }
 
+// ProcessUpdatedMessage tells recipients that a message they were notified
+// about has been edited. Recipients still waiting in a batch are left alone:
+// they will read the new content anyway.
+func (o *Orchestrator) ProcessUpdatedMessage(chatDir string, msg types.Message) {
+	log.Printf("[ORCH] ProcessUpdatedMessage: chatDir=%s id=%d from=%s to=%s", chatDir, msg.ID, msg.From, msg.To)
+
+	if !o.wouldNotify(chatDir, msg) {
+		return
+	}
+	for agent, sessionID := range o.recipientSessions(chatDir, msg) {
+		if o.dropPending(chatDir, agent, msg.ID, false) {
+			continue
+		}
+		o.markNotified(chatDir, agent)
+		prompt := fmt.Sprintf("[agent-chat] Message #%d from %s was edited. read_messages(\"%s\", since_id=%d) to see the new version.",
+			msg.ID, msg.From, agent, msg.ID-1)
+		o.deliver(sessionID, prompt, false)
+	}
+}
+
+// ProcessRetractedMessage removes a retracted message from pending batches and
+// tells recipients that were already notified to disregard it. The hub does
+// not send the retracted content, so the analyzer is not consulted.
+func (o *Orchestrator) ProcessRetractedMessage(chatDir string, msg types.Message) {
+	log.Printf("[ORCH] ProcessRetractedMessage: chatDir=%s id=%d from=%s to=%s", chatDir, msg.ID, msg.From, msg.To)
+
+	if msg.Type == "system" {
+		return
+	}
+	for agent, sessionID := range o.recipientSessions(chatDir, msg) {
+		if o.dropPending(chatDir, agent, msg.ID, true) {
+			log.Printf("[ORCH] Dropped retracted message %d from pending batch of agent=%s", msg.ID, agent)
+			continue
+		}
+		o.markNotified(chatDir, agent)
+		prompt := fmt.Sprintf("[agent-chat] Message #%d from %s was retracted. Disregard it and do not act on it.", msg.ID, msg.From)
+		o.deliver(sessionID, prompt, false)
+	}
+}
+
+// shouldNotify runs the chat directory's analyzer. Low priority messages are
+// picked up on the agent's next read and short acknowledgments are skipped,
+// unless the team's rules say otherwise. Manager-routed messages ignore the
+// acknowledgment filter but still honor low priority.
+func (o *Orchestrator) shouldNotify(chatDir string, msg types.Message) bool {
+	analysis := o.analyzerFor(chatDir).Analyze(msg)
+	log.Printf("[ORCH] Analysis: action=%s reason=%s", analysis.Action, analysis.Reason)
+	if analysis.Action != ActionSkip {
+		return true
+	}
+	return msg.RoutedByManager && msg.Priority != types.PriorityLow
+}
+
+// wouldNotify reports whether ProcessMessage would have nudged anyone for msg.
+func (o *Orchestrator) wouldNotify(chatDir string, msg types.Message) bool {
+	return msg.Type != "system" && o.shouldNotify(chatDir, msg)
+}
+
+// recipientSessions returns the registered sessions a message is addressed to.
+func (o *Orchestrator) recipientSessions(chatDir string, msg types.Message) map[string]string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	out := make(map[string]string)
+	for agent, sessionID := range o.agentSessions[chatDir] {
+		if (agent != msg.From && msg.IsAddressedTo(agent)) || msg.To == agent {
+			out[agent] = sessionID
+		}
+	}
+	return out
+}
+
+// dropPending reports whether msgID is waiting in the agent's batch. When
+// remove is set, the entry is removed and an emptied batch is cancelled.
+func (o *Orchestrator) dropPending(chatDir, agentName string, msgID int, remove bool) bool {
+	key := chatDir + ":" + agentName
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if remove {
+		o.forgetMessageLocked(chatDir, agentName, msgID)
+	}
+	pending := o.pendingMsgs[key]
+	for i, p := range pending {
+		if p.msgID != msgID {
+			continue
+		}
+		if remove {
+			pending = append(pending[:i:i], pending[i+1:]...)
+			if len(pending) == 0 {
+				delete(o.pendingMsgs, key)
+				delete(o.deferredSince, key)
+				if timer, ok := o.pendingTimers[key]; ok {
+					timer.Stop()
+					delete(o.pendingTimers, key)
+				}
+			} else {
+				o.pendingMsgs[key] = pending
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Alert sends an urgent out-of-band notification to an agent's terminal, e.g.
+// to tell the manager about a paused loop. It reports whether the agent has a
+// registered session.
+func (o *Orchestrator) Alert(chatDir, agentName, text string) bool {
+	o.mu.Lock()
+	sessionID, ok := o.agentSessions[chatDir][agentName]
+	if ok {
+		o.lastNotified[chatDir+":"+agentName] = o.now()
+	}
+	o.mu.Unlock()
+	if !ok {
+		return false
+	}
+	o.deliver(sessionID, text, true)
+	return true
+}
+
+// markNotified records a notification time for cooldown purposes.
+func (o *Orchestrator) markNotified(chatDir, agentName string) {
+	o.mu.Lock()
+	o.lastNotified[chatDir+":"+agentName] = o.now()
+	o.mu.Unlock()
+}
+
 // mapKeys returns the keys of a map as a slice (for logging)
 func mapKeys[K comparable, V any](m map[K]V) []K {
 	keys := make([]K, 0, len(m))
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -33,11 +34,22 @@ func newTestOrchestrator() (*Orchestrator, *[]sentNotification) {
 	var sent []sentNotification
 	var mu sync.Mutex
 	o := &Orchestrator{
-		ptyManager:    nil,
-		agentSessions: make(map[string]map[string]string),
-		lastNotified:  make(map[string]time.Time),
-		pendingTimers: make(map[string]*time.Timer),
-		pendingMsgs:   make(map[string][]pendingNotification),
+		ptyManager:      nil,
+		agentSessions:   make(map[string]map[string]string),
+		lastNotified:    make(map[string]time.Time),
+		pendingTimers:   make(map[string]Timer),
+		pendingMsgs:     make(map[string][]pendingNotification),
+		deferredSince:   make(map[string]time.Time),
+		analyzers:       make(map[string]Analyzer),
+		policies:        make(map[string]*deliveryPolicy),
+		sentLog:         make(map[string][]time.Time),
+		pairHistory:     make(map[string][]pairExchange),
+		pairPaused:      make(map[string]LoopAlert),
+		humans:          make(map[string]bool),
+		readWaits:       make(map[string]*readWait),
+		readUpTo:        make(map[string]int),
+		deliveryRecords: make(map[string][]*MessageDelivery),
+		delivery:        newDeliveryState(),
 		sendFunc: func(sessionID, text string) {
 			mu.Lock()
 			sent = append(sent, sentNotification{sessionID, text})
@@ -247,7 +259,7 @@ func TestNotifyAgent_FirstCallImmediate(t *testing.T) {
 		t.Fatal("should not have lastNotified before first call")
 	}
 
-	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false)
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false, 0)
 
 	o.mu.Lock()
 	ts, existed := o.lastNotified[key]
@@ -280,7 +292,7 @@ func TestNotifyAgent_Batching(t *testing.T) {
 	o.lastNotified[key] = time.Now()
 	o.mu.Unlock()
 
-	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false)
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false, 0)
 
 	o.mu.Lock()
 	pc := len(o.pendingMsgs[key])
@@ -294,7 +306,7 @@ func TestNotifyAgent_Batching(t *testing.T) {
 		t.Error("expected timer to be set")
 	}
 
-	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-3", false)
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-3", false, 0)
 
 	o.mu.Lock()
 	pc = len(o.pendingMsgs[key])
@@ -312,9 +324,9 @@ func TestNotifyAgent_BatchFlushDeduplicates(t *testing.T) {
 	o.lastNotified[key] = time.Now()
 	o.mu.Unlock()
 
-	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false)
-	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-3", false)
-	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false)
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false, 0)
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-3", false, 0)
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false, 0)
 
 	o.mu.Lock()
 	if timer := o.pendingTimers[key]; timer != nil {
@@ -355,7 +367,7 @@ func TestNotifyAgent_AfterCooldownExpired(t *testing.T) {
 	o.lastNotified[key] = time.Now().Add(-5 * time.Second)
 	o.mu.Unlock()
 
-	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false)
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false, 0)
 
 	o.mu.Lock()
 	pc := len(o.pendingMsgs[key])
@@ -711,3 +723,226 @@ func TestMultipleChatDirs(t *testing.T) {
 		t.Errorf("expected notification to bob in team1, got %s", (*sent)[0].sessionID)
 	}
 }
+
+func TestRenameChatDir_KeepsNotifyingRegisteredAgents(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	clock := &manualClock{now: time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)}
+	o.SetClock(clock)
+	o.RegisterAgent("old", "bob", "sess-bob")
+
+	msg := types.Message{From: "alice", To: "bob", Content: "first", Type: "direct", ExpectsReply: true}
+	o.ProcessMessage("old", msg)
+	msg.Content = "batched"
+	o.ProcessMessage("old", msg)
+	if len(*sent) != 1 {
+		t.Fatalf("expected the second message to be batched, sent %d", len(*sent))
+	}
+
+	o.RenameChatDir("old", "new")
+	o.RenameChatDir("old", "new") // the room_renamed event repeats it
+
+	// The batch armed under the old name is flushed under the new one.
+	clock.advance(NotifyCooldown)
+	if len(*sent) != 2 {
+		t.Fatalf("batched notification lost across the rename, sent %d", len(*sent))
+	}
+
+	clock.advance(NotifyCooldown)
+	msg.Content = "after rename"
+	o.ProcessMessage("new", msg)
+	if len(*sent) != 3 || (*sent)[2].sessionID != "sess-bob" {
+		t.Fatalf("agent registered before the rename not notified: %+v", *sent)
+	}
+
+	o.UnregisterAgent("new", "bob")
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.agentSessions["old"]) != 0 || len(o.agentSessions["new"]) != 0 || len(o.lastNotified) != 0 {
+		t.Errorf("state left behind: sessions=%v lastNotified=%v", o.agentSessions, o.lastNotified)
+	}
+}
+
+// ── Priority tests ──
+
+func TestProcessMessage_UrgentBypassesCooldown(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	o.RegisterAgent("/rooms/t", "bob", "sess-bob")
+
+	o.mu.Lock()
+	o.lastNotified["/rooms/t:bob"] = time.Now()
+	o.mu.Unlock()
+
+	msg := types.Message{From: "alice", To: "bob", Content: "prod is down", Type: "direct", ExpectsReply: true, Priority: "urgent"}
+	o.ProcessMessage("/rooms/t", msg)
+
+	if len(*sent) != 1 {
+		t.Fatalf("urgent message should be sent immediately, got %d", len(*sent))
+	}
+	if !strings.Contains((*sent)[0].text, "URGENT") {
+		t.Errorf("urgent notification should say URGENT, got: %s", (*sent)[0].text)
+	}
+	o.mu.Lock()
+	pending := len(o.pendingMsgs["/rooms/t:bob"])
+	o.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("urgent message should not be batched, pending=%d", pending)
+	}
+}
+
+func TestProcessMessage_UrgentBypassesAckFilter(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	o.RegisterAgent("/rooms/t", "bob", "sess-bob")
+
+	msg := types.Message{From: "alice", To: "bob", Content: "tamam", Type: "direct", ExpectsReply: false, Priority: "urgent"}
+	o.ProcessMessage("/rooms/t", msg)
+
+	if len(*sent) != 1 {
+		t.Fatalf("urgent ACK-like message should still notify, got %d", len(*sent))
+	}
+}
+
+func TestProcessMessage_LowPriorityNoNudge(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	o.RegisterAgent("/rooms/t", "bob", "sess-bob")
+
+	msg := types.Message{From: "alice", To: "bob", Content: "FYI: refactored the logger", Type: "direct", ExpectsReply: true, Priority: "low"}
+	o.ProcessMessage("/rooms/t", msg)
+
+	if len(*sent) != 0 {
+		t.Fatalf("low priority message should not nudge the PTY, got %d", len(*sent))
+	}
+	o.mu.Lock()
+	_, notified := o.lastNotified["/rooms/t:bob"]
+	o.mu.Unlock()
+	if notified {
+		t.Error("low priority message should not update lastNotified")
+	}
+}
+
+// TestProcessRetractedMessage_DropsPendingOrNotifies verifies that a retracted
+// message is silently removed from a pending batch, and that agents already
+// notified are told to disregard it.
+func TestProcessRetractedMessage_DropsPendingOrNotifies(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	o.RegisterAgent("/rooms/t", "agent-1", "sess-11111111")
+
+	first := types.Message{ID: 1, From: "agent-2", To: "agent-1", Content: "please deploy the backend service", Type: "direct", ExpectsReply: true}
+	second := types.Message{ID: 2, From: "agent-2", To: "agent-1", Content: "also migrate the database schema", Type: "direct", ExpectsReply: true}
+
+	o.ProcessMessage("/rooms/t", first)  // sent immediately
+	o.ProcessMessage("/rooms/t", second) // batched (cooldown)
+	if len(*sent) != 1 {
+		t.Fatalf("expected 1 immediate notification, got %d", len(*sent))
+	}
+
+	o.ProcessRetractedMessage("/rooms/t", second.Redacted())
+	o.mu.Lock()
+	_, hasPending := o.pendingMsgs["/rooms/t:agent-1"]
+	_, hasTimer := o.pendingTimers["/rooms/t:agent-1"]
+	o.mu.Unlock()
+	if hasPending || hasTimer {
+		t.Fatalf("retracted message should be dropped from the pending batch")
+	}
+	if len(*sent) != 1 {
+		t.Fatalf("dropping a pending message should not send anything, got %d", len(*sent))
+	}
+
+	o.ProcessRetractedMessage("/rooms/t", first.Redacted())
+	if len(*sent) != 2 || !strings.Contains((*sent)[1].text, "#1") || !strings.Contains((*sent)[1].text, "retracted") {
+		t.Fatalf("expected retract notice for message 1, got %+v", *sent)
+	}
+}
+
+// TestProcessMessage_GroupRecipientsFanOut verifies that multi-recipient messages
+// notify exactly the resolved recipients.
+func TestProcessMessage_GroupRecipientsFanOut(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	o.RegisterAgent("/rooms/t", "backend", "sess-backend")
+	o.RegisterAgent("/rooms/t", "db", "sess-db")
+	o.RegisterAgent("/rooms/t", "frontend", "sess-frontend")
+
+	o.ProcessMessage("/rooms/t", types.Message{
+		ID:           1,
+		From:         "manager",
+		To:           "backend,@data",
+		Recipients:   []string{"backend", "db"},
+		Content:      "schema change incoming, please check your queries",
+		Type:         "direct",
+		ExpectsReply: true,
+	})
+
+	got := make(map[string]bool)
+	for _, n := range *sent {
+		got[n.sessionID] = true
+	}
+	if len(*sent) != 2 || !got["sess-backend"] || !got["sess-db"] {
+		t.Fatalf("expected backend and db to be notified, got %+v", *sent)
+	}
+}
+
+func TestNotifyAgent_DeferredWhileBusy(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	key := "/rooms/t:agent-1"
+
+	var busy atomic.Bool
+	busy.Store(true)
+	o.activityFunc = func(string) string {
+		if busy.Load() {
+			return types.ActivityBusy
+		}
+		return types.ActivityIdle
+	}
+
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false, 7)
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-3", false, 8)
+
+	if len(*sent) != 0 {
+		t.Fatalf("expected no notification while busy, got %d", len(*sent))
+	}
+	o.mu.Lock()
+	if timer := o.pendingTimers[key]; timer != nil {
+		timer.Stop()
+	}
+	o.mu.Unlock()
+
+	// Still busy at the first poll: the flush must re-arm instead of sending.
+	o.flushPending("/rooms/t", "agent-1", "sess-11111111")
+	o.mu.Lock()
+	pc := len(o.pendingMsgs[key])
+	_, deferred := o.deferredSince[key]
+	if timer := o.pendingTimers[key]; timer != nil {
+		timer.Stop()
+	}
+	o.mu.Unlock()
+	if pc != 2 || !deferred || len(*sent) != 0 {
+		t.Fatalf("expected 2 deferred messages and nothing sent, got pending=%d deferred=%v sent=%d", pc, deferred, len(*sent))
+	}
+
+	// Idle again: the next poll delivers one batched notification.
+	busy.Store(false)
+	o.flushPending("/rooms/t", "agent-1", "sess-11111111")
+
+	if len(*sent) != 1 || !strings.Contains((*sent)[0].text, "2 new messages") {
+		t.Fatalf("expected one batched notification after idle, got %+v", *sent)
+	}
+	o.mu.Lock()
+	_, deferred = o.deferredSince[key]
+	o.mu.Unlock()
+	if deferred {
+		t.Error("deferral state should be cleared after flush")
+	}
+}
+
+func TestProcessMessage_HumanSenderLabelled(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	o.RegisterAgent("/rooms/t", "agent-1", "sess-11111111")
+	msg := types.Message{ID: 1, From: "user", To: "agent-1", Content: "Can you check the build?", Type: "direct", ExpectsReply: true, FromHuman: true}
+	o.ProcessMessage("/rooms/t", msg)
+
+	if len(*sent) != 1 {
+		t.Fatalf("expected 1 notification, got %d", len(*sent))
+	}
+	if text := (*sent)[0].text; !strings.Contains(text, "from user (human)") {
+		t.Errorf("expected human sender in notification, got %q", text)
+	}
+}
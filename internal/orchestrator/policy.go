@@ -0,0 +1,247 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"desktop/internal/team"
+)
+
+// DefaultBudgetWindow is the notification budget window when a budget is set
+// without WindowMinutes.
+const DefaultBudgetWindow = 60 * time.Minute
+
+// MaxHeldNotifications caps the notifications queued for one agent while its
+// delivery settings hold them. Older entries past the cap are folded into the
+// oldest kept one, so the eventual summary still counts every message.
+const MaxHeldNotifications = 100
+
+// deliveryPolicy is the compiled form of team.DeliverySettings.
+type deliveryPolicy struct {
+	dnd          bool
+	quiet        *quietHours
+	maxPerWindow int
+	window       time.Duration
+	digest       time.Duration
+	muted        map[string]bool // lowercased sender names
+}
+
+// quietHours is a daily local-time range in minutes since midnight. A range
+// whose end is before its start wraps past midnight ("22:00-08:00").
+type quietHours struct {
+	start, end int
+}
+
+func parseQuietHours(s string) (*quietHours, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return nil, fmt.Errorf("quiet hours must look like \"22:00-08:00\", got %q", s)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return nil, err
+	}
+	if start == end {
+		return nil, fmt.Errorf("quiet hours start and end are equal: %q", s)
+	}
+	return &quietHours{start: start, end: end}, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// contains reports whether t falls inside the quiet range.
+func (q quietHours) contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if q.start < q.end {
+		return m >= q.start && m < q.end
+	}
+	return m >= q.start || m < q.end
+}
+
+// endAfter returns the first end of the quiet range after t.
+func (q quietHours) endAfter(t time.Time) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	end := midnight.Add(time.Duration(q.end) * time.Minute)
+	if !end.After(t) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+func compileDeliveryPolicy(s team.DeliverySettings) (*deliveryPolicy, error) {
+	if s.MaxPerWindow < 0 || s.WindowMinutes < 0 || s.DigestMinutes < 0 {
+		return nil, fmt.Errorf("delivery limits must not be negative")
+	}
+	p := &deliveryPolicy{
+		dnd:          s.DoNotDisturb,
+		maxPerWindow: s.MaxPerWindow,
+		window:       time.Duration(s.WindowMinutes) * time.Minute,
+		digest:       time.Duration(s.DigestMinutes) * time.Minute,
+		muted:        make(map[string]bool, len(s.MutedSenders)),
+	}
+	if p.maxPerWindow > 0 && p.window == 0 {
+		p.window = DefaultBudgetWindow
+	}
+	if strings.TrimSpace(s.QuietHours) != "" {
+		q, err := parseQuietHours(s.QuietHours)
+		if err != nil {
+			return nil, err
+		}
+		p.quiet = q
+	}
+	for _, sender := range s.MutedSenders {
+		if sender = strings.TrimSpace(sender); sender != "" {
+			p.muted[strings.ToLower(sender)] = true
+		}
+	}
+	return p, nil
+}
+
+// ValidateDeliverySettings reports whether settings can be enforced.
+func ValidateDeliverySettings(s team.DeliverySettings) error {
+	_, err := compileDeliveryPolicy(s)
+	return err
+}
+
+// SetDeliverySettings installs an agent's delivery settings; nil clears them.
+// Notifications held under the previous settings are re-evaluated right away,
+// so turning off do-not-disturb releases them as one summary.
+func (o *Orchestrator) SetDeliverySettings(chatDir, agentName string, settings *team.DeliverySettings) error {
+	var policy *deliveryPolicy
+	if settings != nil {
+		p, err := compileDeliveryPolicy(*settings)
+		if err != nil {
+			return err
+		}
+		policy = p
+	}
+
+	key := chatDir + ":" + agentName
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if policy == nil {
+		delete(o.policies, key)
+		delete(o.sentLog, key)
+	} else {
+		o.policies[key] = policy
+	}
+
+	sessionID, ok := o.agentSessions[chatDir][agentName]
+	if !ok || len(o.pendingMsgs[key]) == 0 {
+		return nil
+	}
+	if timer, exists := o.pendingTimers[key]; exists {
+		timer.Stop()
+	}
+	o.pendingTimers[key] = o.afterFunc(0, func() {
+		o.flushPending(chatDir, agentName, sessionID)
+	})
+	return nil
+}
+
+// isMutedLocked reports whether the agent muted notifications from sender.
+// Callers must hold o.mu.
+func (o *Orchestrator) isMutedLocked(key, sender string) bool {
+	p := o.policies[key]
+	return p != nil && p.muted[strings.ToLower(sender)]
+}
+
+// holdLocked reports whether a non-urgent notification for key must wait, and
+// until when. A zero time means until the settings change (do-not-disturb).
+// Callers must hold o.mu.
+func (o *Orchestrator) holdLocked(key string, now time.Time) (until time.Time, reason string, held bool) {
+	p := o.policies[key]
+	if p == nil {
+		return time.Time{}, "", false
+	}
+	if p.dnd {
+		return time.Time{}, "do-not-disturb", true
+	}
+	if p.quiet != nil && p.quiet.contains(now) {
+		until, reason, held = p.quiet.endAfter(now), "quiet hours", true
+	}
+	if p.maxPerWindow > 0 {
+		sent := o.pruneSentLocked(key, p, now)
+		if len(sent) >= p.maxPerWindow {
+			if free := sent[len(sent)-p.maxPerWindow].Add(p.window); free.After(until) {
+				until, reason = free, "budget exhausted"
+			}
+			held = true
+		}
+	}
+	if p.digest > 0 {
+		if last := o.lastNotified[key]; !last.IsZero() && now.Sub(last) < p.digest {
+			if next := last.Add(p.digest); next.After(until) {
+				until, reason = next, "digest"
+			}
+			held = true
+		}
+	}
+	return until, reason, held
+}
+
+// appendHeldLocked queues a held notification for key, folding the oldest
+// entries once the queue exceeds MaxHeldNotifications. Callers must hold o.mu.
+func (o *Orchestrator) appendHeldLocked(key string, n pendingNotification) {
+	pending := append(o.pendingMsgs[key], n)
+	if over := len(pending) - MaxHeldNotifications; over > 0 {
+		kept := make([]pendingNotification, MaxHeldNotifications)
+		copy(kept, pending[over:])
+		for _, p := range pending[:over] {
+			kept[0].folded += 1 + p.folded
+		}
+		pending = kept
+	}
+	o.pendingMsgs[key] = pending
+}
+
+// pruneSentLocked drops notifications that left the budget window.
+func (o *Orchestrator) pruneSentLocked(key string, p *deliveryPolicy, now time.Time) []time.Time {
+	sent := o.sentLog[key]
+	i := 0
+	for i < len(sent) && now.Sub(sent[i]) >= p.window {
+		i++
+	}
+	sent = sent[i:]
+	o.sentLog[key] = sent
+	return sent
+}
+
+// recordSentLocked marks a notification as delivered for cooldown, digest and
+// budget accounting. Callers must hold o.mu.
+func (o *Orchestrator) recordSentLocked(key string, now time.Time) {
+	o.lastNotified[key] = now
+	if p := o.policies[key]; p != nil && p.maxPerWindow > 0 {
+		o.sentLog[key] = append(o.pruneSentLocked(key, p, now), now)
+	}
+}
+
+// holdPendingLocked arms the flush timer for held notifications. Without an
+// end time (do-not-disturb) nothing is armed; SetDeliverySettings flushes.
+// Callers must hold o.mu.
+func (o *Orchestrator) holdPendingLocked(chatDir, agentName, sessionID string, until time.Time) {
+	key := chatDir + ":" + agentName
+	if timer, exists := o.pendingTimers[key]; exists {
+		timer.Stop()
+		delete(o.pendingTimers, key)
+	}
+	if until.IsZero() {
+		return
+	}
+	o.pendingTimers[key] = o.afterFunc(until.Sub(o.now()), func() {
+		o.flushPending(chatDir, agentName, sessionID)
+	})
+	log.Printf("[ORCH] Notifications for agent=%s held until %s", agentName, until.Format("15:04:05"))
+}
@@ -0,0 +1,179 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"desktop/internal/team"
+)
+
+func TestQuietHours_WrapsPastMidnight(t *testing.T) {
+	q, err := parseQuietHours("22:00-08:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.Local)
+	cases := []struct {
+		at   string
+		want bool
+	}{
+		{"21:59", false}, {"22:00", true}, {"23:30", true}, {"03:00", true}, {"07:59", true}, {"08:00", false}, {"12:00", false},
+	}
+	for _, c := range cases {
+		clock, _ := time.Parse("15:04", c.at)
+		at := day.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+		if got := q.contains(at); got != c.want {
+			t.Errorf("contains(%s) = %v, want %v", c.at, got, c.want)
+		}
+	}
+
+	end := q.endAfter(day.Add(23 * time.Hour))
+	if want := day.AddDate(0, 0, 1).Add(8 * time.Hour); !end.Equal(want) {
+		t.Errorf("endAfter(23:00) = %v, want %v", end, want)
+	}
+
+	for _, bad := range []string{"22:00", "25:00-08:00", "08:00-08:00"} {
+		if _, err := parseQuietHours(bad); err == nil {
+			t.Errorf("parseQuietHours(%q) should fail", bad)
+		}
+	}
+}
+
+func TestDeliverySettings_DoNotDisturbHoldsUntilCleared(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	key := "/rooms/t:agent-1"
+
+	if err := o.SetDeliverySettings("/rooms/t", "agent-1", &team.DeliverySettings{DoNotDisturb: true}); err != nil {
+		t.Fatal(err)
+	}
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false, 1)
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-3", false, 2)
+
+	o.mu.Lock()
+	pc := len(o.pendingMsgs[key])
+	_, armed := o.pendingTimers[key]
+	o.mu.Unlock()
+	if len(*sent) != 0 || pc != 2 || armed {
+		t.Fatalf("expected 2 held messages without a timer, got sent=%d pending=%d armed=%v", len(*sent), pc, armed)
+	}
+
+	// A flush while do-not-disturb is on keeps the messages.
+	o.flushPending("/rooms/t", "agent-1", "sess-11111111")
+	if len(*sent) != 0 {
+		t.Fatalf("flush must not deliver during do-not-disturb, got %+v", *sent)
+	}
+
+	// Urgent messages still interrupt.
+	o.notifyAgentUrgent("/rooms/t", "agent-1", "sess-11111111", "agent-4", false, 3)
+	if len(*sent) != 1 || !strings.Contains((*sent)[0].text, "URGENT") {
+		t.Fatalf("expected urgent notification during do-not-disturb, got %+v", *sent)
+	}
+
+	// Clearing the settings releases the held messages as one summary. The
+	// agent is not registered, so the release flush is driven by hand.
+	if err := o.SetDeliverySettings("/rooms/t", "agent-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	o.mu.Lock()
+	o.lastNotified[key] = time.Now().Add(-NotifyCooldown)
+	o.mu.Unlock()
+	o.flushPending("/rooms/t", "agent-1", "sess-11111111")
+
+	if len(*sent) != 2 || !strings.Contains((*sent)[1].text, "2 new messages") {
+		t.Fatalf("expected one summary after do-not-disturb ends, got %+v", *sent)
+	}
+}
+
+func TestDeliverySettings_MutedSenders(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	if err := o.SetDeliverySettings("/rooms/t", "agent-1", &team.DeliverySettings{MutedSenders: []string{"Chatty"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "chatty", false, 1)
+	if len(*sent) != 0 {
+		t.Fatalf("muted sender must not notify, got %+v", *sent)
+	}
+
+	o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false, 3)
+	if len(*sent) != 1 || !strings.Contains((*sent)[0].text, "agent-2") {
+		t.Fatalf("expected notification from unmuted sender, got %+v", *sent)
+	}
+
+	// Urgent messages get through mutes.
+	o.notifyAgentUrgent("/rooms/t", "agent-1", "sess-11111111", "chatty", false, 4)
+	if len(*sent) != 2 || !strings.Contains((*sent)[1].text, "URGENT message from chatty") {
+		t.Fatalf("expected urgent notification from muted sender, got %+v", *sent)
+	}
+}
+
+func TestDeliverySettings_HeldQueueIsCapped(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	key := "/rooms/t:agent-1"
+	if err := o.SetDeliverySettings("/rooms/t", "agent-1", &team.DeliverySettings{DoNotDisturb: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	total := MaxHeldNotifications + 25
+	for i := 1; i <= total; i++ {
+		o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false, i)
+	}
+	o.mu.Lock()
+	pc := len(o.pendingMsgs[key])
+	o.mu.Unlock()
+	if pc != MaxHeldNotifications {
+		t.Fatalf("expected held queue capped at %d, got %d", MaxHeldNotifications, pc)
+	}
+
+	if err := o.SetDeliverySettings("/rooms/t", "agent-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	o.flushPending("/rooms/t", "agent-1", "sess-11111111")
+	if want := fmt.Sprintf("%d new messages", total); len(*sent) != 1 || !strings.Contains((*sent)[0].text, want) {
+		t.Fatalf("expected summary counting all %d held messages, got %+v", total, *sent)
+	}
+}
+
+func TestDeliverySettings_BudgetAndDigestHold(t *testing.T) {
+	o, sent := newTestOrchestrator()
+	key := "/rooms/t:agent-1"
+	if err := o.SetDeliverySettings("/rooms/t", "agent-1", &team.DeliverySettings{MaxPerWindow: 2, WindowMinutes: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		o.mu.Lock()
+		if !o.lastNotified[key].IsZero() {
+			o.lastNotified[key] = time.Now().Add(-NotifyCooldown) // skip the cooldown
+		}
+		o.mu.Unlock()
+		o.notifyAgent("/rooms/t", "agent-1", "sess-11111111", "agent-2", false, i)
+	}
+
+	o.mu.Lock()
+	pc := len(o.pendingMsgs[key])
+	timer := o.pendingTimers[key]
+	o.mu.Unlock()
+	if len(*sent) != 2 || pc != 1 || timer == nil {
+		t.Fatalf("expected 2 sent and the 3rd held by the budget, got sent=%d pending=%d timer=%v", len(*sent), pc, timer != nil)
+	}
+	timer.Stop()
+
+	// Digest: one summary every 10 minutes.
+	if err := o.SetDeliverySettings("/rooms/t", "agent-1", &team.DeliverySettings{DigestMinutes: 10}); err != nil {
+		t.Fatal(err)
+	}
+	o.mu.Lock()
+	o.lastNotified[key] = time.Now().Add(-time.Minute)
+	until, reason, held := o.holdLocked(key, time.Now())
+	o.mu.Unlock()
+	if !held || reason != "digest" || time.Until(until) < 8*time.Minute {
+		t.Fatalf("expected digest hold ~9m, got held=%v reason=%q until=%v", held, reason, time.Until(until))
+	}
+
+	if err := o.SetDeliverySettings("/rooms/t", "agent-1", &team.DeliverySettings{QuietHours: "nope"}); err == nil {
+		t.Error("invalid quiet hours should be rejected")
+	}
+}
@@ -0,0 +1,187 @@
+package pty
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"desktop/internal/types"
+)
+
+const (
+	// ActivityBusyWindow is how long after the last output a session counts as busy.
+	ActivityBusyWindow = 3 * time.Second
+	// InputIdleWindow is how long a session must be quiet before it may receive
+	// injected input, so half-drawn screens are not mistaken for a prompt.
+	InputIdleWindow = 1500 * time.Millisecond
+	// UserTypingGrace holds injected input back after the user's last keystroke.
+	UserTypingGrace = 2 * time.Second
+	// outputTailSize bounds the trailing output kept for prompt detection.
+	outputTailSize = 2048
+	// promptScanLines is how many trailing visible lines are searched for a
+	// CLI's input prompt; TUIs draw hints and footers below the input box.
+	promptScanLines = 8
+)
+
+// ansiPattern matches CSI/OSC escape sequences and stray control bytes.
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b[@-_]|[\x00-\x08\x0b-\x1f\x7f]`)
+
+// shellPromptMarkers end the last visible line of a shell (or unknown CLI)
+// waiting for input.
+var shellPromptMarkers = []string{"❯", ">", "$", "%", "#", "?", ":"}
+
+// confirmMarkers appear in the last visible lines of a confirmation prompt.
+// Injected text must never land there: Enter would answer the question.
+var confirmMarkers = []string{"(y/n)", "[y/n]", "(yes/no)", "do you want to"}
+
+// recordOutput updates the last-output timestamp and trailing output buffer.
+func (s *PTYSession) recordOutput(data []byte) {
+	s.lastOutputNano.Store(time.Now().UnixNano())
+
+	s.tailMu.Lock()
+	s.tail = append(s.tail, data...)
+	if over := len(s.tail) - outputTailSize; over > 0 {
+		s.tail = append(s.tail[:0], s.tail[over:]...)
+	}
+	s.tailMu.Unlock()
+}
+
+// LastOutput returns the time of the session's last PTY output (zero if none).
+func (s *PTYSession) LastOutput() time.Time {
+	nano := s.lastOutputNano.Load()
+	if nano == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nano)
+}
+
+func (s *PTYSession) outputTail() string {
+	s.tailMu.Lock()
+	defer s.tailMu.Unlock()
+	return string(s.tail)
+}
+
+// MarkUserInput records a keystroke typed by the user (not injected by the
+// orchestrator) and tracks the length of the line being typed, so a draft the
+// user erased no longer holds injection back. Terminal escape sequences such
+// as focus events are ignored.
+func (m *Manager) MarkUserInput(sessionID string, data []byte) {
+	session := m.GetSession(sessionID)
+	if session == nil || len(data) == 0 || data[0] == 0x1b {
+		return
+	}
+	session.lastInputNano.Store(time.Now().UnixNano())
+	session.draftLen.Store(draftLength(session.draftLen.Load(), data))
+}
+
+// draftLength applies typed bytes to the length of the unsubmitted line.
+// Words erased with Ctrl-W are not counted back; the draft stays pending.
+func draftLength(n int64, data []byte) int64 {
+	for _, b := range data {
+		switch {
+		case b == '\r' || b == '\n' || b == 0x03 || b == 0x15: // submit, Ctrl-C, Ctrl-U
+			n = 0
+		case b == 0x7f || b == 0x08: // backspace
+			if n > 0 {
+				n--
+			}
+		case b < 0x20 || b&0xc0 == 0x80: // other controls, UTF-8 continuation bytes
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// Activity classifies what the session's CLI is doing right now as one of
+// types.ActivityBusy, types.ActivityIdle or types.ActivityPromptWaiting.
+// Unknown sessions report idle.
+func (m *Manager) Activity(sessionID string) string {
+	session := m.GetSession(sessionID)
+	if session == nil {
+		return types.ActivityIdle
+	}
+	return classifyActivity(session.CLIType, session.LastOutput(), session.outputTail(), time.Now())
+}
+
+// ReadyForInput reports whether text can be injected into the session without
+// corrupting anything: the CLI is quiet and showing its input prompt (not a
+// confirmation), and the user is not in the middle of typing a line.
+func (m *Manager) ReadyForInput(sessionID string) bool {
+	session := m.GetSession(sessionID)
+	if session == nil {
+		return false
+	}
+	now := time.Now()
+	if last := session.LastOutput(); last.IsZero() || now.Sub(last) < InputIdleWindow {
+		return false
+	}
+	if session.draftLen.Load() > 0 {
+		return false
+	}
+	if nano := session.lastInputNano.Load(); nano > 0 && now.Sub(time.Unix(0, nano)) < UserTypingGrace {
+		return false
+	}
+	tail := session.outputTail()
+	return atInputPrompt(session.CLIType, tail) && !awaitingConfirmation(tail)
+}
+
+// classifyActivity derives the activity state from the last output time and
+// the trailing output of a session.
+func classifyActivity(cliType string, lastOutput time.Time, tail string, now time.Time) string {
+	if !lastOutput.IsZero() && now.Sub(lastOutput) < ActivityBusyWindow {
+		return types.ActivityBusy
+	}
+	if atInputPrompt(cliType, tail) || awaitingConfirmation(tail) {
+		return types.ActivityPromptWaiting
+	}
+	return types.ActivityIdle
+}
+
+// atInputPrompt reports whether the output ends at the CLI's input prompt.
+func atInputPrompt(cliType, tail string) bool {
+	lines := visibleLines(tail, promptScanLines)
+	if len(lines) == 0 {
+		return false
+	}
+	if re := InputDriverFor(cliType).PromptPattern(); re != nil {
+		return re.MatchString(strings.Join(lines, "\n"))
+	}
+	last := lines[len(lines)-1]
+	for _, m := range shellPromptMarkers {
+		if strings.HasSuffix(last, m) || strings.HasPrefix(last, m+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// awaitingConfirmation reports whether the last visible lines ask a yes/no question.
+func awaitingConfirmation(tail string) bool {
+	for _, line := range visibleLines(tail, 3) {
+		lower := strings.ToLower(line)
+		for _, m := range confirmMarkers {
+			if strings.Contains(lower, m) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// visibleLines strips escape sequences and returns up to n trailing non-blank
+// lines, trimmed of surrounding whitespace.
+func visibleLines(s string, n int) []string {
+	s = ansiPattern.ReplaceAllString(s, "")
+	raw := strings.FieldsFunc(s, func(r rune) bool { return r == '\n' || r == '\r' })
+	var lines []string
+	for i := len(raw) - 1; i >= 0 && len(lines) < n; i-- {
+		if line := strings.TrimSpace(raw[i]); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
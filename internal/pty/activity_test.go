@@ -0,0 +1,102 @@
+package pty
+
+import (
+	"testing"
+	"time"
+
+	"desktop/internal/types"
+)
+
+func TestClassifyActivity(t *testing.T) {
+	now := time.Now()
+	quiet := now.Add(-time.Minute)
+	cases := []struct {
+		name       string
+		cliType    string
+		lastOutput time.Time
+		tail       string
+		want       string
+	}{
+		{name: "recent output", cliType: "claude", lastOutput: now.Add(-time.Second), tail: "Running tests...\r\n", want: types.ActivityBusy},
+		{name: "never produced output", tail: "", want: types.ActivityIdle},
+		{name: "quiet without prompt", cliType: "shell", lastOutput: quiet, tail: "Build finished.\r\n", want: types.ActivityIdle},
+		{name: "shell prompt", cliType: "shell", lastOutput: quiet, tail: "\x1b[32muser@host\x1b[0m:~/src$ ", want: types.ActivityPromptWaiting},
+		{name: "claude input box with footer", cliType: "claude", lastOutput: quiet, tail: "╭────╮\r\n│ > \x1b[7m \x1b[27m │\r\n╰────╯\r\n  ? for shortcuts\r\n", want: types.ActivityPromptWaiting},
+		{name: "confirmation", cliType: "claude", lastOutput: quiet, tail: "Do you want to make this edit?\r\n❯ 1. Yes\r\n", want: types.ActivityPromptWaiting},
+	}
+
+	for _, tc := range cases {
+		if got := classifyActivity(tc.cliType, tc.lastOutput, tc.tail, now); got != tc.want {
+			t.Fatalf("%s: classifyActivity = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAtInputPrompt_PerCLIPatterns(t *testing.T) {
+	cases := []struct {
+		cliType string
+		tail    string
+		want    bool
+	}{
+		{cliType: "claude", tail: "│ > \r\n╰───╯\r\n  ? for shortcuts", want: true},
+		{cliType: "claude", tail: "✻ Thinking… (esc to interrupt)\r\n", want: false},
+		{cliType: "gemini", tail: ">   Type your message or @path/to/file\r\n", want: true},
+		{cliType: "codex", tail: "▌ \r\n ⏎ send   ⌃J newline\r\n", want: true},
+		{cliType: "copilot", tail: "❯ \r\nEnter @ to mention files\r\n", want: true},
+		{cliType: "codex", tail: "Working (12s • Esc to interrupt)\r\n", want: false},
+	}
+	for _, tc := range cases {
+		if got := atInputPrompt(tc.cliType, tc.tail); got != tc.want {
+			t.Fatalf("atInputPrompt(%q, %q) = %v, want %v", tc.cliType, tc.tail, got, tc.want)
+		}
+	}
+	if !awaitingConfirmation("Do you want to proceed?\r\n❯ 1. Yes\r\n  2. No\r\n") {
+		t.Fatalf("expected confirmation dialog to be detected")
+	}
+}
+
+func TestReadyForInput_HonorsUserDraft(t *testing.T) {
+	m := NewManager(nil)
+	s := &PTYSession{ID: "s1", CLIType: "claude"}
+	m.sessions[s.ID] = s
+	s.recordOutput([]byte("│ > \r\n  ? for shortcuts\r\n"))
+	s.lastOutputNano.Store(time.Now().Add(-time.Minute).UnixNano())
+
+	if !m.ReadyForInput("s1") {
+		t.Fatalf("expected quiet session at its prompt to be ready")
+	}
+
+	m.MarkUserInput("s1", []byte("\x1b[I")) // focus event: not typing
+	if !m.ReadyForInput("s1") {
+		t.Fatalf("expected focus events to be ignored")
+	}
+
+	m.MarkUserInput("s1", []byte("fix the"))
+	s.lastInputNano.Store(time.Now().Add(-time.Minute).UnixNano())
+	if m.ReadyForInput("s1") {
+		t.Fatalf("expected an unsubmitted user draft to block injection")
+	}
+
+	m.MarkUserInput("s1", []byte("\r"))
+	if m.ReadyForInput("s1") {
+		t.Fatalf("expected injection to wait right after the user submitted")
+	}
+	s.lastInputNano.Store(time.Now().Add(-time.Minute).UnixNano())
+	if !m.ReadyForInput("s1") {
+		t.Fatalf("expected session to be ready once the typing grace passed")
+	}
+
+	// Erasing the draft releases injection without waiting for the fallback.
+	for _, erase := range [][]byte{[]byte("\x7f\x7f\x7f"), {0x15}} {
+		m.MarkUserInput("s1", []byte("düş"))
+		s.lastInputNano.Store(time.Now().Add(-time.Minute).UnixNano())
+		if m.ReadyForInput("s1") {
+			t.Fatalf("expected a new draft to block injection")
+		}
+		m.MarkUserInput("s1", erase)
+		s.lastInputNano.Store(time.Now().Add(-time.Minute).UnixNano())
+		if !m.ReadyForInput("s1") {
+			t.Fatalf("expected an erased draft (%q) not to block injection", erase)
+		}
+	}
+}
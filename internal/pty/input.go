@@ -0,0 +1,181 @@
+package pty
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Terminal sequences used when injecting input.
+const (
+	bracketOpen  = "\x1b[200~"
+	bracketClose = "\x1b[201~"
+	focusIn      = "\x1b[I"
+)
+
+// InputWriter writes raw bytes to a session's PTY. *Manager implements it;
+// tests use a fake that records the writes.
+type InputWriter interface {
+	Write(sessionID string, data []byte) error
+}
+
+// InputDriver knows how to type text into one CLI's input box and submit it.
+type InputDriver interface {
+	// Send writes text into the session and submits it.
+	Send(w InputWriter, sessionID, text string) error
+	// StartupDelay is how long the CLI needs after launch before it accepts
+	// a startup prompt. Zero means the CLI gets no startup prompt.
+	StartupDelay() time.Duration
+	// PromptPattern matches the CLI's input prompt within its last visible
+	// lines. Nil falls back to generic shell prompt markers.
+	PromptPattern() *regexp.Regexp
+}
+
+// KeyboardDriver is the InputDriver used by every built-in CLI.
+type KeyboardDriver struct {
+	// Paste wraps the text in bracketed-paste markers and writes it at once.
+	// Otherwise the text is typed in chunks.
+	Paste bool
+	// FocusIn sends a Focus In event first, for TUIs that ignore input while
+	// their pane is not focused.
+	FocusIn     bool
+	FocusDelay  time.Duration // pause after Focus In
+	ChunkSize   int           // runes per write when typing; 0 writes all at once
+	ChunkDelay  time.Duration // pause between typed chunks
+	SubmitKey   string        // sent after the text, "\r" if empty
+	SubmitDelay time.Duration // pause before the submit key
+	Startup     time.Duration
+	Prompt      *regexp.Regexp
+}
+
+// inputSleep is replaced in tests.
+var inputSleep = time.Sleep
+
+// Send implements InputDriver.
+func (d *KeyboardDriver) Send(w InputWriter, sessionID, text string) error {
+	if d.FocusIn {
+		if err := w.Write(sessionID, []byte(focusIn)); err != nil {
+			return err
+		}
+		inputSleep(d.FocusDelay)
+	}
+
+	if d.Paste {
+		if err := w.Write(sessionID, []byte(bracketOpen+text+bracketClose)); err != nil {
+			return err
+		}
+	} else {
+		for _, chunk := range chunkRunes(text, d.ChunkSize) {
+			if err := w.Write(sessionID, []byte(chunk)); err != nil {
+				return err
+			}
+			inputSleep(d.ChunkDelay)
+		}
+	}
+
+	inputSleep(d.SubmitDelay)
+	submit := d.SubmitKey
+	if submit == "" {
+		submit = "\r"
+	}
+	return w.Write(sessionID, []byte(submit))
+}
+
+// StartupDelay implements InputDriver.
+func (d *KeyboardDriver) StartupDelay() time.Duration { return d.Startup }
+
+// PromptPattern implements InputDriver.
+func (d *KeyboardDriver) PromptPattern() *regexp.Regexp { return d.Prompt }
+
+// chunkRunes splits text into chunks of size runes (one chunk if size <= 0).
+func chunkRunes(text string, size int) []string {
+	if size <= 0 || text == "" {
+		return []string{text}
+	}
+	runes := []rune(text)
+	chunks := make([]string, 0, (len(runes)+size-1)/size)
+	for len(runes) > 0 {
+		n := min(size, len(runes))
+		chunks = append(chunks, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return chunks
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]InputDriver{
+		"claude": &KeyboardDriver{
+			Paste:       true,
+			SubmitDelay: 200 * time.Millisecond,
+			Startup:     3 * time.Second,
+			Prompt:      regexp.MustCompile(`(?m)^[│|]?\s*>(\s|$)|\? for shortcuts`),
+		},
+		"gemini": &KeyboardDriver{
+			Paste:       true,
+			SubmitDelay: 200 * time.Millisecond,
+			Startup:     5 * time.Second,
+			Prompt:      regexp.MustCompile(`(?m)^[│|]?\s*>(\s|$)|Type your message`),
+		},
+		"codex": &KeyboardDriver{
+			Paste:       true,
+			SubmitDelay: 200 * time.Millisecond,
+			Startup:     3 * time.Second,
+			Prompt:      regexp.MustCompile(`(?m)^[│|▌]?\s*[›>](\s|$)|⏎ send`),
+		},
+		// Copilot's Ink/React TUI drops pasted text: simulate keyboard input
+		// character by character. Its startup prompt is not injected.
+		"copilot": &KeyboardDriver{
+			FocusIn:     true,
+			FocusDelay:  50 * time.Millisecond,
+			ChunkSize:   1,
+			ChunkDelay:  5 * time.Millisecond,
+			SubmitDelay: 100 * time.Millisecond,
+			Prompt:      regexp.MustCompile(`(?m)^[│|]?\s*[›>❯](\s|$)|Enter @ to mention`),
+		},
+		"shell": &KeyboardDriver{
+			Paste:       true,
+			SubmitDelay: 200 * time.Millisecond,
+		},
+	}
+	// defaultDriver serves CLI types without a registered driver.
+	defaultDriver InputDriver = &KeyboardDriver{
+		Paste:       true,
+		SubmitDelay: 200 * time.Millisecond,
+		Startup:     3 * time.Second,
+	}
+)
+
+// RegisterInputDriver installs the input driver for a CLI type, replacing
+// any existing one.
+func RegisterInputDriver(cliType string, d InputDriver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	drivers[normalizeCLIType(cliType)] = d
+}
+
+// InputDriverFor returns the input driver of a CLI type.
+func InputDriverFor(cliType string) InputDriver {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	if d, ok := drivers[normalizeCLIType(cliType)]; ok {
+		return d
+	}
+	return defaultDriver
+}
+
+func normalizeCLIType(cliType string) string {
+	return strings.ToLower(strings.TrimSpace(cliType))
+}
+
+// Inject types text into a session's CLI and submits it, using the input
+// driver of the session's CLI type.
+func (m *Manager) Inject(sessionID, text string) error {
+	session := m.GetSession(sessionID)
+	if session == nil {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	return InputDriverFor(session.CLIType).Send(m, sessionID, text)
+}
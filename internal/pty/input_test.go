@@ -0,0 +1,98 @@
+package pty
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakePTY records the writes an input driver makes.
+type fakePTY struct {
+	writes []string
+	failAt int // 1-based write that fails; 0 never fails
+}
+
+func (f *fakePTY) Write(sessionID string, data []byte) error {
+	if f.failAt > 0 && len(f.writes)+1 == f.failAt {
+		return errors.New("pty closed")
+	}
+	f.writes = append(f.writes, string(data))
+	return nil
+}
+
+func noSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var slept []time.Duration
+	inputSleep = func(d time.Duration) { slept = append(slept, d) }
+	t.Cleanup(func() { inputSleep = time.Sleep })
+	return &slept
+}
+
+func TestInputDriver_PasteThenSubmit(t *testing.T) {
+	slept := noSleep(t)
+	f := &fakePTY{}
+	if err := InputDriverFor("Claude").Send(f, "s1", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"\x1b[200~hello\x1b[201~", "\r"}
+	if !reflect.DeepEqual(f.writes, want) {
+		t.Fatalf("writes = %q, want %q", f.writes, want)
+	}
+	if len(*slept) != 1 || (*slept)[0] != 200*time.Millisecond {
+		t.Fatalf("expected one 200ms pause before submit, got %v", *slept)
+	}
+}
+
+func TestInputDriver_CopilotTypesWithFocus(t *testing.T) {
+	noSleep(t)
+	f := &fakePTY{}
+	if err := InputDriverFor("copilot").Send(f, "s1", "hé!"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"\x1b[I", "h", "é", "!", "\r"}
+	if !reflect.DeepEqual(f.writes, want) {
+		t.Fatalf("writes = %q, want %q", f.writes, want)
+	}
+	if InputDriverFor("copilot").StartupDelay() != 0 {
+		t.Fatalf("copilot must not receive a startup prompt")
+	}
+}
+
+func TestInputDriver_ChunksAndCustomSubmit(t *testing.T) {
+	noSleep(t)
+	RegisterInputDriver("custom", &KeyboardDriver{ChunkSize: 2, SubmitKey: "\n"})
+	t.Cleanup(func() {
+		driversMu.Lock()
+		delete(drivers, "custom")
+		driversMu.Unlock()
+	})
+
+	f := &fakePTY{}
+	if err := InputDriverFor(" CUSTOM ").Send(f, "s1", "abcde"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ab", "cd", "e", "\n"}
+	if !reflect.DeepEqual(f.writes, want) {
+		t.Fatalf("writes = %q, want %q", f.writes, want)
+	}
+
+	// A failed write stops the injection before the submit key.
+	f = &fakePTY{failAt: 2}
+	if err := InputDriverFor("custom").Send(f, "s1", "abcde"); err == nil {
+		t.Fatal("expected write error")
+	}
+	if len(f.writes) != 1 {
+		t.Fatalf("expected injection to stop after the failed write, got %q", f.writes)
+	}
+}
+
+func TestInputDriverFor_UnknownCLIUsesDefault(t *testing.T) {
+	d := InputDriverFor("aider")
+	if d != defaultDriver || d.PromptPattern() != nil || d.StartupDelay() <= 0 {
+		t.Fatalf("unknown CLI should get the default paste driver, got %+v", d)
+	}
+	if InputDriverFor("shell").StartupDelay() != 0 {
+		t.Fatalf("shell must not receive a startup prompt")
+	}
+}
@@ -25,8 +25,16 @@ type PTYSession struct {
 	CLIType        string
 	WorkDir        string // stored for restart
 	PromptID       string // stored for restart
+	ConversationID string // CLI conversation the session resumed, if any
+	StartedAt      time.Time
 	done           chan struct{}
 	lastOutputNano atomic.Int64 // unix nano timestamp of last PTY output
+	tailMu         sync.Mutex
+	tail           []byte       // trailing output, for prompt detection
+	lastInputNano  atomic.Int64 // unix nano timestamp of last user keystroke
+	draftLen       atomic.Int64 // characters of the user's unsubmitted line
+	scrollback     *ringBuffer  // recent output for panes that re-mount
+	transcript     *transcript  // nil unless transcripts are enabled
 }
 
 // OutputHandler is called when PTY produces output
@@ -34,9 +42,11 @@ type OutputHandler func(sessionID string, data []byte)
 
 // Manager manages multiple PTY sessions
 type Manager struct {
-	mu       sync.RWMutex
-	sessions map[string]*PTYSession
-	onOutput OutputHandler
+	mu                 sync.RWMutex
+	sessions           map[string]*PTYSession
+	onOutput           OutputHandler
+	transcriptDir      string
+	transcriptSettings TranscriptSettings
 }
 
 // NewManager creates a new PTY manager
@@ -87,15 +97,18 @@ func (m *Manager) Create(teamID, agentName, workDir string, env []string, cmdNam
 	}
 
 	session := &PTYSession{
-		ID:        id,
-		Cmd:       cmd,
-		PTY:       ptmx,
-		TeamID:    teamID,
-		AgentName: agentName,
-		CLIType:   cliType,
-		WorkDir:   workDir,
-		done:      make(chan struct{}),
+		ID:         id,
+		Cmd:        cmd,
+		PTY:        ptmx,
+		TeamID:     teamID,
+		AgentName:  agentName,
+		CLIType:    cliType,
+		WorkDir:    workDir,
+		StartedAt:  time.Now(),
+		done:       make(chan struct{}),
+		scrollback: newRingBuffer(ScrollbackSize),
 	}
+	session.transcript = m.openTranscript(session)
 
 	m.mu.Lock()
 	m.sessions[id] = session
@@ -111,6 +124,9 @@ func (m *Manager) Create(teamID, agentName, workDir string, env []string, cmdNam
 // It buffers incomplete UTF-8 sequences across reads to prevent garbled output.
 func (m *Manager) readLoop(session *PTYSession) {
 	defer close(session.done)
+	if session.transcript != nil {
+		defer session.transcript.close()
+	}
 
 	buf := make([]byte, 8192)
 	var carry []byte // incomplete UTF-8 bytes from previous read
@@ -118,7 +134,11 @@ func (m *Manager) readLoop(session *PTYSession) {
 	for {
 		n, err := session.PTY.Read(buf)
 		if n > 0 {
-			session.lastOutputNano.Store(time.Now().UnixNano())
+			session.recordOutput(buf[:n])
+			session.scrollback.Write(buf[:n])
+			if session.transcript != nil {
+				session.transcript.write(buf[:n])
+			}
 		}
 		if n > 0 && m.onOutput != nil {
 			// Prepend any carried-over bytes from previous read
@@ -244,6 +264,20 @@ func (m *Manager) WaitForIdle(sessionID string, idleDuration, maxWait time.Durat
 	return false
 }
 
+// WaitForExit reports whether the session's process exits within timeout.
+func (m *Manager) WaitForExit(sessionID string, timeout time.Duration) bool {
+	session := m.GetSession(sessionID)
+	if session == nil {
+		return true
+	}
+	select {
+	case <-session.done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 // Resize resizes a PTY session
 func (m *Manager) Resize(sessionID string, cols, rows uint16) error {
 	m.mu.RLock()
@@ -339,6 +373,18 @@ func (m *Manager) GetSessionsByTeam(teamID string) []*PTYSession {
 	return result
 }
 
+// ListSessions returns all active sessions
+func (m *Manager) ListSessions() []*PTYSession {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*PTYSession, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		result = append(result, s)
+	}
+	return result
+}
+
 // filterEnv removes specified keys from an environment variable slice.
 // Keys ending with "*" are treated as prefix filters (e.g. "VSCODE_*" removes
 // all variables starting with "VSCODE_").
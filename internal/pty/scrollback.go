@@ -0,0 +1,77 @@
+package pty
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ScrollbackSize is how many bytes of output each session keeps for panes
+// that re-mount.
+const ScrollbackSize = 256 * 1024
+
+// ringBuffer keeps the last cap(buf) bytes written to it.
+type ringBuffer struct {
+	mu    sync.Mutex
+	buf   []byte
+	start int // index of the oldest byte
+	size  int // bytes in use
+}
+
+func newRingBuffer(capacity int) *ringBuffer {
+	return &ringBuffer{buf: make([]byte, capacity)}
+}
+
+func (r *ringBuffer) Write(p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	capacity := len(r.buf)
+	if len(p) >= capacity {
+		copy(r.buf, p[len(p)-capacity:])
+		r.start, r.size = 0, capacity
+		return
+	}
+	end := (r.start + r.size) % capacity
+	n := copy(r.buf[end:], p)
+	copy(r.buf, p[n:])
+	r.size += len(p)
+	if over := r.size - capacity; over > 0 {
+		r.start = (r.start + over) % capacity
+		r.size = capacity
+	}
+}
+
+// Bytes returns a copy of the buffered bytes, oldest first.
+func (r *ringBuffer) Bytes() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]byte, r.size)
+	n := copy(out, r.buf[r.start:min(r.start+r.size, len(r.buf))])
+	copy(out[n:], r.buf[:r.size-n])
+	return out
+}
+
+// Scrollback returns the session's buffered output, suitable for replaying
+// into a terminal that re-mounts. Closed sessions are no longer found.
+func (m *Manager) Scrollback(sessionID string) (string, error) {
+	session := m.GetSession(sessionID)
+	if session == nil {
+		return "", fmt.Errorf("session not found: %s", sessionID)
+	}
+	return session.Scrollback(), nil
+}
+
+// Scrollback returns the session's buffered output. Callers still holding the
+// session can read it after Close, though the Manager forgets it. Eviction can cut a UTF-8 sequence in half, so
+// leading continuation bytes are dropped.
+func (s *PTYSession) Scrollback() string {
+	if s.scrollback == nil {
+		return ""
+	}
+	data := s.scrollback.Bytes()
+	for len(data) > 0 && !utf8.RuneStart(data[0]) {
+		data = data[1:]
+	}
+	return strings.ToValidUTF8(string(data[:validUTF8Len(data)]), "")
+}
@@ -0,0 +1,43 @@
+package pty
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRingBuffer_KeepsNewestBytes(t *testing.T) {
+	r := newRingBuffer(8)
+	r.Write([]byte("abc"))
+	r.Write([]byte("defgh"))
+	if got := string(r.Bytes()); got != "abcdefgh" {
+		t.Fatalf("full buffer = %q", got)
+	}
+	r.Write([]byte("ij"))
+	if got := string(r.Bytes()); got != "cdefghij" {
+		t.Fatalf("wrapped buffer = %q, want %q", got, "cdefghij")
+	}
+	r.Write([]byte("0123456789"))
+	if got := string(r.Bytes()); got != "23456789" {
+		t.Fatalf("oversized write = %q, want %q", got, "23456789")
+	}
+}
+
+func TestScrollback_DropsCutRunes(t *testing.T) {
+	m := NewManager(nil)
+	s := &PTYSession{ID: "s1", scrollback: newRingBuffer(6)}
+	m.sessions[s.ID] = s
+
+	// "é" is two bytes; eviction leaves its continuation byte at the front.
+	s.scrollback.Write([]byte("xé12345"))
+	got, err := m.Scrollback("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "12345" {
+		t.Fatalf("Scrollback = %q, want %q", got, "12345")
+	}
+
+	if _, err := m.Scrollback("missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not-found error, got %v", err)
+	}
+}
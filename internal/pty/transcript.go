@@ -0,0 +1,256 @@
+package pty
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TranscriptSettings controls on-disk transcript logs. Plain transcripts are
+// ANSI-stripped text (<name>.log) and are what SearchTranscripts reads; raw
+// transcripts keep every byte (<name>.raw) for faithful replay.
+type TranscriptSettings struct {
+	Enabled bool `json:"enabled"`
+	Raw     bool `json:"raw"`
+}
+
+// TranscriptMatch is one line of a transcript that matched a search.
+type TranscriptMatch struct {
+	File    string `json:"file"`
+	TeamID  string `json:"team_id"`
+	Agent   string `json:"agent"`
+	Started string `json:"started"`
+	Line    int    `json:"line"`
+	Text    string `json:"text"`
+}
+
+const (
+	// maxEscapeCarry bounds how much of an unfinished escape sequence is held
+	// back from a plain transcript until the next read completes it.
+	maxEscapeCarry = 64
+	transcriptTime = "20060102-150405"
+)
+
+// transcript writes one session's output to disk.
+type transcript struct {
+	mu    sync.Mutex
+	plain *os.File
+	raw   *os.File
+	carry string
+}
+
+// SetTranscripts configures transcript logging for sessions created from now
+// on. dir is the root transcripts directory.
+func (m *Manager) SetTranscripts(dir string, settings TranscriptSettings) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.transcriptDir = dir
+	m.transcriptSettings = settings
+}
+
+// openTranscript creates the transcript files of a new session, or returns
+// nil when transcripts are disabled.
+func (m *Manager) openTranscript(session *PTYSession) *transcript {
+	m.mu.RLock()
+	dir, settings := m.transcriptDir, m.transcriptSettings
+	m.mu.RUnlock()
+	if !settings.Enabled || dir == "" {
+		return nil
+	}
+
+	teamDir := filepath.Join(dir, transcriptTeam(session.TeamID))
+	if err := os.MkdirAll(teamDir, 0700); err != nil {
+		log.Printf("[PTY] transcript dir failed session=%s: %v", ShortID(session.ID), err)
+		return nil
+	}
+	base := filepath.Join(teamDir, fmt.Sprintf("%s_%s_%s",
+		transcriptAgent(session.AgentName), time.Now().Format(transcriptTime), ShortID(session.ID)))
+
+	t := &transcript{}
+	var err error
+	if t.plain, err = os.OpenFile(base+".log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600); err != nil {
+		log.Printf("[PTY] transcript open failed session=%s: %v", ShortID(session.ID), err)
+		return nil
+	}
+	if settings.Raw {
+		if t.raw, err = os.OpenFile(base+".raw", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600); err != nil {
+			log.Printf("[PTY] raw transcript open failed session=%s: %v", ShortID(session.ID), err)
+		}
+	}
+	return t
+}
+
+func transcriptTeam(teamID string) string {
+	if teamID == "" {
+		return "default"
+	}
+	return teamID
+}
+
+func transcriptAgent(agentName string) string {
+	if agentName == "" {
+		return "terminal"
+	}
+	return agentName
+}
+
+// write appends a chunk of output to the transcript files.
+func (t *transcript) write(data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.raw != nil {
+		t.raw.Write(data)
+	}
+	if t.plain != nil {
+		var text string
+		text, t.carry = stripTerminal(t.carry + string(data))
+		if text != "" {
+			t.plain.WriteString(text)
+		}
+	}
+}
+
+func (t *transcript) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.plain != nil {
+		if t.carry != "" {
+			t.plain.WriteString(ansiPattern.ReplaceAllString(t.carry, ""))
+		}
+		t.plain.Close()
+		t.plain = nil
+	}
+	if t.raw != nil {
+		t.raw.Close()
+		t.raw = nil
+	}
+}
+
+// stripTerminal removes escape sequences and carriage returns from s. A
+// trailing escape sequence that may still be incomplete is returned as carry.
+func stripTerminal(s string) (text, carry string) {
+	if i := strings.LastIndexByte(s, 0x1b); i >= 0 && len(s)-i <= maxEscapeCarry {
+		seq := ""
+		if loc := ansiPattern.FindStringIndex(s[i:]); loc != nil && loc[0] == 0 {
+			seq = s[i : i+loc[1]]
+		}
+		// A lone "ESC [" or "ESC ]" matches as a two-byte escape but is
+		// usually the start of a longer sequence.
+		if seq == "" || seq == "\x1b[" || seq == "\x1b]" {
+			s, carry = s[:i], s[i:]
+		}
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = ansiPattern.ReplaceAllString(s, "")
+	return s, carry
+}
+
+// StripANSI removes terminal escape sequences and control characters from s.
+func StripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(strings.ReplaceAll(s, "\r\n", "\n"), "")
+}
+
+// SearchTranscripts returns plain transcript lines under dir containing
+// query (case-insensitive), newest transcript first. teamID narrows the
+// search to one team; limit caps the matches (0 means 200).
+func SearchTranscripts(dir, teamID, query string, limit int) ([]TranscriptMatch, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil, fmt.Errorf("search query is empty")
+	}
+	if limit <= 0 {
+		limit = 200
+	}
+	if teamID == ".." || strings.ContainsAny(teamID, `/\`) {
+		return nil, fmt.Errorf("invalid team id: %q", teamID)
+	}
+	root := dir
+	if teamID != "" {
+		root = filepath.Join(dir, transcriptTeam(teamID))
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".log") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// File names embed the start time after the agent name; sort on it.
+	sort.Slice(files, func(i, j int) bool {
+		_, _, si := parseTranscriptName(files[i])
+		_, _, sj := parseTranscriptName(files[j])
+		return si > sj
+	})
+
+	var matches []TranscriptMatch
+	for _, path := range files {
+		found, err := searchTranscriptFile(dir, path, query, limit-len(matches))
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, found...)
+		if len(matches) >= limit {
+			break
+		}
+	}
+	return matches, nil
+}
+
+func searchTranscriptFile(dir, path, query string, limit int) ([]TranscriptMatch, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rel, _ := filepath.Rel(dir, path)
+	teamID := filepath.Base(filepath.Dir(path))
+	agent, started, _ := parseTranscriptName(path)
+
+	var matches []TranscriptMatch
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() && len(matches) < limit {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if strings.Contains(strings.ToLower(text), query) {
+			matches = append(matches, TranscriptMatch{File: rel, TeamID: teamID, Agent: agent, Started: started, Line: line, Text: text})
+		}
+	}
+	return matches, scanner.Err()
+}
+
+// parseTranscriptName splits "<agent>_<time>_<id>.log" into the agent name,
+// the RFC 3339 start time and a sortable start key.
+func parseTranscriptName(path string) (agent, started, key string) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	parts := strings.Split(name, "_")
+	if len(parts) < 3 {
+		return name, "", ""
+	}
+	stamp := parts[len(parts)-2]
+	agent = strings.Join(parts[:len(parts)-2], "_")
+	if t, err := time.ParseInLocation(transcriptTime, stamp, time.Local); err == nil {
+		started = t.Format(time.RFC3339)
+	}
+	return agent, started, stamp
+}
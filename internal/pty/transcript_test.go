@@ -0,0 +1,61 @@
+package pty
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStripTerminal_CarriesSplitEscape(t *testing.T) {
+	text, carry := stripTerminal("\x1b[32mok\x1b[0m\r\nnext \x1b[3")
+	if text != "ok\nnext " || carry != "\x1b[3" {
+		t.Fatalf("stripTerminal = %q carry %q", text, carry)
+	}
+	text, carry = stripTerminal(carry + "1mred\x1b[0m\r\n")
+	if text != "red\n" || carry != "" {
+		t.Fatalf("continued stripTerminal = %q carry %q", text, carry)
+	}
+}
+
+func TestTranscripts_WriteAndSearch(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(nil)
+	m.SetTranscripts(dir, TranscriptSettings{Enabled: true, Raw: true})
+
+	s := &PTYSession{ID: "abcdef1234", TeamID: "team-1", AgentName: "backend"}
+	tr := m.openTranscript(s)
+	if tr == nil {
+		t.Fatal("expected transcript to open")
+	}
+	tr.write([]byte("\x1b[1mBuild\x1b[0m started\r\nAll tests PASSED\r\n"))
+	tr.close()
+
+	raws, _ := filepath.Glob(filepath.Join(dir, "team-1", "backend_*_abcdef12.raw"))
+	if len(raws) != 1 {
+		t.Fatalf("expected one raw transcript, got %v", raws)
+	}
+	raw, _ := os.ReadFile(raws[0])
+	if string(raw) != "\x1b[1mBuild\x1b[0m started\r\nAll tests PASSED\r\n" {
+		t.Fatalf("raw transcript = %q", raw)
+	}
+
+	matches, err := SearchTranscripts(dir, "team-1", "passed", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Agent != "backend" || matches[0].Line != 2 || matches[0].Text != "All tests PASSED" || matches[0].Started == "" {
+		t.Fatalf("unexpected matches: %+v", matches)
+	}
+
+	if matches, err := SearchTranscripts(dir, "other-team", "passed", 0); err != nil || len(matches) != 0 {
+		t.Fatalf("expected no matches in another team, got %+v err=%v", matches, err)
+	}
+	if _, err := SearchTranscripts(dir, "../x", "passed", 0); err == nil {
+		t.Fatal("expected invalid team id to be rejected")
+	}
+
+	m.SetTranscripts(dir, TranscriptSettings{})
+	if m.openTranscript(s) != nil {
+		t.Fatal("disabled transcripts must not open files")
+	}
+}
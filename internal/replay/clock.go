@@ -0,0 +1,89 @@
+package replay
+
+import (
+	"sync"
+	"time"
+
+	"desktop/internal/orchestrator"
+)
+
+// virtualClock is the orchestrator's clock during a replay. It only moves
+// when the replay advances it and runs due timers in order, on the
+// replay's goroutine.
+type virtualClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	seq    int
+	timers []*virtualTimer
+}
+
+type virtualTimer struct {
+	clock   *virtualClock
+	at      time.Time
+	seq     int // creation order breaks ties between timers due together
+	f       func()
+	stopped bool
+}
+
+func newVirtualClock(start time.Time) *virtualClock {
+	return &virtualClock{now: start}
+}
+
+func (c *virtualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *virtualClock) AfterFunc(d time.Duration, f func()) orchestrator.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	t := &virtualTimer{clock: c, at: c.now.Add(d), seq: c.seq, f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (t *virtualTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	was := !t.stopped
+	t.stopped = true
+	return was
+}
+
+// advance moves the clock to `to`, stopping at each due timer's time to run
+// it. Timers armed by a running timer fire in the same call when due.
+func (c *virtualClock) advance(to time.Time) {
+	for {
+		c.mu.Lock()
+		var next *virtualTimer
+		live := c.timers[:0]
+		for _, t := range c.timers {
+			if t.stopped {
+				continue
+			}
+			live = append(live, t)
+			if t.at.After(to) {
+				continue
+			}
+			if next == nil || t.at.Before(next.at) || (t.at.Equal(next.at) && t.seq < next.seq) {
+				next = t
+			}
+		}
+		c.timers = live
+		if next == nil {
+			if to.After(c.now) {
+				c.now = to
+			}
+			c.mu.Unlock()
+			return
+		}
+		next.stopped = true
+		if next.at.After(c.now) {
+			c.now = next.at
+		}
+		c.mu.Unlock()
+		next.f()
+	}
+}
@@ -0,0 +1,590 @@
+// Package replay feeds a recorded room (a JSON Lines export, see the hub's
+// export_room) back through an in-process hub and orchestrator into a
+// sandbox room. The orchestrator runs on a virtual clock set from the
+// recorded timestamps and notifies a stub terminal sink instead of PTYs, so
+// routing, batching and notification decisions come out the same on every
+// run, whatever the playback speed. The trace of a replay can be asserted on
+// to turn a real incident into a regression test.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"desktop/internal/hub"
+	"desktop/internal/orchestrator"
+	"desktop/internal/types"
+)
+
+// DefaultRoom is the sandbox room a replay runs in unless Options.Room is set.
+const DefaultRoom = "replay"
+
+// DefaultTail is how long the virtual clock keeps running after the last
+// recorded event, so batched notifications come due.
+const DefaultTail = time.Minute
+
+// Trace event kinds.
+const (
+	KindJoin           = "join"
+	KindLeave          = "leave"
+	KindManager        = "manager"
+	KindSystem         = "system"
+	KindMessage        = "message"
+	KindEdit           = "edit"
+	KindRetract        = "retract"
+	KindRead           = "read"
+	KindNotify         = "notify"
+	KindLoop           = "loop"
+	KindDeliveryFailed = "delivery_failed"
+	KindError          = "error"
+)
+
+// sessionPrefix marks the stub terminal sessions agents are registered with.
+const sessionPrefix = "replay:"
+
+// Options control a replay.
+type Options struct {
+	// Room is the sandbox room name. Default DefaultRoom.
+	Room string
+	// Speed scales the recorded pauses in wall time: 1 plays at the recorded
+	// pace, 10 ten times faster, 0 as fast as possible. The virtual clock
+	// always follows the recording.
+	Speed float64
+	// Until stops after the recorded message with this ID, to inspect the
+	// sandbox as it was at that point. 0 replays the whole recording.
+	Until int
+	// Tail keeps the virtual clock running after the last recorded event.
+	// Default DefaultTail; ignored with Until.
+	Tail time.Duration
+	// Setup configures the orchestrator before the first event, e.g. with a
+	// team's analyzer or delivery settings.
+	Setup func(o *orchestrator.Orchestrator, room string)
+	// OnEvent is called with each trace event as it happens.
+	OnEvent func(Event)
+	// Logger receives the sandbox hub's log. Default: discarded.
+	Logger *log.Logger
+}
+
+// Event is one entry of a replay trace.
+type Event struct {
+	// At is the virtual time since the first recorded event.
+	At   time.Duration `json:"at"`
+	Kind string        `json:"kind"`
+	// Agent is the participant the event concerns: the sender of a message,
+	// the recipient of a notification, the reader of messages.
+	Agent string `json:"agent,omitempty"`
+	// RecordedID is the message's ID in the recording, MessageID its ID in
+	// the sandbox room.
+	RecordedID int    `json:"recorded_id,omitempty"`
+	MessageID  int    `json:"message_id,omitempty"`
+	Text       string `json:"text,omitempty"`
+}
+
+// Result is the sandbox state at the end of a replay.
+type Result struct {
+	Trace       []Event                        `json:"trace"`
+	Messages    []types.Message                `json:"messages"`
+	Agents      map[string]types.Agent         `json:"agents"`
+	Deliveries  []orchestrator.MessageDelivery `json:"deliveries"`
+	PausedPairs []orchestrator.LoopAlert       `json:"paused_pairs"`
+	// Export is the sandbox room as JSON Lines, for import_room.
+	Export string `json:"-"`
+}
+
+// Hub system messages that a replay turns back into joins and leaves
+// (see RoomState.Join, JoinHuman and Leave). Other system messages are the
+// hub's or the desktop's own output and are regenerated, not replayed.
+var (
+	joinPattern      = regexp.MustCompile(`^\x{1F7E2} (\S+) odaya katıldı(?: \(Rol: ([^)]*)\))?`)
+	humanJoinPattern = regexp.MustCompile(`^\x{1F464} (\S+) \(insan\) odaya katıldı`)
+	leavePattern     = regexp.MustCompile(`^\x{1F534} (\S+) odadan ayrıldı`)
+)
+
+// step is one recorded action, at the time it happened.
+type step struct {
+	at      time.Time
+	kind    string // KindJoin, KindLeave, KindMessage, KindEdit or KindRetract
+	name    string // who joins, leaves, edits or retracts
+	role    string
+	human   bool
+	msg     types.Message
+	content string // the message's content before its first edit, or after an edit
+}
+
+// steps turns a recording into actions in time order. Edits and
+// retractions become their own steps at the time they were made.
+func steps(rec hub.PersistedRoom) []step {
+	var out []step
+	var last time.Time
+	at := func(ts string) time.Time {
+		if t, err := types.ParseTime(ts); err == nil {
+			last = t
+		}
+		return last
+	}
+	for _, m := range rec.Messages {
+		when := at(m.Timestamp)
+		if m.Type == "system" {
+			if g := joinPattern.FindStringSubmatch(m.Content); g != nil {
+				out = append(out, step{at: when, kind: KindJoin, name: g[1], role: g[2], msg: m})
+			} else if g := humanJoinPattern.FindStringSubmatch(m.Content); g != nil {
+				out = append(out, step{at: when, kind: KindJoin, name: g[1], human: true, msg: m})
+			} else if g := leavePattern.FindStringSubmatch(m.Content); g != nil {
+				out = append(out, step{at: when, kind: KindLeave, name: g[1], msg: m})
+			}
+			continue
+		}
+		content := m.Content
+		if len(m.Edits) > 0 {
+			content = m.Edits[0].Content
+		}
+		out = append(out, step{at: when, kind: KindMessage, name: m.From, human: m.FromHuman, msg: m, content: content})
+		for i, e := range m.Edits {
+			next := m.Content
+			if i+1 < len(m.Edits) {
+				next = m.Edits[i+1].Content
+			}
+			out = append(out, step{at: at(e.EditedAt), kind: KindEdit, name: e.EditedBy, msg: m, content: next})
+		}
+		if m.Retracted {
+			out = append(out, step{at: at(m.RetractedAt), kind: KindRetract, name: m.RetractedBy, msg: m})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].at.Before(out[j].at) })
+	return out
+}
+
+// runner is one replay in progress.
+type runner struct {
+	opts    Options
+	room    string
+	hub     *hub.Hub
+	desktop *hub.LocalClient
+	agents  map[string]*hub.LocalClient
+	humans  map[string]bool
+	// recorded are the participants in the export header, for the roles of
+	// agents whose join is older than the recorded messages.
+	recorded map[string]types.Agent
+	orch     *orchestrator.Orchestrator
+	clock    *virtualClock
+	start    time.Time
+	ids      map[int]int // recorded message ID → sandbox message ID
+	reqSeq   int
+
+	mu     sync.Mutex
+	trace  []Event
+	loops  map[string]bool // paused pairs already traced
+	failed map[string]bool // failed deliveries already traced
+}
+
+// Run replays a recording (see hub.ReadExport) and returns the trace and
+// the sandbox state. It stops early when ctx is done.
+func Run(ctx context.Context, rec hub.PersistedRoom, opts Options) (*Result, error) {
+	if opts.Speed < 0 {
+		return nil, fmt.Errorf("speed must not be negative")
+	}
+	if opts.Room == "" {
+		opts.Room = DefaultRoom
+	}
+	if opts.Tail == 0 {
+		opts.Tail = DefaultTail
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+
+	plan := steps(rec)
+	if len(plan) == 0 {
+		return nil, fmt.Errorf("recording has no messages to replay")
+	}
+
+	r := &runner{
+		opts:     opts,
+		room:     opts.Room,
+		hub:      hub.New("", opts.Room, logger),
+		agents:   make(map[string]*hub.LocalClient),
+		humans:   make(map[string]bool),
+		recorded: rec.Agents,
+		orch:     orchestrator.New(nil),
+		start:    plan[0].at,
+		ids:      make(map[int]int),
+		loops:    make(map[string]bool),
+		failed:   make(map[string]bool),
+	}
+	// Recorded messages already passed the live hub's rate limits.
+	r.hub.SetRateLimits(hub.RateLimits{})
+	r.clock = newVirtualClock(r.start)
+	r.orch.SetClock(r.clock)
+	r.orch.SetTerminalSink(r.notified)
+	if opts.Setup != nil {
+		opts.Setup(r.orch, r.room)
+	}
+	r.desktop = r.hub.NewLocalClient(true)
+	r.do(r.desktop, "subscribe", map[string]any{"rooms": []string{r.room}})
+	for name, members := range rec.Groups {
+		r.do(r.desktop, "set_group", map[string]any{"group": name, "members": members})
+	}
+
+	prev := r.start
+	stopped := false
+	for _, s := range plan {
+		if err := r.wait(ctx, s.at.Sub(prev)); err != nil {
+			return r.result(), err
+		}
+		prev = s.at
+		r.clock.advance(s.at)
+		r.pump()
+		r.apply(s)
+		r.pump()
+		if opts.Until > 0 && s.msg.ID == opts.Until && (s.kind == KindMessage || s.kind == KindJoin || s.kind == KindLeave) {
+			stopped = true
+			break
+		}
+	}
+	if opts.Until > 0 && !stopped {
+		return r.result(), fmt.Errorf("message %d is not in the recording", opts.Until)
+	}
+	if !stopped {
+		r.clock.advance(prev.Add(opts.Tail))
+		r.pump()
+	}
+	return r.result(), nil
+}
+
+// wait sleeps for a recorded pause scaled by the playback speed.
+func (r *runner) wait(ctx context.Context, d time.Duration) error {
+	if r.opts.Speed == 0 || d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(time.Duration(float64(d) / r.opts.Speed))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (r *runner) apply(s step) {
+	switch s.kind {
+	case KindJoin:
+		if s.human {
+			r.joinHuman(s.name)
+		} else {
+			r.join(s.name, s.role)
+		}
+	case KindLeave:
+		if c, ok := r.agents[s.name]; ok {
+			c.Close()
+			delete(r.agents, s.name)
+			r.orch.UnregisterAgent(r.room, s.name)
+		}
+	case KindMessage:
+		r.send(s)
+	case KindEdit, KindRetract:
+		id, ok := r.ids[s.msg.ID]
+		if !ok {
+			r.record(Event{Kind: KindError, Agent: s.name, RecordedID: s.msg.ID, Text: fmt.Sprintf("%s: message was not replayed", s.kind)})
+			return
+		}
+		client := r.desktop
+		if c, ok := r.agents[s.name]; ok {
+			client = c
+		}
+		reqType, data := "edit_message", map[string]any{"message_id": id, "content": s.content}
+		if s.kind == KindRetract {
+			reqType, data = "retract_message", map[string]any{"message_id": id}
+		}
+		if resp := r.do(client, reqType, data); !resp.Success {
+			r.record(Event{Kind: KindError, Agent: s.name, RecordedID: s.msg.ID, Text: reqType + ": " + resp.Error})
+		}
+	}
+}
+
+// join adds an agent with its own connection, the way its CLI would join.
+// A manager is configured from the desktop first, as the app does.
+func (r *runner) join(name, role string) {
+	if _, ok := r.agents[name]; ok {
+		return
+	}
+	if strings.EqualFold(strings.TrimSpace(role), "manager") {
+		if resp := r.do(r.desktop, "set_manager", map[string]any{"manager_agent": name}); resp.Success {
+			r.record(Event{Kind: KindManager, Agent: name})
+		}
+	}
+	c := r.hub.NewLocalClient(false)
+	if resp := r.do(c, "join_room", map[string]any{"agent_name": name, "role": role}); !resp.Success {
+		r.record(Event{Kind: KindError, Agent: name, Text: "join_room: " + resp.Error})
+		return
+	}
+	r.agents[name] = c
+	r.orch.RegisterAgent(r.room, name, sessionPrefix+name)
+}
+
+func (r *runner) joinHuman(name string) {
+	if r.humans[name] {
+		return
+	}
+	if resp := r.do(r.desktop, "join_room", map[string]any{"agent_name": name, "human": true}); !resp.Success {
+		r.record(Event{Kind: KindError, Agent: name, Text: "join_room: " + resp.Error})
+		return
+	}
+	r.humans[name] = true
+}
+
+// send replays a message with the sender's original intent: a message the
+// manager intercepted is sent to its original recipient again, so the
+// sandbox hub routes it afresh. Agents read their messages before they
+// write, as they do through MCP.
+func (r *runner) send(s step) {
+	m := s.msg
+	to := m.To
+	if m.RoutedByManager && m.OriginalTo != "" {
+		to = m.OriginalTo
+	}
+	data := map[string]any{
+		"from":          m.From,
+		"to":            to,
+		"content":       s.content,
+		"expects_reply": m.ExpectsReply,
+		"priority":      m.Priority,
+	}
+	if ttl := expiryTTL(m); ttl != "" {
+		data["ttl"] = ttl
+	}
+
+	client := r.desktop
+	if m.FromHuman {
+		r.joinHuman(m.From)
+		data["human"] = true
+		data["bypass_manager"] = !m.RoutedByManager
+		data["via"] = m.Via
+	} else {
+		if _, ok := r.agents[m.From]; !ok {
+			// The join happened before the recording starts.
+			r.join(m.From, r.recorded[m.From].Role)
+		}
+		c, ok := r.agents[m.From]
+		if !ok {
+			return
+		}
+		client = c
+		r.do(c, "get_messages", map[string]any{"agent_name": m.From, "limit": 500})
+		r.pump()
+	}
+
+	resp := r.do(client, "send_message", data)
+	if !resp.Success {
+		r.record(Event{Kind: KindError, Agent: m.From, RecordedID: m.ID, Text: "send_message: " + resp.Error})
+		return
+	}
+	var out struct {
+		MessageID int `json:"message_id"`
+	}
+	json.Unmarshal(resp.Data, &out)
+	r.ids[m.ID] = out.MessageID
+}
+
+// do runs a request on an in-process connection.
+func (r *runner) do(c *hub.LocalClient, reqType string, data map[string]any) types.Response {
+	r.reqSeq++
+	raw, _ := json.Marshal(data)
+	return c.Do(types.Request{ID: strconv.Itoa(r.reqSeq), Type: reqType, Room: r.room, Data: raw})
+}
+
+// pump hands the sandbox's events to the orchestrator, as the desktop app
+// does with its hub connection, and records them in the trace.
+func (r *runner) pump() {
+	for _, c := range r.agents {
+		c.Events() // agents read through get_messages, not events
+	}
+	for _, ev := range r.desktop.Events() {
+		switch ev.Event {
+		case "message_new", "message_updated", "message_retracted":
+			var data struct {
+				Message types.Message `json:"message"`
+			}
+			if json.Unmarshal(ev.Data, &data) != nil {
+				continue
+			}
+			r.recordMessage(ev.Event, data.Message)
+			switch ev.Event {
+			case "message_new":
+				r.orch.ProcessMessage(ev.Room, data.Message)
+			case "message_updated":
+				r.orch.ProcessUpdatedMessage(ev.Room, data.Message)
+			default:
+				r.orch.ProcessRetractedMessage(ev.Room, data.Message)
+			}
+		case "agent_joined", "agent_left":
+			var data struct {
+				AgentName string `json:"agent_name"`
+			}
+			json.Unmarshal(ev.Data, &data)
+			kind := KindJoin
+			if ev.Event == "agent_left" {
+				kind = KindLeave
+			}
+			r.record(Event{Kind: kind, Agent: data.AgentName})
+		case "messages_read":
+			var data struct {
+				AgentName  string `json:"agent_name"`
+				MessageIDs []int  `json:"message_ids"`
+				UpToID     int    `json:"up_to_id"`
+			}
+			if json.Unmarshal(ev.Data, &data) != nil {
+				continue
+			}
+			if len(data.MessageIDs) > 0 {
+				r.record(Event{Kind: KindRead, Agent: data.AgentName, Text: fmt.Sprint(data.MessageIDs)})
+			}
+			r.orch.AckRead(ev.Room, data.AgentName, data.MessageIDs, data.UpToID)
+		}
+	}
+	r.checkOrchestrator()
+}
+
+func (r *runner) recordMessage(event string, m types.Message) {
+	ev := Event{Agent: m.From, MessageID: m.ID, RecordedID: r.recordedID(m.ID)}
+	switch {
+	case m.Type == "system":
+		ev.Kind, ev.Agent, ev.Text = KindSystem, "", m.Content
+	case event == "message_updated":
+		ev.Kind, ev.Text = KindEdit, m.Content
+	case event == "message_retracted":
+		ev.Kind = KindRetract
+	default:
+		ev.Kind = KindMessage
+		ev.Text = fmt.Sprintf("→ %s: %s", m.To, m.Content)
+		if m.RoutedByManager {
+			ev.Text = fmt.Sprintf("→ %s (via manager, for %s): %s", m.To, m.OriginalTo, m.Content)
+		}
+	}
+	r.record(ev)
+}
+
+func (r *runner) recordedID(sandboxID int) int {
+	for recID, id := range r.ids {
+		if id == sandboxID {
+			return recID
+		}
+	}
+	return 0
+}
+
+// checkOrchestrator traces loop pauses and failed deliveries. The
+// orchestrator reports both on handler goroutines; reading its state keeps
+// the trace in a deterministic order.
+func (r *runner) checkOrchestrator() {
+	for _, a := range r.orch.PausedPairs(r.room) {
+		key := a.AgentA + "\x00" + a.AgentB + "\x00" + a.PausedUntil.String()
+		if r.loops[key] {
+			continue
+		}
+		r.loops[key] = true
+		r.record(Event{Kind: KindLoop, Agent: a.AgentA, Text: fmt.Sprintf("%s<>%s paused (%s, %d messages)", a.AgentA, a.AgentB, a.Reason, a.Messages)})
+	}
+	for _, d := range r.orch.DeliveryStates(r.room) {
+		key := d.Agent + "\x00" + strconv.Itoa(d.MessageID)
+		if d.State != orchestrator.DeliveryFailed || r.failed[key] {
+			continue
+		}
+		r.failed[key] = true
+		r.record(Event{Kind: KindDeliveryFailed, Agent: d.Agent, MessageID: d.MessageID, RecordedID: r.recordedID(d.MessageID),
+			Text: fmt.Sprintf("unread after %d notifications", d.Attempts)})
+	}
+}
+
+// notified is the stub terminal sink.
+func (r *runner) notified(sessionID, text string) {
+	r.record(Event{Kind: KindNotify, Agent: strings.TrimPrefix(sessionID, sessionPrefix), Text: text})
+}
+
+func (r *runner) record(ev Event) {
+	ev.At = r.clock.Now().Sub(r.start)
+	r.mu.Lock()
+	r.trace = append(r.trace, ev)
+	r.mu.Unlock()
+	if r.opts.OnEvent != nil {
+		r.opts.OnEvent(ev)
+	}
+}
+
+func (r *runner) result() *Result {
+	res := &Result{
+		Deliveries:  r.orch.DeliveryStates(r.room),
+		PausedPairs: r.orch.PausedPairs(r.room),
+	}
+	r.mu.Lock()
+	res.Trace = append([]Event(nil), r.trace...)
+	r.mu.Unlock()
+	sortNotifications(res.Trace)
+
+	if resp := r.do(r.desktop, "get_messages_raw", nil); resp.Success {
+		var data struct {
+			Messages []types.Message `json:"messages"`
+		}
+		json.Unmarshal(resp.Data, &data)
+		res.Messages = data.Messages
+	}
+	if resp := r.do(r.desktop, "get_agents", nil); resp.Success {
+		var data struct {
+			Agents map[string]types.Agent `json:"agents"`
+		}
+		json.Unmarshal(resp.Data, &data)
+		res.Agents = data.Agents
+	}
+	if resp := r.do(r.desktop, "export_room", map[string]any{"format": hub.ExportJSONL}); resp.Success {
+		var data struct {
+			Data string `json:"data"`
+		}
+		json.Unmarshal(resp.Data, &data)
+		res.Export = data.Data
+	}
+	return res
+}
+
+// sortNotifications orders each run of notifications sent at the same
+// moment by agent: a broadcast notifies its recipients in map order, and so
+// do cooldown batches coming due together.
+func sortNotifications(trace []Event) {
+	for i := 0; i < len(trace); {
+		j := i
+		for j < len(trace) && trace[j].Kind == KindNotify && trace[j].At == trace[i].At {
+			j++
+		}
+		if j > i+1 {
+			run := trace[i:j]
+			sort.SliceStable(run, func(a, b int) bool { return run[a].Agent < run[b].Agent })
+		}
+		if j == i {
+			j++
+		}
+		i = j
+	}
+}
+
+// expiryTTL keeps a recorded message's lifetime: its absolute expiry has
+// usually passed by the time it is replayed.
+func expiryTTL(m types.Message) string {
+	if m.ExpiresAt == "" {
+		return ""
+	}
+	sent, err1 := types.ParseTime(m.Timestamp)
+	expires, err2 := types.ParseTime(m.ExpiresAt)
+	if err1 != nil || err2 != nil || !expires.After(sent) {
+		return ""
+	}
+	return expires.Sub(sent).String()
+}
@@ -0,0 +1,124 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"desktop/internal/hub"
+	"desktop/internal/orchestrator"
+)
+
+func loadRecording(t *testing.T, name string) hub.PersistedRoom {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rec, err := hub.ReadExport(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+// findEvent returns the first trace event of a kind for an agent at a
+// virtual time whose text contains want.
+func findEvent(trace []Event, at time.Duration, kind, agent, want string) bool {
+	for _, ev := range trace {
+		if ev.At == at && ev.Kind == kind && ev.Agent == agent && strings.Contains(ev.Text, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// The schema incident: the manager relays a schema change between backend
+// and frontend, and never reads two of the questions routed to it.
+func TestReplay_SchemaIncident(t *testing.T) {
+	rec := loadRecording(t, "schema-incident.jsonl")
+	res, err := Run(context.Background(), rec, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []struct {
+		at          time.Duration
+		kind, agent string
+		text        string
+	}{
+		{0, KindManager, "lead", ""},
+		{time.Minute, KindMessage, "backend", "→ lead (via manager, for frontend)"},
+		{time.Minute, KindNotify, "lead", "New message from backend"},
+		{91 * time.Second, KindNotify, "lead", "New message from frontend"},
+		// Inside the cooldown: batched and flushed when it ends.
+		{94 * time.Second, KindNotify, "lead", "1 new messages from backend"},
+		{91*time.Second + orchestrator.ReadAckTimeout, KindNotify, "lead", "Reminder: 2 unread messages from backend, frontend"},
+		{5 * time.Minute, KindNotify, "backend", "Broadcast from user (human)"},
+		{5*time.Minute + 40*time.Second, KindNotify, "lead", "Message #10 from frontend was edited"},
+		{6 * time.Minute, KindLeave, "backend", ""},
+	} {
+		if !findEvent(res.Trace, want.at, want.kind, want.agent, want.text) {
+			t.Errorf("trace has no %s for %s at %s containing %q", want.kind, want.agent, want.at, want.text)
+		}
+	}
+	for _, ev := range res.Trace {
+		if ev.Kind == KindError {
+			t.Errorf("replay error: %+v", ev)
+		}
+	}
+	if len(res.Messages) != len(rec.Messages) {
+		t.Errorf("sandbox has %d messages, recording %d", len(res.Messages), len(rec.Messages))
+	}
+	if !strings.Contains(res.Export, `"type":"room"`) {
+		t.Error("result should carry the sandbox room as JSONL")
+	}
+
+	again, err := Run(context.Background(), rec, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Trace, again.Trace) {
+		t.Error("replaying the same recording twice gave different traces")
+	}
+}
+
+func TestReplay_UntilStopsAtMessage(t *testing.T) {
+	res, err := Run(context.Background(), loadRecording(t, "schema-incident.jsonl"), Options{Until: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := res.Messages[len(res.Messages)-1]
+	if last.From != "backend" || !strings.Contains(last.Content, "full_name") {
+		t.Errorf("last sandbox message = %+v", last)
+	}
+	// The batched notification for message 8 is still waiting for the cooldown.
+	if findEvent(res.Trace, 94*time.Second, KindNotify, "lead", "") {
+		t.Error("replay ran past message 8")
+	}
+
+	if _, err := Run(context.Background(), loadRecording(t, "schema-incident.jsonl"), Options{Until: 99}); err == nil {
+		t.Error("Until with an unknown message ID should fail")
+	}
+}
+
+func TestReplay_SpeedFollowsWallTimeAndStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	res, err := Run(ctx, loadRecording(t, "schema-incident.jsonl"), Options{Speed: 1})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the context's", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("cancelled replay took %s", elapsed)
+	}
+	if res == nil || len(res.Trace) == 0 {
+		t.Error("a cancelled replay should still return what ran")
+	}
+}
@@ -0,0 +1,110 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"desktop/internal/types"
+)
+
+// Record is a terminal that was running when the sessions were last saved.
+type Record struct {
+	TeamID    string `json:"team_id"`
+	AgentName string `json:"agent_name"`
+	CLIType   string `json:"cli_type"`
+	WorkDir   string `json:"work_dir"`
+	PromptID  string `json:"prompt_id"`
+	// ConversationID is the CLI's own conversation ID, scraped from its
+	// output, used to resume the conversation instead of starting over.
+	ConversationID string `json:"conversation_id,omitempty"`
+}
+
+// Store persists the live terminal sessions across app restarts.
+type Store struct {
+	mu       sync.Mutex
+	filePath string
+}
+
+// NewStore creates a session store in dataDir.
+func NewStore(dataDir string) *Store {
+	os.MkdirAll(dataDir, 0700)
+	return &Store{filePath: filepath.Join(dataDir, "sessions.json")}
+}
+
+// Load returns the saved sessions. A missing file means no sessions.
+func (s *Store) Load() ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", filepath.Base(s.filePath), err)
+	}
+	return records, nil
+}
+
+// Save replaces the saved sessions. The file is written atomically so a
+// crash mid-save keeps the previous list.
+func (s *Store) Save(records []Record) error {
+	if records == nil {
+		records = []Record{}
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := s.filePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.filePath)
+}
+
+// maxRecapLine bounds each message quoted in a recap prompt.
+const maxRecapLine = 200
+
+// RecapPrompt summarizes the last limit room messages an agent sent or
+// received, for agents whose conversation could not be resumed.
+func RecapPrompt(agentName string, msgs []types.Message, limit int) string {
+	var relevant []types.Message
+	for _, m := range msgs {
+		if m.Retracted {
+			continue
+		}
+		if m.From == agentName || m.IsAddressedTo(agentName) {
+			relevant = append(relevant, m)
+		}
+	}
+	if over := len(relevant) - limit; over > 0 {
+		relevant = relevant[over:]
+	}
+
+	var b strings.Builder
+	b.WriteString("[agent-chat] The app restarted and your previous conversation could not be resumed.")
+	if len(relevant) == 0 {
+		fmt.Fprintf(&b, " read_messages(\"%s\") to catch up on the room.", agentName)
+		return b.String()
+	}
+	b.WriteString(" Recent room messages involving you:\n")
+	for _, m := range relevant {
+		content := strings.Join(strings.Fields(m.Content), " ")
+		if r := []rune(content); len(r) > maxRecapLine {
+			content = string(r[:maxRecapLine]) + "…"
+		}
+		fmt.Fprintf(&b, "- #%d %s -> %s: %s\n", m.ID, m.From, m.To, content)
+	}
+	fmt.Fprintf(&b, "Continue your work from there; read_messages(\"%s\") for anything newer.", agentName)
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package session
+
+import (
+	"strings"
+	"testing"
+
+	"desktop/internal/types"
+)
+
+func TestStore_SaveLoad(t *testing.T) {
+	s := NewStore(t.TempDir())
+	if records, err := s.Load(); err != nil || records != nil {
+		t.Fatalf("missing file should load as no sessions, got %v err=%v", records, err)
+	}
+
+	want := []Record{
+		{TeamID: "t1", AgentName: "backend", CLIType: "claude", WorkDir: "/src", ConversationID: "abc"},
+		{TeamID: "t1", AgentName: "sh", CLIType: "shell"},
+	}
+	if err := s.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("loaded %+v, want %+v", got, want)
+	}
+}
+
+func TestRecapPrompt(t *testing.T) {
+	msgs := []types.Message{
+		{ID: 1, From: "frontend", To: "backend", Content: "old question"},
+		{ID: 2, From: "backend", To: "frontend", Content: "old answer"},
+		{ID: 3, From: "frontend", To: "qa", Content: "not for backend"},
+		{ID: 4, From: "manager", To: "all", Content: "ship   it\ntoday"},
+		{ID: 5, From: "qa", To: "backend", Content: "gone", Retracted: true},
+	}
+	got := RecapPrompt("backend", msgs, 2)
+	if !strings.Contains(got, "#2 backend -> frontend: old answer") || !strings.Contains(got, "#4 manager -> all: ship it today") {
+		t.Fatalf("recap missing recent messages:\n%s", got)
+	}
+	if strings.Contains(got, "#1 ") || strings.Contains(got, "#3 ") || strings.Contains(got, "gone") {
+		t.Fatalf("recap should keep only the last relevant messages:\n%s", got)
+	}
+
+	if got := RecapPrompt("backend", nil, 5); !strings.Contains(got, `read_messages("backend")`) {
+		t.Fatalf("empty recap should point to read_messages, got %q", got)
+	}
+}
@@ -20,18 +20,42 @@ type AgentConfig struct {
 	PromptID string `json:"prompt_id"`
 	WorkDir  string `json:"work_dir"`
 	CLIType  string `json:"cli_type"`
+	// Delivery limits how often the orchestrator nudges this agent's terminal.
+	Delivery *DeliverySettings `json:"delivery,omitempty"`
+}
+
+// DeliverySettings are per-agent notification rules. Urgent messages ignore
+// all of them, mutes included.
+type DeliverySettings struct {
+	DoNotDisturb  bool     `json:"do_not_disturb,omitempty"`
+	QuietHours    string   `json:"quiet_hours,omitempty"`    // local time range, e.g. "22:00-08:00"
+	MaxPerWindow  int      `json:"max_per_window,omitempty"` // 0 = unlimited
+	WindowMinutes int      `json:"window_minutes,omitempty"` // budget window, default 60
+	DigestMinutes int      `json:"digest_minutes,omitempty"` // at most one summary every N minutes
+	MutedSenders  []string `json:"muted_senders,omitempty"`
+}
+
+// AnalysisRule decides whether matching messages nudge agent terminals.
+// Rules are evaluated in order; empty fields match anything.
+type AnalysisRule struct {
+	Name     string   `json:"name,omitempty"`
+	Patterns []string `json:"patterns,omitempty"` // words/phrases ("ship it", "lgtm*") or "re:<regexp>"
+	Sender   string   `json:"sender,omitempty"`   // agent name, "*" for any
+	Priority string   `json:"priority,omitempty"` // "urgent", "normal" or "low"
+	Action   string   `json:"action"`             // "skip" or "notify"
 }
 
 // Team represents a tab/team configuration
 type Team struct {
-	ID           string        `json:"id"`
-	Name         string        `json:"name"`
-	Agents       []AgentConfig `json:"agents"`
-	GridLayout   string        `json:"grid_layout"` // "1x1", "2x2", "2x3", etc.
-	ChatDir      string        `json:"chat_dir"`
-	ManagerAgent string        `json:"manager_agent"`
-	CustomPrompt string        `json:"custom_prompt"`
-	CreatedAt    string        `json:"created_at"`
+	ID            string         `json:"id"`
+	Name          string         `json:"name"`
+	Agents        []AgentConfig  `json:"agents"`
+	GridLayout    string         `json:"grid_layout"` // "1x1", "2x2", "2x3", etc.
+	ChatDir       string         `json:"chat_dir"`
+	ManagerAgent  string         `json:"manager_agent"`
+	CustomPrompt  string         `json:"custom_prompt"`
+	AnalysisRules []AnalysisRule `json:"analysis_rules,omitempty"`
+	CreatedAt     string         `json:"created_at"`
 }
 
 // Store manages team/tab persistence
@@ -141,7 +165,7 @@ func (s *Store) Update(id, name, gridLayout string, agents []AgentConfig) (Team,
 		if t.ID == id {
 			s.teams[i].Name = name
 			s.teams[i].GridLayout = gridLayout
-			s.teams[i].Agents = agents
+			s.teams[i].Agents = keepDelivery(t.Agents, agents)
 
 			if err := s.save(); err != nil {
 				return Team{}, err
@@ -152,6 +176,21 @@ func (s *Store) Update(id, name, gridLayout string, agents []AgentConfig) (Team,
 	return Team{}, fmt.Errorf("team not found: %s", id)
 }
 
+// keepDelivery carries delivery settings over to updated agents that do not
+// set their own; they are changed through SetAgentDelivery.
+func keepDelivery(prev, agents []AgentConfig) []AgentConfig {
+	byName := make(map[string]*DeliverySettings, len(prev))
+	for _, a := range prev {
+		byName[a.Name] = a.Delivery
+	}
+	for i := range agents {
+		if agents[i].Delivery == nil {
+			agents[i].Delivery = byName[agents[i].Name]
+		}
+	}
+	return agents
+}
+
 // SetManager sets or clears manager agent for a team. Empty string clears manager.
 func (s *Store) SetManager(id, managerAgent string) (Team, error) {
 	if managerAgent != "" {
@@ -175,6 +214,46 @@ func (s *Store) SetManager(id, managerAgent string) (Team, error) {
 	return Team{}, fmt.Errorf("team not found: %s", id)
 }
 
+// SetAnalysisRules replaces a team's message analysis rules. Empty clears them.
+func (s *Store) SetAnalysisRules(id string, rules []AnalysisRule) (Team, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, t := range s.teams {
+		if t.ID == id {
+			s.teams[i].AnalysisRules = rules
+			if err := s.save(); err != nil {
+				return Team{}, err
+			}
+			return s.teams[i], nil
+		}
+	}
+	return Team{}, fmt.Errorf("team not found: %s", id)
+}
+
+// SetAgentDelivery replaces one agent's delivery settings. Nil clears them.
+func (s *Store) SetAgentDelivery(id, agentName string, settings *DeliverySettings) (Team, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, t := range s.teams {
+		if t.ID != id {
+			continue
+		}
+		for j, a := range t.Agents {
+			if a.Name == agentName {
+				s.teams[i].Agents[j].Delivery = settings
+				if err := s.save(); err != nil {
+					return Team{}, err
+				}
+				return s.teams[i], nil
+			}
+		}
+		return Team{}, fmt.Errorf("agent not found in team %s: %s", id, agentName)
+	}
+	return Team{}, fmt.Errorf("team not found: %s", id)
+}
+
 // Delete deletes a team
 func (s *Store) Delete(id string) error {
 	s.mu.Lock()
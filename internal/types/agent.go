@@ -0,0 +1,146 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CapabilityPrefix marks a send_message recipient resolved by capability
+// (e.g. "capability:db") instead of by agent name.
+const CapabilityPrefix = "capability:"
+
+// Capabilities describes what an agent can work on, declared at join_room.
+type Capabilities struct {
+	Languages []string `json:"languages,omitempty"` // e.g. "go", "typescript"
+	Areas     []string `json:"areas,omitempty"`     // e.g. "db", "frontend", "infra"
+	Tools     []string `json:"tools,omitempty"`     // e.g. "docker", "psql"
+}
+
+// Normalize lowercases, trims and de-duplicates all capability lists.
+func (c Capabilities) Normalize() Capabilities {
+	return Capabilities{
+		Languages: normalizeList(c.Languages),
+		Areas:     normalizeList(c.Areas),
+		Tools:     normalizeList(c.Tools),
+	}
+}
+
+// IsEmpty reports whether no capabilities are declared.
+func (c Capabilities) IsEmpty() bool {
+	return len(c.Languages) == 0 && len(c.Areas) == 0 && len(c.Tools) == 0
+}
+
+// All returns every declared capability in a single sorted list.
+func (c Capabilities) All() []string {
+	all := make([]string, 0, len(c.Languages)+len(c.Areas)+len(c.Tools))
+	all = append(all, c.Areas...)
+	all = append(all, c.Languages...)
+	all = append(all, c.Tools...)
+	return normalizeList(all)
+}
+
+// Score rates how well the capabilities match a requested capability.
+// Areas weigh most, then languages, then tools; 0 means no match.
+func (c Capabilities) Score(capability string) int {
+	capability = strings.ToLower(strings.TrimSpace(capability))
+	if capability == "" {
+		return 0
+	}
+	score := 0
+	if containsFold(c.Areas, capability) {
+		score += 3
+	}
+	if containsFold(c.Languages, capability) {
+		score += 2
+	}
+	if containsFold(c.Tools, capability) {
+		score++
+	}
+	return score
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeList(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	var out []string
+	for _, v := range list {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Agent statuses accepted by set_status.
+const (
+	StatusAvailable     = "available"
+	StatusThinking      = "thinking"
+	StatusWorking       = "working"
+	StatusTesting       = "testing"
+	StatusWaitingReview = "waiting_review"
+	StatusBlocked       = "blocked"
+	StatusAway          = "away"
+)
+
+var validStatuses = []string{
+	StatusAvailable, StatusThinking, StatusWorking, StatusTesting,
+	StatusWaitingReview, StatusBlocked, StatusAway,
+}
+
+// NormalizeStatus lowercases and validates a status value.
+// Empty input defaults to "available".
+func NormalizeStatus(status string) (string, error) {
+	s := strings.ToLower(strings.TrimSpace(status))
+	s = strings.ReplaceAll(s, "-", "_")
+	if s == "" {
+		return StatusAvailable, nil
+	}
+	for _, v := range validStatuses {
+		if s == v {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("geçersiz status %q: yalnızca %s olabilir", status, strings.Join(validStatuses, ", "))
+}
+
+// StatusRank orders statuses by availability for routing (lower is more available).
+// Agents that never reported a status count as available.
+func StatusRank(status string) int {
+	switch status {
+	case "", StatusAvailable:
+		return 0
+	case StatusBlocked, StatusAway:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// PTY activity states reported by the desktop app for agents it hosts.
+const (
+	ActivityBusy          = "busy"           // CLI produced output recently
+	ActivityIdle          = "idle"           // no recent output
+	ActivityPromptWaiting = "prompt_waiting" // idle and showing an input prompt
+)
+
+// IsValidActivity reports whether activity is a known PTY activity state.
+func IsValidActivity(activity string) bool {
+	switch activity {
+	case ActivityBusy, ActivityIdle, ActivityPromptWaiting:
+		return true
+	}
+	return false
+}
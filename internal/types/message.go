@@ -1,12 +1,38 @@
 package types
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message priorities accepted by send_message.
+const (
+	PriorityUrgent = "urgent"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
 
 // Agent represents an agent in the chat room.
 type Agent struct {
-	Role     string  `json:"role"`
-	JoinedAt string  `json:"joined_at"`
-	LastSeen float64 `json:"last_seen"`
+	Role         string        `json:"role"`
+	JoinedAt     string        `json:"joined_at"`
+	LastSeen     float64       `json:"last_seen"`
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
+
+	// Status is self-reported via set_status; empty means never reported.
+	Status          string `json:"status,omitempty"`
+	StatusText      string `json:"status_text,omitempty"`
+	StatusETA       string `json:"status_eta,omitempty"`
+	StatusUpdatedAt string `json:"status_updated_at,omitempty"`
+
+	// Activity is the PTY activity reported by the desktop app hosting the agent.
+	Activity   string `json:"activity,omitempty"`
+	ActivityAt string `json:"activity_at,omitempty"`
+
+	// Human marks a person taking part through the desktop app rather than
+	// an AI agent. Humans never go stale.
+	Human bool `json:"human,omitempty"`
 }
 
 // Message represents a chat message.
@@ -19,8 +45,110 @@ type Message struct {
 	Timestamp       string `json:"timestamp"`
 	Type            string `json:"type"`
 	RoutedByManager bool   `json:"routed_by_manager,omitempty"`
-	ExpectsReply    bool   `json:"expects_reply"`
-	Priority        string `json:"priority"`
+	FromHuman       bool   `json:"from_human,omitempty"`
+	// Via names the connector that bridged the message in from an external
+	// chat system ("webhook:3"); connectors skip their own messages outbound.
+	Via          string `json:"via,omitempty"`
+	ExpectsReply bool   `json:"expects_reply"`
+	Priority     string `json:"priority"`
+	// Recipients lists the resolved agents of a multi-recipient or group
+	// message. Empty for broadcasts and single-recipient messages.
+	Recipients []string `json:"recipients,omitempty"`
+
+	ExpiresAt   string        `json:"expires_at,omitempty"`
+	EditedAt    string        `json:"edited_at,omitempty"`
+	Edits       []MessageEdit `json:"edits,omitempty"`
+	Retracted   bool          `json:"retracted,omitempty"`
+	RetractedAt string        `json:"retracted_at,omitempty"`
+	RetractedBy string        `json:"retracted_by,omitempty"`
+}
+
+// IsAddressedTo reports whether agent is a recipient of the message.
+func (m Message) IsAddressedTo(agent string) bool {
+	if m.To == "all" {
+		return true
+	}
+	if len(m.Recipients) > 0 {
+		for _, r := range m.Recipients {
+			if r == agent {
+				return true
+			}
+		}
+		return false
+	}
+	return m.To == agent
+}
+
+// SplitRecipients splits a comma-separated recipient spec ("backend, db, @reviewers")
+// into trimmed, non-empty entries.
+func SplitRecipients(spec string) []string {
+	var out []string
+	for _, part := range strings.Split(spec, ",") {
+		if p := strings.TrimSpace(part); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// MessageEdit records the content a message had before an edit.
+type MessageEdit struct {
+	Content  string `json:"content"`
+	EditedAt string `json:"edited_at"`
+	EditedBy string `json:"edited_by"`
+}
+
+// IsExpired reports whether the message has an expiry time at or before now.
+// Messages with an unparseable expiry never expire.
+func (m Message) IsExpired(now time.Time) bool {
+	if m.ExpiresAt == "" {
+		return false
+	}
+	t, err := ParseTime(m.ExpiresAt)
+	if err != nil {
+		return false
+	}
+	return !t.After(now)
+}
+
+// IsVisible reports whether the message should still be shown to agents.
+func (m Message) IsVisible(now time.Time) bool {
+	return !m.Retracted && !m.IsExpired(now)
+}
+
+// Redacted returns the message without its content and edit history, for
+// announcing a retraction without repeating what was retracted.
+func (m Message) Redacted() Message {
+	m.Content = ""
+	m.Edits = nil
+	return m
+}
+
+// NormalizePriority lowercases and validates a priority value.
+// Empty input defaults to "normal".
+func NormalizePriority(priority string) (string, error) {
+	p := strings.ToLower(strings.TrimSpace(priority))
+	switch p {
+	case "":
+		return PriorityNormal, nil
+	case PriorityUrgent, PriorityNormal, PriorityLow:
+		return p, nil
+	default:
+		return "", fmt.Errorf("geçersiz priority %q: yalnızca \"urgent\", \"normal\" veya \"low\" olabilir", priority)
+	}
+}
+
+// PriorityRank returns the sort rank of a priority (lower is more important).
+// Unknown or empty priorities rank as "normal".
+func PriorityRank(priority string) int {
+	switch priority {
+	case PriorityUrgent:
+		return 0
+	case PriorityLow:
+		return 2
+	default:
+		return 1
+	}
 }
 
 // Now returns current time as float64 (Python time.time() compatible).
@@ -30,7 +158,27 @@ func Now() float64 {
 
 // Timestamp returns current time in ISO format.
 func Timestamp() string {
-	return time.Now().Format("2006-01-02T15:04:05.000000")
+	return FormatTimestamp(time.Now())
+}
+
+// FormatTimestamp formats t in the same local ISO format as Timestamp.
+func FormatTimestamp(t time.Time) string {
+	return t.Local().Format("2006-01-02T15:04:05.000000")
+}
+
+// ParseTime parses an RFC3339 timestamp or a local ISO timestamp as produced by
+// Timestamp (with or without fractional seconds).
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05.000000", "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("geçersiz zaman %q: RFC3339 (2006-01-02T15:04:05Z07:00) veya 2006-01-02T15:04:05 bekleniyor", s)
 }
 
 // CleanupStaleAgents removes agents inactive for more than timeout seconds.
@@ -17,8 +17,16 @@ type Response struct {
 	Success     bool            `json:"success"`
 	Data        json.RawMessage `json:"data,omitempty"`
 	Error       string          `json:"error,omitempty"`
+	Code        string          `json:"code,omitempty"` // machine-readable error code
 }
 
+// Error codes carried in Response.Code.
+const (
+	// ErrCodeRateLimited means the request was throttled; Data holds
+	// retry_after (seconds) and the exhausted scope.
+	ErrCodeRateLimited = "rate_limited"
+)
+
 // Event is a hub-to-subscriber broadcast.
 type Event struct {
 	Type  string          `json:"type"`
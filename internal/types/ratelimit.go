@@ -0,0 +1,16 @@
+package types
+
+// RateCounter counts send_message outcomes for an agent or a room.
+type RateCounter struct {
+	Allowed     int64  `json:"allowed"`
+	Limited     int64  `json:"limited"`
+	Bytes       int64  `json:"bytes"`
+	LastLimited string `json:"last_limited,omitempty"`
+}
+
+// RateStats is the hub's rate limiting state for one room.
+type RateStats struct {
+	Room   string                 `json:"room"`
+	Total  RateCounter            `json:"total"`
+	Agents map[string]RateCounter `json:"agents"`
+}
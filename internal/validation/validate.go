@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"desktop/internal/types"
 )
 
 var validNameRe = regexp.MustCompile(`^[a-zA-Z0-9._\- ]{1,50}$`)
@@ -24,3 +26,30 @@ func ValidateName(name string) error {
 	}
 	return nil
 }
+
+// ValidateRecipients checks a send_message recipient list. Entries are agent
+// names, "@group" or "capability:<name>" references; "all" is only allowed on its own.
+func ValidateRecipients(recipients []string) error {
+	if len(recipients) == 0 {
+		return fmt.Errorf("recipient list is empty")
+	}
+	for _, r := range recipients {
+		if r == "all" {
+			if len(recipients) > 1 {
+				return fmt.Errorf("\"all\" cannot be combined with other recipients")
+			}
+			continue
+		}
+		name := strings.TrimPrefix(r, "@")
+		if capability, ok := strings.CutPrefix(r, types.CapabilityPrefix); ok {
+			name = capability
+		}
+		if name == "" {
+			return fmt.Errorf("invalid recipient %q: empty group or capability name", r)
+		}
+		if err := ValidateName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
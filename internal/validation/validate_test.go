@@ -58,3 +58,32 @@ func TestValidateName(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateRecipients(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []string
+		wantErr bool
+	}{
+		{"broadcast", []string{"all"}, false},
+		{"single agent", []string{"backend"}, false},
+		{"agents and group", []string{"backend", "db", "@reviewers"}, false},
+		{"capability", []string{"capability:db"}, false},
+
+		{"empty list", nil, true},
+		{"all with others", []string{"all", "backend"}, true},
+		{"bare at sign", []string{"@"}, true},
+		{"empty capability", []string{"capability:"}, true},
+		{"invalid group", []string{"@../x"}, true},
+		{"invalid agent", []string{"foo/bar"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRecipients(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRecipients(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,6 +2,10 @@ package main
 
 import (
 	"embed"
+	"os"
+
+	"desktop/internal/ctl"
+	"desktop/internal/engine"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -12,6 +16,15 @@ import (
 var assets embed.FS
 
 func main() {
+	if len(os.Args) > 1 {
+		switch cmd := os.Args[1]; {
+		case cmd == "daemon":
+			os.Exit(runDaemon(os.Args[2:]))
+		case ctl.IsCommand(cmd):
+			os.Exit(ctl.Run(os.Args[1:], dataDir()))
+		}
+	}
+
 	app := NewApp()
 
 	err := wails.Run(&options.App{
@@ -35,3 +48,12 @@ func main() {
 		println("Error:", err.Error())
 	}
 }
+
+// dataDir is the data directory of the command-line modes:
+// AGENT_CHAT_DATA_DIR, else ~/.agent-chat.
+func dataDir() string {
+	if dir := os.Getenv("AGENT_CHAT_DATA_DIR"); dir != "" {
+		return dir
+	}
+	return engine.DefaultDataDir()
+}